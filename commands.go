@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JuanPidarraga/talkus-backend/config"
+	"github.com/JuanPidarraga/talkus-backend/internal/backup"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/migrations"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/postgres"
+	"github.com/JuanPidarraga/talkus-backend/internal/search"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+// runCommand ejecuta el subcomando name con sus argumentos.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrate(args)
+	case "export":
+		return runExport(args)
+	case "import":
+		return runImport(args)
+	case "reindex":
+		return runReindex(args)
+	default:
+		return fmt.Errorf("subcomando desconocido %q (disponibles: migrate, export, import, reindex)", name)
+	}
+}
+
+// runMigrate aplica las migraciones pendientes del backend configurado en
+// STORAGE_BACKEND: las de esquema SQL en PostgreSQL y las de documentos en
+// Firestore. Con -status solo lista su estado.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := fs.Bool("status", false, "solo mostrar el estado de las migraciones")
+	dryRun := fs.Bool("dry-run", false, "Firestore: contar los documentos que cambiarían sin escribir nada")
+	batchSize := fs.Int("batch-size", migrations.DefaultBatchSize, "Firestore: documentos por lote")
+	fs.Parse(args)
+
+	backend := os.Getenv("STORAGE_BACKEND")
+	switch backend {
+	case backendPostgres:
+		return migratePostgres(*status)
+	case "", backendFirestore:
+		return migrateFirestore(*status, migrations.Options{DryRun: *dryRun, BatchSize: *batchSize})
+	default:
+		return fmt.Errorf("el backend %q no tiene migraciones de esquema", backend)
+	}
+}
+
+func migratePostgres(status bool) error {
+	ctx := context.Background()
+	pool, err := config.InitPostgres(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if status {
+		statuses, err := postgres.MigrationStatuses(ctx, pool)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.AppliedAt != nil {
+				fmt.Printf("✅ %s (aplicada %s)\n", s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %s (pendiente)\n", s.Name)
+			}
+		}
+		return nil
+	}
+
+	applied, err := postgres.Migrate(ctx, pool)
+	for _, m := range applied {
+		log.Printf("✅ Migración aplicada: %s", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		log.Println("La base de datos ya está al día")
+	}
+	return nil
+}
+
+func migrateFirestore(status bool, opts migrations.Options) error {
+	ctx := context.Background()
+	firebaseApp, err := config.InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer firebaseApp.Firestore.Close()
+	db := firebaseApp.Firestore
+
+	if status {
+		statuses, err := migrations.Statuses(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			name := fmt.Sprintf("%04d_%s", s.Version, s.Name)
+			switch {
+			case s.FinishedAt != nil:
+				fmt.Printf("✅ %s (aplicada %s, %d documentos cambiados)\n", name, s.FinishedAt.Format("2006-01-02 15:04:05"), s.Updated)
+			case s.Running:
+				fmt.Printf("🔄 %s (en curso: %d documentos revisados, último %q)\n", name, s.Processed, s.Cursor)
+			default:
+				fmt.Printf("⏳ %s (pendiente)\n", name)
+			}
+		}
+		return nil
+	}
+
+	opts.Progress = func(r migrations.Result) {
+		log.Printf("… %04d_%s: %d documentos revisados, %d cambiados", r.Version, r.Name, r.Processed, r.Updated)
+	}
+	results, err := migrations.Run(ctx, db, opts)
+	for _, r := range results {
+		name := fmt.Sprintf("%04d_%s", r.Version, r.Name)
+		switch {
+		case opts.DryRun:
+			log.Printf("🔍 %s: cambiaría %d de %d documentos", name, r.Updated, r.Processed)
+		case r.Done:
+			log.Printf("✅ Migración aplicada: %s (%d de %d documentos cambiados)", name, r.Updated, r.Processed)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		log.Println("Firestore ya está al día")
+	}
+	return nil
+}
+
+// openBackupStorage abre el backend de STORAGE_BACKEND para exportar o
+// importar datos. Firebase solo se inicializa con el backend de Firestore. La
+// función devuelta libera las conexiones.
+func openBackupStorage(ctx context.Context) (*storage, func(), error) {
+	backend := os.Getenv("STORAGE_BACKEND")
+	var firebaseApp *config.FirebaseApp
+	if backend == "" || backend == backendFirestore {
+		var err error
+		firebaseApp, err = config.InitFirebase()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	store, err := newStorage(ctx, backend, firebaseApp)
+	if err != nil {
+		if firebaseApp != nil {
+			firebaseApp.Firestore.Close()
+		}
+		return nil, nil, err
+	}
+	return store, func() {
+		store.close()
+		if firebaseApp != nil {
+			firebaseApp.Firestore.Close()
+		}
+	}, nil
+}
+
+// runExport vuelca todas las colecciones del backend configurado a archivos
+// NDJSON en el directorio -dir.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("dir", "backup", "directorio donde escribir los archivos .ndjson")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	store, closeStore, err := openBackupStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	results, err := backup.Export(ctx, store.backup, *dir)
+	for _, r := range results {
+		log.Printf("✅ %s: %d documentos exportados", r.Collection, r.Count)
+	}
+	return err
+}
+
+// runImport restaura en el backend configurado los archivos NDJSON del
+// directorio -dir, conservando los IDs de los documentos.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("dir", "backup", "directorio con los archivos .ndjson")
+	fs.Parse(args)
+
+	if os.Getenv("STORAGE_BACKEND") == backendMemory {
+		return fmt.Errorf("el backend %q no guarda datos entre ejecuciones; usa SEED_DIR para cargar un respaldo al arrancar", backendMemory)
+	}
+
+	ctx := context.Background()
+	store, closeStore, err := openBackupStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	results, err := backup.Import(ctx, store.backup, *dir)
+	logImport(results)
+	return err
+}
+
+// logImport muestra cuántos documentos se importaron de cada colección.
+func logImport(results []backup.Result) {
+	for _, r := range results {
+		if r.Missing {
+			log.Printf("⏭️ %s: sin archivo, se omite", r.Collection)
+			continue
+		}
+		log.Printf("✅ %s: %d documentos importados", r.Collection, r.Count)
+	}
+}
+
+// runReindex reconstruye desde cero el índice de búsqueda de SEARCH_INDEX con
+// los datos del backend configurado. El índice nuevo se arma aparte y reemplaza
+// al anterior al terminar; el servidor debe estar detenido, porque tiene el
+// índice abierto. Con -embeddings además calcula los vectores de la búsqueda
+// semántica de los posts que no lo tienen al día.
+func runReindex(args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	embeddings := fs.Bool("embeddings", false, "calcular los vectores de la búsqueda semántica que falten")
+	fs.Parse(args)
+
+	path := os.Getenv("SEARCH_INDEX")
+	if path == "" && !*embeddings {
+		return fmt.Errorf("SEARCH_INDEX no está definido; sin él el índice vive en memoria y se reconstruye al arrancar")
+	}
+	if os.Getenv("STORAGE_BACKEND") == backendMemory {
+		return fmt.Errorf("el backend %q no guarda datos entre ejecuciones; su índice se llena al arrancar", backendMemory)
+	}
+
+	ctx := context.Background()
+	store, closeStore, err := openBackupStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if path != "" {
+		if err := rebuildSearchIndex(ctx, store, path); err != nil {
+			return err
+		}
+	}
+	if *embeddings {
+		saved, err := search.BackfillEmbeddings(ctx, store.backup, store.embeddings, service.NewMiniLMEmbedder())
+		log.Printf("🧭 Búsqueda semántica: %d vectores calculados", saved)
+		return err
+	}
+	return nil
+}
+
+// rebuildSearchIndex arma el índice en path+".tmp" y lo mueve a path.
+func rebuildSearchIndex(ctx context.Context, store *storage, path string) error {
+	tmp := path + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	index, err := search.Create(tmp)
+	if err != nil {
+		return err
+	}
+	result, err := index.Rebuild(ctx, store.backup)
+	if closeErr := index.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	logRebuild(result)
+
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,206 @@
+// Package backup exporta e importa todas las colecciones en archivos NDJSON
+// (un documento JSON por línea), uno por colección: users.ndjson,
+// subforos.ndjson, etc. El formato no depende del backend, así que un
+// respaldo de Firestore se puede restaurar en memoria o en PostgreSQL.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// batchSize es la cantidad de documentos que se importan de una vez.
+const batchSize = 500
+
+// maxLineSize es el tamaño máximo de un documento en el archivo.
+const maxLineSize = 16 << 20
+
+// Result es la cantidad de documentos exportados o importados de una colección.
+type Result struct {
+	Collection string
+	Count      int
+	// Missing indica que al importar no había archivo para la colección.
+	Missing bool
+}
+
+type collection struct {
+	name       string
+	exportTo   func(ctx context.Context, repo repositories.BackupRepository, w io.Writer) (int, error)
+	importFrom func(ctx context.Context, repo repositories.BackupRepository, r io.Reader) (int, error)
+}
+
+// collections están en el orden en que se importan: primero las colecciones
+// de las que dependen las demás.
+var collections = []collection{
+	newCollection("users", repositories.BackupRepository.ExportUsers, repositories.BackupRepository.ImportUsers),
+	newCollection("subforos", repositories.BackupRepository.ExportSubforos, repositories.BackupRepository.ImportSubforos),
+	newCollection("posts", exportPosts, importPosts),
+	newCollection("comments", repositories.BackupRepository.ExportComments, repositories.BackupRepository.ImportComments),
+	newCollection("votes", repositories.BackupRepository.ExportVotes, repositories.BackupRepository.ImportVotes),
+	newCollection("userSavedPosts", repositories.BackupRepository.ExportSavedPosts, repositories.BackupRepository.ImportSavedPosts),
+	newCollection("postRevisions", repositories.BackupRepository.ExportPostRevisions, repositories.BackupRepository.ImportPostRevisions),
+	newCollection("pollBallots", repositories.BackupRepository.ExportPollBallots, repositories.BackupRepository.ImportPollBallots),
+	newCollection("userBlocks", repositories.BackupRepository.ExportBlocks, repositories.BackupRepository.ImportBlocks),
+	newCollection("moderationLog", repositories.BackupRepository.ExportModerationLog, repositories.BackupRepository.ImportModerationLog),
+	newCollection("postEmbeddings", repositories.BackupRepository.ExportEmbeddings, repositories.BackupRepository.ImportEmbeddings),
+	newCollection("tags", repositories.BackupRepository.ExportTags, repositories.BackupRepository.ImportTags),
+	newCollection("tagFollows", repositories.BackupRepository.ExportTagFollows, repositories.BackupRepository.ImportTagFollows),
+	newCollection("subforoJoins", repositories.BackupRepository.ExportSubforoJoins, repositories.BackupRepository.ImportSubforoJoins),
+}
+
+// postRecord es un post en el respaldo. Agrega los campos del post que la API
+// no devuelve pero que hay que conservar al restaurarlo.
+type postRecord struct {
+	models.Post
+	// KeepOpen evita que el archivado automático vuelva a archivar los posts
+	// que un moderador desarchivó.
+	KeepOpen bool `json:"keep_open,omitempty"`
+}
+
+func exportPosts(repo repositories.BackupRepository, ctx context.Context, fn func(*postRecord) error) error {
+	return repo.ExportPosts(ctx, func(p *models.Post) error {
+		return fn(&postRecord{Post: *p, KeepOpen: p.KeepOpen})
+	})
+}
+
+func importPosts(repo repositories.BackupRepository, ctx context.Context, records []*postRecord) error {
+	posts := make([]*models.Post, 0, len(records))
+	for _, rec := range records {
+		rec.Post.KeepOpen = rec.KeepOpen
+		posts = append(posts, &rec.Post)
+	}
+	return repo.ImportPosts(ctx, posts)
+}
+
+func newCollection[T any](
+	name string,
+	export func(repositories.BackupRepository, context.Context, func(T) error) error,
+	imp func(repositories.BackupRepository, context.Context, []T) error,
+) collection {
+	return collection{
+		name: name,
+		exportTo: func(ctx context.Context, repo repositories.BackupRepository, w io.Writer) (int, error) {
+			enc := json.NewEncoder(w)
+			n := 0
+			err := export(repo, ctx, func(doc T) error {
+				n++
+				return enc.Encode(doc)
+			})
+			return n, err
+		},
+		importFrom: func(ctx context.Context, repo repositories.BackupRepository, r io.Reader) (int, error) {
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+			n, line := 0, 0
+			batch := make([]T, 0, batchSize)
+			flush := func() error {
+				if len(batch) == 0 {
+					return nil
+				}
+				if err := imp(repo, ctx, batch); err != nil {
+					return err
+				}
+				n += len(batch)
+				batch = make([]T, 0, batchSize)
+				return nil
+			}
+
+			for scanner.Scan() {
+				line++
+				if len(scanner.Bytes()) == 0 {
+					continue
+				}
+				var doc T
+				if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+					return n, fmt.Errorf("línea %d: %w", line, err)
+				}
+				batch = append(batch, doc)
+				if len(batch) == batchSize {
+					if err := flush(); err != nil {
+						return n, err
+					}
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return n, err
+			}
+			return n, flush()
+		},
+	}
+}
+
+// fileName es el archivo de la colección dentro de dir.
+func fileName(dir, collection string) string {
+	return filepath.Join(dir, collection+".ndjson")
+}
+
+// Export escribe todas las colecciones en dir, creando el directorio si no
+// existe y reemplazando los archivos de un respaldo anterior.
+func Export(ctx context.Context, repo repositories.BackupRepository, dir string) ([]Result, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(collections))
+	for _, c := range collections {
+		n, err := exportFile(ctx, repo, c, fileName(dir, c.name))
+		if err != nil {
+			return results, fmt.Errorf("error al exportar %s: %w", c.name, err)
+		}
+		results = append(results, Result{Collection: c.name, Count: n})
+	}
+	return results, nil
+}
+
+func exportFile(ctx context.Context, repo repositories.BackupRepository, c collection, path string) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	n, err := c.exportTo(ctx, repo, w)
+	if err != nil {
+		return n, err
+	}
+	if err := w.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// Import restaura las colecciones de dir conservando los IDs. Los documentos
+// que ya existen se reemplazan, así que se puede repetir sin duplicar datos.
+// Las colecciones sin archivo se saltan.
+func Import(ctx context.Context, repo repositories.BackupRepository, dir string) ([]Result, error) {
+	results := make([]Result, 0, len(collections))
+	for _, c := range collections {
+		f, err := os.Open(fileName(dir, c.name))
+		if errors.Is(err, os.ErrNotExist) {
+			results = append(results, Result{Collection: c.name, Missing: true})
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+
+		n, err := c.importFrom(ctx, repo, f)
+		f.Close()
+		if err != nil {
+			return results, fmt.Errorf("error al importar %s: %w", c.name, err)
+		}
+		results = append(results, Result{Collection: c.name, Count: n})
+	}
+	return results, nil
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+)
+
+func TestPostRoundTripKeepsKeepOpen(t *testing.T) {
+	ctx := context.Background()
+	src := memory.NewBackupRepository(memory.NewStore())
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := src.ImportPosts(ctx, []*models.Post{
+		{ID: "abierto", Title: "desarchivado", CreatedAt: created, KeepOpen: true},
+		{ID: "normal", Title: "normal", CreatedAt: created},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if _, err := Export(ctx, src, dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "posts.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"keep_open":true`); n != 1 {
+		t.Errorf("posts.ndjson tiene %d keep_open, se esperaba 1:\n%s", n, data)
+	}
+
+	dst := memory.NewBackupRepository(memory.NewStore())
+	if _, err := Import(ctx, dst, dir); err != nil {
+		t.Fatal(err)
+	}
+	keepOpen := make(map[string]bool)
+	err = dst.ExportPosts(ctx, func(p *models.Post) error {
+		keepOpen[p.ID] = p.KeepOpen
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keepOpen["abierto"] || keepOpen["normal"] {
+		t.Errorf("KeepOpen restaurado = %v", keepOpen)
+	}
+}
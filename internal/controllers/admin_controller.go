@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type AdminController struct {
+	counters usecases.CounterUsecase
+}
+
+func NewAdminController(counters usecases.CounterUsecase) *AdminController {
+	return &AdminController{counters: counters}
+}
+
+// ReconcileCounters godoc
+// @Summary Revisar los contadores de likes y dislikes
+// @Description Recalcula los likes y dislikes de posts y comentarios a partir de sus votos y devuelve los documentos cuyos contadores no coinciden. Con repair=true además los corrige. Solo administradores.
+// @Tags Admin
+// @Produce json
+// @Param repair query bool false "Corregir los contadores que no coinciden"
+// @Success 200 {object} models.CounterReport "Reporte de diferencias"
+// @Failure 400 {object} map[string]string "repair inválido"
+// @Failure 403 {object} map[string]string "El usuario no es administrador"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/reconcile-counters [post]
+func (c *AdminController) ReconcileCounters(w http.ResponseWriter, r *http.Request) {
+	repair := false
+	if raw := r.URL.Query().Get("repair"); raw != "" {
+		var err error
+		if repair, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "repair debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := c.counters.ReconcileCounters(r.Context(), repair)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
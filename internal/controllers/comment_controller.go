@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -35,10 +36,6 @@ type ReplyRequest struct {
 	ParentID string `json:"parentId" validate:"required"`
 }
 
-type ReactionRequest struct {
-	Reaction string `json:"reaction" validate:"required,oneof=like dislike"`
-}
-
 func (c *CommentController) CreateComment(w http.ResponseWriter, r *http.Request) {
 	var req CommentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -60,6 +57,13 @@ func (c *CommentController) CreateComment(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := c.usecase.CreateComment(r.Context(), &comment); err != nil {
+		if writeReadOnly(w, err) {
+			return
+		}
+		if errors.Is(err, usecases.ErrNotFound) {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
 		return
 	}
@@ -88,8 +92,16 @@ func (c *CommentController) GetCommentByID(w http.ResponseWriter, r *http.Reques
 func (c *CommentController) GetCommentsByPostID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID := vars["postId"]
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
 
-	comments, err := c.usecase.GetCommentsByPostID(r.Context(), postID)
+	comments, err := c.usecase.GetCommentsByPostID(r.Context(), postID, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -124,6 +136,10 @@ func (c *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request
 	updatedComment, err := c.usecase.UpdateComment(r.Context(), commentID, token.UID, req.Content)
 	if err != nil {
 		switch {
+		case errors.Is(err, models.ErrPostArchived):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, usecases.ErrNotFound):
+			http.Error(w, "Post not found", http.StatusNotFound)
 		case strings.Contains(err.Error(), "unauthorized"):
 			http.Error(w, err.Error(), http.StatusForbidden)
 		case strings.Contains(err.Error(), "not found"):
@@ -138,36 +154,6 @@ func (c *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(updatedComment)
 }
 
-func (c *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	commentID := vars["commentId"]
-
-	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	comment, err := c.usecase.GetCommentByID(r.Context(), commentID)
-	if err != nil {
-		http.Error(w, "Comment not found", http.StatusNotFound)
-		return
-	}
-
-	// Verificar que el usuario sea el autor
-	if comment.AuthorID != token.UID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
-	if err := c.usecase.DeleteComment(r.Context(), commentID); err != nil {
-		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
 func (c *CommentController) CreateReply(w http.ResponseWriter, r *http.Request) {
 	var req ReplyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -188,10 +174,12 @@ func (c *CommentController) CreateReply(w http.ResponseWriter, r *http.Request)
 		CreatedAt: time.Now(),
 		Likes:     0,
 		Dislikes:  0,
-		Reactions: make(map[string]string),
 	}
 
 	if err := c.usecase.CreateReply(r.Context(), req.ParentID, &comment); err != nil {
+		if writeReadOnly(w, err) {
+			return
+		}
 		http.Error(w, "Failed to create reply: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -211,59 +199,29 @@ func (c *CommentController) GetCommentTree(w http.ResponseWriter, r *http.Reques
 		userID = token.UID
 	}
 
-	comments, err := c.usecase.GetCommentTree(r.Context(), postID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
 		return
 	}
 
-	// Opcional: agregar información de reacción del usuario actual
-	if userID != "" {
-		for _, comment := range comments {
-			addUserReaction(comment, userID)
-		}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(comments)
-}
-
-func addUserReaction(comment *models.CommentWithReplies, userID string) {
-	if reaction, exists := comment.Comment.Reactions[userID]; exists {
-		comment.UserReaction = &reaction
-	}
-
-	for _, reply := range comment.Replies {
-		addUserReaction(reply, userID)
-	}
-}
-
-func (c *CommentController) AddReaction(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	commentID := vars["commentId"]
-
-	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	comments, err := c.usecase.GetCommentTree(r.Context(), postID, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	var req ReactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	updatedComment, err := c.usecase.AddReaction(r.Context(), commentID, token.UID, req.Reaction)
-	if err != nil {
-		status := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "comment not found") {
-			status = http.StatusNotFound
+	// Opcional: agregar información de reacción del usuario actual
+	if userID != "" {
+		if err := c.usecase.AddUserReactions(r.Context(), userID, comments.Items); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		http.Error(w, err.Error(), status)
-		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedComment)
+	json.NewEncoder(w).Encode(comments)
 }
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/gorilla/mux"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type FeedController struct {
+	usecase usecases.FeedUsecase
+}
+
+func NewFeedController(usecase usecases.FeedUsecase) *FeedController {
+	return &FeedController{usecase: usecase}
+}
+
+// writeFeedError traduce los errores de FeedUsecase a respuestas HTTP.
+func writeFeedError(w http.ResponseWriter, err error) {
+	switch {
+	case isInvalidCursor(err), errors.Is(err, usecases.ErrSelfBlock):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+	default:
+		log.Printf("Error en el feed: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Feed del usuario
+// @Description Obtiene una página con los posts de los subforos de los que el usuario es miembro o moderador y los de los tags que sigue. No incluye los posts reportados ni los de los usuarios que bloqueó. Por defecto se ordenan por hot.
+// @Tags Post
+// @Produce json
+// @Param sort query string false "Orden: hot (por defecto), new, top, controversial o rising"
+// @Param t query string false "Ventana de top y controversial: day (por defecto), week, month, year o all"
+// @Param limit query int false "Número de publicaciones por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página del feed"
+// @Failure 400 {object} map[string]string "sort, t, limit o cursor inválidos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/feed [get]
+func (c *FeedController) Feed(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	order, ok := postSortFromQuery(w, r, models.SortHot)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := c.usecase.Feed(r.Context(), token.UID, order, page)
+	if err != nil {
+		writeFeedError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(posts)
+}
+
+// @Summary Bloquear un usuario
+// @Description Bloquea al usuario: sus posts dejan de aparecer en el feed. Bloquear dos veces no es un error.
+// @Tags User
+// @Produce json
+// @Param id path string true "ID del usuario a bloquear"
+// @Success 200 {object} models.Block "Bloqueo"
+// @Failure 400 {object} map[string]string "El usuario intentó bloquearse a sí mismo"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 404 {object} map[string]string "El usuario no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/block [post]
+func (c *FeedController) Block(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	block, err := c.usecase.Block(r.Context(), token.UID, mux.Vars(r)["id"])
+	if err != nil {
+		writeFeedError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(block)
+}
+
+// @Summary Desbloquear un usuario
+// @Description Quita el bloqueo del usuario, si existe.
+// @Tags User
+// @Param id path string true "ID del usuario a desbloquear"
+// @Success 204 "Usuario desbloqueado"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/block [delete]
+func (c *FeedController) Unblock(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.usecase.Unblock(r.Context(), token.UID, mux.Vars(r)["id"]); err != nil {
+		writeFeedError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Usuarios bloqueados
+// @Description Lista los usuarios que bloqueó el usuario autenticado, los más recientes primero.
+// @Tags User
+// @Produce json
+// @Success 200 {array} models.Block "Bloqueos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/blocked [get]
+func (c *FeedController) Blocked(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	blocks, err := c.usecase.Blocked(r.Context(), token.UID)
+	if err != nil {
+		writeFeedError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
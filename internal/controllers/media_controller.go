@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+	"github.com/gorilla/mux"
+)
+
+type MediaController struct {
+	usecase usecases.MediaUsecase
+}
+
+func NewMediaController(usecase usecases.MediaUsecase) *MediaController {
+	return &MediaController{usecase: usecase}
+}
+
+// MediaRequest cambia la leyenda y el texto alternativo de una imagen; los
+// campos que no vienen no cambian.
+type MediaRequest struct {
+	Caption *string `json:"caption"`
+	Alt     *string `json:"alt"`
+}
+
+// MediaOrderRequest son los IDs de todas las imágenes de la galería en el
+// orden nuevo.
+type MediaOrderRequest struct {
+	Order []string `json:"order"`
+}
+
+// writeMediaError traduce los errores de MediaUsecase a respuestas HTTP.
+func writeMediaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el post o la imagen", http.StatusNotFound)
+	case errors.Is(err, usecases.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, usecases.ErrInvalidMedia):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecases.ErrMediaLimit),
+		errors.Is(err, models.ErrPostLocked), errors.Is(err, models.ErrPostArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error en la galería: %v", err)
+		http.Error(w, "No se pudo actualizar la galería", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Agregar una imagen a la galería
+// @Description Sube una imagen y la agrega a la galería de un post propio, sin tocar las demás. Un post tiene a lo sumo 10 imágenes.
+// @Tags Post
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param image formData file true "Imagen"
+// @Param caption formData string false "Leyenda"
+// @Param alt formData string false "Texto alternativo"
+// @Param position formData int false "Posición en la galería, empezando en 0 (por defecto al final)"
+// @Success 201 {object} models.Media "Imagen agregada"
+// @Failure 400 {object} map[string]string "Falta la imagen o los textos son demasiado largos"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 409 {object} map[string]string "La galería está llena o el post está archivado o cerrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/media [post]
+func (c *MediaController) Add(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		http.Error(w, "Content-Type debe ser multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Error parsing form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		http.Error(w, "image es obligatoria", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	position := -1
+	if raw := r.FormValue("position"); raw != "" {
+		if position, err = strconv.Atoi(raw); err != nil || position < 0 {
+			http.Error(w, "position debe ser un entero no negativo", http.StatusBadRequest)
+			return
+		}
+	}
+
+	media, err := c.usecase.AddMedia(r.Context(), mux.Vars(r)["id"], token.UID, file,
+		r.FormValue("caption"), r.FormValue("alt"), position)
+	if err != nil {
+		writeMediaError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(media)
+}
+
+// @Summary Editar una imagen de la galería
+// @Description Cambia la leyenda o el texto alternativo de una imagen de un post propio.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param mediaId path string true "ID de la imagen"
+// @Param media body MediaRequest true "Textos nuevos"
+// @Success 200 {object} models.Media "Imagen actualizada"
+// @Failure 400 {object} map[string]string "Cuerpo inválido o textos demasiado largos"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post o la imagen no existen"
+// @Failure 409 {object} map[string]string "El post está archivado o cerrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/media/{mediaId} [patch]
+func (c *MediaController) Update(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req MediaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Cuerpo inválido", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	media, err := c.usecase.UpdateMedia(r.Context(), vars["id"], token.UID, vars["mediaId"], req.Caption, req.Alt)
+	if err != nil {
+		writeMediaError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(media)
+}
+
+// @Summary Quitar una imagen de la galería
+// @Description Quita una imagen de la galería de un post propio y la borra de Cloudinary, salvo que la use una revisión anterior del post.
+// @Tags Post
+// @Param id path string true "ID del post"
+// @Param mediaId path string true "ID de la imagen"
+// @Success 204 "Imagen quitada"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post o la imagen no existen"
+// @Failure 409 {object} map[string]string "El post está archivado o cerrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/media/{mediaId} [delete]
+func (c *MediaController) Remove(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.usecase.RemoveMedia(r.Context(), vars["id"], token.UID, vars["mediaId"]); err != nil {
+		writeMediaError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Reordenar la galería
+// @Description Cambia el orden de las imágenes de un post propio. La primera también queda en image_url.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param order body MediaOrderRequest true "IDs de todas las imágenes en el orden nuevo"
+// @Success 200 {array} models.Media "Galería reordenada"
+// @Failure 400 {object} map[string]string "El orden no tiene exactamente las imágenes del post"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 409 {object} map[string]string "El post está archivado o cerrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/media/order [put]
+func (c *MediaController) Reorder(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req MediaOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Cuerpo inválido", http.StatusBadRequest)
+		return
+	}
+
+	media, err := c.usecase.ReorderMedia(r.Context(), mux.Vars(r)["id"], token.UID, req.Order)
+	if err != nil {
+		writeMediaError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(media)
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/gorilla/mux"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type ModerationController struct {
+	usecase usecases.ModerationUsecase
+}
+
+func NewModerationController(usecase usecases.ModerationUsecase) *ModerationController {
+	return &ModerationController{usecase: usecase}
+}
+
+type ModerationRequest struct {
+	Action models.ModerationAction `json:"action"`
+}
+
+type ArchiveAfterRequest struct {
+	ArchiveAfterDays int `json:"archive_after_days"`
+}
+
+// writeReadOnly responde 409 si err indica que el post está cerrado o
+// archivado, y devuelve si lo hizo.
+func writeReadOnly(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, models.ErrPostLocked) || errors.Is(err, models.ErrPostArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return true
+	}
+	return false
+}
+
+// writeModerationError traduce los errores de ModerationUsecase a respuestas HTTP.
+func writeModerationError(w http.ResponseWriter, err error) {
+	switch {
+	case isInvalidCursor(err), errors.Is(err, models.ErrInvalidAction), errors.Is(err, usecases.ErrInvalidArchiveAfter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecases.ErrForbidden):
+		http.Error(w, "Solo los moderadores del subforo pueden hacerlo", http.StatusForbidden)
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el post o el subforo", http.StatusNotFound)
+	case errors.Is(err, usecases.ErrPinLimit):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error de moderación: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Moderar un post
+// @Description Fija, cierra o archiva el post, o deshace cualquiera de esas acciones. Los posts fijados van primero en la primera página del subforo (máximo 3 por subforo). Los cerrados no admiten comentarios, respuestas ni votos; los archivados además son de solo lectura. Cada acción queda en el registro de moderación del subforo.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param action body ModerationRequest true "Acción: pin, unpin, lock, unlock, archive o unarchive"
+// @Success 200 {object} models.Post "Post moderado"
+// @Failure 400 {object} map[string]string "Acción inválida"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 403 {object} map[string]string "No es moderador del subforo"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 409 {object} map[string]string "El subforo ya tiene el máximo de posts fijados"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/moderation [post]
+func (c *ModerationController) Moderate(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+
+	post, err := c.usecase.Moderate(r.Context(), mux.Vars(r)["id"], token.UID, req.Action)
+	if err != nil {
+		writeModerationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// @Summary Archivado automático de un subforo
+// @Description Define a los cuántos días de publicados se archivan los posts del subforo. 0 desactiva el archivado automático. Los posts que un moderador desarchivó no se vuelven a archivar solos.
+// @Tags Subforo
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del subforo"
+// @Param archive body ArchiveAfterRequest true "Días hasta archivar"
+// @Success 200 {object} models.Subforo "Subforo actualizado"
+// @Failure 400 {object} map[string]string "Días inválidos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 403 {object} map[string]string "No es moderador del subforo"
+// @Failure 404 {object} map[string]string "El subforo no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/subforos/{id}/archive [put]
+func (c *ModerationController) SetArchiveAfter(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req ArchiveAfterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+
+	subforo, err := c.usecase.SetArchiveAfter(r.Context(), mux.Vars(r)["id"], token.UID, req.ArchiveAfterDays)
+	if err != nil {
+		writeModerationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subforo)
+}
+
+// @Summary Registro de moderación
+// @Description Pagina las acciones de moderación sobre los posts del subforo, las más recientes primero. Las del archivado automático no tienen moderator_id.
+// @Tags Subforo
+// @Produce json
+// @Param id path string true "ID del subforo"
+// @Param limit query int false "Número de acciones por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.ModerationLogEntry] "Página del registro"
+// @Failure 400 {object} map[string]string "limit o cursor inválidos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 403 {object} map[string]string "No es moderador del subforo"
+// @Failure 404 {object} map[string]string "El subforo no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/subforos/{id}/moderation-log [get]
+func (c *ModerationController) Log(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := c.usecase.Log(r.Context(), mux.Vars(r)["id"], token.UID, page)
+	if err != nil {
+		writeModerationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// pageRequestFromQuery lee los parámetros de paginación limit y cursor. Si
+// limit no es un entero positivo responde 400 y devuelve false.
+func pageRequestFromQuery(w http.ResponseWriter, r *http.Request) (models.PageRequest, bool) {
+	page := models.PageRequest{Cursor: r.URL.Query().Get("cursor")}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit debe ser un entero positivo", http.StatusBadRequest)
+			return page, false
+		}
+		page.Limit = limit
+	}
+	return page, true
+}
+
+// limitFromQuery lee el parámetro limit de los listados sin cursor; 0 si no
+// viene. Si no es un entero positivo responde 400 y devuelve false.
+func limitFromQuery(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit debe ser un entero positivo", http.StatusBadRequest)
+		return 0, false
+	}
+	return limit, true
+}
+
+// postSortFromQuery lee el orden de un listado de posts (sort), que sin el
+// parámetro es byDefault, y su ventana de tiempo (t). Si no son válidos
+// responde 400 y devuelve false.
+func postSortFromQuery(w http.ResponseWriter, r *http.Request, byDefault models.PostSortMode) (models.PostSort, bool) {
+	mode := r.URL.Query().Get("sort")
+	if mode == "" {
+		mode = string(byDefault)
+	}
+	order, err := models.ParseSort(mode, r.URL.Query().Get("t"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return order, false
+	}
+	return order, true
+}
+
+// isInvalidCursor indica si err se debe a un cursor mal formado, que es un
+// error del cliente (400) y no del servidor.
+func isInvalidCursor(err error) bool {
+	return errors.Is(err, models.ErrInvalidCursor)
+}
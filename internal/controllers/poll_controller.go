@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+	"github.com/gorilla/mux"
+)
+
+type PollController struct {
+	usecase usecases.PollUsecase
+}
+
+func NewPollController(usecase usecases.PollUsecase) *PollController {
+	return &PollController{usecase: usecase}
+}
+
+// BallotRequest son los índices de las opciones elegidas, empezando en 0.
+type BallotRequest struct {
+	Options []int `json:"options"`
+}
+
+// writePollError traduce los errores de PollUsecase a respuestas HTTP.
+func writePollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el post o no tiene encuesta", http.StatusNotFound)
+	case errors.Is(err, usecases.ErrInvalidBallot):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecases.ErrPollClosed), errors.Is(err, repositories.ErrAlreadyVoted),
+		errors.Is(err, models.ErrPostLocked), errors.Is(err, models.ErrPostArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error en la encuesta: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Votar en una encuesta
+// @Description Guarda el voto del usuario en la encuesta del post y devuelve los resultados. Cada usuario vota una sola vez; en las encuestas de opción única se elige exactamente una opción.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param ballot body BallotRequest true "Opciones elegidas"
+// @Success 200 {object} models.PollResults "Encuesta con resultados"
+// @Failure 400 {object} map[string]string "Opciones inválidas"
+// @Failure 404 {object} map[string]string "El post no existe o no tiene encuesta"
+// @Failure 409 {object} map[string]string "Ya votaste, la encuesta está cerrada o el post está cerrado o archivado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/poll/vote [post]
+func (c *PollController) Vote(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BallotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Cuerpo inválido", http.StatusBadRequest)
+		return
+	}
+
+	results, err := c.usecase.Vote(r.Context(), mux.Vars(r)["id"], token.UID, req.Options)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// @Summary Resultados de una encuesta
+// @Description Devuelve la encuesta del post y el voto del usuario. results es null mientras el usuario no haya votado y la encuesta siga abierta.
+// @Tags Post
+// @Produce json
+// @Param id path string true "ID del post"
+// @Success 200 {object} models.PollResults "Encuesta"
+// @Failure 404 {object} map[string]string "El post no existe o no tiene encuesta"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/poll/results [get]
+func (c *PollController) Results(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	results, err := c.usecase.Results(r.Context(), mux.Vars(r)["id"], token.UID)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
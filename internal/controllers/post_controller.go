@@ -3,39 +3,106 @@ package controllers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"firebase.google.com/go/v4/auth"
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
 	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
 	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/gorilla/mux"
 )
 
 type PostController struct {
 	postUsecase *usecases.PostUsecase
-	cld         *cloudinary.Cloudinary
+	media       service.MediaStorage
 }
 
 func NewPostController(u *usecases.PostUsecase, cld *cloudinary.Cloudinary) *PostController {
-	return &PostController{postUsecase: u, cld: cld}
+	return &PostController{postUsecase: u, media: service.NewCloudinaryStorage(cld)}
+}
+
+// uploadMedia sube las imágenes del campo image del formulario, con la
+// leyenda y el texto alternativo de los campos caption y alt en el mismo
+// orden. Si falla alguna, borra las que ya subió.
+func (c *PostController) uploadMedia(r *http.Request) ([]models.Media, error) {
+	files := r.MultipartForm.File["image"]
+	if len(files) > models.MaxPostMedia {
+		return nil, usecases.ErrMediaLimit
+	}
+	captions, alts := r.MultipartForm.Value["caption"], r.MultipartForm.Value["alt"]
+	at := func(values []string, i int) string {
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
+
+	media := make([]models.Media, 0, len(files))
+	for i, header := range files {
+		item := models.Media{ID: repositories.NewDocumentID(), Caption: at(captions, i), Alt: at(alts, i)}
+		err := usecases.ValidateMediaText(item.Caption, item.Alt)
+		if err == nil {
+			item.URL, item.PublicID, err = c.upload(r.Context(), header)
+		}
+		if err != nil {
+			for _, m := range media {
+				if errDel := c.media.Delete(r.Context(), m.PublicID); errDel != nil {
+					log.Printf("⚠️ No se pudo borrar la imagen %s: %v", m.PublicID, errDel)
+				}
+			}
+			return nil, err
+		}
+		media = append(media, item)
+	}
+	return media, nil
+}
+
+func (c *PostController) upload(ctx context.Context, header *multipart.FileHeader) (string, string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	return c.media.Upload(ctx, file)
 }
 
 // @Summary Obtener todas las publicaciones
-// @Description Obtiene una lista de todas las publicaciones ordenadas por fecha de creación.
+// @Description Obtiene una página de publicaciones. Por defecto se ordenan por fecha de creación (más recientes primero).
 // @Tags Post
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Post "Lista de publicaciones"
+// @Param sort query string false "Orden: new (por defecto), hot, top, controversial o rising"
+// @Param t query string false "Ventana de top y controversial: day (por defecto), week, month, year o all"
+// @Param limit query int false "Número de publicaciones por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página de publicaciones"
+// @Failure 400 {object} map[string]string "sort, t, limit o cursor inválidos"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /public/posts [get]
 func (c *PostController) GetAll(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	posts, err := c.postUsecase.GetAllPosts(ctx)
+	order, ok := postSortFromQuery(w, r, models.SortNew)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	posts, err := c.postUsecase.GetAllPosts(ctx, order, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error obteniendo posts: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -52,16 +119,23 @@ func (c *PostController) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Crear una nueva publicación
-// @Description Permite crear una nueva publicación con un título, contenido y una imagen opcional. La imagen se sube a Cloudinary y se guarda la URL en la publicación. El contenido será analizado por IA para determinar su relevancia con el subforo.
+// @Description Permite crear una nueva publicación con un título, contenido y una galería opcional de hasta 10 imágenes. Las imágenes se suben a Cloudinary; la primera también queda en image_url. El contenido será analizado por IA para determinar su relevancia con el subforo.
 // @Tags Post
 // @Accept multipart/form-data
 // @Produce json
 // @Param title formData string true "Título de la publicación"
 // @Param content formData string true "Contenido de la publicación"
-// @Param image formData file false "Imagen para la publicación"
+// @Param image formData file false "Imágenes de la galería, en orden (hasta 10; se puede repetir el campo)"
+// @Param caption formData string false "Leyenda de cada imagen, en el mismo orden (se puede repetir el campo)"
+// @Param alt formData string false "Texto alternativo de cada imagen, en el mismo orden (se puede repetir el campo)"
 // @Param forum_id formData string false "ID del subforo donde se publica"
+// @Param tags formData string false "Tags en JSON: [\"go\", \"#Música\"]. Se guardan normalizados (sin mayúsculas, tildes ni #, con guiones entre palabras) y los sinónimos se reemplazan por su tag"
+// @Param status formData string false "draft para guardarla como borrador (por defecto published)"
+// @Param publish_at formData string false "Fecha RFC 3339 en la que se publica sola; la guarda como programada"
+// @Param link formData string false "URL de un post de enlace; se guarda con su vista previa (content pasa a ser opcional)"
+// @Param poll formData string false "Encuesta en JSON: {\"options\": [...], \"multiple\": false, \"closes_at\": \"RFC 3339\"}, con 2 a 10 opciones"
 // @Success 201 {object} models.Post "Publicación creada exitosamente"
-// @Failure 400 {object} map[string]string "Solicitud inválida, título o contenido faltante"
+// @Failure 400 {object} map[string]string "Solicitud inválida, título o contenido faltante o tag inválido"
 // @Failure 500 {object} map[string]string "Error interno al crear la publicación"
 // @Router /public/posts [post]
 func (c *PostController) Create(w http.ResponseWriter, r *http.Request) {
@@ -79,29 +153,51 @@ func (c *PostController) Create(w http.ResponseWriter, r *http.Request) {
 	//leer directamente los valores del form
 	title := r.FormValue("title")
 	content := r.FormValue("content")
-	if title == "" || content == "" {
+	link := r.FormValue("link")
+	if title == "" || (content == "" && link == "") {
 		http.Error(w, "title y content son obligatorios", http.StatusBadRequest)
 		return
 	}
 
-	//subir imagen
-	var imageURL string
-	var ImageID string
-	file, _, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-		uploadParams := uploader.UploadParams{
-			Folder:    "posts_images",
-			PublicID:  fmt.Sprintf("post_%d", time.Now().Unix()),
-			Overwrite: func(b bool) *bool { return &b }(true),
-		}
-		res, err := c.cld.Upload.Upload(r.Context(), file, uploadParams)
+	// Borrador o publicación programada
+	status := models.PostStatus(r.FormValue("status"))
+	if status != "" && status != models.PostDraft && status != models.PostPublished {
+		http.Error(w, "status debe ser draft o published", http.StatusBadRequest)
+		return
+	}
+	var publishAt *time.Time
+	if raw := r.FormValue("publish_at"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
 		if err != nil {
-			http.Error(w, "Error subiendo imagen: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, "publish_at debe ser una fecha RFC 3339", http.StatusBadRequest)
+			return
+		}
+		publishAt = &t
+		status = models.PostScheduled
+	}
+
+	// Encuesta opcional, como JSON: {"options": [...], "multiple": false, "closes_at": "..."}
+	var poll *models.Poll
+	if raw := r.FormValue("poll"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &poll); err != nil {
+			http.Error(w, "poll inválido", http.StatusBadRequest)
 			return
 		}
-		imageURL = res.SecureURL
-		ImageID = res.PublicID
+		if err := poll.Validate(time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	//subir imágenes
+	media, err := c.uploadMedia(r)
+	if errors.Is(err, usecases.ErrMediaLimit) || errors.Is(err, usecases.ErrInvalidMedia) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error subiendo imagen: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	authorID := r.FormValue("author_id")
@@ -129,8 +225,6 @@ func (c *PostController) Create(w http.ResponseWriter, r *http.Request) {
 		Title:     title,
 		AuthorID:  authorID,
 		Content:   content,
-		ImageURL:  imageURL,
-		ImageID:   ImageID,
 		Tags:      tags,
 		ForumID:   forumID,
 		Likes:     0,
@@ -138,9 +232,20 @@ func (c *PostController) Create(w http.ResponseWriter, r *http.Request) {
 		IsFlagged: false,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Status:    status,
+		PublishAt: publishAt,
+		Poll:      poll,
 	}
+	if link != "" {
+		post.Link = &models.LinkPreview{URL: link}
+	}
+	post.SetMedia(media)
 
 	created, err := c.postUsecase.CreatePost(r.Context(), post)
+	if errors.Is(err, usecases.ErrPublishAt) || errors.Is(err, service.ErrInvalidLink) || errors.Is(err, service.ErrBlockedLink) || isInvalidTag(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error creando post: %v", err)
 		http.Error(w, "No se pudo crear el post", http.StatusInternalServerError)
@@ -151,22 +256,22 @@ func (c *PostController) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(created)
 }
 
-func (c *PostController) Delete(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "ID de la publicación es obligatorio", http.StatusBadRequest)
-		return
-	}
-
-	err := c.postUsecase.DeletePost(ctx, id)
+// @Summary Vista previa de un enlace
+// @Description Descarga la página y devuelve su título, descripción, imagen y sitio según OpenGraph o Twitter card. Solo se permiten URLs http o https públicas.
+// @Tags Post
+// @Produce json
+// @Param url query string true "URL del enlace"
+// @Success 200 {object} models.LinkPreview "Vista previa; sin metadatos si la página no respondió"
+// @Failure 400 {object} map[string]string "URL inválida o no permitida"
+// @Router /api/link-preview [get]
+func (c *PostController) PreviewLink(w http.ResponseWriter, r *http.Request) {
+	preview, err := c.postUsecase.PreviewLink(r.Context(), r.URL.Query().Get("url"))
 	if err != nil {
-		log.Printf("Error eliminando post: %v", err)
-		http.Error(w, "No se pudo eliminar el post", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
 }
 
 func (c *PostController) Edit(w http.ResponseWriter, r *http.Request) {
@@ -178,12 +283,37 @@ func (c *PostController) Edit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Recuperar post existente (para obtener ImageID)
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	oldPost, err := c.postUsecase.GetPostByID(ctx, id)
 	if err != nil {
 		http.Error(w, "No existe el post", http.StatusNotFound)
 		return
 	}
+	writeErr := func(err error) {
+		if writeReadOnly(w, err) {
+			return
+		}
+		if errors.Is(err, usecases.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if isInvalidTag(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error editando post: %v", err)
+		http.Error(w, "No se pudo editar el post", http.StatusInternalServerError)
+	}
+	// Antes de subir la imagen nueva, para no dejarla huérfana
+	if err := c.postUsecase.CanEdit(ctx, &oldPost.Post, token.UID); err != nil {
+		writeErr(err)
+		return
+	}
 
 	ct := r.Header.Get("Content-Type")
 	if !strings.HasPrefix(ct, "multipart/form-data") {
@@ -199,7 +329,13 @@ func (c *PostController) Edit(w http.ResponseWriter, r *http.Request) {
 	content := r.FormValue("content")
 	tags := r.FormValue("tags")
 
-	update := &models.Post{}
+	// Los campos que no vienen en el formulario conservan su valor. La
+	// galería no se manda: la portada se cambia aparte con ReplaceCover.
+	update := &models.Post{
+		Title:   oldPost.Post.Title,
+		Content: oldPost.Post.Content,
+		Tags:    oldPost.Post.Tags,
+	}
 	if title != "" {
 		update.Title = title
 	}
@@ -215,51 +351,49 @@ func (c *PostController) Edit(w http.ResponseWriter, r *http.Request) {
 		update.Tags = parsedTags
 	}
 
-	// Procesar nueva imagen si viene
+	// Procesar nueva imagen si viene: reemplaza la portada de la galería. La
+	// imagen anterior no se borra: la siguen usando las revisiones anteriores
+	// del post. Se borra cuando se purga el post.
+	var url, publicID string
 	file, _, errFile := r.FormFile("image")
 	if errFile == nil {
 		defer file.Close()
 
-		// Destruir imagen antigua
-		if oldPost.Post.ImageID != "" {
-			if _, err := c.cld.Upload.Destroy(ctx, uploader.DestroyParams{
-				PublicID:   oldPost.Post.ImageID,
-				Invalidate: func(b bool) *bool { return &b }(true),
-			}); err != nil {
-				log.Printf("no se pudo borrar imagen antigua: %v", err)
-			}
-		}
-
-		// Subir nueva imagen
-		uploadParams := uploader.UploadParams{
-			Folder:    "posts_images",
-			PublicID:  fmt.Sprintf("post_%d", time.Now().Unix()),
-			Overwrite: func(b bool) *bool { return &b }(true),
-		}
-		res, errUp := c.cld.Upload.Upload(ctx, file, uploadParams)
+		var errUp error
+		url, publicID, errUp = c.media.Upload(ctx, file)
 		if errUp != nil {
 			http.Error(w, "Error subiendo imagen: "+errUp.Error(), http.StatusInternalServerError)
 			return
 		}
-
-		update.ImageURL = res.SecureURL
-		update.ImageID = res.PublicID
-	} else {
-		update.ImageURL = oldPost.Post.ImageURL
-		update.ImageID = oldPost.Post.ImageID
+	}
+	// Si la edición falla, la imagen subida no quedó en el post
+	discard := func() {
+		if publicID == "" {
+			return
+		}
+		if errDel := c.media.Delete(ctx, publicID); errDel != nil {
+			log.Printf("⚠️ No se pudo borrar la imagen %s: %v", publicID, errDel)
+		}
 	}
 
-	if err := c.postUsecase.EditPost(ctx, id, update); err != nil {
-		log.Printf("Error editando post: %v", err)
-		http.Error(w, "No se pudo editar el post", http.StatusInternalServerError)
+	if err := c.postUsecase.EditPost(ctx, id, token.UID, update); err != nil {
+		discard()
+		writeErr(err)
 		return
 	}
+	if publicID != "" {
+		if err := c.postUsecase.ReplaceCover(ctx, id, token.UID, url, publicID); err != nil {
+			discard()
+			writeErr(err)
+			return
+		}
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (c *PostController) GetByID(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok || id == "" {
@@ -268,6 +402,10 @@ func (c *PostController) GetByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	post, err := c.postUsecase.GetPostByID(ctx, id)
+	if errors.Is(err, usecases.ErrNotFound) {
+		http.Error(w, "No existe el post", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		log.Printf("Error obteniendo post: %v", err)
 		http.Error(w, "No se pudo obtener el post", http.StatusInternalServerError)
@@ -279,15 +417,88 @@ func (c *PostController) GetByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(post)
 }
 
+// @Summary Historial de ediciones de un post
+// @Description Devuelve las revisiones del post de la más antigua a la más nueva, con su editor y fecha. La revisión 1 es el post como se publicó.
+// @Tags Post
+// @Produce json
+// @Param id path string true "ID del post"
+// @Success 200 {array} models.PostRevision "Revisiones del post"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/post/{id}/revisions [get]
+func (c *PostController) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := c.postUsecase.GetRevisions(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, usecases.ErrNotFound) {
+		http.Error(w, "No existe el post", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error obteniendo revisiones: %v", err)
+		http.Error(w, "No se pudieron obtener las revisiones", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// @Summary Comparar dos revisiones de un post
+// @Description Devuelve los cambios de título y contenido línea por línea, los tags agregados y quitados y si cambió la imagen entre las revisiones from y to.
+// @Tags Post
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param from query int true "Número de la revisión de origen"
+// @Param to query int true "Número de la revisión de destino"
+// @Success 200 {object} models.RevisionDiff "Cambios entre las revisiones"
+// @Failure 400 {object} map[string]string "from o to inválidos"
+// @Failure 404 {object} map[string]string "El post o alguna de las revisiones no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/post/{id}/revisions/diff [get]
+func (c *PostController) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "from y to deben ser números de revisión", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.postUsecase.DiffRevisions(r.Context(), mux.Vars(r)["id"], from, to)
+	if errors.Is(err, usecases.ErrNotFound) {
+		http.Error(w, "No existe el post o la revisión", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error comparando revisiones: %v", err)
+		http.Error(w, "No se pudieron comparar las revisiones", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func (c *PostController) GetByAuthorID(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	authorID := r.URL.Query().Get("author_id")
 	if authorID == "" {
 		http.Error(w, "ID de autor es obligatorio", http.StatusBadRequest)
 		return
 	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	// El autor ve también sus borradores
+	var viewerID string
+	if token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token); ok {
+		viewerID = token.UID
+	}
 
-	posts, err := c.postUsecase.GetPostsByAuthorID(ctx, authorID)
+	posts, err := c.postUsecase.GetPostsByAuthorID(ctx, authorID, viewerID, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error obteniendo posts del autor: %v", err)
 		http.Error(w, "No se pudieron obtener los posts", http.StatusInternalServerError)
@@ -299,6 +510,136 @@ func (c *PostController) GetByAuthorID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(posts)
 }
 
+// @Summary Listar mis borradores
+// @Description Obtiene una página de los borradores y posts programados del usuario autenticado, más recientes primero.
+// @Tags Post
+// @Produce json
+// @Param limit query int false "Número de posts por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página de borradores"
+// @Failure 400 {object} map[string]string "limit o cursor inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/drafts [get]
+func (c *PostController) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := c.postUsecase.GetDrafts(r.Context(), token.UID, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error obteniendo borradores: %v", err)
+		http.Error(w, "No se pudieron obtener los borradores", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(posts)
+}
+
+// DraftRequest es el contenido de un borrador. Sin publish_at queda como
+// borrador; con publish_at se publica solo en esa fecha.
+type DraftRequest struct {
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	Tags      []string   `json:"tags"`
+	ForumID   string     `json:"forum_id"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// writeDraftError responde con el código que corresponde al error del borrador.
+func writeDraftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el post", http.StatusNotFound)
+	case errors.Is(err, usecases.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repositories.ErrPublished):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecases.ErrPublishAt), isInvalidTag(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error en el borrador: %v", err)
+		http.Error(w, "No se pudo guardar el borrador", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Editar un borrador
+// @Description Reemplaza el título, el contenido, los tags, el subforo y la fecha de publicación de un borrador propio. Sin publish_at queda como borrador; con publish_at, programado.
+// @Tags Post
+// @Accept json
+// @Param id path string true "ID del post"
+// @Param draft body DraftRequest true "Contenido del borrador"
+// @Success 204 "Borrador guardado"
+// @Failure 400 {object} map[string]string "Cuerpo inválido, publish_at pasada o tag inválido"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 409 {object} map[string]string "El post ya está publicado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/drafts/{id} [put]
+func (c *PostController) UpdateDraft(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req DraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Title == "" || req.Content == "" {
+		http.Error(w, "title y content son obligatorios", http.StatusBadRequest)
+		return
+	}
+
+	draft := &models.Post{
+		Title:     req.Title,
+		Content:   req.Content,
+		Tags:      req.Tags,
+		ForumID:   req.ForumID,
+		PublishAt: req.PublishAt,
+	}
+	if err := c.postUsecase.UpdateDraft(r.Context(), mux.Vars(r)["id"], token.UID, draft); err != nil {
+		writeDraftError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Publicar un borrador
+// @Description Publica ahora un borrador o post programado propio y calcula su veredicto con IA.
+// @Tags Post
+// @Param id path string true "ID del post"
+// @Success 204 "Post publicado"
+// @Failure 403 {object} map[string]string "El post es de otro usuario"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 409 {object} map[string]string "El post ya está publicado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/drafts/{id}/publish [post]
+func (c *PostController) PublishDraft(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.postUsecase.PublishDraft(r.Context(), mux.Vars(r)["id"], token.UID); err != nil {
+		writeDraftError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // @Summary Obtener posts votados por el usuario
 // @Description Obtiene una lista de todos los posts que el usuario ha votado (like o dislike).
 // @Tags Post
@@ -308,7 +649,7 @@ func (c *PostController) GetByAuthorID(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/posts/liked [get]
 func (c *PostController) GetPostsILiked(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
 		http.Error(w, "user_id es obligatorio", http.StatusBadRequest)
@@ -364,6 +705,11 @@ func (c *PostController) UnsavePost(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Lista los posts guardados por un usuario
+// @Description Los posts se devuelven paginados, del guardado más reciente al más antiguo.
+// @Param user_id query string true "ID del usuario"
+// @Param limit query int false "Número de posts por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página de posts guardados"
 // @Router /api/posts/saved [get]
 func (c *PostController) GetSavedPosts(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
@@ -371,7 +717,15 @@ func (c *PostController) GetSavedPosts(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "user_id es obligatorio", http.StatusBadRequest)
 		return
 	}
-	posts, err := c.postUsecase.GetSavedPosts(r.Context(), userID)
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, err := c.postUsecase.GetSavedPosts(r.Context(), userID, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error obteniendo guardados: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -405,13 +759,17 @@ func (c *PostController) IsSaved(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Obtener posts de un subforo
-// @Description Obtiene todos los posts que pertenecen a un subforo (forum_id).
+// @Description Obtiene una página de los posts que pertenecen a un subforo (forum_id). Por defecto, más recientes primero. La primera página empieza con los posts fijados por los moderadores, que no cuentan para limit.
 // @Tags Post
 // @Accept json
 // @Produce json
 // @Param forum_id path string true "ID del subforo"
-// @Success 200 {array} models.Post "Lista de posts del subforo"
-// @Failure 400 {object} map[string]string "forum_id es obligatorio"
+// @Param sort query string false "Orden: new (por defecto), hot, top, controversial o rising"
+// @Param t query string false "Ventana de top y controversial: day (por defecto), week, month, year o all"
+// @Param limit query int false "Número de posts por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página de posts del subforo"
+// @Failure 400 {object} map[string]string "forum_id es obligatorio, o sort, t, limit o cursor inválidos"
 // @Failure 500 {object} map[string]string "Error interno"
 // @Router /public/posts/forum/{forum_id} [get]
 func (c *PostController) GetPostsByForumID(w http.ResponseWriter, r *http.Request) {
@@ -421,7 +779,19 @@ func (c *PostController) GetPostsByForumID(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "forum_id es obligatorio", http.StatusBadRequest)
 		return
 	}
-	posts, err := c.postUsecase.GetPostsByForumID(r.Context(), forumID)
+	order, ok := postSortFromQuery(w, r, models.SortNew)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, err := c.postUsecase.GetPostsByForumID(r.Context(), forumID, order, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error obteniendo posts del foro", http.StatusInternalServerError)
 		return
@@ -438,7 +808,15 @@ func (c *PostController) GetPostsByForumIDWithVerdict(w http.ResponseWriter, r *
 		http.Error(w, "forum_id y verdict son obligatorios", http.StatusBadRequest)
 		return
 	}
-	posts, err := c.postUsecase.GetPostsByForumIDWithVerdict(r.Context(), forumID, verdict)
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, err := c.postUsecase.GetPostsByForumIDWithVerdict(r.Context(), forumID, verdict, page)
+	if isInvalidCursor(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error obteniendo posts del foro", http.StatusInternalServerError)
 		return
@@ -469,3 +847,87 @@ func (c *PostController) ReportPost(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// CrosspostRequest es el subforo en el que se comparte el post y, opcional,
+// el título del crosspost.
+type CrosspostRequest struct {
+	ForumID string `json:"forum_id"`
+	Title   string `json:"title"`
+}
+
+// @Summary Compartir un post en otro subforo
+// @Description Crea un crosspost: un post nuevo del usuario en el subforo forum_id que referencia al original (crosspost_of) y tiene sus propios votos y comentarios. Copia el título (salvo que se envíe title), el contenido, los tags y el enlace. El veredicto de IA se calcula con la descripción del subforo de destino. Compartir un crosspost comparte su original.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param crosspost body CrosspostRequest true "Subforo de destino y título opcional"
+// @Success 201 {object} models.Post "Crosspost creado"
+// @Failure 400 {object} map[string]string "Falta forum_id o es el subforo del original"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 404 {object} map[string]string "El post o el subforo no existe"
+// @Failure 409 {object} map[string]string "El post ya se compartió en ese subforo"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/crosspost [post]
+func (c *PostController) Crosspost(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req CrosspostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ForumID == "" {
+		http.Error(w, "forum_id es obligatorio", http.StatusBadRequest)
+		return
+	}
+
+	post, err := c.postUsecase.Crosspost(r.Context(), mux.Vars(r)["id"], token.UID, req.ForumID, req.Title)
+	switch {
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el post o el subforo", http.StatusNotFound)
+		return
+	case errors.Is(err, usecases.ErrCrosspostSameForum):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, usecases.ErrAlreadyCrossposted):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Error compartiendo post: %v", err)
+		http.Error(w, "No se pudo compartir el post", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(post)
+}
+
+// @Summary Crossposts de un post
+// @Description Lista los crossposts del post, los más recientes primero; su forum_id indica en qué subforos se compartió.
+// @Tags Post
+// @Produce json
+// @Param id path string true "ID del post"
+// @Success 200 {array} models.Post "Crossposts del post"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/post/{id}/crossposts [get]
+func (c *PostController) GetCrossposts(w http.ResponseWriter, r *http.Request) {
+	crossposts, err := c.postUsecase.GetCrossposts(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, usecases.ErrNotFound) {
+		http.Error(w, "No existe el post", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error obteniendo crossposts: %v", err)
+		http.Error(w, "No se pudieron obtener los crossposts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(crossposts)
+}
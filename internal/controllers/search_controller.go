@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type SearchController struct {
+	usecase usecases.SearchUsecase
+}
+
+func NewSearchController(usecase usecases.SearchUsecase) *SearchController {
+	return &SearchController{usecase: usecase}
+}
+
+// searchDate lee una fecha RFC3339 o un día (2006-01-02). Un día como límite
+// final (end) abarca el día completo.
+func searchDate(raw string, end bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// @Summary Buscar
+// @Description Busca el texto en los posts, comentarios y subforos visibles, de mayor a menor relevancia. La búsqueda ignora mayúsculas y tildes y encuentra las distintas formas de una palabra (canción, canciones). Cada resultado trae fragmentos del título y el contenido con las palabras encontradas entre <mark> y </mark>.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Texto a buscar"
+// @Param type query string false "Tipo de resultado: post, comment o subforo"
+// @Param forum query string false "ID del subforo"
+// @Param author query string false "ID del autor (en los subforos, su creador)"
+// @Param tag query string false "Tag de los posts o categoría de los subforos"
+// @Param from query string false "Creados desde esta fecha (RFC3339 o 2006-01-02)"
+// @Param to query string false "Creados hasta esta fecha, incluida (RFC3339 o 2006-01-02)"
+// @Param limit query int false "Número de resultados por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.SearchHit] "Página de resultados"
+// @Failure 400 {object} map[string]string "Falta q o algún filtro es inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/search [get]
+func (c *SearchController) Search(w http.ResponseWriter, r *http.Request) {
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	q := models.SearchQuery{
+		Text:     query.Get("q"),
+		Type:     models.SearchType(query.Get("type")),
+		ForumID:  query.Get("forum"),
+		AuthorID: query.Get("author"),
+		Tag:      query.Get("tag"),
+	}
+	var err error
+	if q.From, err = searchDate(query.Get("from"), false); err != nil {
+		http.Error(w, "from debe ser una fecha RFC3339 o 2006-01-02", http.StatusBadRequest)
+		return
+	}
+	if q.To, err = searchDate(query.Get("to"), true); err != nil {
+		http.Error(w, "to debe ser una fecha RFC3339 o 2006-01-02", http.StatusBadRequest)
+		return
+	}
+
+	hits, err := c.usecase.Search(r.Context(), q, page)
+	if err != nil {
+		switch {
+		case isInvalidCursor(err), errors.Is(err, models.ErrEmptySearch), errors.Is(err, models.ErrInvalidSearchType), isInvalidTag(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Error al buscar: %v", err)
+			http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hits)
+}
+
+// @Summary Búsqueda semántica
+// @Description Busca los posts de significado parecido al texto aunque no compartan palabras (por ejemplo, preguntas ya hechas con otras palabras), de mayor a menor similitud. Compara el vector del texto con los de los posts, calculados con all-MiniLM-L6-v2 al crearlos y editarlos.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Texto a buscar"
+// @Param forum query string false "ID del subforo"
+// @Param limit query int false "Número de resultados por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.SemanticHit] "Página de resultados"
+// @Failure 400 {object} map[string]string "Falta q, o limit o cursor inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Failure 503 {object} map[string]string "No se pudo calcular el vector de la búsqueda"
+// @Router /public/search/semantic [get]
+func (c *SearchController) Semantic(w http.ResponseWriter, r *http.Request) {
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	hits, err := c.usecase.Semantic(r.Context(), r.URL.Query().Get("q"), r.URL.Query().Get("forum"), page)
+	if err != nil {
+		switch {
+		case isInvalidCursor(err), errors.Is(err, models.ErrEmptySearch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, usecases.ErrSemanticUnavailable):
+			log.Printf("Error en la búsqueda semántica: %v", err)
+			http.Error(w, usecases.ErrSemanticUnavailable.Error(), http.StatusServiceUnavailable)
+		default:
+			log.Printf("Error en la búsqueda semántica: %v", err)
+			http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hits)
+}
+
+// @Summary Posts relacionados
+// @Description Devuelve los posts más parecidos al post ("más como este"), de mayor a menor puntaje. El puntaje, entre 0 y 1, mezcla la similitud de sus vectores, los tags en común y si son del mismo subforo. No incluye los posts reportados ni los de la papelera. El resultado se guarda por post hasta que se edita él o uno de sus relacionados, o pasa RELATED_CACHE_TTL.
+// @Tags Search
+// @Produce json
+// @Param id path string true "ID del post"
+// @Param limit query int false "Número de posts (por defecto 5, máximo 20)"
+// @Success 200 {array} models.RelatedPost "Posts relacionados"
+// @Failure 400 {object} map[string]string "limit inválido"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/post/{id}/related [get]
+func (c *SearchController) Related(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			http.Error(w, "limit debe ser un entero positivo", http.StatusBadRequest)
+			return
+		}
+	}
+
+	related, err := c.usecase.Related(r.Context(), mux.Vars(r)["id"], limit)
+	if errors.Is(err, usecases.ErrNotFound) {
+		http.Error(w, "No existe el post", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error obteniendo posts relacionados: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(related)
+}
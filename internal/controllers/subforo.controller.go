@@ -46,29 +46,31 @@ func (c *SubforoController) checkPermissions(ctx context.Context, subforoID stri
 	if err != nil {
 		return false
 	}
-	if subforo.CreatedBy == userID {
-		return true
-	}
-
-	for _, mod := range subforo.Moderators {
-		if mod == userID {
-			return true
-		}
-	}
-	return false
+	return subforo.CanModerate(userID)
 }
 
 // @Summary Obtener todos los subforos
-// @Description Obtiene una lista de todos los subforos ordenados por fecha de creación.
+// @Description Obtiene una página de los subforos activos ordenados por fecha de creación.
 // @Tags Subforo
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Subforo "Lista de subforos"
+// @Param limit query int false "Número de subforos por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Subforo] "Página de subforos"
+// @Failure 400 {object} map[string]string "limit o cursor inválidos"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
-// @Router /api/subforos [get]
+// @Router /public/subforos [get]
 func (c *SubforoController) GetAll(w http.ResponseWriter, r *http.Request) {
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
 	ctx := context.Background()
-	subforos, err := c.subforoUsecase.GetAllSubforos(ctx)
+	subforos, err := c.subforoUsecase.GetAllSubforos(ctx, page)
+	if isInvalidCursor(err) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("Error obteniendo subforos: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -79,17 +81,9 @@ func (c *SubforoController) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Filtrar solo los subforos activos
-	activeSubforos := make([]*models.Subforo, 0)
-	for _, s := range subforos {
-		if s.IsActive {
-			activeSubforos = append(activeSubforos, s)
-		}
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(activeSubforos)
+	json.NewEncoder(w).Encode(subforos)
 }
 
 func (c *SubforoController) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -454,3 +448,28 @@ func (c *SubforoController) GetSubforosByUserID(w http.ResponseWriter, r *http.R
 	}
 	respondWithJSON(w, http.StatusOK, subforos)
 }
+
+// @Summary Subforos recomendados
+// @Description Devuelve subforos activos de los que el usuario no es miembro, del más recomendado al menos. El puntaje mezcla las categorías en común con sus subforos, la similitud de sus posts con la descripción y los miembros nuevos del último mes; reason explica la recomendación y becauseOf es el subforo del usuario con más categorías en común.
+// @Tags Subforo
+// @Produce json
+// @Param limit query int false "Número de subforos (por defecto 10, máximo 50)"
+// @Success 200 {array} models.SubforoRecommendation "Subforos recomendados"
+// @Failure 400 {object} map[string]string "limit inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/subforos/recommended [get]
+func (c *SubforoController) Recommended(w http.ResponseWriter, r *http.Request) {
+	limit, ok := limitFromQuery(w, r)
+	if !ok {
+		return
+	}
+	token := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+
+	recommended, err := c.subforoUsecase.Recommended(r.Context(), token.UID, limit)
+	if err != nil {
+		log.Printf("Error obteniendo subforos recomendados: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Error interno del servidor")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, recommended)
+}
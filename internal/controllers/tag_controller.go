@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/gorilla/mux"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type TagController struct {
+	usecase usecases.TagUsecase
+}
+
+func NewTagController(usecase usecases.TagUsecase) *TagController {
+	return &TagController{usecase: usecase}
+}
+
+// isInvalidTag indica si err se debe a un tag vacío o demasiado largo.
+func isInvalidTag(err error) bool {
+	return errors.Is(err, models.ErrInvalidTag) || errors.Is(err, models.ErrTagTooLong)
+}
+
+// writeTagError traduce los errores de TagUsecase a respuestas HTTP.
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case isInvalidCursor(err), isInvalidTag(err), errors.Is(err, models.ErrInvalidWindow), errors.Is(err, usecases.ErrSameTag):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecases.ErrNotFound):
+		http.Error(w, "No existe el tag", http.StatusNotFound)
+	case errors.Is(err, usecases.ErrTagInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error en los tags: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Autocompletar tags
+// @Description Devuelve los tags con posts que empiezan con q, del que más posts tiene al que menos. q se normaliza igual que los tags (sin mayúsculas, tildes ni #) y los sinónimos que coinciden devuelven su tag.
+// @Tags Tag
+// @Produce json
+// @Param q query string true "Comienzo del tag"
+// @Param limit query int false "Número de tags (por defecto 10, máximo 50)"
+// @Success 200 {array} models.Tag "Tags"
+// @Failure 400 {object} map[string]string "Falta q o limit inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/tags [get]
+func (c *TagController) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	limit, ok := limitFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := c.usecase.Autocomplete(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// @Summary Tags en tendencia
+// @Description Devuelve los tags con más posts publicados en la ventana, con la cantidad en recent_posts.
+// @Tags Tag
+// @Produce json
+// @Param t query string false "Ventana: day, week (por defecto), month, year o all"
+// @Param limit query int false "Número de tags (por defecto 10, máximo 50)"
+// @Success 200 {array} models.Tag "Tags"
+// @Failure 400 {object} map[string]string "t o limit inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/tags/trending [get]
+func (c *TagController) Trending(w http.ResponseWriter, r *http.Request) {
+	limit, ok := limitFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := c.usecase.Trending(r.Context(), models.SortWindow(r.URL.Query().Get("t")), limit)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// @Summary Obtener un tag
+// @Description Devuelve el tag con su cantidad de posts y sus sinónimos. Si el tag es un sinónimo, devuelve el tag al que equivale.
+// @Tags Tag
+// @Produce json
+// @Param tag path string true "Tag"
+// @Success 200 {object} models.Tag "Tag"
+// @Failure 404 {object} map[string]string "El tag no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/tags/{tag} [get]
+func (c *TagController) GetTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := c.usecase.GetTag(r.Context(), mux.Vars(r)["tag"])
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// @Summary Posts de un tag
+// @Description Obtiene una página con los posts publicados con el tag, o con el tag del sinónimo. No incluye los posts reportados. Por defecto se ordenan por new.
+// @Tags Tag
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param sort query string false "Orden: new (por defecto), hot, top, controversial o rising"
+// @Param t query string false "Ventana de top y controversial: day (por defecto), week, month, year o all"
+// @Param limit query int false "Número de publicaciones por página (por defecto 20, máximo 100)"
+// @Param cursor query string false "Cursor next_cursor de la página anterior"
+// @Success 200 {object} models.Page[models.Post] "Página de posts"
+// @Failure 400 {object} map[string]string "sort, t, limit o cursor inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /public/tags/{tag}/posts [get]
+func (c *TagController) Posts(w http.ResponseWriter, r *http.Request) {
+	order, ok := postSortFromQuery(w, r, models.SortNew)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := c.usecase.PostsByTag(r.Context(), mux.Vars(r)["tag"], order, page)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(posts)
+}
+
+// @Summary Seguir un tag
+// @Description Los posts con el tag aparecen en el feed del usuario. Seguir un sinónimo sigue su tag; seguir dos veces no es un error.
+// @Tags Tag
+// @Produce json
+// @Param tag path string true "Tag"
+// @Success 200 {object} models.TagFollow "Tag seguido"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 404 {object} map[string]string "El tag no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/tags/{tag}/follow [post]
+func (c *TagController) Follow(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	follow, err := c.usecase.Follow(r.Context(), token.UID, mux.Vars(r)["tag"])
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(follow)
+}
+
+// @Summary Dejar de seguir un tag
+// @Description Deja de seguir el tag, si lo seguía.
+// @Tags Tag
+// @Param tag path string true "Tag"
+// @Success 204 "Tag dejado de seguir"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/tags/{tag}/follow [delete]
+func (c *TagController) Unfollow(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.usecase.Unfollow(r.Context(), token.UID, mux.Vars(r)["tag"]); err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Tags seguidos
+// @Description Lista los tags que sigue el usuario autenticado, los más recientes primero.
+// @Tags Tag
+// @Produce json
+// @Success 200 {array} models.TagFollow "Tags seguidos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/tags/followed [get]
+func (c *TagController) Followed(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	follows, err := c.usecase.Followed(r.Context(), token.UID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(follows)
+}
+
+// @Summary Agregar un sinónimo
+// @Description Hace del sinónimo un equivalente del tag: los posts que se crean o editan con el sinónimo guardan el tag. Si el sinónimo ya es un tag con posts o sinónimos, hay que unirlo al tag.
+// @Tags Admin
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param synonym path string true "Sinónimo"
+// @Success 200 {object} models.Tag "Tag con sus sinónimos"
+// @Failure 400 {object} map[string]string "Sinónimo inválido o igual al tag"
+// @Failure 403 {object} map[string]string "No es administrador"
+// @Failure 404 {object} map[string]string "El tag no existe"
+// @Failure 409 {object} map[string]string "El sinónimo tiene posts o sinónimos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/{tag}/synonyms/{synonym} [put]
+func (c *TagController) AddSynonym(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tag, err := c.usecase.AddSynonym(r.Context(), vars["tag"], vars["synonym"])
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// @Summary Quitar un sinónimo
+// @Description El sinónimo pasa a ser un tag independiente, sin posts.
+// @Tags Admin
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param synonym path string true "Sinónimo"
+// @Success 200 {object} models.Tag "Tag con sus sinónimos"
+// @Failure 403 {object} map[string]string "No es administrador"
+// @Failure 404 {object} map[string]string "El tag no existe o no tiene ese sinónimo"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/{tag}/synonyms/{synonym} [delete]
+func (c *TagController) RemoveSynonym(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tag, err := c.usecase.RemoveSynonym(r.Context(), vars["tag"], vars["synonym"])
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// MergeTagRequest es el cuerpo de la unión de tags.
+type MergeTagRequest struct {
+	Into string `json:"into"`
+}
+
+// @Summary Unir tags
+// @Description Reemplaza el tag por into en todos los posts, borradores y papelera incluidos, y deja al tag y a sus sinónimos como sinónimos de into.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param tag path string true "Tag a unir"
+// @Param merge body MergeTagRequest true "Tag que queda"
+// @Success 200 {object} models.Tag "Tag into con sus sinónimos"
+// @Failure 400 {object} map[string]string "Cuerpo inválido o tags iguales"
+// @Failure 403 {object} map[string]string "No es administrador"
+// @Failure 404 {object} map[string]string "Alguno de los tags no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/{tag}/merge [post]
+func (c *TagController) Merge(w http.ResponseWriter, r *http.Request) {
+	var req MergeTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Into == "" {
+		http.Error(w, "into es obligatorio", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := c.usecase.Merge(r.Context(), mux.Vars(r)["tag"], req.Into)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// @Summary Recontar los tags
+// @Description Vuelve a contar los posts de todos los tags y agrega al registro los tags de los posts que no estaban. Sirve para armar el registro después de migrar los posts anteriores.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} map[string]int "Cantidad de tags contados"
+// @Failure 403 {object} map[string]string "No es administrador"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/recount [post]
+func (c *TagController) Recount(w http.ResponseWriter, r *http.Request) {
+	n, err := c.usecase.RecountAll(r.Context())
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"tags": n})
+}
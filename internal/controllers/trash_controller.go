@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/gorilla/mux"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+type TrashController struct {
+	usecase usecases.TrashUsecase
+	admins  *middleware.AdminMiddleware
+}
+
+// NewTrashController crea el controlador de la papelera. Los moderadores de
+// cada subforo pueden eliminar y restaurar sus posts y comentarios; los
+// administradores de admins, los de cualquier subforo.
+func NewTrashController(usecase usecases.TrashUsecase, admins *middleware.AdminMiddleware) *TrashController {
+	return &TrashController{usecase: usecase, admins: admins}
+}
+
+// caller devuelve el UID del usuario autenticado y si es administrador. Si no
+// hay token responde 401 y devuelve false.
+func (c *TrashController) caller(w http.ResponseWriter, r *http.Request) (string, bool, bool) {
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false, false
+	}
+	return token.UID, c.admins.IsAdmin(token), true
+}
+
+// writeTrashError responde con el código que corresponde al error de la papelera.
+func writeTrashError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, usecases.ErrNotFound), errors.Is(err, repositories.ErrNotInTrash):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Printf("Error en la papelera: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+	}
+}
+
+// DeletePost godoc
+// @Summary Eliminar un post
+// @Description Manda el post a la papelera, de donde se puede restaurar hasta que se purga. Solo su autor o un moderador del subforo.
+// @Tags Posts
+// @Param id query string true "ID del post"
+// @Success 204 "Post enviado a la papelera"
+// @Failure 400 {object} map[string]string "Falta el ID"
+// @Failure 403 {object} map[string]string "El usuario no es el autor ni moderador del subforo"
+// @Failure 404 {object} map[string]string "El post no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts [delete]
+func (c *TrashController) DeletePost(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "ID de la publicación es obligatorio", http.StatusBadRequest)
+		return
+	}
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.DeletePost(r.Context(), id, uid, admin); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestorePost godoc
+// @Summary Restaurar un post
+// @Description Saca el post de la papelera. Solo quien lo eliminó o un moderador del subforo.
+// @Tags Posts
+// @Param id path string true "ID del post"
+// @Success 204 "Post restaurado"
+// @Failure 403 {object} map[string]string "El usuario no lo eliminó ni es moderador del subforo"
+// @Failure 404 {object} map[string]string "El post no está en la papelera"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/posts/{id}/restore [post]
+func (c *TrashController) RestorePost(w http.ResponseWriter, r *http.Request) {
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.RestorePost(r.Context(), mux.Vars(r)["id"], uid, admin); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteComment godoc
+// @Summary Eliminar un comentario
+// @Description Manda el comentario a la papelera junto con sus respuestas, que dejan de mostrarse. Solo su autor o un moderador del subforo.
+// @Tags Comments
+// @Param commentId path string true "ID del comentario"
+// @Success 204 "Comentario enviado a la papelera"
+// @Failure 403 {object} map[string]string "El usuario no es el autor ni moderador del subforo"
+// @Failure 404 {object} map[string]string "El comentario no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/comments/{commentId} [delete]
+func (c *TrashController) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.DeleteComment(r.Context(), mux.Vars(r)["commentId"], uid, admin); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreComment godoc
+// @Summary Restaurar un comentario
+// @Description Saca el comentario de la papelera. Solo quien lo eliminó o un moderador del subforo.
+// @Tags Comments
+// @Param commentId path string true "ID del comentario"
+// @Success 204 "Comentario restaurado"
+// @Failure 403 {object} map[string]string "El usuario no lo eliminó ni es moderador del subforo"
+// @Failure 404 {object} map[string]string "El comentario no está en la papelera"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/comments/{commentId}/restore [post]
+func (c *TrashController) RestoreComment(w http.ResponseWriter, r *http.Request) {
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.RestoreComment(r.Context(), mux.Vars(r)["commentId"], uid, admin); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MyTrashedPosts godoc
+// @Summary Mi papelera de posts
+// @Description Lista los posts que eliminó el usuario autenticado, del más reciente al más antiguo.
+// @Tags Trash
+// @Produce json
+// @Param limit query int false "Cantidad de posts por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Post]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/trash/posts [get]
+func (c *TrashController) MyTrashedPosts(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+	c.trashedPosts(w, r, models.TrashFilter{DeletedBy: uid})
+}
+
+// MyTrashedComments godoc
+// @Summary Mi papelera de comentarios
+// @Description Lista los comentarios que eliminó el usuario autenticado, del más reciente al más antiguo.
+// @Tags Trash
+// @Produce json
+// @Param limit query int false "Cantidad de comentarios por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Comment]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/trash/comments [get]
+func (c *TrashController) MyTrashedComments(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+	c.trashedComments(w, r, models.TrashFilter{DeletedBy: uid})
+}
+
+// moderatorFilter arma el filtro de la papelera de administración: deleted_by
+// (por defecto el propio administrador) y author_id opcional.
+func (c *TrashController) moderatorFilter(w http.ResponseWriter, r *http.Request) (models.TrashFilter, bool) {
+	uid, _, ok := c.caller(w, r)
+	if !ok {
+		return models.TrashFilter{}, false
+	}
+	filter := models.TrashFilter{
+		DeletedBy: r.URL.Query().Get("deleted_by"),
+		AuthorID:  r.URL.Query().Get("author_id"),
+	}
+	if filter.DeletedBy == "" {
+		filter.DeletedBy = uid
+	}
+	return filter, true
+}
+
+// TrashedPosts godoc
+// @Summary Papelera de posts de moderación
+// @Description Lista los posts eliminados por un moderador o usuario (por defecto el que consulta), opcionalmente de un solo autor. Solo administradores.
+// @Tags Admin
+// @Produce json
+// @Param deleted_by query string false "UID de quien eliminó los posts"
+// @Param author_id query string false "UID del autor de los posts"
+// @Param limit query int false "Cantidad de posts por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Post]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 403 {object} map[string]string "El usuario no es administrador"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/trash/posts [get]
+func (c *TrashController) TrashedPosts(w http.ResponseWriter, r *http.Request) {
+	filter, ok := c.moderatorFilter(w, r)
+	if !ok {
+		return
+	}
+	c.trashedPosts(w, r, filter)
+}
+
+// TrashedComments godoc
+// @Summary Papelera de comentarios de moderación
+// @Description Lista los comentarios eliminados por un moderador o usuario (por defecto el que consulta), opcionalmente de un solo autor. Solo administradores.
+// @Tags Admin
+// @Produce json
+// @Param deleted_by query string false "UID de quien eliminó los comentarios"
+// @Param author_id query string false "UID del autor de los comentarios"
+// @Param limit query int false "Cantidad de comentarios por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Comment]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 403 {object} map[string]string "El usuario no es administrador"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/trash/comments [get]
+func (c *TrashController) TrashedComments(w http.ResponseWriter, r *http.Request) {
+	filter, ok := c.moderatorFilter(w, r)
+	if !ok {
+		return
+	}
+	c.trashedComments(w, r, filter)
+}
+
+// forumFilter arma el filtro de la papelera de un subforo: deleted_by y
+// author_id opcionales.
+func forumFilter(r *http.Request) models.TrashFilter {
+	return models.TrashFilter{
+		ForumID:   mux.Vars(r)["id"],
+		DeletedBy: r.URL.Query().Get("deleted_by"),
+		AuthorID:  r.URL.Query().Get("author_id"),
+	}
+}
+
+// ForumTrashedPosts godoc
+// @Summary Papelera de posts de un subforo
+// @Description Lista los posts eliminados del subforo, opcionalmente solo los que eliminó un usuario o los de un autor. Solo moderadores del subforo.
+// @Tags Trash
+// @Produce json
+// @Param id path string true "ID del subforo"
+// @Param deleted_by query string false "UID de quien eliminó los posts"
+// @Param author_id query string false "UID del autor de los posts"
+// @Param limit query int false "Cantidad de posts por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Post]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 403 {object} map[string]string "El usuario no modera el subforo"
+// @Failure 404 {object} map[string]string "El subforo no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/subforos/{id}/trash/posts [get]
+func (c *TrashController) ForumTrashedPosts(w http.ResponseWriter, r *http.Request) {
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, err := c.usecase.ForumTrashedPosts(r.Context(), uid, admin, forumFilter(r), page)
+	if err != nil {
+		if isInvalidCursor(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTrashError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(posts)
+}
+
+// ForumTrashedComments godoc
+// @Summary Papelera de comentarios de un subforo
+// @Description Lista los comentarios eliminados de posts del subforo, opcionalmente solo los que eliminó un usuario o los de un autor. Solo moderadores del subforo.
+// @Tags Trash
+// @Produce json
+// @Param id path string true "ID del subforo"
+// @Param deleted_by query string false "UID de quien eliminó los comentarios"
+// @Param author_id query string false "UID del autor de los comentarios"
+// @Param limit query int false "Cantidad de comentarios por página"
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Success 200 {object} models.Page[models.Comment]
+// @Failure 400 {object} map[string]string "Parámetros de paginación inválidos"
+// @Failure 403 {object} map[string]string "El usuario no modera el subforo"
+// @Failure 404 {object} map[string]string "El subforo no existe"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/subforos/{id}/trash/comments [get]
+func (c *TrashController) ForumTrashedComments(w http.ResponseWriter, r *http.Request) {
+	uid, admin, ok := c.caller(w, r)
+	if !ok {
+		return
+	}
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	comments, err := c.usecase.ForumTrashedComments(r.Context(), uid, admin, forumFilter(r), page)
+	if err != nil {
+		if isInvalidCursor(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTrashError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+func (c *TrashController) trashedPosts(w http.ResponseWriter, r *http.Request, filter models.TrashFilter) {
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, err := c.usecase.TrashedPosts(r.Context(), filter, page)
+	if err != nil {
+		if isInvalidCursor(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTrashError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(posts)
+}
+
+func (c *TrashController) trashedComments(w http.ResponseWriter, r *http.Request, filter models.TrashFilter) {
+	page, ok := pageRequestFromQuery(w, r)
+	if !ok {
+		return
+	}
+	comments, err := c.usecase.TrashedComments(r.Context(), filter, page)
+	if err != nil {
+		if isInvalidCursor(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTrashError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// PurgeTrash godoc
+// @Summary Purgar la papelera
+// @Description Borra para siempre los posts y comentarios que llevan en la papelera más que el tiempo de retención (TRASH_RETENTION), con sus votos, guardados e imágenes. Solo administradores.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.PurgeReport
+// @Failure 403 {object} map[string]string "El usuario no es administrador"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/purge-trash [post]
+func (c *TrashController) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	report, err := c.usecase.Purge(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"firebase.google.com/go/v4/auth"
@@ -19,43 +20,16 @@ func NewVoteController(usecase usecases.VoteUsecase) *VoteController {
 	return &VoteController{usecase: usecase}
 }
 
-// CreateVote maneja la creación de un nuevo voto.
-func (v *VoteController) CreateVote(w http.ResponseWriter, r *http.Request) {
-	var vote models.Vote
-	if err := json.NewDecoder(r.Body).Decode(&vote); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Obtener el UID del usuario desde el contexto (lo pasó el middleware de autenticación)
-	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
-	if !ok {
-		http.Error(w, "❌ No se pudo obtener el usuario autenticado", http.StatusUnauthorized)
-		return
-	}
-
-	// Extraer el UID del token (campo 'UID' en el 'auth.Token')
-	userID := token.UID // El UID ahora se obtiene correctamente desde el token
-
-	// Asociar el UID con el voto
-	vote.UserID = userID
-
-	// Asegurarse de que esté asociado a un post o un comentario, pero no ambos
-	if vote.PostID == "" && vote.CommentID == "" {
-		http.Error(w, "❌ El voto debe estar asociado a un post o a un comentario", http.StatusBadRequest)
-		return
-	}
-
-	// Llamar al caso de uso para crear el voto
-	if err := v.usecase.CreateVote(r.Context(), &vote); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// voteTarget lee el tipo y el ID del destino de la ruta. Si el tipo no es
+// válido responde 400 y devuelve ok en false.
+func voteTarget(w http.ResponseWriter, r *http.Request) (models.VoteTarget, string, bool) {
+	vars := mux.Vars(r)
+	target := models.VoteTarget(vars["targetType"])
+	if !target.Valid() {
+		http.Error(w, "❌ El tipo de destino debe ser post o comment", http.StatusBadRequest)
+		return "", "", false
 	}
-
-	// Responder con el voto creado
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(vote)
+	return target, vars["targetId"], true
 }
 
 // GetVoteByID maneja la obtención de un voto por su ID.
@@ -76,12 +50,23 @@ func (v *VoteController) GetVoteByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(vote)
 }
 
-// GetVotesByPostID maneja la obtención de todos los votos de un post por su ID.
-func (v *VoteController) GetVotesByPostID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	postID := vars["postId"]
+// GetVotesByTarget maneja la obtención de todos los votos de un post o comentario.
+// @Summary Listar los votos de un post o comentario
+// @Tags Votes
+// @Produce json
+// @Param targetType path string true "Tipo de destino (post o comment)"
+// @Param targetId path string true "ID del post o comentario"
+// @Success 200 {array} models.Vote "Votos del destino"
+// @Failure 400 {object} map[string]string "Tipo de destino inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/votes/{targetType}/{targetId} [get]
+func (v *VoteController) GetVotesByTarget(w http.ResponseWriter, r *http.Request) {
+	target, targetID, ok := voteTarget(w, r)
+	if !ok {
+		return
+	}
 
-	votes, err := v.usecase.GetVotesByPostID(r.Context(), postID)
+	votes, err := v.usecase.GetVotesByTarget(r.Context(), target, targetID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -92,114 +77,122 @@ func (v *VoteController) GetVotesByPostID(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(votes)
 }
 
-// GetVotesByCommentID maneja la obtención de todos los votos de un comentario por su ID.
-func (v *VoteController) GetVotesByCommentID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	commentID := vars["commentId"]
+// Vote deja el voto del usuario autenticado a un post o comentario.
+// @Summary Votar un post o comentario
+// @Description Deja el voto del usuario en like o dislike, o lo quita con none. Un usuario tiene a lo sumo un voto por destino y repetir el mismo voto no cambia nada.
+// @Tags Votes
+// @Accept json
+// @Produce json
+// @Param targetType path string true "Tipo de destino (post o comment)"
+// @Param targetId path string true "ID del post o comentario"
+// @Param vote body object true "Reacción: {\"type\": \"like|dislike|none\"}"
+// @Success 200 {object} models.Vote "Voto resultante"
+// @Success 204 "Voto quitado"
+// @Failure 400 {object} map[string]string "Destino o reacción inválidos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 404 {object} map[string]string "El post o comentario no existe"
+// @Failure 409 {object} map[string]string "El post está cerrado o archivado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/votes/{targetType}/{targetId} [put]
+func (v *VoteController) Vote(w http.ResponseWriter, r *http.Request) {
+	target, targetID, ok := voteTarget(w, r)
+	if !ok {
+		return
+	}
 
-	votes, err := v.usecase.GetVotesByCommentID(r.Context(), commentID)
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "❌ No se pudo obtener el usuario autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		Type string `json:"type"` // like|dislike|none
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	if err := usecases.ValidateReaction(payload.Type); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vote, err := v.usecase.React(r.Context(), token.UID, target, targetID, payload.Type)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if writeReadOnly(w, err) {
+			return
+		}
+		if errors.Is(err, usecases.ErrNotFound) {
+			http.Error(w, "No existe el destino del voto", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "No se pudo registrar la reacción: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if vote == nil {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	// Devolver los votos como respuesta
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(votes)
+	json.NewEncoder(w).Encode(vote)
 }
 
-// DeleteVote maneja la eliminación de un voto por su ID.
-func (v *VoteController) DeleteVote(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	voteID := vars["voteId"]
+// GetMyVote devuelve el voto del usuario autenticado a un post o comentario.
+// @Summary Obtener mi voto a un post o comentario
+// @Tags Votes
+// @Produce json
+// @Param targetType path string true "Tipo de destino (post o comment)"
+// @Param targetId path string true "ID del post o comentario"
+// @Success 200 {object} models.Vote "Voto del usuario"
+// @Success 204 "El usuario no votó"
+// @Failure 400 {object} map[string]string "Tipo de destino inválido"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/votes/{targetType}/{targetId}/me [get]
+func (v *VoteController) GetMyVote(w http.ResponseWriter, r *http.Request) {
+	target, targetID, ok := voteTarget(w, r)
+	if !ok {
+		return
+	}
 
-	// Llamar al caso de uso para obtener el voto antes de eliminarlo
-	vote, err := v.usecase.GetVoteByID(r.Context(), voteID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	token, ok := r.Context().Value(middleware.AuthUserKey).(*auth.Token)
+	if !ok {
+		http.Error(w, "❌ No se pudo obtener el usuario autenticado", http.StatusUnauthorized)
 		return
 	}
 
-	// Llamar al caso de uso para eliminar el voto
-	err = v.usecase.DeleteVote(r.Context(), voteID)
+	v.writeUserVote(w, r, token.UID, target, targetID)
+}
+
+func (v *VoteController) writeUserVote(w http.ResponseWriter, r *http.Request, userID string, target models.VoteTarget, targetID string) {
+	vote, err := v.usecase.GetUserVote(r.Context(), userID, target, targetID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Error buscando voto: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if vote == nil {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	// Devolver el voto eliminado como respuesta
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // 200 OK
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Voto eliminado con éxito",
-		"vote":    vote, // Devolver el voto eliminado
-	})
-}
-
-func (c *VoteController) React(w http.ResponseWriter, r *http.Request) {
-    postID := mux.Vars(r)["id"]
-
-    // Decodificamos sólo el campo "type" y "userId"
-    var payload struct {
-        Type   string `json:"type"`   // like|dislike|none
-        UserID string `json:"userId"`
-    }
-    if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-        http.Error(w, "Payload inválido", http.StatusBadRequest)
-        return
-    }
-
-    // Validamos type
-    switch payload.Type {
-    case "like", "dislike", "none":
-        // ok
-    default:
-        http.Error(w, "Tipo de reacción inválido", http.StatusBadRequest)
-        return
-    }
-
-    if payload.UserID == "" {
-        http.Error(w, "userId es obligatorio", http.StatusBadRequest)
-        return
-    }
-
-    // Llamamos al usecase, devolviendo *models.Vote o nil (si type=="none")
-	vote, err := c.usecase.ReactPost(r.Context(), payload.UserID, postID, payload.Type)
-    if err != nil {
-        http.Error(w, "No se pudo registrar la reacción", http.StatusInternalServerError)
-        return
-    }
-
-    // Si fue anular voto, devolvemos 204 No Content
-    if payload.Type == "none" {
-        w.WriteHeader(http.StatusNoContent)
-        return
-    }
-
-    // Caso like/dislike: devolvemos 201 con el objeto vote
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(vote)
+	json.NewEncoder(w).Encode(vote)
 }
 
+// GetUserVote devuelve el voto de un usuario a un post (o a un comentario si
+// se indica comment_id).
 func (vc *VoteController) GetUserVote(w http.ResponseWriter, r *http.Request) {
-    userID := r.URL.Query().Get("user_id")
-    postID := r.URL.Query().Get("post_id")
-    if userID == "" || postID == "" {
-        http.Error(w, "user_id y post_id son requeridos", http.StatusBadRequest)
-        return
-    }
-
-    vote, err := vc.usecase.GetUserVote(r.Context(), userID, postID)
-    if err != nil {
-        http.Error(w, "Error buscando voto: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-    if vote == nil {
-        w.WriteHeader(http.StatusNoContent)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(vote)
-}
\ No newline at end of file
+	userID := r.URL.Query().Get("user_id")
+	target, targetID := models.TargetPost, r.URL.Query().Get("post_id")
+	if commentID := r.URL.Query().Get("comment_id"); commentID != "" {
+		target, targetID = models.TargetComment, commentID
+	}
+	if userID == "" || targetID == "" {
+		http.Error(w, "user_id y post_id son requeridos", http.StatusBadRequest)
+		return
+	}
+
+	vc.writeUserVote(w, r, userID, target, targetID)
+}
@@ -0,0 +1,130 @@
+// Package diff compara dos textos línea por línea con el algoritmo de Myers,
+// que encuentra la menor cantidad de líneas agregadas y quitadas.
+package diff
+
+import "strings"
+
+// Op es el tipo de cambio de una línea.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Edit es una línea del resultado: sin cambios, agregada en el texto nuevo o
+// quitada del anterior.
+type Edit struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines compara los textos a y b línea por línea.
+func Lines(a, b string) []Edit {
+	return Diff(splitLines(a), splitLines(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+// Diff devuelve los cambios para pasar de a a b, en orden. Las líneas
+// quitadas van antes que las agregadas en el mismo lugar.
+func Diff(a, b []string) []Edit {
+	// Las líneas iguales del principio y del final no cambian el resultado y
+	// achican la búsqueda, que es lo más común al editar un post.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Text: line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Text: line})
+	}
+	return edits
+}
+
+// myers busca el camino más corto en la grilla de ediciones guardando, para
+// cada cantidad de cambios d, el punto más lejano alcanzado en cada diagonal.
+// Después recorre esos puntos hacia atrás para armar el resultado.
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	edits := make([]Edit, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Op: Equal, Text: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Op: Insert, Text: b[y]})
+		} else {
+			x--
+			edits = append(edits, Edit{Op: Delete, Text: a[x]})
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,73 @@
+// Package jobs corre tareas periódicas en segundo plano dentro del servidor.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job es una tarea que se ejecuta cada Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler ejecuta los jobs registrados, cada uno en su propia goroutine.
+// Una ejecución de un job nunca se solapa con la siguiente del mismo job.
+type Scheduler struct {
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registra un job. Se debe llamar antes de Start.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start arranca los jobs. La primera ejecución de cada uno es después de su
+// intervalo, no al arrancar.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+		log.Printf("⏱️ Job %s cada %s", job.Name, job.Interval)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := job.Run(ctx); err != nil {
+				log.Printf("❌ Job %s: %v", job.Name, err)
+				continue
+			}
+			log.Printf("✅ Job %s terminado en %s", job.Name, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
+
+// Stop cancela los jobs y espera a que terminen las ejecuciones en curso.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
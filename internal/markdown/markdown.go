@@ -0,0 +1,104 @@
+// Package markdown convierte el Markdown de posts y comentarios en HTML
+// seguro. Además del Markdown común (títulos, citas, bloques de código,
+// listas) admite spoilers (||texto||), enlaces automáticos y menciones de
+// usuarios (u/<uid>) y subforos (s/<id>).
+//
+// El HTML que escriben los usuarios no se copia nunca a la salida, y el
+// resultado pasa además por un sanitizador con una lista cerrada de etiquetas
+// y atributos.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Names son los nombres a mostrar de los usuarios y subforos mencionados,
+// por ID. Las menciones de IDs que no están quedan como texto.
+type Names struct {
+	Users    map[string]string
+	Subforos map[string]string
+}
+
+// Refs son los IDs de usuarios y subforos mencionados en un texto.
+type Refs struct {
+	Users    []string
+	Subforos []string
+}
+
+var namesKey = parser.NewContextKey()
+
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.Linkify, extension.Strikethrough),
+	goldmark.WithParserOptions(
+		parser.WithInlineParsers(
+			util.Prioritized(&spoilerParser{}, 500),
+			util.Prioritized(&mentionParser{}, 600),
+		),
+	),
+	goldmark.WithRendererOptions(
+		// Sin html.WithUnsafe: el HTML crudo y los enlaces javascript: no se copian
+		html.WithHardWraps(),
+		renderer.WithNodeRenderers(util.Prioritized(&htmlRenderer{}, 500)),
+	),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^spoiler$`)).OnElements("span")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(mention|subforo)$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Render convierte src en HTML sanitizado. names resuelve las menciones.
+func Render(src string, names Names) string {
+	if src == "" {
+		return ""
+	}
+	pc := parser.NewContext()
+	pc.Set(namesKey, names)
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf, parser.WithContext(pc)); err != nil {
+		// goldmark solo falla si falla el writer, que acá es un buffer
+		return ""
+	}
+	return policy.Sanitize(buf.String())
+}
+
+// mentionPattern reconoce las menciones en el texto plano. Debe coincidir con
+// lo que acepta mentionParser.
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(])([us])/([A-Za-z0-9_-]{1,128})`)
+
+// FindRefs devuelve los IDs mencionados en src, sin repetir, para cargar sus
+// nombres antes de llamar a Render.
+func FindRefs(src string) Refs {
+	var refs Refs
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(src, -1) {
+		key := m[1] + "/" + m[2]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if m[1] == "u" {
+			refs.Users = append(refs.Users, m[2])
+		} else {
+			refs.Subforos = append(refs.Subforos, m[2])
+		}
+	}
+	return refs
+}
@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+// xssNames tiene nombres con HTML para comprobar que las menciones se escapan.
+var xssNames = Names{
+	Users: map[string]string{
+		"u1":   "Ana <b>",
+		"evil": `"><script>alert(1)</script>`,
+	},
+	Subforos: map[string]string{"f1": "Música"},
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Enlaces con esquemas peligrosos: queda solo el texto
+		{"enlace javascript", "[a](javascript:alert(1))", "<p>a</p>\n"},
+		{"enlace javascript con mayúsculas", "[a](JaVaScRiPt:alert(1))", "<p>a</p>\n"},
+		{"enlace javascript con entidad", "[a](&#106;avascript:alert(1))", "<p>a</p>\n"},
+		{"enlace javascript con tabulación", "[a](java\tscript:alert(1))", "<p>[a](java\tscript:alert(1))</p>\n"},
+		{"enlace data", "[a](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)", "<p>a</p>\n"},
+		{"imagen javascript", "![x](javascript:alert(1))", "<p><img alt=\"x\"></p>\n"},
+		{"imagen data", "![x](data:image/svg+xml;base64,AAAA)", "<p><img alt=\"x\"></p>\n"},
+		{"título con comillas", `[x](https://e.com "a\" onmouseover=\"alert(1)")`,
+			"<p><a href=\"https://e.com\" rel=\"nofollow noopener\" target=\"_blank\">x</a></p>\n"},
+
+		// HTML crudo: no se copia
+		{"script en bloque", "<script>alert(1)</script>", "\n"},
+		{"script en línea", "hola <script>alert(1)</script>", "<p>hola alert(1)</p>\n"},
+		{"img onerror en bloque", "<img src=x onerror=alert(1)>", "\n"},
+		{"img onerror en línea", "texto <img src=x onerror=alert(1)> fin", "<p>texto  fin</p>\n"},
+		{"a con href javascript", `<a href="javascript:alert(1)">x</a>`, "<p>x</p>\n"},
+		{"código en línea", "`<script>`", "<p><code>&lt;script&gt;</code></p>\n"},
+		{"bloque de código", "```html\n<script>alert(1)</script>\n```",
+			"<pre><code class=\"language-html\">&lt;script&gt;alert(1)&lt;/script&gt;\n</code></pre>\n"},
+		{"lenguaje con comillas", "```\" onmouseover=alert(1)\nx\n```", "<pre><code>x\n</code></pre>\n"},
+
+		// Enlaces automáticos
+		{"autolink https", "<https://example.com>",
+			"<p><a href=\"https://example.com\" rel=\"nofollow noopener\" target=\"_blank\">https://example.com</a></p>\n"},
+		{"autolink javascript", "<javascript:alert(1)>", "<p>javascript:alert(1)</p>\n"},
+		{"url suelta javascript", "javascript:alert(1)", "<p>javascript:alert(1)</p>\n"},
+		{"url suelta con HTML", "visita https://example.com/a?b=1&c=<2>",
+			"<p>visita <a href=\"https://example.com/a?b=1&amp;c=\" rel=\"nofollow noopener\" target=\"_blank\">https://example.com/a?b=1&amp;c=</a>&lt;2&gt;</p>\n"},
+		{"url www", "www.example.com",
+			"<p><a href=\"http://www.example.com\" rel=\"nofollow noopener\" target=\"_blank\">www.example.com</a></p>\n"},
+
+		// Spoilers anidados y con HTML
+		{"spoilers anidados", "||uno ||dos|| tres||",
+			"<p><span class=\"spoiler\">uno <span class=\"spoiler\">dos</span> tres</span></p>\n"},
+		{"spoiler con img onerror", "||a <img src=x onerror=alert(1)>||", "<p><span class=\"spoiler\">a </span></p>\n"},
+
+		// Menciones: el nombre se escapa y el enlace es siempre relativo
+		{"mención en spoiler", "||u/u1||",
+			"<p><span class=\"spoiler\"><a href=\"/u/u1\" class=\"mention\" rel=\"nofollow\">@Ana &lt;b&gt;</a></span></p>\n"},
+		{"menciones", "hola u/u1 y s/f1",
+			"<p>hola <a href=\"/u/u1\" class=\"mention\" rel=\"nofollow\">@Ana &lt;b&gt;</a> y <a href=\"/s/f1\" class=\"subforo\" rel=\"nofollow\">s/Música</a></p>\n"},
+		{"mención con nombre malicioso", "hola u/evil",
+			"<p>hola <a href=\"/u/evil\" class=\"mention\" rel=\"nofollow\">@&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;</a></p>\n"},
+		{"mención desconocida", "hola u/desconocido", "<p>hola u/desconocido</p>\n"},
+		{"mención al comienzo", "s/f1 y u/u1",
+			"<p><a href=\"/s/f1\" class=\"subforo\" rel=\"nofollow\">s/Música</a> y <a href=\"/u/u1\" class=\"mention\" rel=\"nofollow\">@Ana &lt;b&gt;</a></p>\n"},
+		{"mención al comienzo de la segunda línea", "hola\ns/f1",
+			"<p>hola<br>\n<a href=\"/s/f1\" class=\"subforo\" rel=\"nofollow\">s/Música</a></p>\n"},
+		{"mención al comienzo de una cita", "> u/u1",
+			"<blockquote>\n<p><a href=\"/u/u1\" class=\"mention\" rel=\"nofollow\">@Ana &lt;b&gt;</a></p>\n</blockquote>\n"},
+		{"mención tras tabulación", "hola\ts/f1",
+			"<p>hola\t<a href=\"/s/f1\" class=\"subforo\" rel=\"nofollow\">s/Música</a></p>\n"},
+		{"mención como texto de enlace javascript", "[u/u1](javascript:alert(1))",
+			"<p><a href=\"/u/u1\" class=\"mention\" rel=\"nofollow\">@Ana &lt;b&gt;</a></p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.src, xssNames)
+			if got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+			lower := strings.ToLower(got)
+			for _, bad := range []string{"<script", "onerror", "onmouseover", `href="javascript:`, `src="javascript:`, `="data:`} {
+				if strings.Contains(lower, bad) {
+					t.Errorf("Render(%q) contiene %q: %q", tt.src, bad, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFindRefs(t *testing.T) {
+	refs := FindRefs("hola u/u1, (s/f1) u/u1 y x/u2")
+	if strings.Join(refs.Users, ",") != "u1" || strings.Join(refs.Subforos, ",") != "f1" {
+		t.Errorf("FindRefs = %+v", refs)
+	}
+
+	// Las menciones al comienzo de una línea también se encuentran
+	for _, src := range []string{"s/f1 hola", "hola\ns/f1", "> s/f1", "- s/f1", "hola\ts/f1"} {
+		refs := FindRefs(src)
+		if len(refs.Subforos) != 1 || refs.Subforos[0] != "f1" {
+			t.Errorf("FindRefs(%q) = %+v", src, refs)
+		}
+		if got := Render(src, Names{Subforos: map[string]string{"f1": "Música"}}); !strings.Contains(got, `href="/s/f1"`) {
+			t.Errorf("Render(%q) no enlaza la mención: %q", src, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package markdown
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Spoiler es un texto oculto hasta que el lector lo revela: ||texto||.
+type Spoiler struct {
+	ast.BaseInline
+}
+
+var KindSpoiler = ast.NewNodeKind("Spoiler")
+
+func (n *Spoiler) Kind() ast.NodeKind { return KindSpoiler }
+
+func (n *Spoiler) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// Mention es una mención resuelta de un usuario (u/<uid>) o un subforo (s/<id>).
+type Mention struct {
+	ast.BaseInline
+	// Prefix es "u" o "s".
+	Prefix string
+	ID     string
+	Name   string
+}
+
+var KindMention = ast.NewNodeKind("Mention")
+
+func (n *Mention) Kind() ast.NodeKind { return KindMention }
+
+func (n *Mention) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Prefix": n.Prefix, "ID": n.ID}, nil)
+}
+
+type spoilerDelimiter struct{}
+
+func (spoilerDelimiter) IsDelimiter(b byte) bool { return b == '|' }
+
+func (spoilerDelimiter) CanOpenCloser(opener, closer *parser.Delimiter) bool {
+	return opener.Char == closer.Char
+}
+
+func (spoilerDelimiter) OnMatch(consumes int) ast.Node { return &Spoiler{} }
+
+// spoilerParser reconoce los delimitadores || de los spoilers, igual que
+// goldmark reconoce los ~~ del tachado.
+type spoilerParser struct{}
+
+func (p *spoilerParser) Trigger() []byte { return []byte{'|'} }
+
+func (p *spoilerParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+	node := parser.ScanDelimiter(line, before, 2, spoilerDelimiter{})
+	if node == nil || node.OriginalLength != 2 || before == '|' {
+		return nil
+	}
+	node.Segment = segment.WithStop(segment.Start + node.OriginalLength)
+	block.Advance(node.OriginalLength)
+	pc.PushDelimiter(node)
+	return node
+}
+
+func (p *spoilerParser) CloseBlock(parent ast.Node, pc parser.Context) {}
+
+// mentionParser reconoce u/<uid> y s/<id> cuando el ID está en los Names del
+// contexto. Las demás quedan como texto. goldmark solo prueba los parsers en
+// línea tras un espacio o un signo, así que como Linkify se dispara con el
+// carácter anterior a la mención. Al comienzo de cada línea (y por lo tanto
+// de cada párrafo, título, cita o elemento de lista) goldmark prueba los
+// parsers de ' ' con la línea tal cual: ahí la mención es el primer carácter.
+// Acepta lo mismo que mentionPattern.
+type mentionParser struct{}
+
+func (p *mentionParser) Trigger() []byte { return []byte{' ', '('} }
+
+func (p *mentionParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	consumes := 0
+	if len(line) > 0 && (util.IsSpace(line[0]) || line[0] == '(') {
+		consumes = 1
+		line = line[1:]
+	}
+	if len(line) < 3 || (line[0] != 'u' && line[0] != 's') || line[1] != '/' {
+		return nil
+	}
+	end := 2
+	for end < len(line) && end < 2+128 && isIDChar(line[end]) {
+		end++
+	}
+	if end == 2 {
+		return nil
+	}
+
+	names, _ := pc.Get(namesKey).(Names)
+	prefix, id := string(line[0]), string(line[2:end])
+	known := names.Users
+	if prefix == "s" {
+		known = names.Subforos
+	}
+	name, ok := known[id]
+	if !ok {
+		return nil
+	}
+	if consumes != 0 {
+		ast.MergeOrAppendTextSegment(parent, segment.WithStop(segment.Start+1))
+	}
+	block.Advance(consumes + end)
+	return &Mention{Prefix: prefix, ID: id, Name: name}
+}
+
+func isIDChar(c byte) bool {
+	return c == '_' || c == '-' || util.IsAlphaNumeric(c)
+}
+
+type htmlRenderer struct{}
+
+func (r *htmlRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSpoiler, r.renderSpoiler)
+	reg.Register(KindMention, r.renderMention)
+}
+
+func (r *htmlRenderer) renderSpoiler(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<span class="spoiler">`)
+	} else {
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *htmlRenderer) renderMention(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	m := n.(*Mention)
+	class, label := "mention", "@"+m.Name
+	if m.Prefix == "s" {
+		class, label = "subforo", "s/"+m.Name
+	}
+	_, _ = w.WriteString(`<a href="/` + m.Prefix + "/" + m.ID + `" class="` + class + `">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(label)))
+	_, _ = w.WriteString("</a>")
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+// AdminMiddleware deja pasar solo a los administradores: los usuarios con el
+// custom claim "admin" de Firebase o cuyo UID está en la lista configurada.
+// Va después de AuthMiddleware.Authenticate.
+type AdminMiddleware struct {
+	uids map[string]bool
+}
+
+// NewAdminMiddleware recibe los UIDs de administradores separados por comas
+// (por ejemplo la variable de entorno ADMIN_UIDS).
+func NewAdminMiddleware(adminUIDs string) *AdminMiddleware {
+	uids := make(map[string]bool)
+	for _, uid := range strings.Split(adminUIDs, ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			uids[uid] = true
+		}
+	}
+	return &AdminMiddleware{uids: uids}
+}
+
+// IsAdmin indica si el token pertenece a un administrador.
+func (m *AdminMiddleware) IsAdmin(token *auth.Token) bool {
+	if token == nil {
+		return false
+	}
+	if admin, _ := token.Claims["admin"].(bool); admin {
+		return true
+	}
+	return m.uids[token.UID]
+}
+
+func (m *AdminMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := r.Context().Value(AuthUserKey).(*auth.Token)
+		if !m.IsAdmin(token) {
+			http.Error(w, "❌ se requieren permisos de administrador", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
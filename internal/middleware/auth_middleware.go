@@ -5,16 +5,21 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/JuanPidarraga/talkus-backend/internal/service"
+	"firebase.google.com/go/v4/auth"
 )
 
+// TokenVerifier valida el token Bearer de un request y devuelve sus claims.
+type TokenVerifier interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error)
+}
+
 type AuthMiddleware struct {
-	authService *service.AuthService
+	verifier TokenVerifier
 }
 
-func NewAuthMiddleware(authService *service.AuthService) *AuthMiddleware {
+func NewAuthMiddleware(verifier TokenVerifier) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		verifier: verifier,
 	}
 }
 
@@ -33,7 +38,7 @@ func (middleware *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		decodedToken, err := middleware.authService.VerifyIDToken(r.Context(), tokenParts[1])
+		decodedToken, err := middleware.verifier.VerifyIDToken(r.Context(), tokenParts[1])
 		if err != nil {
 			http.Error(w, "❌ invalid token", http.StatusUnauthorized)
 			return
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// UserCache da a cada request su propia caché de usuarios, para que los
+// repositorios no vuelvan a leer un autor que ya cargaron en el mismo request.
+func UserCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repositories.WithUserCache(r.Context())))
+	})
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Block es un documento de la colección userBlocks: un usuario que UserID
+// bloqueó. Los posts de BlockedID no aparecen en el feed de UserID.
+type Block struct {
+	ID        string    `firestore:"-"          json:"id"`
+	UserID    string    `firestore:"user_id"    json:"user_id"`
+	BlockedID string    `firestore:"blocked_id" json:"blocked_id"`
+	CreatedAt time.Time `firestore:"created_at" json:"created_at"`
+	// Blocked es el usuario bloqueado; se completa al listar los bloqueos.
+	Blocked *User `firestore:"-" json:"blocked,omitempty"`
+}
@@ -7,17 +7,25 @@ import (
 )
 
 type Comment struct {
-	CommentID string            `firestore:"commentID" json:"commentID"`
-	PostID    string            `firestore:"postId" json:"postId"`
-	AuthorID  string            `firestore:"authorId" json:"authorId"`
-	Author    *User             `firestore:"-" json:"author"`
-	Content   string            `firestore:"content" json:"content"`
-	CreatedAt time.Time         `firestore:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time         `firestore:"updatedAt,omitempty" json:"updatedAt"`
-	Likes     int               `firestore:"likes" json:"likes"`
-	Dislikes  int               `firestore:"dislikes" json:"dislikes"`
-	ParentID  string            `firestore:"parentId" json:"parentId"`
-	Reactions map[string]string `firestore:"reactions" json:"reactions"`
+	CommentID string    `firestore:"comment_id" json:"commentID"`
+	PostID    string    `firestore:"post_id" json:"postId"`
+	AuthorID  string    `firestore:"author_id" json:"authorId"`
+	Author    *User     `firestore:"-" json:"author"`
+	Content   string    `firestore:"content" json:"content"`
+	CreatedAt time.Time `firestore:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `firestore:"updated_at,omitempty" json:"updatedAt"`
+	Likes     int       `firestore:"likes" json:"likes"`
+	Dislikes  int       `firestore:"dislikes" json:"dislikes"`
+	ParentID  string    `firestore:"parent_id" json:"parentId"`
+	// DeletedAt y DeletedBy indican que el comentario está en la papelera.
+	DeletedAt *time.Time `firestore:"deleted_at" json:"deletedAt,omitempty"`
+	DeletedBy string     `firestore:"deleted_by,omitempty" json:"deletedBy,omitempty"`
+	// DeletedForumID es el subforo del post, que Firestore guarda al mandar
+	// el comentario a la papelera para poder filtrarla por subforo.
+	DeletedForumID string `firestore:"deleted_forum_id,omitempty" json:"-"`
+	// ContentHTML es Content convertido de Markdown a HTML sanitizado. No se
+	// guarda: se genera al leer el comentario.
+	ContentHTML string `firestore:"-" json:"content_html"`
 }
 
 func (c *Comment) Validate() error {
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// CounterDrift compara los contadores guardados en un post o comentario con
+// los que resultan de sus votos.
+type CounterDrift struct {
+	ID             string `json:"id"`
+	StoredLikes    int    `json:"stored_likes"`
+	StoredDislikes int    `json:"stored_dislikes"`
+	Likes          int    `json:"likes"`
+	Dislikes       int    `json:"dislikes"`
+	Repaired       bool   `json:"repaired"`
+}
+
+// Drifted indica si los contadores guardados no coinciden con los reales.
+func (d CounterDrift) Drifted() bool {
+	return d.StoredLikes != d.Likes || d.StoredDislikes != d.Dislikes
+}
+
+// CounterReport es el resultado de revisar los contadores de likes y dislikes.
+type CounterReport struct {
+	StartedAt       time.Time      `json:"started_at"`
+	FinishedAt      time.Time      `json:"finished_at"`
+	Repair          bool           `json:"repair"`
+	PostsChecked    int            `json:"posts_checked"`
+	CommentsChecked int            `json:"comments_checked"`
+	Posts           []CounterDrift `json:"posts"`
+	Comments        []CounterDrift `json:"comments"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CrosspostOf es la referencia de un crosspost al post original, que está en
+// otro subforo. El crosspost es un post aparte, con sus propios votos y
+// comentarios.
+type CrosspostOf struct {
+	PostID   string `firestore:"post_id"   json:"post_id"`
+	AuthorID string `firestore:"author_id" json:"author_id"`
+	ForumID  string `firestore:"forum_id"  json:"forum_id"`
+	// DeletedAt indica que el original se eliminó: está en la papelera o ya
+	// se purgó.
+	DeletedAt *time.Time `firestore:"deleted_at" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// PostEmbedding es un documento de la colección postEmbeddings, con el mismo
+// ID que su post: el vector del título y el contenido del post según el
+// modelo Model.
+type PostEmbedding struct {
+	PostID string    `firestore:"-"      json:"post_id"`
+	Model  string    `firestore:"model"  json:"model"`
+	Vector []float64 `firestore:"vector" json:"vector"`
+	// TextHash es EmbeddingHash del texto con el que se calculó el vector;
+	// si el post cambia, el vector se vuelve a calcular.
+	TextHash  string    `firestore:"text_hash"  json:"text_hash"`
+	UpdatedAt time.Time `firestore:"updated_at" json:"updated_at"`
+}
+
+// EmbeddingText es el texto del post del que se calcula su vector.
+func (p *Post) EmbeddingText() string {
+	return p.Title + " " + p.Content
+}
+
+// EmbeddingHash resume el texto para saber si el vector guardado sigue al día.
+func EmbeddingHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Current indica si el vector se calculó con el modelo model a partir del
+// texto actual del post.
+func (e *PostEmbedding) Current(p *Post, model string) bool {
+	return e.Model == model && e.TextHash == EmbeddingHash(p.EmbeddingText())
+}
+
+// SemanticHit es un resultado de la búsqueda semántica: el post y la
+// similitud coseno entre su vector y el de la búsqueda.
+type SemanticHit struct {
+	Post       *Post   `json:"post"`
+	Similarity float64 `json:"similarity"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LinkPreview son los metadatos (OpenGraph o Twitter card) de la página a la
+// que apunta un post de enlace.
+type LinkPreview struct {
+	URL         string    `firestore:"url"         json:"url"`
+	Title       string    `firestore:"title"       json:"title"`
+	Description string    `firestore:"description" json:"description"`
+	Image       string    `firestore:"image"       json:"image"`
+	SiteName    string    `firestore:"site_name"   json:"site_name"`
+	FetchedAt   time.Time `firestore:"fetched_at"  json:"fetched_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// MaxPostMedia es la cantidad máxima de imágenes de un post.
+const MaxPostMedia = 10
+
+// Media es una imagen de la galería de un post.
+type Media struct {
+	ID  string `firestore:"id"  json:"id"`
+	URL string `firestore:"url" json:"url"`
+	// PublicID es el ID de la imagen en Cloudinary.
+	PublicID string `firestore:"public_id" json:"public_id"`
+	Caption  string `firestore:"caption"   json:"caption"`
+	// Alt es el texto alternativo para lectores de pantalla.
+	Alt string `firestore:"alt" json:"alt"`
+}
+
+// SetMedia reemplaza la galería del post. ImageURL e ImageID quedan con la
+// primera imagen, para los clientes que solo muestran una.
+func (p *Post) SetMedia(media []Media) {
+	p.Media = media
+	p.ImageURL, p.ImageID = "", ""
+	if len(media) > 0 {
+		p.ImageURL, p.ImageID = media[0].URL, media[0].PublicID
+	}
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// MaxPinnedPosts es la cantidad máxima de posts fijados por subforo.
+const MaxPinnedPosts = 3
+
+// ModerationAction es una acción de un moderador sobre un post. Cada acción
+// tiene su inversa.
+type ModerationAction string
+
+const (
+	ActionPin       ModerationAction = "pin"
+	ActionUnpin     ModerationAction = "unpin"
+	ActionLock      ModerationAction = "lock"
+	ActionUnlock    ModerationAction = "unlock"
+	ActionArchive   ModerationAction = "archive"
+	ActionUnarchive ModerationAction = "unarchive"
+)
+
+var (
+	// ErrInvalidAction indica una acción de moderación desconocida.
+	ErrInvalidAction = errors.New("action debe ser pin, unpin, lock, unlock, archive o unarchive")
+	// ErrPostLocked indica que un moderador cerró el post.
+	ErrPostLocked = errors.New("el post está cerrado: no admite comentarios, respuestas ni votos")
+	// ErrPostArchived indica que el post está archivado.
+	ErrPostArchived = errors.New("el post está archivado: es de solo lectura")
+)
+
+// Valid indica si la acción es una de las conocidas.
+func (a ModerationAction) Valid() bool {
+	switch a {
+	case ActionPin, ActionUnpin, ActionLock, ActionUnlock, ActionArchive, ActionUnarchive:
+		return true
+	}
+	return false
+}
+
+// Moderate aplica la acción al post en now. Devuelve false si el post ya
+// estaba en ese estado y no cambió nada.
+func (p *Post) Moderate(action ModerationAction, now time.Time) bool {
+	switch action {
+	case ActionPin:
+		if p.PinnedAt != nil {
+			return false
+		}
+		p.PinnedAt = &now
+	case ActionUnpin:
+		if p.PinnedAt == nil {
+			return false
+		}
+		p.PinnedAt = nil
+	case ActionLock:
+		if p.LockedAt != nil {
+			return false
+		}
+		p.LockedAt = &now
+	case ActionUnlock:
+		if p.LockedAt == nil {
+			return false
+		}
+		p.LockedAt = nil
+	case ActionArchive:
+		if p.ArchivedAt != nil {
+			return false
+		}
+		p.ArchivedAt = &now
+		p.KeepOpen = false
+	case ActionUnarchive:
+		if p.ArchivedAt == nil {
+			return false
+		}
+		p.ArchivedAt = nil
+		p.KeepOpen = true
+	default:
+		return false
+	}
+	return true
+}
+
+// Writable devuelve ErrPostArchived o ErrPostLocked si el post no admite
+// comentarios, respuestas ni votos.
+func (p *Post) Writable() error {
+	if p.ArchivedAt != nil {
+		return ErrPostArchived
+	}
+	if p.LockedAt != nil {
+		return ErrPostLocked
+	}
+	return nil
+}
+
+// ModerationLogEntry es un documento de la colección moderationLog: una
+// acción de moderación sobre un post de un subforo.
+type ModerationLogEntry struct {
+	ID      string           `firestore:"-"        json:"id"`
+	ForumID string           `firestore:"forum_id" json:"forum_id"`
+	PostID  string           `firestore:"post_id"  json:"post_id"`
+	Action  ModerationAction `firestore:"action"   json:"action"`
+	// ModeratorID es quien hizo la acción; vacío si la hizo el archivado
+	// automático.
+	ModeratorID string    `firestore:"moderator_id" json:"moderator_id"`
+	CreatedAt   time.Time `firestore:"created_at"   json:"created_at"`
+}
+
+// CanModerate indica si userID creó el subforo o es uno de sus moderadores.
+func (s *Subforo) CanModerate(userID string) bool {
+	return s.CreatedBy == userID || slices.Contains(s.Moderators, userID)
+}
@@ -0,0 +1,38 @@
+package models
+
+import "errors"
+
+// Límites del tamaño de página de los listados.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor se devuelve cuando el cursor recibido no es válido.
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// PageRequest pide una página de un listado. Un Cursor vacío pide la primera
+// página; si no, es el NextCursor devuelto en la página anterior.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// Size devuelve el número de elementos a devolver, aplicando el valor por
+// defecto y el máximo.
+func (p PageRequest) Size() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return p.Limit
+	}
+}
+
+// Page es una página de un listado. NextCursor viene vacío en la última página.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
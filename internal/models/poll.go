@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Límites de opciones de una encuesta.
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 10
+)
+
+// Poll es la encuesta de un post. Los votos (PollBallot) se guardan aparte
+// para que los resultados no viajen con el post.
+type Poll struct {
+	Options []string `firestore:"options" json:"options"`
+	// Multiple permite elegir más de una opción.
+	Multiple bool `firestore:"multiple" json:"multiple"`
+	// ClosesAt es la fecha en que deja de aceptar votos; nil si no cierra.
+	ClosesAt *time.Time `firestore:"closes_at" json:"closes_at,omitempty"`
+}
+
+// Validate controla la cantidad y el texto de las opciones y que el cierre,
+// si lo hay, sea posterior a now. Quita los espacios sobrantes de las opciones.
+func (p *Poll) Validate(now time.Time) error {
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return fmt.Errorf("la encuesta debe tener entre %d y %d opciones", MinPollOptions, MaxPollOptions)
+	}
+	seen := make(map[string]bool, len(p.Options))
+	for i, option := range p.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return errors.New("las opciones de la encuesta no pueden estar vacías")
+		}
+		if len(option) > 200 {
+			return errors.New("las opciones de la encuesta tienen a lo sumo 200 caracteres")
+		}
+		if seen[option] {
+			return errors.New("las opciones de la encuesta no se pueden repetir")
+		}
+		seen[option] = true
+		p.Options[i] = option
+	}
+	if p.ClosesAt != nil && !p.ClosesAt.After(now) {
+		return errors.New("closes_at debe ser una fecha futura")
+	}
+	return nil
+}
+
+// Closed indica si la encuesta ya no acepta votos en now.
+func (p *Poll) Closed(now time.Time) bool {
+	return p.ClosesAt != nil && !now.Before(*p.ClosesAt)
+}
+
+// PollBallot es el voto de un usuario en una encuesta: los índices de las
+// opciones que eligió. Cada usuario vota una sola vez por encuesta.
+type PollBallot struct {
+	ID        string    `firestore:"-"          json:"id"`
+	PostID    string    `firestore:"post_id"    json:"post_id"`
+	UserID    string    `firestore:"user_id"    json:"user_id"`
+	Options   []int     `firestore:"options"    json:"options"`
+	CreatedAt time.Time `firestore:"created_at" json:"created_at"`
+}
+
+// PollTally son los resultados de una encuesta: cuántos usuarios votaron y
+// cuántos votos tiene cada opción, en el orden de Poll.Options.
+type PollTally struct {
+	Voters int   `json:"voters"`
+	Counts []int `json:"counts"`
+}
+
+// PollResults es la encuesta de un post vista por un usuario. Results es
+// nil mientras el usuario no haya votado y la encuesta siga abierta.
+type PollResults struct {
+	PostID string `json:"post_id"`
+	Poll
+	Closed bool `json:"closed"`
+	// MyOptions son las opciones que eligió el usuario; vacío si no votó.
+	MyOptions []int      `json:"my_options"`
+	Results   *PollTally `json:"results"`
+}
@@ -2,6 +2,17 @@ package models
 
 import "time"
 
+// PostStatus es el estado de publicación de un post.
+type PostStatus string
+
+const (
+	// PostDraft es un borrador: solo lo ve su autor.
+	PostDraft PostStatus = "draft"
+	// PostScheduled es un borrador que se publica solo en PublishAt.
+	PostScheduled PostStatus = "scheduled"
+	PostPublished PostStatus = "published"
+)
+
 type Post struct {
 	ID        string    `firestore:"-"             json:"id"`
 	AuthorID  string    `firestore:"author_id"     json:"author_id"`
@@ -18,4 +29,44 @@ type Post struct {
 	Likes     int       `firestore:"likes"         json:"likes"`
 	Dislikes  int       `firestore:"dislikes"      json:"dislikes"`
 	Verdict   string    `firestore:"verdict"       json:"verdict"`
+	// Status es el estado de publicación; vacío en los posts anteriores a los
+	// borradores, que están publicados. Al publicarse, CreatedAt pasa a ser la
+	// fecha de publicación.
+	Status    PostStatus `firestore:"status"     json:"status"`
+	PublishAt *time.Time `firestore:"publish_at" json:"publish_at,omitempty"`
+	// Poll es la encuesta del post; nil si el post no es una encuesta.
+	Poll *Poll `firestore:"poll" json:"poll,omitempty"`
+	// Link es el enlace de los posts de enlace, con su vista previa.
+	Link *LinkPreview `firestore:"link" json:"link,omitempty"`
+	// Media es la galería del post, en orden; la primera imagen también está
+	// en ImageURL e ImageID (ver SetMedia).
+	Media []Media `firestore:"media" json:"media"`
+	// Ranks son los valores por los que se ordenan los listados (ver PostSort).
+	Ranks PostRanks `firestore:"ranks" json:"-"`
+	// ContentHTML es Content convertido de Markdown a HTML sanitizado. No se
+	// guarda: se genera al leer el post.
+	ContentHTML string `firestore:"-" json:"content_html"`
+	// EditedAt es la fecha de la última edición; nil si nunca se editó.
+	EditedAt *time.Time `firestore:"edited_at"      json:"edited_at,omitempty"`
+	// Revisions es la cantidad de versiones guardadas del post (ver PostRevision).
+	Revisions int `firestore:"revisions" json:"revisions,omitempty"`
+	// DeletedAt y DeletedBy indican que el post está en la papelera.
+	DeletedAt *time.Time `firestore:"deleted_at"           json:"deleted_at,omitempty"`
+	DeletedBy string     `firestore:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	// Moderación (ver Moderate): PinnedAt fija el post al principio de su
+	// subforo, LockedAt lo cierra a comentarios y votos y ArchivedAt lo deja
+	// de solo lectura.
+	PinnedAt   *time.Time `firestore:"pinned_at"   json:"pinned_at,omitempty"`
+	LockedAt   *time.Time `firestore:"locked_at"   json:"locked_at,omitempty"`
+	ArchivedAt *time.Time `firestore:"archived_at" json:"archived_at,omitempty"`
+	// KeepOpen indica que un moderador desarchivó el post: el archivado
+	// automático no lo vuelve a archivar.
+	KeepOpen bool `firestore:"keep_open" json:"-"`
+	// CrosspostOf es el post original si el post es un crosspost; nil si no.
+	CrosspostOf *CrosspostOf `firestore:"crosspost_of" json:"crosspost_of,omitempty"`
+}
+
+// IsPublished indica si el post es visible para todos.
+func (p *Post) IsPublished() bool {
+	return p.Status == "" || p.Status == PostPublished
 }
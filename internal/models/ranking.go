@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// PostSortMode es el orden de un listado de posts.
+type PostSortMode string
+
+const (
+	// SortNew ordena por fecha de creación, más recientes primero.
+	SortNew PostSortMode = "new"
+	// SortHot ordena por puntaje con decaimiento en el tiempo: un post nuevo
+	// necesita menos votos que uno viejo para quedar arriba.
+	SortHot PostSortMode = "hot"
+	// SortTop ordena por puntaje (likes menos dislikes) dentro de una ventana
+	// de tiempo.
+	SortTop PostSortMode = "top"
+	// SortControversial ordena por cantidad de votos, favoreciendo los posts
+	// con likes y dislikes parejos.
+	SortControversial PostSortMode = "controversial"
+	// SortRising ordena los posts de las últimas RisingWindow por la velocidad
+	// con la que suman puntaje.
+	SortRising PostSortMode = "rising"
+)
+
+// SortWindow es la ventana de tiempo de los órdenes top y controversial.
+type SortWindow string
+
+const (
+	WindowDay   SortWindow = "day"
+	WindowWeek  SortWindow = "week"
+	WindowMonth SortWindow = "month"
+	WindowYear  SortWindow = "year"
+	WindowAll   SortWindow = "all"
+)
+
+// RisingWindow es la antigüedad máxima de los posts del orden rising.
+const RisingWindow = 24 * time.Hour
+
+var (
+	// ErrInvalidSort indica un orden de listado desconocido.
+	ErrInvalidSort = errors.New("sort debe ser new, hot, top, controversial o rising")
+	// ErrInvalidWindow indica una ventana de tiempo desconocida.
+	ErrInvalidWindow = errors.New("t debe ser day, week, month, year o all")
+)
+
+// PostSort es el orden pedido para un listado de posts.
+type PostSort struct {
+	Mode PostSortMode
+	// Window solo se usa con SortTop y SortControversial.
+	Window SortWindow
+}
+
+// ParseSort valida el orden y la ventana de un listado. Sin orden se usa
+// SortNew y sin ventana, WindowDay.
+func ParseSort(mode, window string) (PostSort, error) {
+	s := PostSort{Mode: PostSortMode(mode), Window: SortWindow(window)}
+	switch s.Mode {
+	case "":
+		s.Mode = SortNew
+	case SortNew, SortHot, SortTop, SortControversial, SortRising:
+	default:
+		return s, ErrInvalidSort
+	}
+	if s.Window == "" {
+		s.Window = WindowDay
+	} else if !s.Window.Valid() {
+		return s, ErrInvalidWindow
+	}
+	return s, nil
+}
+
+// Valid indica si w es una ventana conocida.
+func (w SortWindow) Valid() bool {
+	switch w {
+	case WindowDay, WindowWeek, WindowMonth, WindowYear, WindowAll:
+		return true
+	}
+	return false
+}
+
+// Since devuelve el comienzo de la ventana, o la fecha cero con WindowAll.
+func (w SortWindow) Since(now time.Time) time.Time {
+	switch w {
+	case WindowDay:
+		return now.AddDate(0, 0, -1)
+	case WindowWeek:
+		return now.AddDate(0, 0, -7)
+	case WindowMonth:
+		return now.AddDate(0, -1, 0)
+	case WindowYear:
+		return now.AddDate(-1, 0, 0)
+	}
+	return time.Time{}
+}
+
+// Since devuelve la fecha de creación mínima de los posts del listado, o la
+// fecha cero si no hay límite.
+func (s PostSort) Since(now time.Time) time.Time {
+	switch s.Mode {
+	case SortRising:
+		return now.Add(-RisingWindow)
+	case SortTop, SortControversial:
+		return s.Window.Since(now)
+	}
+	return time.Time{}
+}
+
+// Rank devuelve el valor de p por el que se ordena el listado; 0 con SortNew.
+func (s PostSort) Rank(p *Post) float64 {
+	switch s.Mode {
+	case SortHot:
+		return p.Ranks.Hot
+	case SortTop:
+		return float64(p.Ranks.Score)
+	case SortControversial:
+		return p.Ranks.Controversy
+	case SortRising:
+		return p.Ranks.Rising
+	}
+	return 0
+}
+
+// PostRanks son los valores precalculados de los órdenes de los listados. Se
+// recalculan cada vez que cambian los votos o la fecha de publicación del
+// post, así los listados se resuelven con un índice en vez de recorrer todos
+// los posts. Rising depende además de la antigüedad, así que un job lo
+// recalcula periódicamente para los posts de RisingWindow.
+type PostRanks struct {
+	Score       int     `firestore:"score"       json:"score"`
+	Hot         float64 `firestore:"hot"         json:"hot"`
+	Controversy float64 `firestore:"controversy" json:"controversy"`
+	Rising      float64 `firestore:"rising"      json:"rising"`
+}
+
+// hotEpoch y hotDecay definen el decaimiento de SortHot: cada hotDecay de
+// antigüedad equivale a un factor 10 en el puntaje.
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const hotDecay = 45000 // segundos
+
+// RankPost calcula los valores de orden de un post con esos votos y esa
+// fecha de creación. now es el momento del cálculo, que solo afecta a Rising.
+func RankPost(likes, dislikes int, createdAt, now time.Time) PostRanks {
+	score := likes - dislikes
+
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+	hot := sign*order + createdAt.Sub(hotEpoch).Seconds()/hotDecay
+
+	controversy := 0.0
+	if likes > 0 && dislikes > 0 {
+		balance := float64(min(likes, dislikes)) / float64(max(likes, dislikes))
+		controversy = math.Pow(float64(likes+dislikes), balance)
+	}
+
+	// Puntaje por hora desde la publicación, contando como mínimo una hora
+	// para que los primeros votos no disparen el valor
+	age := math.Max(now.Sub(createdAt).Hours(), 1)
+	rising := float64(score) / age
+
+	return PostRanks{Score: score, Hot: hot, Controversy: controversy, Rising: rising}
+}
+
+// Rank recalcula p.Ranks a partir de sus votos y su fecha de creación.
+func (p *Post) Rank(now time.Time) {
+	p.Ranks = RankPost(p.Likes, p.Dislikes, p.CreatedAt, now)
+}
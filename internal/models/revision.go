@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/diff"
+)
+
+// PostRevision es una versión de un post. La revisión 1 es el post tal como
+// se publicó y cada edición agrega la siguiente con el contenido que quedó.
+type PostRevision struct {
+	ID       string    `firestore:"-"         json:"id"`
+	PostID   string    `firestore:"post_id"   json:"post_id"`
+	Number   int       `firestore:"number"    json:"number"`
+	EditorID string    `firestore:"editor_id" json:"editor_id"`
+	EditedAt time.Time `firestore:"edited_at" json:"edited_at"`
+	Title    string    `firestore:"title"     json:"title"`
+	Content  string    `firestore:"content"   json:"content"`
+	Tags     []string  `firestore:"tags"      json:"tags"`
+	ImageURL string    `firestore:"image_url" json:"image_url"`
+	ImageID  string    `firestore:"image_id"  json:"image_id"`
+}
+
+// RevisionDiff son los cambios de un post entre las revisiones From y To.
+type RevisionDiff struct {
+	PostID      string      `json:"post_id"`
+	From        int         `json:"from"`
+	To          int         `json:"to"`
+	Title       []diff.Edit `json:"title"`
+	Content     []diff.Edit `json:"content"`
+	TagsAdded   []string    `json:"tags_added"`
+	TagsRemoved []string    `json:"tags_removed"`
+	// ImageChanged indica si la imagen es otra; las URLs están en las revisiones.
+	ImageChanged bool `json:"image_changed"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SavedPost es un documento de la colección userSavedPosts: un post que un
+// usuario guardó en favoritos.
+type SavedPost struct {
+	ID      string    `firestore:"-" json:"id"`
+	UserID  string    `firestore:"user_id" json:"user_id"`
+	PostID  string    `firestore:"post_id" json:"post_id"`
+	SavedAt time.Time `firestore:"saved_at" json:"saved_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// SearchType es el tipo de documento de un resultado de búsqueda.
+type SearchType string
+
+const (
+	SearchPost    SearchType = "post"
+	SearchComment SearchType = "comment"
+	SearchSubforo SearchType = "subforo"
+)
+
+var (
+	// ErrEmptySearch indica una búsqueda sin texto.
+	ErrEmptySearch = errors.New("q es obligatorio")
+	// ErrInvalidSearchType indica un tipo de documento desconocido.
+	ErrInvalidSearchType = errors.New("type debe ser post, comment o subforo")
+)
+
+// Valid indica si el tipo es uno de los conocidos.
+func (t SearchType) Valid() bool {
+	return t == SearchPost || t == SearchComment || t == SearchSubforo
+}
+
+// SearchQuery es una búsqueda de texto con sus filtros. Los filtros vacíos no
+// se aplican.
+type SearchQuery struct {
+	Text string
+	// Type limita los resultados a un tipo de documento.
+	Type     SearchType
+	ForumID  string
+	AuthorID string
+	Tag      string
+	// Synonyms son los sinónimos de Tag: el filtro acepta cualquiera. Los
+	// posts guardan el tag canónico, pero las categorías de los subforos se
+	// guardan como se escribieron.
+	Synonyms []string
+	// From y To limitan la fecha de creación, ambos incluidos.
+	From *time.Time
+	To   *time.Time
+}
+
+// SearchHit es un resultado de búsqueda. En los comentarios, PostID es su
+// post; en los subforos, ForumID es el propio subforo.
+type SearchHit struct {
+	Type      SearchType `json:"type"`
+	ID        string     `json:"id"`
+	PostID    string     `json:"post_id,omitempty"`
+	ForumID   string     `json:"forum_id"`
+	AuthorID  string     `json:"author_id"`
+	Title     string     `json:"title,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Score     float64    `json:"score"`
+	// Highlights son fragmentos del título y el contenido con las palabras
+	// encontradas entre <mark> y </mark>; el resto del texto va escapado.
+	Highlights map[string][]string `json:"highlights"`
+}
+
+// RelatedPost es un post relacionado con otro. Score, entre 0 y 1, mezcla la
+// similitud de su texto, los tags en común y si es del mismo subforo.
+type RelatedPost struct {
+	Post  *Post   `json:"post"`
+	Score float64 `json:"score"`
+}
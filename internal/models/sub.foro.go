@@ -18,6 +18,9 @@ type Subforo struct {
 	BannerURL   string    `firestore:"banner_url" json:"bannerUrl"`
 	IconURL     string    `firestore:"icon_url" json:"iconUrl"`
 	Members     []string  `firestore:"members" json:"members"`
+	// ArchiveAfterDays es la antigüedad en días a partir de la cual los posts
+	// del subforo se archivan solos; 0 si no se archivan.
+	ArchiveAfterDays int `firestore:"archive_after_days" json:"archiveAfterDays"`
 }
 
 func (s *Subforo) Validate() error {
@@ -41,3 +44,26 @@ func (s *Subforo) Validate() error {
 
 	return nil
 }
+
+// SubforoJoin es un documento de la colección subforoJoins: cuándo se unió
+// UserID al subforo. Se borra al salir, así que solo guarda las membresías
+// actuales; sirve para medir cuánto crece cada subforo.
+type SubforoJoin struct {
+	ID       string    `firestore:"-"         json:"id"`
+	ForumID  string    `firestore:"forum_id"  json:"forum_id"`
+	UserID   string    `firestore:"user_id"   json:"user_id"`
+	JoinedAt time.Time `firestore:"joined_at" json:"joined_at"`
+}
+
+// SubforoRecommendation es un subforo recomendado a un usuario, con su
+// puntaje y el motivo principal de la recomendación.
+type SubforoRecommendation struct {
+	Subforo *Subforo `json:"subforo"`
+	// Score mezcla las categorías en común con los subforos del usuario, la
+	// similitud con sus posts y el crecimiento del subforo; queda entre 0 y 1.
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+	// BecauseOf es el ID del subforo del usuario con más categorías en
+	// común, si el motivo es ese.
+	BecauseOf string `json:"becauseOf,omitempty"`
+}
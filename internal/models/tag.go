@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTagLength es la cantidad máxima de caracteres de un tag normalizado.
+const MaxTagLength = 35
+
+var (
+	// ErrInvalidTag indica un tag que queda vacío al normalizarlo.
+	ErrInvalidTag = errors.New("el tag no puede estar vacío")
+	// ErrTagTooLong indica un tag de más de MaxTagLength caracteres.
+	ErrTagTooLong = fmt.Errorf("los tags no pueden tener más de %d caracteres", MaxTagLength)
+)
+
+// Tag es un documento de la colección tags, con el nombre normalizado como ID
+// (ver NormalizeTag). Los posts guardan sus tags con el nombre normalizado.
+type Tag struct {
+	Name string `firestore:"name" json:"name"`
+	// SynonymOf es el tag al que equivale este, si es un sinónimo: los posts
+	// creados o editados con un sinónimo guardan su tag.
+	SynonymOf string `firestore:"synonym_of" json:"synonym_of,omitempty"`
+	// PostCount es la cantidad de posts publicados, fuera de la papelera, con
+	// el tag.
+	PostCount int       `firestore:"post_count" json:"post_count"`
+	CreatedAt time.Time `firestore:"created_at" json:"created_at"`
+	// Synonyms son los sinónimos del tag; se completan en la página del tag.
+	Synonyms []string `firestore:"-" json:"synonyms,omitempty"`
+	// RecentPosts es la cantidad de posts con el tag publicados en la ventana
+	// de los tags en tendencia.
+	RecentPosts int `firestore:"-" json:"recent_posts,omitempty"`
+}
+
+// Canonical devuelve el nombre del tag que se guarda en los posts: el mismo
+// tag o, si es un sinónimo, al que equivale.
+func (t *Tag) Canonical() string {
+	if t.SynonymOf != "" {
+		return t.SynonymOf
+	}
+	return t.Name
+}
+
+// TagFollow es un documento de la colección tagFollows: un tag que sigue
+// UserID. Los posts con el tag aparecen en su feed.
+type TagFollow struct {
+	ID        string    `firestore:"-"          json:"id"`
+	UserID    string    `firestore:"user_id"    json:"user_id"`
+	Tag       string    `firestore:"tag"        json:"tag"`
+	CreatedAt time.Time `firestore:"created_at" json:"created_at"`
+}
+
+// stripMarks quita las tildes y diéresis (á, ü, ñ) dejando la letra base.
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// NormalizeTag pasa el tag a minúsculas, le quita las tildes y los # del
+// principio y une sus palabras con guiones, así "#Música Clásica" y
+// "musica-clasica" son el mismo tag. Devuelve "" si no queda nada.
+func NormalizeTag(raw string) string {
+	tag := strings.TrimLeft(strings.TrimSpace(raw), "#")
+	if plain, _, err := transform.String(stripMarks, tag); err == nil {
+		tag = plain
+	}
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// NormalizeTags normaliza los tags y descarta los vacíos y los repetidos,
+// conservando el orden.
+func NormalizeTags(raw []string) []string {
+	tags := make([]string, 0, len(raw))
+	for _, r := range raw {
+		if tag := NormalizeTag(r); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ValidTag devuelve ErrInvalidTag o ErrTagTooLong si el tag normalizado no
+// se puede usar.
+func ValidTag(tag string) error {
+	if tag == "" {
+		return ErrInvalidTag
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return ErrTagTooLong
+	}
+	return nil
+}
+
+// ReplaceTag devuelve tags con from reemplazado por to, sin repetir to si ya
+// estaba.
+func ReplaceTag(tags []string, from, to string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == from {
+			tag = to
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
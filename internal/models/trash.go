@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TrashFilter elige los elementos de la papelera a listar. Cada campo que no
+// está vacío restringe el listado: DeletedBy a los eliminados por ese
+// usuario, AuthorID a los de ese autor y ForumID a los del subforo (los
+// comentarios, a los de posts del subforo). La papelera de un usuario y la de
+// administración siempre fijan DeletedBy.
+type TrashFilter struct {
+	DeletedBy string
+	AuthorID  string
+	ForumID   string
+}
+
+// Matches indica si un elemento eliminado por deletedBy, escrito por authorID
+// y del subforo forumID entra en el filtro.
+func (f TrashFilter) Matches(deletedBy, authorID, forumID string) bool {
+	return (f.DeletedBy == "" || deletedBy == f.DeletedBy) &&
+		(f.AuthorID == "" || authorID == f.AuthorID) &&
+		(f.ForumID == "" || forumID == f.ForumID)
+}
+
+// PurgeReport es el resultado de vaciar de la papelera los elementos
+// eliminados antes de Before.
+type PurgeReport struct {
+	Before   time.Time `json:"before"`
+	Posts    int       `json:"posts"`
+	Comments int       `json:"comments"`
+	// Failed cuenta los elementos que no se pudieron borrar; quedan en la
+	// papelera para el próximo intento.
+	Failed int `json:"failed"`
+}
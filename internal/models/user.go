@@ -1,9 +1,75 @@
 package models
 
+import "time"
+
 type User struct {
-	UID          string `json:"uid"`
-	Username     string `json:"username"`
+	UID          string `firestore:"uid" json:"uid"`
+	Username     string `firestore:"username" json:"username"`
 	ProfilePhoto string `firestore:"profile_photo" json:"profile_photo"`
-	BannerImage  string `json:"banner_image"`
-	Email        string `json:"email"`
+	BannerImage  string `firestore:"banner_image" json:"banner_image"`
+	Email        string `firestore:"email" json:"email"`
+}
+
+// UserRecord es el documento completo de un usuario, tal como se guarda en la
+// colección "users". Se usa para exportar e importar datos; los campos que no
+// tienen campo propio van en Extra.
+type UserRecord struct {
+	UID          string                 `json:"uid"`
+	Username     string                 `json:"username"`
+	Email        string                 `json:"email"`
+	ProfilePhoto string                 `json:"profile_photo"`
+	BannerImage  string                 `json:"banner_image"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    *time.Time             `json:"updatedAt,omitempty"`
+	Extra        map[string]interface{} `json:"extra,omitempty"`
+}
+
+// UserRecordFromData arma un UserRecord a partir del documento del usuario,
+// con las mismas claves que devuelve UserRepository.GetUserByID.
+func UserRecordFromData(id string, data map[string]interface{}) *UserRecord {
+	u := &UserRecord{UID: id}
+	for k, v := range data {
+		switch k {
+		case "uid":
+		case "username":
+			u.Username, _ = v.(string)
+		case "email":
+			u.Email, _ = v.(string)
+		case "profile_photo":
+			u.ProfilePhoto, _ = v.(string)
+		case "banner_image":
+			u.BannerImage, _ = v.(string)
+		case "createdAt":
+			u.CreatedAt, _ = v.(time.Time)
+		case "updatedAt":
+			if t, ok := v.(time.Time); ok {
+				u.UpdatedAt = &t
+			}
+		default:
+			if u.Extra == nil {
+				u.Extra = make(map[string]interface{})
+			}
+			u.Extra[k] = v
+		}
+	}
+	return u
+}
+
+// Data devuelve el documento del usuario, en el formato que espera
+// UserRepository.CreateUser.
+func (u *UserRecord) Data() map[string]interface{} {
+	data := make(map[string]interface{}, len(u.Extra)+7)
+	for k, v := range u.Extra {
+		data[k] = v
+	}
+	data["uid"] = u.UID
+	data["username"] = u.Username
+	data["email"] = u.Email
+	data["profile_photo"] = u.ProfilePhoto
+	data["banner_image"] = u.BannerImage
+	data["createdAt"] = u.CreatedAt
+	if u.UpdatedAt != nil {
+		data["updatedAt"] = *u.UpdatedAt
+	}
+	return data
 }
@@ -9,12 +9,42 @@ const (
     Dislike VoteType = "dislike"
 )
 
+// VoteTarget es el tipo de documento que recibe un voto.
+type VoteTarget string
+
+const (
+    TargetPost    VoteTarget = "post"
+    TargetComment VoteTarget = "comment"
+)
+
+// Vote es el voto de un usuario a un post o a un comentario (TargetType y
+// TargetID). PostID es siempre el post, también en los votos a comentarios,
+// y CommentID solo se usa en los votos a comentarios.
 type Vote struct {
-    VoteID    string    `firestore:"-" json:"vote_id"`
-    UserID    string    `firestore:"user_id" json:"user_id"`
-    PostID    string    `firestore:"post_id" json:"post_id"`
-    CommentID string    `firestore:"comment_id,omitempty" json:"comment_id,omitempty"`
-    Type      VoteType  `firestore:"type" json:"type"`
-    CreatedAt time.Time `firestore:"created_at" json:"created_at"`
-    UpdatedAt time.Time `firestore:"updated_at" json:"updated_at"`
+    VoteID     string     `firestore:"-" json:"vote_id"`
+    UserID     string     `firestore:"user_id" json:"user_id"`
+    TargetType VoteTarget `firestore:"target_type" json:"target_type"`
+    TargetID   string     `firestore:"target_id" json:"target_id"`
+    PostID     string     `firestore:"post_id" json:"post_id"`
+    CommentID  string     `firestore:"comment_id,omitempty" json:"comment_id,omitempty"`
+    Type       VoteType   `firestore:"type" json:"type"`
+    CreatedAt  time.Time  `firestore:"created_at" json:"created_at"`
+    UpdatedAt  time.Time  `firestore:"updated_at" json:"updated_at"`
+}
+
+// Target devuelve a qué documento va el voto. Los votos guardados antes de
+// tener TargetType se resuelven con PostID y CommentID.
+func (v *Vote) Target() (VoteTarget, string) {
+    if v.TargetType != "" {
+        return v.TargetType, v.TargetID
+    }
+    if v.CommentID != "" {
+        return TargetComment, v.CommentID
+    }
+    return TargetPost, v.PostID
+}
+
+// Valid indica si el tipo de destino es uno de los conocidos.
+func (t VoteTarget) Valid() bool {
+    return t == TargetPost || t == TargetComment
 }
@@ -0,0 +1,343 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// BackupRepository recorre y restaura colecciones completas conservando los
+// IDs de los documentos. Lo usan los subcomandos export e import.
+//
+// Los métodos Export llaman a fn con cada documento; si fn devuelve un error
+// se corta el recorrido. Los métodos Import reemplazan los documentos con el
+// mismo ID, así que importar dos veces el mismo respaldo no duplica nada.
+type BackupRepository interface {
+	ExportUsers(ctx context.Context, fn func(*models.UserRecord) error) error
+	ExportSubforos(ctx context.Context, fn func(*models.Subforo) error) error
+	ExportPosts(ctx context.Context, fn func(*models.Post) error) error
+	ExportComments(ctx context.Context, fn func(*models.Comment) error) error
+	ExportVotes(ctx context.Context, fn func(*models.Vote) error) error
+	ExportSavedPosts(ctx context.Context, fn func(*models.SavedPost) error) error
+	ExportPostRevisions(ctx context.Context, fn func(*models.PostRevision) error) error
+	ExportPollBallots(ctx context.Context, fn func(*models.PollBallot) error) error
+	ExportBlocks(ctx context.Context, fn func(*models.Block) error) error
+	ExportModerationLog(ctx context.Context, fn func(*models.ModerationLogEntry) error) error
+	ExportEmbeddings(ctx context.Context, fn func(*models.PostEmbedding) error) error
+	ExportTags(ctx context.Context, fn func(*models.Tag) error) error
+	ExportTagFollows(ctx context.Context, fn func(*models.TagFollow) error) error
+	ExportSubforoJoins(ctx context.Context, fn func(*models.SubforoJoin) error) error
+
+	ImportUsers(ctx context.Context, users []*models.UserRecord) error
+	ImportSubforos(ctx context.Context, subforos []*models.Subforo) error
+	ImportPosts(ctx context.Context, posts []*models.Post) error
+	ImportComments(ctx context.Context, comments []*models.Comment) error
+	ImportVotes(ctx context.Context, votes []*models.Vote) error
+	ImportSavedPosts(ctx context.Context, saved []*models.SavedPost) error
+	ImportPostRevisions(ctx context.Context, revisions []*models.PostRevision) error
+	ImportPollBallots(ctx context.Context, ballots []*models.PollBallot) error
+	ImportBlocks(ctx context.Context, blocks []*models.Block) error
+	ImportModerationLog(ctx context.Context, entries []*models.ModerationLogEntry) error
+	ImportEmbeddings(ctx context.Context, embeddings []*models.PostEmbedding) error
+	ImportTags(ctx context.Context, tags []*models.Tag) error
+	ImportTagFollows(ctx context.Context, follows []*models.TagFollow) error
+	ImportSubforoJoins(ctx context.Context, joins []*models.SubforoJoin) error
+}
+
+type backupRepository struct {
+	db *firestore.Client
+}
+
+// NewBackupRepository crea un BackupRepository sobre Firestore.
+func NewBackupRepository(db *firestore.Client) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+// eachDoc llama a fn con cada documento de la colección.
+func (r *backupRepository) eachDoc(ctx context.Context, collection string, fn func(*firestore.DocumentSnapshot) error) error {
+	iter := r.db.Collection(collection).Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error al leer %s: %w", collection, err)
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *backupRepository) ExportUsers(ctx context.Context, fn func(*models.UserRecord) error) error {
+	return r.eachDoc(ctx, "users", func(doc *firestore.DocumentSnapshot) error {
+		return fn(models.UserRecordFromData(doc.Ref.ID, doc.Data()))
+	})
+}
+
+func (r *backupRepository) ExportSubforos(ctx context.Context, fn func(*models.Subforo) error) error {
+	return r.eachDoc(ctx, "subforos", func(doc *firestore.DocumentSnapshot) error {
+		var s models.Subforo
+		if err := doc.DataTo(&s); err != nil {
+			return fmt.Errorf("error al decodificar subforo %s: %w", doc.Ref.ID, err)
+		}
+		s.ForumID = doc.Ref.ID
+		return fn(&s)
+	})
+}
+
+func (r *backupRepository) ExportPosts(ctx context.Context, fn func(*models.Post) error) error {
+	return r.eachDoc(ctx, "posts", func(doc *firestore.DocumentSnapshot) error {
+		var p models.Post
+		if err := doc.DataTo(&p); err != nil {
+			return fmt.Errorf("error al decodificar post %s: %w", doc.Ref.ID, err)
+		}
+		p.ID = doc.Ref.ID
+		return fn(&p)
+	})
+}
+
+func (r *backupRepository) ExportComments(ctx context.Context, fn func(*models.Comment) error) error {
+	return r.eachDoc(ctx, "comments", func(doc *firestore.DocumentSnapshot) error {
+		var c models.Comment
+		if err := doc.DataTo(&c); err != nil {
+			return fmt.Errorf("error al decodificar comentario %s: %w", doc.Ref.ID, err)
+		}
+		c.CommentID = doc.Ref.ID
+		return fn(&c)
+	})
+}
+
+func (r *backupRepository) ExportVotes(ctx context.Context, fn func(*models.Vote) error) error {
+	return r.eachDoc(ctx, "votes", func(doc *firestore.DocumentSnapshot) error {
+		var v models.Vote
+		if err := doc.DataTo(&v); err != nil {
+			return fmt.Errorf("error al decodificar voto %s: %w", doc.Ref.ID, err)
+		}
+		v.VoteID = doc.Ref.ID
+		return fn(&v)
+	})
+}
+
+func (r *backupRepository) ExportSavedPosts(ctx context.Context, fn func(*models.SavedPost) error) error {
+	return r.eachDoc(ctx, "userSavedPosts", func(doc *firestore.DocumentSnapshot) error {
+		var s models.SavedPost
+		if err := doc.DataTo(&s); err != nil {
+			return fmt.Errorf("error al decodificar guardado %s: %w", doc.Ref.ID, err)
+		}
+		s.ID = doc.Ref.ID
+		return fn(&s)
+	})
+}
+
+func (r *backupRepository) ExportPostRevisions(ctx context.Context, fn func(*models.PostRevision) error) error {
+	return r.eachDoc(ctx, "postRevisions", func(doc *firestore.DocumentSnapshot) error {
+		var rev models.PostRevision
+		if err := doc.DataTo(&rev); err != nil {
+			return fmt.Errorf("error al decodificar revisión %s: %w", doc.Ref.ID, err)
+		}
+		rev.ID = doc.Ref.ID
+		return fn(&rev)
+	})
+}
+
+func (r *backupRepository) ExportPollBallots(ctx context.Context, fn func(*models.PollBallot) error) error {
+	return r.eachDoc(ctx, "pollBallots", func(doc *firestore.DocumentSnapshot) error {
+		var b models.PollBallot
+		if err := doc.DataTo(&b); err != nil {
+			return fmt.Errorf("error al decodificar voto de encuesta %s: %w", doc.Ref.ID, err)
+		}
+		b.ID = doc.Ref.ID
+		return fn(&b)
+	})
+}
+
+func (r *backupRepository) ExportBlocks(ctx context.Context, fn func(*models.Block) error) error {
+	return r.eachDoc(ctx, "userBlocks", func(doc *firestore.DocumentSnapshot) error {
+		var b models.Block
+		if err := doc.DataTo(&b); err != nil {
+			return fmt.Errorf("error al decodificar bloqueo %s: %w", doc.Ref.ID, err)
+		}
+		b.ID = doc.Ref.ID
+		return fn(&b)
+	})
+}
+
+func (r *backupRepository) ExportModerationLog(ctx context.Context, fn func(*models.ModerationLogEntry) error) error {
+	return r.eachDoc(ctx, "moderationLog", func(doc *firestore.DocumentSnapshot) error {
+		var e models.ModerationLogEntry
+		if err := doc.DataTo(&e); err != nil {
+			return fmt.Errorf("error al decodificar acción de moderación %s: %w", doc.Ref.ID, err)
+		}
+		e.ID = doc.Ref.ID
+		return fn(&e)
+	})
+}
+
+func (r *backupRepository) ExportEmbeddings(ctx context.Context, fn func(*models.PostEmbedding) error) error {
+	return r.eachDoc(ctx, "postEmbeddings", func(doc *firestore.DocumentSnapshot) error {
+		var e models.PostEmbedding
+		if err := doc.DataTo(&e); err != nil {
+			return fmt.Errorf("error al decodificar el vector del post %s: %w", doc.Ref.ID, err)
+		}
+		e.PostID = doc.Ref.ID
+		return fn(&e)
+	})
+}
+
+func (r *backupRepository) ExportTags(ctx context.Context, fn func(*models.Tag) error) error {
+	return r.eachDoc(ctx, "tags", func(doc *firestore.DocumentSnapshot) error {
+		var t models.Tag
+		if err := doc.DataTo(&t); err != nil {
+			return fmt.Errorf("error al decodificar el tag %s: %w", doc.Ref.ID, err)
+		}
+		t.Name = doc.Ref.ID
+		return fn(&t)
+	})
+}
+
+func (r *backupRepository) ExportTagFollows(ctx context.Context, fn func(*models.TagFollow) error) error {
+	return r.eachDoc(ctx, "tagFollows", func(doc *firestore.DocumentSnapshot) error {
+		var f models.TagFollow
+		if err := doc.DataTo(&f); err != nil {
+			return fmt.Errorf("error al decodificar el tag seguido %s: %w", doc.Ref.ID, err)
+		}
+		f.ID = doc.Ref.ID
+		return fn(&f)
+	})
+}
+
+func (r *backupRepository) ExportSubforoJoins(ctx context.Context, fn func(*models.SubforoJoin) error) error {
+	return r.eachDoc(ctx, "subforoJoins", func(doc *firestore.DocumentSnapshot) error {
+		var j models.SubforoJoin
+		if err := doc.DataTo(&j); err != nil {
+			return fmt.Errorf("error al decodificar el ingreso %s: %w", doc.Ref.ID, err)
+		}
+		j.ID = doc.Ref.ID
+		return fn(&j)
+	})
+}
+
+// setDocs escribe los documentos con un BulkWriter, reemplazando los que ya
+// existan. doc devuelve el ID y los datos de cada elemento.
+func setDocs[T any](ctx context.Context, db *firestore.Client, collection string, items []T, doc func(T) (string, interface{})) error {
+	bw := db.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(items))
+	for _, item := range items {
+		id, data := doc(item)
+		job, err := bw.Set(db.Collection(collection).Doc(id), data)
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("error al escribir %s/%s: %w", collection, id, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("error al escribir %s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportUsers(ctx context.Context, users []*models.UserRecord) error {
+	return setDocs(ctx, r.db, "users", users, func(u *models.UserRecord) (string, interface{}) {
+		return u.UID, u.Data()
+	})
+}
+
+func (r *backupRepository) ImportSubforos(ctx context.Context, subforos []*models.Subforo) error {
+	return setDocs(ctx, r.db, "subforos", subforos, func(s *models.Subforo) (string, interface{}) {
+		return s.ForumID, s
+	})
+}
+
+// ImportPosts marca como publicados los posts sin status, que vienen de
+// respaldos anteriores a los borradores, arma la galería de los anteriores
+// a las galerías y normaliza los tags de los anteriores al registro de tags.
+func (r *backupRepository) ImportPosts(ctx context.Context, posts []*models.Post) error {
+	return setDocs(ctx, r.db, "posts", posts, func(p *models.Post) (string, interface{}) {
+		c := *p
+		if c.Status == "" {
+			c.Status = models.PostPublished
+		}
+		LegacyMedia(&c)
+		LegacyTags(&c)
+		c.Rank(time.Now())
+		return p.ID, &c
+	})
+}
+
+func (r *backupRepository) ImportComments(ctx context.Context, comments []*models.Comment) error {
+	return setDocs(ctx, r.db, "comments", comments, func(c *models.Comment) (string, interface{}) {
+		return c.CommentID, c
+	})
+}
+
+func (r *backupRepository) ImportVotes(ctx context.Context, votes []*models.Vote) error {
+	return setDocs(ctx, r.db, "votes", votes, func(v *models.Vote) (string, interface{}) {
+		return v.VoteID, v
+	})
+}
+
+func (r *backupRepository) ImportSavedPosts(ctx context.Context, saved []*models.SavedPost) error {
+	return setDocs(ctx, r.db, "userSavedPosts", saved, func(s *models.SavedPost) (string, interface{}) {
+		return s.ID, s
+	})
+}
+
+func (r *backupRepository) ImportPostRevisions(ctx context.Context, revisions []*models.PostRevision) error {
+	return setDocs(ctx, r.db, "postRevisions", revisions, func(rev *models.PostRevision) (string, interface{}) {
+		return rev.ID, rev
+	})
+}
+
+func (r *backupRepository) ImportPollBallots(ctx context.Context, ballots []*models.PollBallot) error {
+	return setDocs(ctx, r.db, "pollBallots", ballots, func(b *models.PollBallot) (string, interface{}) {
+		return b.ID, b
+	})
+}
+
+func (r *backupRepository) ImportBlocks(ctx context.Context, blocks []*models.Block) error {
+	return setDocs(ctx, r.db, "userBlocks", blocks, func(b *models.Block) (string, interface{}) {
+		return b.ID, b
+	})
+}
+
+func (r *backupRepository) ImportModerationLog(ctx context.Context, entries []*models.ModerationLogEntry) error {
+	return setDocs(ctx, r.db, "moderationLog", entries, func(e *models.ModerationLogEntry) (string, interface{}) {
+		return e.ID, e
+	})
+}
+
+func (r *backupRepository) ImportEmbeddings(ctx context.Context, embeddings []*models.PostEmbedding) error {
+	return setDocs(ctx, r.db, "postEmbeddings", embeddings, func(e *models.PostEmbedding) (string, interface{}) {
+		return e.PostID, e
+	})
+}
+
+func (r *backupRepository) ImportTags(ctx context.Context, tags []*models.Tag) error {
+	return setDocs(ctx, r.db, "tags", tags, func(t *models.Tag) (string, interface{}) {
+		return t.Name, t
+	})
+}
+
+func (r *backupRepository) ImportTagFollows(ctx context.Context, follows []*models.TagFollow) error {
+	return setDocs(ctx, r.db, "tagFollows", follows, func(f *models.TagFollow) (string, interface{}) {
+		return f.ID, f
+	})
+}
+
+func (r *backupRepository) ImportSubforoJoins(ctx context.Context, joins []*models.SubforoJoin) error {
+	return setDocs(ctx, r.db, "subforoJoins", joins, func(j *models.SubforoJoin) (string, interface{}) {
+		return j.ID, j
+	})
+}
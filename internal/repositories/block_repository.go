@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// BlockRepository guarda los usuarios que cada usuario bloqueó.
+type BlockRepository interface {
+	// Block guarda el bloqueo. Bloquear dos veces al mismo usuario no es un
+	// error.
+	Block(ctx context.Context, block *models.Block) error
+	// Unblock borra el bloqueo, si existe.
+	Unblock(ctx context.Context, userID, blockedID string) error
+	// GetBlocked devuelve los bloqueos de userID, los más recientes primero.
+	GetBlocked(ctx context.Context, userID string) ([]*models.Block, error)
+}
+
+// BlockID es el ID del bloqueo de blockedID por parte de userID; que sea
+// fijo hace que bloquear sea idempotente.
+func BlockID(userID, blockedID string) string {
+	return userID + "_" + blockedID
+}
+
+type blockRepository struct {
+	db *firestore.Client
+}
+
+// NewBlockRepository crea un BlockRepository sobre Firestore.
+func NewBlockRepository(db *firestore.Client) BlockRepository {
+	return &blockRepository{db: db}
+}
+
+// Block usa Set para no pisar con un error el bloqueo que ya existía; la
+// fecha pasa a ser la del último bloqueo.
+func (r *blockRepository) Block(ctx context.Context, block *models.Block) error {
+	block.ID = BlockID(block.UserID, block.BlockedID)
+	if _, err := r.db.Collection("userBlocks").Doc(block.ID).Set(ctx, block); err != nil {
+		return fmt.Errorf("error al bloquear usuario: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, userID, blockedID string) error {
+	if _, err := r.db.Collection("userBlocks").Doc(BlockID(userID, blockedID)).Delete(ctx); err != nil {
+		return fmt.Errorf("error al desbloquear usuario: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) GetBlocked(ctx context.Context, userID string) ([]*models.Block, error) {
+	iter := r.db.Collection("userBlocks").
+		Where("user_id", "==", userID).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	blocks := make([]*models.Block, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener usuarios bloqueados: %w", err)
+		}
+		var b models.Block
+		if err := doc.DataTo(&b); err != nil {
+			return nil, fmt.Errorf("error al decodificar bloqueo: %w", err)
+		}
+		b.ID = doc.Ref.ID
+		blocks = append(blocks, &b)
+	}
+	return blocks, nil
+}
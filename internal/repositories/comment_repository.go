@@ -3,7 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"strings"
+	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -13,22 +13,28 @@ import (
 
 type CommentRepository interface {
 	CreateComment(ctx context.Context, comment *models.Comment) error
-	GetCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error)
-	DeleteComment(ctx context.Context, commentID string) error
+	GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error)
+	GetAllCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error)
 	GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error)
 	UpdateComment(ctx context.Context, commentID string, updatedContent string) (*models.Comment, error)
 	CreateReply(ctx context.Context, parentID string, comment *models.Comment) error
 	GetReplies(ctx context.Context, parentID string) ([]models.Comment, error)
-	AddReaction(ctx context.Context, commentID, userID, reaction string) (*models.Comment, error)
+	// GetRootComments pagina los comentarios del post que no son respuestas,
+	// del más antiguo al más reciente.
+	GetRootComments(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error)
+	// GetRepliesTo devuelve las respuestas directas a los comentarios
+	// parentIDs, con su autor, de la más antigua a la más reciente.
+	GetRepliesTo(ctx context.Context, parentIDs []string) ([]models.Comment, error)
 }
 
 type commentRepository struct {
-	db *firestore.Client
+	db    *firestore.Client
+	users UserLoader
 }
 
 // NewCommentRepository crea una nueva instancia de CommentRepository.
-func NewCommentRepository(db *firestore.Client) CommentRepository {
-	return &commentRepository{db: db}
+func NewCommentRepository(db *firestore.Client, users UserLoader) CommentRepository {
+	return &commentRepository{db: db, users: users}
 }
 
 func (r *commentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
@@ -39,28 +45,24 @@ func (r *commentRepository) CreateComment(ctx context.Context, comment *models.C
 	comment.UpdatedAt = time.Now()
 
 	_, err := docRef.Set(ctx, map[string]interface{}{
-		"commentID": comment.CommentID,
-		"postId":    comment.PostID,
-		"authorId":  comment.AuthorID,
-		"content":   comment.Content,
-		"createdAt": comment.CreatedAt,
-		"updatedAt": comment.UpdatedAt,
-		"likes":     comment.Likes,
-		"dislikes":  comment.Dislikes,
-		"parentId":  comment.ParentID,
+		"comment_id": comment.CommentID,
+		"post_id":    comment.PostID,
+		"author_id":  comment.AuthorID,
+		"content":    comment.Content,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
+		"likes":      comment.Likes,
+		"dislikes":   comment.Dislikes,
+		"parent_id":  comment.ParentID,
+		"deleted_at": nil,
 	})
 
 	if err != nil {
 		return err
 	}
 
-	userDoc, err := r.db.Collection("users").Doc(comment.AuthorID).Get(ctx)
-	if err == nil {
-		var user models.User
-		if err := userDoc.DataTo(&user); err == nil {
-			user.UID = userDoc.Ref.ID
-			comment.Author = &user
-		}
+	if author, err := LoadUser(ctx, r.users, comment.AuthorID); err == nil {
+		comment.Author = author
 	}
 
 	return nil
@@ -76,23 +78,42 @@ func (r *commentRepository) GetCommentByID(ctx context.Context, commentID string
 	if err != nil {
 		return nil, err
 	}
+	if comment.DeletedAt != nil {
+		return nil, fmt.Errorf("comment %s is in the trash", commentID)
+	}
 
 	return &comment, nil
 }
 
-func (r *commentRepository) GetCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
+// GetCommentsByPostID pagina los comentarios de un post, ordenados por likes y,
+// a igualdad de likes, del más antiguo al más reciente.
+func (r *commentRepository) GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	size := page.Size()
+
+	q := r.db.Collection("comments").
+		Where("post_id", "==", postID).
+		Where("deleted_at", "==", nil).
+		OrderBy("likes", firestore.Desc).
+		OrderBy("created_at", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Score, cursor.Time, cursor.ID)
+	}
+	iter := q.Limit(size + 1).Documents(ctx)
+	defer iter.Stop()
 
-	iter := r.db.Collection("comments").Where("postId", "==", postID).OrderBy("likes", firestore.Desc).Documents(ctx)
 	var comments []models.Comment
-	var authorIDs []string
-
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return models.Page[models.Comment]{}, err
 		}
 
 		var comment models.Comment
@@ -101,47 +122,139 @@ func (r *commentRepository) GetCommentsByPostID(ctx context.Context, postID stri
 		}
 		comment.CommentID = doc.Ref.ID
 		comments = append(comments, comment)
-		authorIDs = append(authorIDs, comment.AuthorID)
 	}
 
-	authors, err := r.getUsersByIDs(ctx, authorIDs)
+	result := NewPage(comments, size, CommentCursor)
+	r.attachAuthors(ctx, result.Items)
+	return result, nil
+}
+
+func (r *commentRepository) GetRootComments(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	cursor, err := DecodeCursor(page.Cursor)
 	if err != nil {
-		return comments, nil
+		return models.Page[models.Comment]{}, err
+	}
+	size := page.Size()
+
+	q := r.db.Collection("comments").
+		Where("post_id", "==", postID).
+		Where("parent_id", "==", "").
+		Where("deleted_at", "==", nil).
+		OrderBy("created_at", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	comments, err := readComments(q.Limit(size + 1).Documents(ctx))
+	if err != nil {
+		return models.Page[models.Comment]{}, err
 	}
 
-	for i := range comments {
-		if author, exists := authors[comments[i].AuthorID]; exists {
-			comments[i].Author = author
+	result := NewPage(comments, size, RootCommentCursor)
+	r.attachAuthors(ctx, result.Items)
+	return result, nil
+}
+
+func (r *commentRepository) GetRepliesTo(ctx context.Context, parentIDs []string) ([]models.Comment, error) {
+	var replies []models.Comment
+	// Los filtros "in" admiten hasta 30 valores
+	for start := 0; start < len(parentIDs); start += 30 {
+		end := min(start+30, len(parentIDs))
+		found, err := readComments(r.db.Collection("comments").
+			Where("parent_id", "in", parentIDs[start:end]).
+			Documents(ctx))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			if c.DeletedAt == nil {
+				replies = append(replies, c)
+			}
 		}
 	}
+	sort.Slice(replies, func(i, j int) bool {
+		if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+			return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+		}
+		return replies[i].CommentID < replies[j].CommentID
+	})
 
-	return comments, nil
+	r.attachAuthors(ctx, replies)
+	return replies, nil
 }
 
-func (r *commentRepository) getUsersByIDs(ctx context.Context, userIDs []string) (map[string]*models.User, error) {
-	usersMap := make(map[string]*models.User)
+// readComments lee los comentarios de iter; los que no se pueden decodificar
+// se saltean.
+func readComments(iter *firestore.DocumentIterator) ([]models.Comment, error) {
+	defer iter.Stop()
 
-	// Eliminar IDs duplicados
-	uniqueIDs := make(map[string]struct{})
-	for _, id := range userIDs {
-		uniqueIDs[id] = struct{}{}
+	var comments []models.Comment
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return comments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var comment models.Comment
+		if err := doc.DataTo(&comment); err != nil {
+			continue
+		}
+		comment.CommentID = doc.Ref.ID
+		comments = append(comments, comment)
 	}
+}
+
+// GetAllCommentsByPostID devuelve todos los comentarios de un post, con sus
+// respuestas, para armar el árbol.
+func (r *commentRepository) GetAllCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
 
-	// Cargar usuarios desde Firestore
-	for id := range uniqueIDs {
-		doc, err := r.db.Collection("users").Doc(id).Get(ctx)
+	iter := r.db.Collection("comments").Where("post_id", "==", postID).OrderBy("likes", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+	var comments []models.Comment
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
 		if err != nil {
-			continue
+			return nil, err
 		}
 
-		var user models.User
-		if err := doc.DataTo(&user); err == nil {
-			user.UID = doc.Ref.ID
-			usersMap[id] = &user
+		var comment models.Comment
+		if err := doc.DataTo(&comment); err != nil {
+			continue
+		}
+		if comment.DeletedAt != nil {
+			continue
 		}
+		comment.CommentID = doc.Ref.ID
+		comments = append(comments, comment)
+	}
+
+	r.attachAuthors(ctx, comments)
+	return comments, nil
+}
+
+// attachAuthors asigna a cada comentario su autor.
+func (r *commentRepository) attachAuthors(ctx context.Context, comments []models.Comment) {
+	authorIDs := make([]string, 0, len(comments))
+	for _, c := range comments {
+		authorIDs = append(authorIDs, c.AuthorID)
 	}
 
-	return usersMap, nil
+	authors, err := r.users.LoadUsers(ctx, authorIDs)
+	if err != nil {
+		return
+	}
+
+	for i := range comments {
+		if author, exists := authors[comments[i].AuthorID]; exists {
+			comments[i].Author = author
+		}
+	}
 }
 
 func (r *commentRepository) UpdateComment(ctx context.Context, commentID string, updatedContent string) (*models.Comment, error) {
@@ -161,7 +274,7 @@ func (r *commentRepository) UpdateComment(ctx context.Context, commentID string,
 	// 2. Actualizamos solo los campos permitidos
 	updates := []firestore.Update{
 		{Path: "content", Value: updatedContent},
-		{Path: "updatedAt", Value: time.Now()},
+		{Path: "updated_at", Value: time.Now()},
 	}
 
 	if _, err := docRef.Update(ctx, updates); err != nil {
@@ -181,26 +294,13 @@ func (r *commentRepository) UpdateComment(ctx context.Context, commentID string,
 	updatedComment.CommentID = updatedDoc.Ref.ID
 
 	// 4. ¡Cargamos el autor aquí! (igual que en CreateComment)
-	userDoc, err := r.db.Collection("users").Doc(existingComment.AuthorID).Get(ctx)
-	if err == nil { // Si el usuario existe
-		var user models.User
-		if err := userDoc.DataTo(&user); err == nil {
-			user.UID = userDoc.Ref.ID
-			updatedComment.Author = &user
-		}
+	if author, err := LoadUser(ctx, r.users, existingComment.AuthorID); err == nil {
+		updatedComment.Author = author
 	}
 
 	return &updatedComment, nil
 }
 
-func (r *commentRepository) DeleteComment(ctx context.Context, commentID string) error {
-	// Asegurarnos de que el commentID no tiene una barra inclinada al final
-	commentID = strings.TrimSuffix(commentID, "/") // Esto elimina la barra inclinada al final, si la tiene.
-
-	_, err := r.db.Collection("comments").Doc(commentID).Delete(ctx)
-	return err
-}
-
 func (r *commentRepository) CreateReply(ctx context.Context, ParentID string, comment *models.Comment) error {
 	// Obtener el comentario padre
 	parent, err := r.GetCommentByID(ctx, ParentID)
@@ -225,15 +325,16 @@ func (r *commentRepository) CreateReply(ctx context.Context, ParentID string, co
 
 	// Mapa de datos para Firestore
 	data := map[string]interface{}{
-		"commentID": comment.CommentID,
-		"postId":    comment.PostID,
-		"parentId":  comment.ParentID,
-		"authorId":  comment.AuthorID,
-		"content":   comment.Content,
-		"createdAt": comment.CreatedAt,
-		"updatedAt": comment.UpdatedAt,
-		"likes":     comment.Likes,
-		"dislikes":  comment.Dislikes,
+		"comment_id": comment.CommentID,
+		"post_id":    comment.PostID,
+		"parent_id":  comment.ParentID,
+		"author_id":  comment.AuthorID,
+		"content":    comment.Content,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
+		"likes":      comment.Likes,
+		"dislikes":   comment.Dislikes,
+		"deleted_at": nil,
 	}
 
 	_, err = docRef.Set(ctx, data)
@@ -256,8 +357,8 @@ func (r *commentRepository) GetReplies(ctx context.Context, parentID string) ([]
 	fmt.Printf("Buscando respuestas para parentID: %s\n", parentID)
 
 	iter := r.db.Collection("comments").
-		Where("parentId", "==", parentID).
-		OrderBy("createdAt", firestore.Asc).
+		Where("parent_id", "==", parentID).
+		OrderBy("created_at", firestore.Asc).
 		Documents(ctx)
 
 	defer iter.Stop()
@@ -283,6 +384,9 @@ func (r *commentRepository) GetReplies(ctx context.Context, parentID string) ([]
 		}
 
 		comment.CommentID = doc.Ref.ID
+		if comment.DeletedAt != nil {
+			continue
+		}
 		fmt.Printf("Respuesta encontrada: %+v\n", comment)
 
 		replies = append(replies, comment)
@@ -291,81 +395,3 @@ func (r *commentRepository) GetReplies(ctx context.Context, parentID string) ([]
 	fmt.Printf("Total respuestas encontradas: %d\n", len(replies))
 	return replies, nil
 }
-
-func (r *commentRepository) AddReaction(ctx context.Context, commentID, userID, reaction string) (*models.Comment, error) {
-	if reaction != "like" && reaction != "dislike" {
-		return nil, fmt.Errorf("reacción inválida: %s", reaction)
-	}
-
-	docRef := r.db.Collection("comments").Doc(commentID)
-
-	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		doc, err := tx.Get(docRef)
-		if err != nil {
-			return err
-		}
-
-		var comment models.Comment
-		if err := doc.DataTo(&comment); err != nil {
-			return err
-		}
-
-		if comment.Reactions == nil {
-			comment.Reactions = make(map[string]string)
-		}
-
-		prevReaction, exists := comment.Reactions[userID]
-
-		if !exists {
-			// Primera vez que reacciona
-			comment.Reactions[userID] = reaction
-			if reaction == "like" {
-				comment.Likes++
-			} else {
-				comment.Dislikes++
-			}
-		} else if prevReaction == reaction {
-			// Quitar reacción si es igual a la que ya tiene
-			delete(comment.Reactions, userID)
-			if reaction == "like" && comment.Likes > 0 {
-				comment.Likes--
-			}
-			if reaction == "dislike" && comment.Dislikes > 0 {
-				comment.Dislikes--
-			}
-		} else {
-			// Cambiar reacción
-			comment.Reactions[userID] = reaction
-			if prevReaction == "like" && comment.Likes > 0 {
-				comment.Likes--
-			}
-			if prevReaction == "dislike" && comment.Dislikes > 0 {
-				comment.Dislikes--
-			}
-			if reaction == "like" {
-				comment.Likes++
-			}
-			if reaction == "dislike" {
-				comment.Dislikes++
-			}
-		}
-
-		return tx.Set(docRef, map[string]interface{}{
-			"authorId":  comment.AuthorID,
-			"commentID": comment.CommentID,
-			"content":   comment.Content,
-			"createdAt": comment.CreatedAt,
-			"dislikes":  comment.Dislikes,
-			"likes":     comment.Likes,
-			"reactions": comment.Reactions,
-			"postId":    comment.PostID,
-			"updatedAt": comment.UpdatedAt,
-		})
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return r.GetCommentByID(ctx, commentID)
-}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// CounterRepository recalcula los contadores de likes y dislikes de posts y
+// comentarios a partir de su fuente: los votos.
+type CounterRepository interface {
+	// PostCounterDrift revisa todos los posts y devuelve cuántos revisó y los
+	// que tienen contadores distintos de sus votos.
+	PostCounterDrift(ctx context.Context) (int, []models.CounterDrift, error)
+	// CommentCounterDrift hace lo mismo con los comentarios.
+	CommentCounterDrift(ctx context.Context) (int, []models.CounterDrift, error)
+	// RepairPostCounters recalcula y guarda los contadores de un post de forma
+	// atómica, y devuelve los valores anteriores y los nuevos.
+	RepairPostCounters(ctx context.Context, postID string) (models.CounterDrift, error)
+	RepairCommentCounters(ctx context.Context, commentID string) (models.CounterDrift, error)
+}
+
+type counterRepository struct {
+	db *firestore.Client
+}
+
+// NewCounterRepository crea un CounterRepository sobre Firestore.
+func NewCounterRepository(db *firestore.Client) CounterRepository {
+	return &counterRepository{db: db}
+}
+
+// counts son los likes y dislikes contados de un documento.
+type counts struct {
+	likes, dislikes int
+}
+
+func (c *counts) add(reaction string) {
+	switch reaction {
+	case string(models.Like):
+		c.likes++
+	case string(models.Dislike):
+		c.dislikes++
+	}
+}
+
+// voteCounts cuenta los votos de todos los destinos del tipo indicado.
+func (r *counterRepository) voteCounts(ctx context.Context, target models.VoteTarget) (map[string]*counts, error) {
+	result := make(map[string]*counts)
+	iter := r.db.Collection("votes").Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al iterar votos: %w", err)
+		}
+		var v models.Vote
+		if err := doc.DataTo(&v); err != nil {
+			continue
+		}
+		t, id := v.Target()
+		if t != target || id == "" {
+			continue
+		}
+		if result[id] == nil {
+			result[id] = &counts{}
+		}
+		result[id].add(string(v.Type))
+	}
+}
+
+// counterDrift compara los contadores guardados de cada documento de la
+// colección con los votos contados.
+func (r *counterRepository) counterDrift(ctx context.Context, target models.VoteTarget) (int, []models.CounterDrift, error) {
+	votes, err := r.voteCounts(ctx, target)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	checked := 0
+	var drift []models.CounterDrift
+	iter := r.db.Collection(TargetCollection(target)).Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return checked, nil, fmt.Errorf("error al iterar %s: %w", TargetCollection(target), err)
+		}
+		var stored struct {
+			Likes    int `firestore:"likes"`
+			Dislikes int `firestore:"dislikes"`
+		}
+		if err := doc.DataTo(&stored); err != nil {
+			continue
+		}
+		checked++
+		d := models.CounterDrift{ID: doc.Ref.ID, StoredLikes: stored.Likes, StoredDislikes: stored.Dislikes}
+		if c := votes[doc.Ref.ID]; c != nil {
+			d.Likes, d.Dislikes = c.likes, c.dislikes
+		}
+		if d.Drifted() {
+			drift = append(drift, d)
+		}
+	}
+	return checked, drift, nil
+}
+
+func (r *counterRepository) PostCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	return r.counterDrift(ctx, models.TargetPost)
+}
+
+func (r *counterRepository) CommentCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	return r.counterDrift(ctx, models.TargetComment)
+}
+
+// repair recalcula en una transacción los contadores del destino a partir de
+// sus votos y los guarda si no coinciden.
+func (r *counterRepository) repair(ctx context.Context, target models.VoteTarget, id string) (models.CounterDrift, error) {
+	d := models.CounterDrift{ID: id}
+	ref := r.db.Collection(TargetCollection(target)).Doc(id)
+	field := "post_id"
+	if target == models.TargetComment {
+		field = "comment_id"
+	}
+	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var stored struct {
+			Likes    int `firestore:"likes"`
+			Dislikes int `firestore:"dislikes"`
+		}
+		if err := doc.DataTo(&stored); err != nil {
+			return err
+		}
+
+		votes, err := tx.Documents(r.db.Collection("votes").Where(field, "==", id)).GetAll()
+		if err != nil {
+			return err
+		}
+		var actual counts
+		for _, vdoc := range votes {
+			var v models.Vote
+			if err := vdoc.DataTo(&v); err != nil {
+				continue
+			}
+			if t, tid := v.Target(); t == target && tid == id {
+				actual.add(string(v.Type))
+			}
+		}
+
+		d = models.CounterDrift{
+			ID:             id,
+			StoredLikes:    stored.Likes,
+			StoredDislikes: stored.Dislikes,
+			Likes:          actual.likes,
+			Dislikes:       actual.dislikes,
+		}
+		if !d.Drifted() {
+			return nil
+		}
+		d.Repaired = true
+		updates := []firestore.Update{
+			{Path: "likes", Value: d.Likes},
+			{Path: "dislikes", Value: d.Dislikes},
+		}
+		if target == models.TargetPost {
+			ranks, err := rerank(doc, d.Likes-d.StoredLikes, d.Dislikes-d.StoredDislikes)
+			if err != nil {
+				return err
+			}
+			updates = append(updates, firestore.Update{Path: "ranks", Value: ranks})
+		}
+		return tx.Update(ref, updates)
+	})
+	return d, err
+}
+
+func (r *counterRepository) RepairPostCounters(ctx context.Context, postID string) (models.CounterDrift, error) {
+	d, err := r.repair(ctx, models.TargetPost, postID)
+	if err != nil {
+		return d, fmt.Errorf("error al reparar contadores del post %s: %w", postID, err)
+	}
+	return d, nil
+}
+
+func (r *counterRepository) RepairCommentCounters(ctx context.Context, commentID string) (models.CounterDrift, error) {
+	d, err := r.repair(ctx, models.TargetComment, commentID)
+	if err != nil {
+		return d, fmt.Errorf("error al reparar contadores del comentario %s: %w", commentID, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// Cursor es la posición del último elemento de una página dentro del orden
+// del listado. Time es la fecha por la que se ordena, Score el valor numérico
+// previo a la fecha cuando lo hay (por ejemplo los likes de los comentarios),
+// Rank el valor de orden de los posts (ver models.PostSort) e ID el ID del
+// documento, que desempata para que el orden sea estable.
+type Cursor struct {
+	Time  time.Time `json:"t"`
+	Score int       `json:"s,omitempty"`
+	Rank  float64   `json:"r,omitempty"`
+	ID    string    `json:"id"`
+}
+
+// EncodeCursor serializa el cursor como texto opaco para los clientes.
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor interpreta el cursor de una PageRequest. Devuelve nil si está
+// vacío (primera página) y models.ErrInvalidCursor si está mal formado.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidCursor, err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidCursor, err)
+	}
+	if c.ID == "" {
+		return nil, models.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NewPage arma una página a partir de items leídos con límite size+1: si hay
+// un elemento de más, se descarta y el cursor apunta al último devuelto.
+func NewPage[T any](items []T, size int, cursorOf func(T) Cursor) models.Page[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+	if len(items) <= size {
+		return models.Page[T]{Items: items}
+	}
+	items = items[:size]
+	return models.Page[T]{
+		Items:      items,
+		NextCursor: EncodeCursor(cursorOf(items[size-1])),
+	}
+}
+
+// PostCursor es el cursor de los listados de posts ordenados por fecha.
+func PostCursor(p *models.Post) Cursor {
+	return Cursor{Time: p.CreatedAt, ID: p.ID}
+}
+
+// SortedPostCursor devuelve el cursor de los listados de posts con el orden
+// order: el valor de orden, después la fecha y el ID.
+func SortedPostCursor(order models.PostSort) func(p *models.Post) Cursor {
+	if order.Mode == models.SortNew {
+		return PostCursor
+	}
+	return func(p *models.Post) Cursor {
+		return Cursor{Rank: order.Rank(p), Time: p.CreatedAt, ID: p.ID}
+	}
+}
+
+// SubforoCursor es el cursor del listado de subforos ordenado por fecha.
+func SubforoCursor(s *models.Subforo) Cursor {
+	return Cursor{Time: s.CreatedAt, ID: s.ForumID}
+}
+
+// CommentCursor es el cursor de los comentarios de un post, ordenados por
+// likes y después por fecha.
+func CommentCursor(c models.Comment) Cursor {
+	return Cursor{Score: c.Likes, Time: c.CreatedAt, ID: c.CommentID}
+}
+
+// RootCommentCursor es el cursor de los comentarios raíz de un post, ordenados
+// por fecha.
+func RootCommentCursor(c models.Comment) Cursor {
+	return Cursor{Time: c.CreatedAt, ID: c.CommentID}
+}
+
+// ModerationCursor es el cursor del registro de moderación de un subforo,
+// ordenado por fecha.
+func ModerationCursor(e *models.ModerationLogEntry) Cursor {
+	return Cursor{Time: e.CreatedAt, ID: e.ID}
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EmbeddingRepository guarda los vectores de los posts para la búsqueda
+// semántica. Para recorrerlos todos se usa BackupRepository.ExportEmbeddings.
+type EmbeddingRepository interface {
+	// GetEmbedding devuelve el vector del post, o nil si no tiene.
+	GetEmbedding(ctx context.Context, postID string) (*models.PostEmbedding, error)
+	// SaveEmbedding guarda el vector, reemplazando el anterior del post.
+	SaveEmbedding(ctx context.Context, e *models.PostEmbedding) error
+}
+
+type embeddingRepository struct {
+	db *firestore.Client
+}
+
+// NewEmbeddingRepository crea un EmbeddingRepository sobre Firestore.
+func NewEmbeddingRepository(db *firestore.Client) EmbeddingRepository {
+	return &embeddingRepository{db: db}
+}
+
+func (r *embeddingRepository) GetEmbedding(ctx context.Context, postID string) (*models.PostEmbedding, error) {
+	doc, err := r.db.Collection("postEmbeddings").Doc(postID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el vector del post %s: %w", postID, err)
+	}
+	var e models.PostEmbedding
+	if err := doc.DataTo(&e); err != nil {
+		return nil, fmt.Errorf("error al decodificar el vector del post %s: %w", postID, err)
+	}
+	e.PostID = postID
+	return &e, nil
+}
+
+func (r *embeddingRepository) SaveEmbedding(ctx context.Context, e *models.PostEmbedding) error {
+	if _, err := r.db.Collection("postEmbeddings").Doc(e.PostID).Set(ctx, e); err != nil {
+		return fmt.Errorf("error al guardar el vector del post %s: %w", e.PostID, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const idAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// NewDocumentID genera IDs con el mismo formato que los auto-IDs de Firestore,
+// para que los backends que no son Firestore produzcan IDs intercambiables.
+func NewDocumentID() string {
+	b := make([]byte, 20)
+	max := big.NewInt(int64(len(idAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("repositories: no se pudo generar ID: %v", err))
+		}
+		b[i] = idAlphabet[n.Int64()]
+	}
+	return string(b)
+}
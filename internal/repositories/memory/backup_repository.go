@@ -0,0 +1,332 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type backupRepository struct {
+	store *Store
+}
+
+// NewBackupRepository crea un BackupRepository en memoria.
+func NewBackupRepository(store *Store) repositories.BackupRepository {
+	return &backupRepository{store: store}
+}
+
+// each copia los elementos de una colección con el lock tomado y luego llama
+// a fn con cada uno, ordenados por ID para que la exportación sea estable.
+func each[T any](s *Store, collect func() map[string]T, fn func(T) error) error {
+	s.mu.RLock()
+	items := collect()
+	s.mu.RUnlock()
+
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := fn(items[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *backupRepository) ExportUsers(ctx context.Context, fn func(*models.UserRecord) error) error {
+	return each(r.store, func() map[string]*models.UserRecord {
+		users := make(map[string]*models.UserRecord, len(r.store.users))
+		for id, data := range r.store.users {
+			users[id] = models.UserRecordFromData(id, data)
+		}
+		return users
+	}, fn)
+}
+
+func (r *backupRepository) ExportSubforos(ctx context.Context, fn func(*models.Subforo) error) error {
+	return each(r.store, func() map[string]*models.Subforo {
+		subforos := make(map[string]*models.Subforo, len(r.store.subforos))
+		for id, s := range r.store.subforos {
+			subforos[id] = copySubforo(s)
+		}
+		return subforos
+	}, fn)
+}
+
+func (r *backupRepository) ExportPosts(ctx context.Context, fn func(*models.Post) error) error {
+	return each(r.store, func() map[string]*models.Post {
+		posts := make(map[string]*models.Post, len(r.store.posts))
+		for id, p := range r.store.posts {
+			posts[id] = copyPost(p)
+		}
+		return posts
+	}, fn)
+}
+
+func (r *backupRepository) ExportComments(ctx context.Context, fn func(*models.Comment) error) error {
+	return each(r.store, func() map[string]*models.Comment {
+		comments := make(map[string]*models.Comment, len(r.store.comments))
+		for id, c := range r.store.comments {
+			comments[id] = copyComment(c)
+		}
+		return comments
+	}, fn)
+}
+
+func (r *backupRepository) ExportVotes(ctx context.Context, fn func(*models.Vote) error) error {
+	return each(r.store, func() map[string]*models.Vote {
+		votes := make(map[string]*models.Vote, len(r.store.votes))
+		for id, v := range r.store.votes {
+			c := *v
+			votes[id] = &c
+		}
+		return votes
+	}, fn)
+}
+
+func (r *backupRepository) ExportSavedPosts(ctx context.Context, fn func(*models.SavedPost) error) error {
+	return each(r.store, func() map[string]*models.SavedPost {
+		saved := make(map[string]*models.SavedPost, len(r.store.saved))
+		for id, s := range r.store.saved {
+			saved[id] = &models.SavedPost{ID: s.ID, UserID: s.UserID, PostID: s.PostID, SavedAt: s.SavedAt}
+		}
+		return saved
+	}, fn)
+}
+
+func (r *backupRepository) ExportPostRevisions(ctx context.Context, fn func(*models.PostRevision) error) error {
+	return each(r.store, func() map[string]*models.PostRevision {
+		revisions := make(map[string]*models.PostRevision, len(r.store.revisions))
+		for id, rev := range r.store.revisions {
+			c := *rev
+			c.Tags = cloneStrings(rev.Tags)
+			revisions[id] = &c
+		}
+		return revisions
+	}, fn)
+}
+
+func (r *backupRepository) ExportPollBallots(ctx context.Context, fn func(*models.PollBallot) error) error {
+	return each(r.store, func() map[string]*models.PollBallot {
+		ballots := make(map[string]*models.PollBallot, len(r.store.ballots))
+		for id, b := range r.store.ballots {
+			ballots[id] = copyBallot(b)
+		}
+		return ballots
+	}, fn)
+}
+
+func (r *backupRepository) ExportBlocks(ctx context.Context, fn func(*models.Block) error) error {
+	return each(r.store, func() map[string]*models.Block {
+		blocks := make(map[string]*models.Block, len(r.store.blocks))
+		for id, b := range r.store.blocks {
+			c := *b
+			blocks[id] = &c
+		}
+		return blocks
+	}, fn)
+}
+
+func (r *backupRepository) ExportModerationLog(ctx context.Context, fn func(*models.ModerationLogEntry) error) error {
+	return each(r.store, func() map[string]*models.ModerationLogEntry {
+		entries := make(map[string]*models.ModerationLogEntry, len(r.store.moderation))
+		for id, e := range r.store.moderation {
+			c := *e
+			entries[id] = &c
+		}
+		return entries
+	}, fn)
+}
+
+func (r *backupRepository) ExportEmbeddings(ctx context.Context, fn func(*models.PostEmbedding) error) error {
+	return each(r.store, func() map[string]*models.PostEmbedding {
+		embeddings := make(map[string]*models.PostEmbedding, len(r.store.embeddings))
+		for id, e := range r.store.embeddings {
+			embeddings[id] = copyEmbedding(e)
+		}
+		return embeddings
+	}, fn)
+}
+
+func (r *backupRepository) ExportTags(ctx context.Context, fn func(*models.Tag) error) error {
+	return each(r.store, func() map[string]*models.Tag {
+		tags := make(map[string]*models.Tag, len(r.store.tags))
+		for name, t := range r.store.tags {
+			tags[name] = copyTag(t)
+		}
+		return tags
+	}, fn)
+}
+
+func (r *backupRepository) ExportTagFollows(ctx context.Context, fn func(*models.TagFollow) error) error {
+	return each(r.store, func() map[string]*models.TagFollow {
+		follows := make(map[string]*models.TagFollow, len(r.store.tagFollows))
+		for id, f := range r.store.tagFollows {
+			c := *f
+			follows[id] = &c
+		}
+		return follows
+	}, fn)
+}
+
+func (r *backupRepository) ExportSubforoJoins(ctx context.Context, fn func(*models.SubforoJoin) error) error {
+	return each(r.store, func() map[string]*models.SubforoJoin {
+		joins := make(map[string]*models.SubforoJoin, len(r.store.subforoJoins))
+		for id, j := range r.store.subforoJoins {
+			c := *j
+			joins[id] = &c
+		}
+		return joins
+	}, fn)
+}
+
+func (r *backupRepository) ImportUsers(ctx context.Context, users []*models.UserRecord) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, u := range users {
+		r.store.users[u.UID] = u.Data()
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportSubforos(ctx context.Context, subforos []*models.Subforo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, s := range subforos {
+		r.store.subforos[s.ForumID] = copySubforo(s)
+	}
+	return nil
+}
+
+// ImportPosts marca como publicados los posts sin status, que vienen de
+// respaldos anteriores a los borradores, y arma la galería de los anteriores
+// a las galerías.
+func (r *backupRepository) ImportPosts(ctx context.Context, posts []*models.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, p := range posts {
+		c := copyPost(p)
+		c.Author = nil
+		if c.Status == "" {
+			c.Status = models.PostPublished
+		}
+		repositories.LegacyMedia(c)
+		repositories.LegacyTags(c)
+		c.Rank(time.Now())
+		r.store.posts[p.ID] = c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportComments(ctx context.Context, comments []*models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, c := range comments {
+		cp := copyComment(c)
+		cp.Author = nil
+		r.store.comments[c.CommentID] = cp
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportVotes(ctx context.Context, votes []*models.Vote) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, v := range votes {
+		c := *v
+		r.store.votes[v.VoteID] = &c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportSavedPosts(ctx context.Context, saved []*models.SavedPost) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, s := range saved {
+		r.store.saved[s.ID] = &savedPost{ID: s.ID, UserID: s.UserID, PostID: s.PostID, SavedAt: s.SavedAt}
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportPostRevisions(ctx context.Context, revisions []*models.PostRevision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, rev := range revisions {
+		c := *rev
+		c.Tags = cloneStrings(rev.Tags)
+		r.store.revisions[rev.ID] = &c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportPollBallots(ctx context.Context, ballots []*models.PollBallot) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, b := range ballots {
+		r.store.ballots[b.ID] = copyBallot(b)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportBlocks(ctx context.Context, blocks []*models.Block) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, b := range blocks {
+		c := *b
+		r.store.blocks[b.ID] = &c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportModerationLog(ctx context.Context, entries []*models.ModerationLogEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, e := range entries {
+		c := *e
+		r.store.moderation[e.ID] = &c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportEmbeddings(ctx context.Context, embeddings []*models.PostEmbedding) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, e := range embeddings {
+		r.store.embeddings[e.PostID] = copyEmbedding(e)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportTags(ctx context.Context, tags []*models.Tag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, t := range tags {
+		r.store.tags[t.Name] = copyTag(t)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportTagFollows(ctx context.Context, follows []*models.TagFollow) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, f := range follows {
+		c := *f
+		r.store.tagFollows[f.ID] = &c
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportSubforoJoins(ctx context.Context, joins []*models.SubforoJoin) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, j := range joins {
+		c := *j
+		r.store.subforoJoins[j.ID] = &c
+	}
+	return nil
+}
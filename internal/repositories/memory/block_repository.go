@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type blockRepository struct {
+	store *Store
+}
+
+// NewBlockRepository crea un BlockRepository en memoria.
+func NewBlockRepository(store *Store) repositories.BlockRepository {
+	return &blockRepository{store: store}
+}
+
+func (r *blockRepository) Block(ctx context.Context, block *models.Block) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	block.ID = repositories.BlockID(block.UserID, block.BlockedID)
+	c := *block
+	r.store.blocks[block.ID] = &c
+	return nil
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, userID, blockedID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.blocks, repositories.BlockID(userID, blockedID))
+	return nil
+}
+
+func (r *blockRepository) GetBlocked(ctx context.Context, userID string) ([]*models.Block, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	blocks := make([]*models.Block, 0)
+	for _, b := range r.store.blocks {
+		if b.UserID == userID {
+			c := *b
+			blocks = append(blocks, &c)
+		}
+	}
+	sortBy(blocks, func(b *models.Block) repositories.Cursor {
+		return repositories.Cursor{Time: b.CreatedAt, ID: b.ID}
+	}, newestFirst)
+	return blocks, nil
+}
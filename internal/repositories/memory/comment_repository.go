@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type commentRepository struct {
+	store *Store
+}
+
+// NewCommentRepository crea un CommentRepository en memoria.
+func NewCommentRepository(store *Store) repositories.CommentRepository {
+	return &commentRepository{store: store}
+}
+
+func (r *commentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	comment.CommentID = repositories.NewDocumentID()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	stored := copyComment(comment)
+	stored.Author = nil
+	r.store.comments[comment.CommentID] = stored
+
+	comment.Author = r.store.author(comment.AuthorID)
+	return nil
+}
+
+func (r *commentRepository) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok || c.DeletedAt != nil {
+		return nil, fmt.Errorf("comment %s not found", commentID)
+	}
+	return copyComment(c), nil
+}
+
+// commentsOfPost devuelve copias de los comentarios del post que no están en
+// la papelera, con su autor, ordenados por likes. Se debe llamar con el lock
+// tomado.
+func (r *commentRepository) commentsOfPost(postID string) []models.Comment {
+	var comments []models.Comment
+	for _, c := range r.store.comments {
+		if c.PostID != postID || c.DeletedAt != nil {
+			continue
+		}
+		cp := copyComment(c)
+		cp.Author = r.store.author(c.AuthorID)
+		comments = append(comments, *cp)
+	}
+	sortBy(comments, repositories.CommentCursor, mostLikedFirst)
+	return comments
+}
+
+func (r *commentRepository) GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return paginate(r.commentsOfPost(postID), page, repositories.CommentCursor, mostLikedFirst)
+}
+
+func (r *commentRepository) GetAllCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.commentsOfPost(postID), nil
+}
+
+func (r *commentRepository) GetRootComments(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var roots []models.Comment
+	for _, c := range r.commentsOfPost(postID) {
+		if c.ParentID == "" {
+			roots = append(roots, c)
+		}
+	}
+	sortBy(roots, repositories.RootCommentCursor, oldestFirst)
+	return paginate(roots, page, repositories.RootCommentCursor, oldestFirst)
+}
+
+func (r *commentRepository) GetRepliesTo(ctx context.Context, parentIDs []string) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var replies []models.Comment
+	for _, c := range r.store.comments {
+		if c.ParentID != "" && c.DeletedAt == nil && slices.Contains(parentIDs, c.ParentID) {
+			cp := copyComment(c)
+			cp.Author = r.store.author(c.AuthorID)
+			replies = append(replies, *cp)
+		}
+	}
+	sortBy(replies, repositories.RootCommentCursor, oldestFirst)
+	return replies, nil
+}
+
+func (r *commentRepository) UpdateComment(ctx context.Context, commentID string, updatedContent string) (*models.Comment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok {
+		return nil, fmt.Errorf("comment not found")
+	}
+	c.Content = updatedContent
+	c.UpdatedAt = time.Now()
+
+	updated := copyComment(c)
+	updated.Author = r.store.author(c.AuthorID)
+	return updated, nil
+}
+
+func (r *commentRepository) CreateReply(ctx context.Context, parentID string, comment *models.Comment) error {
+	parent, err := r.GetCommentByID(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("parent comment not found: %w", err)
+	}
+
+	comment.PostID = parent.PostID
+	comment.ParentID = parentID
+	if err := comment.Validate(); err != nil {
+		return fmt.Errorf("comment validation failed: %w", err)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	comment.CommentID = repositories.NewDocumentID()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	stored := copyComment(comment)
+	stored.Author = nil
+	r.store.comments[comment.CommentID] = stored
+	return nil
+}
+
+func (r *commentRepository) GetReplies(ctx context.Context, parentID string) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if _, ok := r.store.comments[parentID]; !ok {
+		return nil, fmt.Errorf("parent comment does not exist: %s", parentID)
+	}
+
+	var replies []models.Comment
+	for _, c := range r.store.comments {
+		if c.ParentID == parentID && c.DeletedAt == nil {
+			replies = append(replies, *copyComment(c))
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+	})
+	return replies, nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type counterRepository struct {
+	store *Store
+}
+
+// NewCounterRepository crea un CounterRepository en memoria.
+func NewCounterRepository(store *Store) repositories.CounterRepository {
+	return &counterRepository{store: store}
+}
+
+// drift compara los contadores guardados con los votos al destino. Se debe
+// llamar con el lock tomado.
+func (s *Store) drift(target models.VoteTarget, id string, likes, dislikes int) models.CounterDrift {
+	d := models.CounterDrift{ID: id, StoredLikes: likes, StoredDislikes: dislikes}
+	for _, v := range s.votes {
+		if !isVoteTo(v, target, id) {
+			continue
+		}
+		switch v.Type {
+		case models.Like:
+			d.Likes++
+		case models.Dislike:
+			d.Dislikes++
+		}
+	}
+	return d
+}
+
+func sortDrift(drift []models.CounterDrift) {
+	sort.Slice(drift, func(i, j int) bool { return drift[i].ID < drift[j].ID })
+}
+
+func (r *counterRepository) PostCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var drift []models.CounterDrift
+	for _, p := range r.store.posts {
+		if d := r.store.drift(models.TargetPost, p.ID, p.Likes, p.Dislikes); d.Drifted() {
+			drift = append(drift, d)
+		}
+	}
+	sortDrift(drift)
+	return len(r.store.posts), drift, nil
+}
+
+func (r *counterRepository) CommentCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var drift []models.CounterDrift
+	for _, c := range r.store.comments {
+		if d := r.store.drift(models.TargetComment, c.CommentID, c.Likes, c.Dislikes); d.Drifted() {
+			drift = append(drift, d)
+		}
+	}
+	sortDrift(drift)
+	return len(r.store.comments), drift, nil
+}
+
+func (r *counterRepository) RepairPostCounters(ctx context.Context, postID string) (models.CounterDrift, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok {
+		return models.CounterDrift{}, fmt.Errorf("post %s no existe", postID)
+	}
+	d := r.store.drift(models.TargetPost, p.ID, p.Likes, p.Dislikes)
+	if d.Drifted() {
+		p.Likes, p.Dislikes = d.Likes, d.Dislikes
+		p.Rank(time.Now())
+		d.Repaired = true
+	}
+	return d, nil
+}
+
+func (r *counterRepository) RepairCommentCounters(ctx context.Context, commentID string) (models.CounterDrift, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok {
+		return models.CounterDrift{}, fmt.Errorf("comentario %s no existe", commentID)
+	}
+	d := r.store.drift(models.TargetComment, c.CommentID, c.Likes, c.Dislikes)
+	if d.Drifted() {
+		c.Likes, c.Dislikes = d.Likes, d.Dislikes
+		d.Repaired = true
+	}
+	return d, nil
+}
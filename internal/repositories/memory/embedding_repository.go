@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type embeddingRepository struct {
+	store *Store
+}
+
+// NewEmbeddingRepository crea un EmbeddingRepository en memoria.
+func NewEmbeddingRepository(store *Store) repositories.EmbeddingRepository {
+	return &embeddingRepository{store: store}
+}
+
+// copyEmbedding copia el vector para que no se comparta con el Store.
+func copyEmbedding(e *models.PostEmbedding) *models.PostEmbedding {
+	c := *e
+	c.Vector = slices.Clone(e.Vector)
+	return &c
+}
+
+func (r *embeddingRepository) GetEmbedding(ctx context.Context, postID string) (*models.PostEmbedding, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	e, ok := r.store.embeddings[postID]
+	if !ok {
+		return nil, nil
+	}
+	return copyEmbedding(e), nil
+}
+
+func (r *embeddingRepository) SaveEmbedding(ctx context.Context, e *models.PostEmbedding) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.embeddings[e.PostID] = copyEmbedding(e)
+	return nil
+}
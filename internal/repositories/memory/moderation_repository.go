@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type moderationRepository struct {
+	store *Store
+}
+
+// NewModerationRepository crea un ModerationRepository en memoria.
+func NewModerationRepository(store *Store) repositories.ModerationRepository {
+	return &moderationRepository{store: store}
+}
+
+func (r *moderationRepository) Record(ctx context.Context, entry *models.ModerationLogEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry.ID = repositories.NewDocumentID()
+	c := *entry
+	r.store.moderation[entry.ID] = &c
+	return nil
+}
+
+func (r *moderationRepository) GetLog(ctx context.Context, forumID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entries := make([]*models.ModerationLogEntry, 0)
+	for _, e := range r.store.moderation {
+		if e.ForumID == forumID {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+	sortBy(entries, repositories.ModerationCursor, newestFirst)
+	return paginate(entries, page, repositories.ModerationCursor, newestFirst)
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// ordering compara dos posiciones de un listado: true si a va antes que b.
+// Es el mismo orden que usan las consultas de Firestore y PostgreSQL.
+type ordering func(a, b repositories.Cursor) bool
+
+// newestFirst ordena por fecha descendente y, a igual fecha, por ID descendente.
+func newestFirst(a, b repositories.Cursor) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.After(b.Time)
+	}
+	return a.ID > b.ID
+}
+
+// oldestFirst ordena por fecha ascendente y, a igual fecha, por ID ascendente.
+func oldestFirst(a, b repositories.Cursor) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.Before(b.Time)
+	}
+	return a.ID < b.ID
+}
+
+// rankedFirst ordena por Rank descendente y después como newestFirst.
+func rankedFirst(a, b repositories.Cursor) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	return newestFirst(a, b)
+}
+
+// mostLikedFirst ordena por Score descendente y después como oldestFirst.
+func mostLikedFirst(a, b repositories.Cursor) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return oldestFirst(a, b)
+}
+
+// sortBy ordena items según less.
+func sortBy[T any](items []T, cursorOf func(T) repositories.Cursor, less ordering) {
+	sort.Slice(items, func(i, j int) bool {
+		return less(cursorOf(items[i]), cursorOf(items[j]))
+	})
+}
+
+// paginate devuelve la página de items, ya ordenados según less, que sigue al
+// cursor de la petición.
+func paginate[T any](items []T, page models.PageRequest, cursorOf func(T) repositories.Cursor, less ordering) (models.Page[T], error) {
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return less(*cursor, cursorOf(items[i]))
+		})
+	}
+	size := page.Size()
+	end := min(start+size+1, len(items))
+	return repositories.NewPage(items[start:end], size, cursorOf), nil
+}
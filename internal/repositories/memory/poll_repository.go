@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type pollRepository struct {
+	store *Store
+}
+
+// NewPollRepository crea un PollRepository en memoria.
+func NewPollRepository(store *Store) repositories.PollRepository {
+	return &pollRepository{store: store}
+}
+
+func (r *pollRepository) CastBallot(ctx context.Context, ballot *models.PollBallot) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ballot.ID = repositories.BallotID(ballot.PostID, ballot.UserID)
+	if _, ok := r.store.ballots[ballot.ID]; ok {
+		return repositories.ErrAlreadyVoted
+	}
+	r.store.ballots[ballot.ID] = copyBallot(ballot)
+	return nil
+}
+
+func (r *pollRepository) GetBallot(ctx context.Context, postID, userID string) (*models.PollBallot, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	b, ok := r.store.ballots[repositories.BallotID(postID, userID)]
+	if !ok {
+		return nil, nil
+	}
+	return copyBallot(b), nil
+}
+
+func (r *pollRepository) Tally(ctx context.Context, postID string, options int) (*models.PollTally, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tally := &models.PollTally{Counts: make([]int, options)}
+	for _, b := range r.store.ballots {
+		if b.PostID != postID {
+			continue
+		}
+		tally.Voters++
+		for _, i := range b.Options {
+			if i >= 0 && i < options {
+				tally.Counts[i]++
+			}
+		}
+	}
+	return tally, nil
+}
@@ -0,0 +1,516 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type postRepository struct {
+	store *Store
+}
+
+// NewPostRepository crea un PostRepository en memoria.
+func NewPostRepository(store *Store) repositories.PostRepository {
+	return &postRepository{store: store}
+}
+
+func (r *postRepository) GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	p, ok := r.store.posts[id]
+	if !ok {
+		return nil, fmt.Errorf("error al obtener el post por ID %s: no existe", id)
+	}
+	if p.DeletedAt != nil {
+		return nil, fmt.Errorf("error al obtener el post por ID %s: está en la papelera", id)
+	}
+
+	post := &models.PostWithAuthor{Post: *copyPost(p)}
+	if p.AuthorID != "" {
+		author := r.store.author(p.AuthorID)
+		if author == nil {
+			return nil, fmt.Errorf("error al obtener el autor con ID %s: no existe", p.AuthorID)
+		}
+		post.Author = author
+	}
+	return post, nil
+}
+
+// filterPosts devuelve copias de los posts que cumplen match, con su autor,
+// ordenados por fecha de creación (más recientes primero). Los posts en la
+// papelera no se incluyen. Se debe llamar con el lock tomado.
+func (r *postRepository) filterPosts(match func(p *models.Post) bool) []*models.Post {
+	posts := make([]*models.Post, 0)
+	for _, p := range r.store.posts {
+		if p.DeletedAt != nil || !match(p) {
+			continue
+		}
+		c := copyPost(p)
+		c.Author = r.store.author(p.AuthorID)
+		posts = append(posts, c)
+	}
+	sortBy(posts, repositories.PostCursor, newestFirst)
+	return posts
+}
+
+// pagePosts pagina los posts que cumplen match. Se debe llamar con el lock tomado.
+func (r *postRepository) pagePosts(page models.PageRequest, match func(p *models.Post) bool) (models.Page[*models.Post], error) {
+	return paginate(r.filterPosts(match), page, repositories.PostCursor, newestFirst)
+}
+
+// sortedPosts pagina los posts que cumplen match con el orden order,
+// dejando fuera los creados antes de su ventana de tiempo. Se debe llamar con
+// el lock tomado.
+func (r *postRepository) sortedPosts(order models.PostSort, page models.PageRequest, match func(p *models.Post) bool) (models.Page[*models.Post], error) {
+	if order.Mode == models.SortNew {
+		return r.pagePosts(page, match)
+	}
+	since := order.Since(time.Now())
+	posts := r.filterPosts(func(p *models.Post) bool {
+		return !p.CreatedAt.Before(since) && match(p)
+	})
+	cursorOf := repositories.SortedPostCursor(order)
+	sortBy(posts, cursorOf, rankedFirst)
+	return paginate(posts, page, cursorOf, rankedFirst)
+}
+
+func (r *postRepository) GetAll(ctx context.Context, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.sortedPosts(order, page, func(p *models.Post) bool { return p.IsPublished() })
+}
+
+func (r *postRepository) Create(ctx context.Context, p *models.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p.CreatedAt = time.Now()
+	p.ID = repositories.NewDocumentID()
+	repositories.LegacyMedia(p)
+	p.Rank(p.CreatedAt)
+	stored := copyPost(p)
+	stored.Author = nil
+	r.store.posts[p.ID] = stored
+	return nil
+}
+
+func (r *postRepository) Edit(ctx context.Context, id, editorID string, p *models.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[id]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("error al editar el post: %s no existe", id)
+	}
+
+	p = repositories.KeepMedia(stored, p)
+	now := time.Now()
+	revisions := repositories.NewRevisions(stored, p, editorID, now)
+	if revisions == nil {
+		return nil
+	}
+	for _, rev := range revisions {
+		r.store.revisions[rev.ID] = rev
+	}
+	stored.Title = p.Title
+	stored.Content = p.Content
+	stored.Tags = cloneStrings(p.Tags)
+	stored.ImageID = p.ImageID
+	stored.ImageURL = p.ImageURL
+	stored.Media = copyPost(p).Media
+	stored.UpdatedAt = now
+	stored.EditedAt = &now
+	stored.Revisions = revisions[len(revisions)-1].Number
+	return nil
+}
+
+func (r *postRepository) GetRevisions(ctx context.Context, postID string) ([]*models.PostRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := make([]*models.PostRevision, 0)
+	for _, rev := range r.store.revisions {
+		if rev.PostID == postID {
+			c := *rev
+			c.Tags = cloneStrings(rev.Tags)
+			revisions = append(revisions, &c)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Number < revisions[j].Number })
+	return revisions, nil
+}
+
+func (r *postRepository) GetPostsByAuthorID(ctx context.Context, authorID string, drafts bool, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.pagePosts(page, func(p *models.Post) bool {
+		return p.AuthorID == authorID && (drafts || p.IsPublished())
+	})
+}
+
+func (r *postRepository) GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	liked := make(map[string]bool)
+	for _, v := range r.store.votes {
+		if target, id := v.Target(); v.UserID == userID && target == models.TargetPost && v.Type == models.Like {
+			liked[id] = true
+		}
+	}
+	return r.filterPosts(func(p *models.Post) bool { return liked[p.ID] }), nil
+}
+
+func (r *postRepository) IncrementReaction(ctx context.Context, postID string, reactionType string, delta int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok {
+		return fmt.Errorf("post %s no existe", postID)
+	}
+	if reactionType == "dislike" {
+		p.Dislikes += delta
+	} else {
+		p.Likes += delta
+	}
+	p.Rank(time.Now())
+	return nil
+}
+
+func (r *postRepository) SavePostForUser(ctx context.Context, userID, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := repositories.NewDocumentID()
+	r.store.saved[id] = &savedPost{ID: id, UserID: userID, PostID: postID, SavedAt: time.Now()}
+	return nil
+}
+
+func (r *postRepository) RemoveSavedPost(ctx context.Context, userID, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, s := range r.store.saved {
+		if s.UserID == userID && s.PostID == postID {
+			delete(r.store.saved, id)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *postRepository) GetSavedPostsByUser(ctx context.Context, userID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	saves := make([]*savedPost, 0)
+	for _, s := range r.store.saved {
+		if s.UserID == userID {
+			saves = append(saves, s)
+		}
+	}
+	sortBy(saves, savedCursor, newestFirst)
+	savesPage, err := paginate(saves, page, savedCursor, newestFirst)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+
+	posts := make([]*models.Post, 0, len(savesPage.Items))
+	for _, s := range savesPage.Items {
+		p, ok := r.store.posts[s.PostID]
+		if !ok || p.DeletedAt != nil {
+			continue
+		}
+		c := copyPost(p)
+		c.Author = r.store.author(p.AuthorID)
+		posts = append(posts, c)
+	}
+	return models.Page[*models.Post]{Items: posts, NextCursor: savesPage.NextCursor}, nil
+}
+
+func savedCursor(s *savedPost) repositories.Cursor {
+	return repositories.Cursor{Time: s.SavedAt, ID: s.ID}
+}
+
+func (r *postRepository) IsPostSavedByUser(ctx context.Context, userID, postID string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, s := range r.store.saved {
+		if s.UserID == userID && s.PostID == postID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *postRepository) GetPostsByForumID(ctx context.Context, forumID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.sortedPosts(order, page, func(p *models.Post) bool {
+		return p.ForumID == forumID && !p.IsFlagged && p.PinnedAt == nil && p.IsPublished()
+	})
+}
+
+func (r *postRepository) GetPinnedPosts(ctx context.Context, forumID string) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	posts := r.filterPosts(func(p *models.Post) bool {
+		return p.ForumID == forumID && p.PinnedAt != nil && p.IsPublished()
+	})
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].PinnedAt.After(*posts[j].PinnedAt)
+	})
+	return posts, nil
+}
+
+func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.sortedPosts(order, page, func(p *models.Post) bool {
+		return slices.Contains(p.Tags, tag) && !p.IsFlagged && p.IsPublished()
+	})
+}
+
+func (r *postRepository) GetFeed(ctx context.Context, forumIDs, tags, blockedIDs []string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.sortedPosts(order, page, func(p *models.Post) bool {
+		followed := slices.Contains(forumIDs, p.ForumID) || slices.ContainsFunc(p.Tags, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+		return followed && !slices.Contains(blockedIDs, p.AuthorID) && !p.IsFlagged && p.IsPublished()
+	})
+}
+
+func (r *postRepository) ReplaceTag(ctx context.Context, from, to string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ids := make([]string, 0)
+	for id, p := range r.store.posts {
+		if slices.Contains(p.Tags, from) {
+			p.Tags = models.ReplaceTag(p.Tags, from, to)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *postRepository) GetCrossposts(ctx context.Context, postID string) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.filterPosts(func(p *models.Post) bool {
+		return p.CrosspostOf != nil && p.CrosspostOf.PostID == postID && p.IsPublished()
+	}), nil
+}
+
+func (r *postRepository) MarkCrossposts(ctx context.Context, postID string, deletedAt *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, p := range r.store.posts {
+		if p.CrosspostOf != nil && p.CrosspostOf.PostID == postID {
+			p.CrosspostOf.DeletedAt = deletedAt
+		}
+	}
+	return nil
+}
+
+func (r *postRepository) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.pagePosts(page, func(p *models.Post) bool {
+		return p.ForumID == forumID && p.Verdict == verdict && !p.IsFlagged && p.IsPublished()
+	})
+}
+
+func (r *postRepository) ReportPost(ctx context.Context, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok {
+		return fmt.Errorf("error al reportar el post: %s no existe", postID)
+	}
+	p.IsFlagged = true
+	return nil
+}
+
+func (r *postRepository) GetDrafts(ctx context.Context, authorID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.pagePosts(page, func(p *models.Post) bool {
+		return p.AuthorID == authorID && !p.IsPublished()
+	})
+}
+
+// unpublished devuelve el post id si existe, no está en la papelera y todavía
+// no se publicó. Se debe llamar con el lock tomado.
+func (r *postRepository) unpublished(id string) (*models.Post, error) {
+	p, ok := r.store.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, fmt.Errorf("%s no existe", id)
+	}
+	if p.IsPublished() {
+		return nil, repositories.ErrPublished
+	}
+	return p, nil
+}
+
+func (r *postRepository) UpdateDraft(ctx context.Context, id string, p *models.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, err := r.unpublished(id)
+	if err != nil {
+		return fmt.Errorf("error al editar el borrador: %w", err)
+	}
+	stored.Title = p.Title
+	stored.Content = p.Content
+	stored.Tags = cloneStrings(p.Tags)
+	stored.ForumID = p.ForumID
+	stored.Status = p.Status
+	stored.PublishAt = p.PublishAt
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *postRepository) DuePosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	posts := make([]*models.Post, 0)
+	for _, p := range r.store.posts {
+		if p.Status == models.PostScheduled && p.DeletedAt == nil && p.PublishAt != nil && !p.PublishAt.After(before) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].PublishAt.Before(*posts[j].PublishAt) })
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (r *postRepository) Publish(ctx context.Context, id, verdict string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, err := r.unpublished(id)
+	if err != nil {
+		return fmt.Errorf("error al publicar el post: %w", err)
+	}
+	stored.Status = models.PostPublished
+	stored.PublishAt = nil
+	stored.Verdict = verdict
+	stored.CreatedAt = at
+	stored.Rank(time.Now())
+	return nil
+}
+
+func (r *postRepository) UpdateMedia(ctx context.Context, id, editorID string, update func(*models.Post) ([]models.Media, error)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[id]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("error al obtener el post por ID %s: no existe", id)
+	}
+	repositories.LegacyMedia(stored)
+
+	media, err := update(copyPost(stored))
+	if err != nil {
+		return err
+	}
+	edited := copyPost(stored)
+	edited.SetMedia(media)
+
+	now := time.Now()
+	if revisions := repositories.NewRevisions(stored, edited, editorID, now); revisions != nil {
+		for _, rev := range revisions {
+			r.store.revisions[rev.ID] = rev
+		}
+		stored.EditedAt = &now
+		stored.Revisions = revisions[len(revisions)-1].Number
+	}
+	stored.SetMedia(copyPost(edited).Media)
+	stored.UpdatedAt = now
+	return nil
+}
+
+func (r *postRepository) Moderate(ctx context.Context, id string, entry *models.ModerationLogEntry) (*models.Post, bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, false, fmt.Errorf("error al obtener el post por ID %s: no existe", id)
+	}
+	if entry.Action == models.ActionPin && stored.PinnedAt == nil {
+		pinned := r.filterPosts(func(p *models.Post) bool {
+			return p.ForumID == stored.ForumID && p.PinnedAt != nil && p.IsPublished()
+		})
+		if len(pinned) >= models.MaxPinnedPosts {
+			return nil, false, repositories.ErrPinLimit
+		}
+	}
+	if !stored.Moderate(entry.Action, entry.CreatedAt) {
+		return copyPost(stored), false, nil
+	}
+	entry.ID = repositories.NewDocumentID()
+	entry.ForumID, entry.PostID = stored.ForumID, id
+	logged := *entry
+	r.store.moderation[entry.ID] = &logged
+	return copyPost(stored), true, nil
+}
+
+func (r *postRepository) RerankRising(ctx context.Context, since, now time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	updated := 0
+	for _, p := range r.store.posts {
+		if p.DeletedAt != nil || !p.IsPublished() || p.CreatedAt.Before(since) {
+			continue
+		}
+		p.Rank(now)
+		updated++
+	}
+	return updated, nil
+}
+
+func (r *postRepository) ArchiveBefore(ctx context.Context, forumID string, before, at time.Time) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ids := make([]string, 0)
+	for id, p := range r.store.posts {
+		if p.ForumID != forumID || p.DeletedAt != nil || !p.IsPublished() ||
+			p.ArchivedAt != nil || p.KeepOpen || !p.CreatedAt.Before(before) {
+			continue
+		}
+		archivedAt := at
+		p.ArchivedAt = &archivedAt
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,141 @@
+// Package memory implementa todos los repositorios en memoria, sin
+// depender de Firestore. Sirve para correr la API en local y para los
+// tests de los casos de uso.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// Store guarda todas las colecciones. Los repositorios de este paquete
+// comparten un mismo Store para que, por ejemplo, los votos y los contadores
+// de los posts se mantengan consistentes.
+type Store struct {
+	mu       sync.RWMutex
+	users    map[string]map[string]interface{}
+	posts    map[string]*models.Post
+	comments map[string]*models.Comment
+	votes    map[string]*models.Vote
+	subforos map[string]*models.Subforo
+	saved    map[string]*savedPost
+	// revisions son las revisiones de los posts, por ID de revisión.
+	revisions map[string]*models.PostRevision
+	// ballots son los votos de las encuestas, por ID de voto.
+	ballots map[string]*models.PollBallot
+	// blocks son los bloqueos entre usuarios, por ID de bloqueo.
+	blocks map[string]*models.Block
+	// moderation es el registro de moderación, por ID de acción.
+	moderation map[string]*models.ModerationLogEntry
+	// embeddings son los vectores de los posts, por ID de post.
+	embeddings map[string]*models.PostEmbedding
+	// tags es el registro de tags, por nombre, y tagFollows los tags que
+	// sigue cada usuario, por ID de seguimiento.
+	tags       map[string]*models.Tag
+	tagFollows map[string]*models.TagFollow
+	// subforoJoins son los ingresos a los subforos, por ID de ingreso.
+	subforoJoins map[string]*models.SubforoJoin
+}
+
+type savedPost struct {
+	ID      string
+	UserID  string
+	PostID  string
+	SavedAt time.Time
+}
+
+// NewStore crea un Store vacío.
+func NewStore() *Store {
+	return &Store{
+		users:    make(map[string]map[string]interface{}),
+		posts:    make(map[string]*models.Post),
+		comments: make(map[string]*models.Comment),
+		votes:    make(map[string]*models.Vote),
+		subforos: make(map[string]*models.Subforo),
+		saved:    make(map[string]*savedPost),
+
+		revisions: make(map[string]*models.PostRevision),
+		ballots:   make(map[string]*models.PollBallot),
+		blocks:    make(map[string]*models.Block),
+
+		moderation: make(map[string]*models.ModerationLogEntry),
+		embeddings: make(map[string]*models.PostEmbedding),
+		tags:       make(map[string]*models.Tag),
+		tagFollows: make(map[string]*models.TagFollow),
+
+		subforoJoins: make(map[string]*models.SubforoJoin),
+	}
+}
+
+// userFromData convierte un documento de usuario en el modelo User, igual que
+// DataTo en Firestore.
+func userFromData(id string, data map[string]interface{}) *models.User {
+	u := &models.User{UID: id}
+	u.Username, _ = data["username"].(string)
+	u.ProfilePhoto, _ = data["profile_photo"].(string)
+	u.BannerImage, _ = data["banner_image"].(string)
+	u.Email, _ = data["email"].(string)
+	return u
+}
+
+// author devuelve el autor con el ID dado o nil si no existe. Se debe llamar
+// con el lock tomado.
+func (s *Store) author(id string) *models.User {
+	data, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+	return userFromData(id, data)
+}
+
+// cloneStrings copia un slice conservando la diferencia entre nil y vacío,
+// para que el JSON devuelto sea el mismo que con Firestore.
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+func copyPost(p *models.Post) *models.Post {
+	c := *p
+	c.Tags = cloneStrings(p.Tags)
+	if p.Poll != nil {
+		poll := *p.Poll
+		poll.Options = cloneStrings(p.Poll.Options)
+		c.Poll = &poll
+	}
+	if p.Link != nil {
+		link := *p.Link
+		c.Link = &link
+	}
+	if p.Media != nil {
+		c.Media = append(make([]models.Media, 0, len(p.Media)), p.Media...)
+	}
+	if p.CrosspostOf != nil {
+		of := *p.CrosspostOf
+		c.CrosspostOf = &of
+	}
+	return &c
+}
+
+func copyBallot(b *models.PollBallot) *models.PollBallot {
+	c := *b
+	c.Options = append([]int(nil), b.Options...)
+	return &c
+}
+
+func copyComment(c *models.Comment) *models.Comment {
+	cp := *c
+	return &cp
+}
+
+func copySubforo(s *models.Subforo) *models.Subforo {
+	c := *s
+	c.Categories = cloneStrings(s.Categories)
+	c.Moderators = cloneStrings(s.Moderators)
+	c.Members = cloneStrings(s.Members)
+	return &c
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type subforoRepository struct {
+	store *Store
+}
+
+// NewSubforoRepository crea un SubforoRepository en memoria.
+func NewSubforoRepository(store *Store) repositories.SubforoRepository {
+	return &subforoRepository{store: store}
+}
+
+func (r *subforoRepository) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	s, ok := r.store.subforos[id]
+	if !ok {
+		return nil, fmt.Errorf("error al obtener el subforo por ID %s: no existe", id)
+	}
+	return copySubforo(s), nil
+}
+
+func (r *subforoRepository) GetSubforosByIDs(ctx context.Context, ids []string) (map[string]*models.Subforo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subforos := make(map[string]*models.Subforo, len(ids))
+	for _, id := range ids {
+		if s, ok := r.store.subforos[id]; ok {
+			subforos[id] = copySubforo(s)
+		}
+	}
+	return subforos, nil
+}
+
+func (r *subforoRepository) GetAll(ctx context.Context, page models.PageRequest) (models.Page[*models.Subforo], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subforos := make([]*models.Subforo, 0)
+	for _, s := range r.store.subforos {
+		if s.IsActive {
+			subforos = append(subforos, copySubforo(s))
+		}
+	}
+	sortBy(subforos, repositories.SubforoCursor, newestFirst)
+	return paginate(subforos, page, repositories.SubforoCursor, newestFirst)
+}
+
+func (r *subforoRepository) Create(ctx context.Context, subforo *models.Subforo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subforo.CreatedAt = time.Now()
+	subforo.UpdatedAt = subforo.CreatedAt
+	subforo.ForumID = repositories.NewDocumentID()
+	r.store.subforos[subforo.ForumID] = copySubforo(subforo)
+	return nil
+}
+
+func (r *subforoRepository) Deactivate(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s, ok := r.store.subforos[id]
+	if !ok {
+		s = &models.Subforo{ForumID: id}
+		r.store.subforos[id] = s
+	}
+	s.IsActive = false
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *subforoRepository) EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error) {
+	r.store.mu.Lock()
+	s, ok := r.store.subforos[id]
+	if !ok {
+		s = &models.Subforo{ForumID: id}
+		r.store.subforos[id] = s
+	}
+	s.Title = subforo.Title
+	s.Description = subforo.Description
+	s.Categories = cloneStrings(subforo.Categories)
+	s.BannerURL = subforo.BannerURL
+	s.IconURL = subforo.IconURL
+	s.Moderators = cloneStrings(subforo.Moderators)
+	s.IsActive = subforo.IsActive
+	s.UpdatedAt = time.Now()
+	r.store.mu.Unlock()
+
+	return r.GetSubforoByID(ctx, id)
+}
+
+func (r *subforoRepository) JoinSubforo(ctx context.Context, subforoID, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s, ok := r.store.subforos[subforoID]
+	if !ok {
+		return fmt.Errorf("subforo %s no existe", subforoID)
+	}
+	for _, m := range s.Members {
+		if m == userID {
+			return nil
+		}
+	}
+	now := time.Now()
+	s.Members = append(s.Members, userID)
+	s.UpdatedAt = now
+	id := repositories.SubforoJoinID(subforoID, userID)
+	r.store.subforoJoins[id] = &models.SubforoJoin{ID: id, ForumID: subforoID, UserID: userID, JoinedAt: now}
+	return nil
+}
+
+func (r *subforoRepository) LeaveSubforo(ctx context.Context, subforoID, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s, ok := r.store.subforos[subforoID]
+	if !ok {
+		return fmt.Errorf("subforo %s no existe", subforoID)
+	}
+	members := s.Members[:0]
+	for _, m := range s.Members {
+		if m != userID {
+			members = append(members, m)
+		}
+	}
+	s.Members = members
+	s.UpdatedAt = time.Now()
+	delete(r.store.subforoJoins, repositories.SubforoJoinID(subforoID, userID))
+	return nil
+}
+
+func (r *subforoRepository) GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subforos := make([]*models.Subforo, 0)
+	for _, s := range r.store.subforos {
+		if containsString(s.Members, userID) || containsString(s.Moderators, userID) {
+			subforos = append(subforos, copySubforo(s))
+		}
+	}
+	return subforos, nil
+}
+
+func (r *subforoRepository) SetArchiveAfter(ctx context.Context, id string, days int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s, ok := r.store.subforos[id]
+	if !ok {
+		return fmt.Errorf("subforo %s no existe", id)
+	}
+	s.ArchiveAfterDays = days
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *subforoRepository) GetArchiving(ctx context.Context) ([]*models.Subforo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subforos := make([]*models.Subforo, 0)
+	for _, s := range r.store.subforos {
+		if s.IsActive && s.ArchiveAfterDays > 0 {
+			subforos = append(subforos, copySubforo(s))
+		}
+	}
+	return subforos, nil
+}
+
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *subforoRepository) CountJoins(ctx context.Context, since time.Time) (map[string]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	joins := make(map[string]int)
+	for _, j := range r.store.subforoJoins {
+		if !j.JoinedAt.Before(since) {
+			joins[j.ForumID]++
+		}
+	}
+	return joins, nil
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type tagRepository struct {
+	store *Store
+}
+
+// NewTagRepository crea un TagRepository en memoria.
+func NewTagRepository(store *Store) repositories.TagRepository {
+	return &tagRepository{store: store}
+}
+
+// copyTag copia el tag sin los campos que no se guardan, igual que Firestore.
+func copyTag(t *models.Tag) *models.Tag {
+	c := *t
+	c.Synonyms = nil
+	c.RecentPosts = 0
+	return &c
+}
+
+func (r *tagRepository) GetTag(ctx context.Context, name string) (*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.tags[name]
+	if !ok {
+		return nil, nil
+	}
+	return copyTag(t), nil
+}
+
+func (r *tagRepository) GetTags(ctx context.Context, names []string) (map[string]*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := make(map[string]*models.Tag, len(names))
+	for _, name := range names {
+		if t, ok := r.store.tags[name]; ok {
+			tags[name] = copyTag(t)
+		}
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) SaveTag(ctx context.Context, tag *models.Tag) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.tags[tag.Name] = copyTag(tag)
+	return nil
+}
+
+// filterTags devuelve copias de los tags para los que keep devuelve true, en
+// orden alfabético. Se debe llamar con el lock tomado.
+func (r *tagRepository) filterTags(keep func(*models.Tag) bool) []*models.Tag {
+	tags := make([]*models.Tag, 0)
+	for _, t := range r.store.tags {
+		if keep(t) {
+			tags = append(tags, copyTag(t))
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+func (r *tagRepository) SearchTags(ctx context.Context, prefix string, limit int) ([]*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := r.filterTags(func(t *models.Tag) bool {
+		return strings.HasPrefix(t.Name, prefix)
+	})
+	return tags[:min(limit, len(tags))], nil
+}
+
+func (r *tagRepository) GetSynonyms(ctx context.Context, name string) ([]*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.filterTags(func(t *models.Tag) bool {
+		return t.SynonymOf == name
+	}), nil
+}
+
+// visible indica si el post cuenta para los tags: publicado y fuera de la
+// papelera.
+func visible(p *models.Post) bool {
+	return p.DeletedAt == nil && p.IsPublished()
+}
+
+func (r *tagRepository) Recount(ctx context.Context, names []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	counts := make(map[string]int, len(names))
+	for _, p := range r.store.posts {
+		if !visible(p) {
+			continue
+		}
+		for _, tag := range p.Tags {
+			counts[tag]++
+		}
+	}
+	for _, name := range names {
+		n := counts[name]
+		if t, ok := r.store.tags[name]; ok {
+			t.PostCount = n
+		} else if n > 0 {
+			r.store.tags[name] = &models.Tag{Name: name, PostCount: n, CreatedAt: time.Now()}
+		}
+	}
+	return nil
+}
+
+func (r *tagRepository) Trending(ctx context.Context, since time.Time, limit int) ([]*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, p := range r.store.posts {
+		if visible(p) && !p.CreatedAt.Before(since) {
+			for _, tag := range p.Tags {
+				counts[tag]++
+			}
+		}
+	}
+	trending := make([]*models.Tag, 0, len(counts))
+	for name, n := range counts {
+		t := &models.Tag{Name: name}
+		if reg, ok := r.store.tags[name]; ok {
+			t = copyTag(reg)
+		}
+		t.RecentPosts = n
+		trending = append(trending, t)
+	}
+	repositories.SortTrending(trending)
+	return trending[:min(limit, len(trending))], nil
+}
+
+func (r *tagRepository) Follow(ctx context.Context, follow *models.TagFollow) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	follow.ID = repositories.TagFollowID(follow.UserID, follow.Tag)
+	c := *follow
+	r.store.tagFollows[follow.ID] = &c
+	return nil
+}
+
+func (r *tagRepository) Unfollow(ctx context.Context, userID, tag string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.tagFollows, repositories.TagFollowID(userID, tag))
+	return nil
+}
+
+func (r *tagRepository) GetFollowed(ctx context.Context, userID string) ([]*models.TagFollow, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	follows := make([]*models.TagFollow, 0)
+	for _, f := range r.store.tagFollows {
+		if f.UserID == userID {
+			c := *f
+			follows = append(follows, &c)
+		}
+	}
+	sortBy(follows, func(f *models.TagFollow) repositories.Cursor {
+		return repositories.Cursor{Time: f.CreatedAt, ID: f.ID}
+	}, newestFirst)
+	return follows, nil
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type trashRepository struct {
+	store *Store
+}
+
+// NewTrashRepository crea un TrashRepository en memoria.
+func NewTrashRepository(store *Store) repositories.TrashRepository {
+	return &trashRepository{store: store}
+}
+
+func (r *trashRepository) TrashPost(ctx context.Context, postID, deletedBy string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok {
+		return fmt.Errorf("error al eliminar el post: %s no existe", postID)
+	}
+	if p.DeletedAt == nil {
+		now := time.Now()
+		p.DeletedAt = &now
+		p.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashComment(ctx context.Context, commentID, deletedBy string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok {
+		return fmt.Errorf("error al eliminar el comentario: %s no existe", commentID)
+	}
+	if c.DeletedAt == nil {
+		now := time.Now()
+		c.DeletedAt = &now
+		c.DeletedBy = deletedBy
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashedPost(ctx context.Context, postID string) (*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok || p.DeletedAt == nil {
+		return nil, repositories.ErrNotInTrash
+	}
+	return copyPost(p), nil
+}
+
+func (r *trashRepository) TrashedComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok || c.DeletedAt == nil {
+		return nil, repositories.ErrNotInTrash
+	}
+	return copyComment(c), nil
+}
+
+func (r *trashRepository) RestorePost(ctx context.Context, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.posts[postID]
+	if !ok || p.DeletedAt == nil {
+		return repositories.ErrNotInTrash
+	}
+	p.DeletedAt = nil
+	p.DeletedBy = ""
+	return nil
+}
+
+func (r *trashRepository) RestoreComment(ctx context.Context, commentID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.store.comments[commentID]
+	if !ok || c.DeletedAt == nil {
+		return repositories.ErrNotInTrash
+	}
+	c.DeletedAt = nil
+	c.DeletedBy = ""
+	return nil
+}
+
+func (r *trashRepository) GetTrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	posts := make([]*models.Post, 0)
+	for _, p := range r.store.posts {
+		if p.DeletedAt != nil && filter.Matches(p.DeletedBy, p.AuthorID, p.ForumID) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+	sortBy(posts, repositories.TrashedPostCursor, newestFirst)
+	return paginate(posts, page, repositories.TrashedPostCursor, newestFirst)
+}
+
+// forumOf devuelve el subforo del post del comentario.
+func (r *trashRepository) forumOf(c *models.Comment) string {
+	if p, ok := r.store.posts[c.PostID]; ok {
+		return p.ForumID
+	}
+	return ""
+}
+
+func (r *trashRepository) GetTrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	comments := make([]models.Comment, 0)
+	for _, c := range r.store.comments {
+		if c.DeletedAt != nil && filter.Matches(c.DeletedBy, c.AuthorID, r.forumOf(c)) {
+			comments = append(comments, *copyComment(c))
+		}
+	}
+	sortBy(comments, repositories.TrashedCommentCursor, newestFirst)
+	return paginate(comments, page, repositories.TrashedCommentCursor, newestFirst)
+}
+
+func (r *trashRepository) ExpiredPosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var posts []*models.Post
+	for _, p := range r.store.posts {
+		if p.DeletedAt != nil && p.DeletedAt.Before(before) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+	sortBy(posts, repositories.TrashedPostCursor, oldestFirst)
+	return posts[:min(limit, len(posts))], nil
+}
+
+func (r *trashRepository) ExpiredComments(ctx context.Context, before time.Time, limit int) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, c := range r.store.comments {
+		if c.DeletedAt != nil && c.DeletedAt.Before(before) {
+			comments = append(comments, *copyComment(c))
+		}
+	}
+	sortBy(comments, repositories.TrashedCommentCursor, oldestFirst)
+	return comments[:min(limit, len(comments))], nil
+}
+
+// deleteVotesTo borra los votos al destino. Se debe llamar con el lock tomado.
+func (s *Store) deleteVotesTo(target models.VoteTarget, targetID string) {
+	for id, v := range s.votes {
+		if isVoteTo(v, target, targetID) {
+			delete(s.votes, id)
+		}
+	}
+}
+
+// purgeComment borra el comentario, sus respuestas y los votos a todos ellos.
+// Se debe llamar con el lock tomado.
+func (s *Store) purgeComment(commentID string) {
+	for id, c := range s.comments {
+		if c.ParentID == commentID {
+			s.purgeComment(id)
+		}
+	}
+	s.deleteVotesTo(models.TargetComment, commentID)
+	delete(s.comments, commentID)
+}
+
+func (r *trashRepository) PurgePost(ctx context.Context, postID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, c := range r.store.comments {
+		if c.PostID == postID {
+			r.store.deleteVotesTo(models.TargetComment, id)
+			delete(r.store.comments, id)
+		}
+	}
+	for id, s := range r.store.saved {
+		if s.PostID == postID {
+			delete(r.store.saved, id)
+		}
+	}
+	for id, rev := range r.store.revisions {
+		if rev.PostID == postID {
+			delete(r.store.revisions, id)
+		}
+	}
+	for id, b := range r.store.ballots {
+		if b.PostID == postID {
+			delete(r.store.ballots, id)
+		}
+	}
+	r.store.deleteVotesTo(models.TargetPost, postID)
+	delete(r.store.embeddings, postID)
+	delete(r.store.posts, postID)
+	return nil
+}
+
+func (r *trashRepository) PurgeComment(ctx context.Context, commentID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.purgeComment(commentID)
+	return nil
+}
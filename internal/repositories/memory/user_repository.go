@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+// NewUserRepository crea un UserRepository en memoria.
+func NewUserRepository(store *Store) repositories.UserRepository {
+	return &userRepository{store: store}
+}
+
+// NewUserLoader crea un UserLoader en memoria.
+func NewUserLoader(store *Store) repositories.UserLoader {
+	return repositories.NewCachedUserLoader(func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+		store.mu.RLock()
+		defer store.mu.RUnlock()
+
+		users := make(map[string]*models.User, len(ids))
+		for _, id := range ids {
+			if u := store.author(id); u != nil {
+				users[id] = u
+			}
+		}
+		return users, nil
+	})
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, userID string) (map[string]interface{}, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	data, ok := r.store.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("error obteniendo usuario: %s no existe", userID)
+	}
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, userID string, userData map[string]interface{}) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	data := make(map[string]interface{}, len(userData))
+	for k, v := range userData {
+		data[k] = v
+	}
+	r.store.users[userID] = data
+	return nil
+}
+
+func (r *userRepository) EditUserProfile(ctx context.Context, userID string, userData models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	data, ok := r.store.users[userID]
+	if !ok {
+		return fmt.Errorf("usuario %s no existe", userID)
+	}
+	data["profile_photo"] = userData.ProfilePhoto
+	data["username"] = userData.Username
+	data["banner_image"] = userData.BannerImage
+	return nil
+}
+
+func (r *userRepository) UpdateUserEmail(ctx context.Context, userID, email string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	data, ok := r.store.users[userID]
+	if !ok {
+		return fmt.Errorf("usuario %s no existe", userID)
+	}
+	data["email"] = email
+	data["updatedAt"] = time.Now()
+	return nil
+}
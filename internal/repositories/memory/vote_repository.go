@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type voteRepository struct {
+	store *Store
+}
+
+// NewVoteRepository crea un VoteRepository en memoria.
+func NewVoteRepository(store *Store) repositories.VoteRepository {
+	return &voteRepository{store: store}
+}
+
+func (r *voteRepository) GetVoteByID(ctx context.Context, voteID string) (*models.Vote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	v, ok := r.store.votes[voteID]
+	if !ok {
+		return nil, fmt.Errorf("vote %s not found", voteID)
+	}
+	vote := *v
+	return &vote, nil
+}
+
+// isVoteTo indica si el voto va al destino.
+func isVoteTo(v *models.Vote, target models.VoteTarget, targetID string) bool {
+	t, id := v.Target()
+	return t == target && id == targetID
+}
+
+func (r *voteRepository) GetVotesByTarget(ctx context.Context, target models.VoteTarget, targetID string) ([]models.Vote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var votes []models.Vote
+	for _, v := range r.store.votes {
+		if isVoteTo(v, target, targetID) {
+			votes = append(votes, *v)
+		}
+	}
+	return votes, nil
+}
+
+// userVote devuelve el voto del usuario al destino, si existe. Se debe llamar
+// con el lock tomado.
+func (s *Store) userVote(userID string, target models.VoteTarget, targetID string) *models.Vote {
+	if v, ok := s.votes[repositories.VoteID(target, targetID, userID)]; ok {
+		return v
+	}
+	for _, v := range s.votes {
+		if v.UserID == userID && isVoteTo(v, target, targetID) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (r *voteRepository) GetUserVote(ctx context.Context, userID string, target models.VoteTarget, targetID string) (*models.Vote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if v := r.store.userVote(userID, target, targetID); v != nil {
+		vote := *v
+		return &vote, nil
+	}
+	return nil, nil
+}
+
+func (r *voteRepository) GetUserVotes(ctx context.Context, userID string, target models.VoteTarget, targetIDs []string) (map[string]*models.Vote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	votes := make(map[string]*models.Vote)
+	for _, id := range targetIDs {
+		if v := r.store.userVote(userID, target, id); v != nil {
+			vote := *v
+			votes[id] = &vote
+		}
+	}
+	return votes, nil
+}
+
+// counters devuelve los contadores del destino. Se debe llamar con el lock tomado.
+func (s *Store) counters(target models.VoteTarget, targetID string) (likes, dislikes *int, postID string, err error) {
+	switch target {
+	case models.TargetPost:
+		if p, ok := s.posts[targetID]; ok {
+			return &p.Likes, &p.Dislikes, p.ID, nil
+		}
+	case models.TargetComment:
+		if c, ok := s.comments[targetID]; ok {
+			return &c.Likes, &c.Dislikes, c.PostID, nil
+		}
+	}
+	return nil, nil, "", fmt.Errorf("%s %s no existe", target, targetID)
+}
+
+func (r *voteRepository) React(ctx context.Context, userID string, target models.VoteTarget, targetID, reaction string) (*models.Vote, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	likes, dislikes, postID, err := r.store.counters(target, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	id := repositories.VoteID(target, targetID, userID)
+	prev := r.store.votes[id]
+	// Votos guardados con ID aleatorio: se reemplazan por el voto con ID fijo
+	for voteID, v := range r.store.votes {
+		if voteID != id && v.UserID == userID && isVoteTo(v, target, targetID) {
+			if prev == nil {
+				prev = v
+			}
+			delete(r.store.votes, voteID)
+		}
+	}
+
+	prevType := ""
+	if prev != nil {
+		prevType = string(prev.Type)
+	}
+	next := reaction
+	if next == "none" {
+		next = ""
+	}
+
+	dl, dd := repositories.ReactionDelta(prevType, next)
+	*likes += dl
+	*dislikes += dd
+	if p, ok := r.store.posts[targetID]; ok && target == models.TargetPost {
+		p.Rank(time.Now())
+	}
+
+	if next == "" {
+		delete(r.store.votes, id)
+		return nil, nil
+	}
+
+	now := time.Now()
+	v := &models.Vote{
+		VoteID:     id,
+		UserID:     userID,
+		TargetType: target,
+		TargetID:   targetID,
+		PostID:     postID,
+		Type:       models.VoteType(next),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if target == models.TargetComment {
+		v.CommentID = targetID
+	}
+	if prev != nil {
+		v.CreatedAt = prev.CreatedAt
+		if prevType == next {
+			v.UpdatedAt = prev.UpdatedAt
+		}
+	}
+	r.store.votes[id] = v
+	vote := *v
+	return &vote, nil
+}
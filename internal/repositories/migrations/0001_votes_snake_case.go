@@ -0,0 +1,23 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Los votos se guardan con los campos en snake_case (post_id, comment_id...),
+// pero versiones anteriores escribían algunos en camelCase. Esta migración los
+// renombra para que las consultas por post_id y comment_id los encuentren.
+func init() {
+	Register(Migration{
+		Version:    1,
+		Name:       "votes_snake_case",
+		Collection: "votes",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			return rename(data, map[string]string{
+				"postId":    "post_id",
+				"commentId": "comment_id",
+				"userId":    "user_id",
+				"createdAt": "created_at",
+				"updatedAt": "updated_at",
+			})
+		},
+	})
+}
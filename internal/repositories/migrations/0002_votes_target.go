@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// Los votos indican a qué apuntan con target_type y target_id, igual para
+// posts y comentarios. Esta migración completa esos campos en los votos
+// guardados antes, a partir de comment_id o post_id.
+func init() {
+	Register(Migration{
+		Version:    2,
+		Name:       "votes_target",
+		Collection: "votes",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			if _, ok := data["target_type"]; ok {
+				return nil
+			}
+			postID, _ := data["post_id"].(string)
+			target, targetID := models.TargetPost, postID
+			if commentID, _ := data["comment_id"].(string); commentID != "" {
+				target, targetID = models.TargetComment, commentID
+			}
+			return []firestore.Update{
+				{Path: "target_id", Value: targetID},
+				{Path: "target_type", Value: string(target)},
+			}
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// Las reacciones a comentarios se guardaban en el mapa reactions del
+// comentario (usuario -> like/dislike). Ahora son votos en la colección votes,
+// como los de los posts. Esta migración crea un voto por cada reacción y
+// borra el mapa; los likes y dislikes del comentario ya las contaban, así que
+// no cambian. Si el usuario ya votó el comentario con la API nueva se
+// conserva ese voto.
+func init() {
+	Register(Migration{
+		Version:    3,
+		Name:       "comment_reactions_to_votes",
+		Collection: "comments",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			if _, ok := data["reactions"]; !ok {
+				return nil
+			}
+			return []firestore.Update{{Path: "reactions", Value: firestore.Delete}}
+		},
+		Create: func(id string, data map[string]interface{}) []Doc {
+			reactions, _ := data["reactions"].(map[string]interface{})
+			postID, _ := data["postId"].(string)
+			at, ok := data["updatedAt"].(time.Time)
+			if !ok || at.IsZero() {
+				at, _ = data["createdAt"].(time.Time)
+			}
+
+			var docs []Doc
+			for userID, reaction := range reactions {
+				t, _ := reaction.(string)
+				if t != string(models.Like) && t != string(models.Dislike) {
+					continue
+				}
+				docs = append(docs, Doc{
+					Collection: "votes",
+					ID:         repositories.VoteID(models.TargetComment, id, userID),
+					Data: &models.Vote{
+						UserID:     userID,
+						TargetType: models.TargetComment,
+						TargetID:   id,
+						PostID:     postID,
+						CommentID:  id,
+						Type:       models.VoteType(t),
+						CreatedAt:  at,
+						UpdatedAt:  at,
+					},
+				})
+			}
+			sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+			return docs
+		},
+	})
+}
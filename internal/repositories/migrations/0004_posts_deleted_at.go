@@ -0,0 +1,17 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Los listados de posts excluyen los de la papelera con deleted_at == null,
+// y Firestore solo encuentra así a los documentos que tienen el campo. Esta
+// migración lo agrega, en null, a los posts creados antes.
+func init() {
+	Register(Migration{
+		Version:    4,
+		Name:       "posts_deleted_at",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			return setMissing(data, "deleted_at", nil)
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Igual que 0004 para los comentarios, que se filtran con deletedAt == null.
+func init() {
+	Register(Migration{
+		Version:    5,
+		Name:       "comments_deleted_at",
+		Collection: "comments",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			return setMissing(data, "deletedAt", nil)
+		},
+	})
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Los listados solo muestran los posts con status == "published". Los posts
+// creados antes de los borradores no tienen status y están publicados.
+func init() {
+	Register(Migration{
+		Version:    6,
+		Name:       "posts_status",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			return setMissing(data, "status", "published")
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// Los posts pasaron de una sola imagen (image_url, image_id) a una galería
+// (media). Esta migración arma la galería de los posts anteriores con su
+// imagen, si tienen.
+func init() {
+	Register(Migration{
+		Version:    7,
+		Name:       "posts_media",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			if _, ok := data["media"]; ok {
+				return nil
+			}
+			media := []map[string]interface{}{}
+			if url, _ := data["image_url"].(string); url != "" {
+				publicID, _ := data["image_id"].(string)
+				media = append(media, map[string]interface{}{
+					"id":        repositories.NewDocumentID(),
+					"url":       url,
+					"public_id": publicID,
+					"caption":   "",
+					"alt":       "",
+				})
+			}
+			return []firestore.Update{{Path: "media", Value: media}}
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// Los órdenes hot, top, controversial y rising usan los valores
+// precalculados de ranks. Esta migración los calcula para los posts
+// anteriores; los demás los recalculan al recibir votos.
+func init() {
+	Register(Migration{
+		Version:    8,
+		Name:       "posts_ranks",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			if _, ok := data["ranks"]; ok {
+				return nil
+			}
+			likes, _ := data["likes"].(int64)
+			dislikes, _ := data["dislikes"].(int64)
+			createdAt, _ := data["created_at"].(time.Time)
+			ranks := models.RankPost(int(likes), int(dislikes), createdAt, time.Now())
+			return []firestore.Update{{Path: "ranks", Value: ranks}}
+		},
+	})
+}
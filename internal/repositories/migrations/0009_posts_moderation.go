@@ -0,0 +1,24 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Los listados de un subforo dejan afuera los posts fijados con
+// pinned_at == null y el archivado automático busca los posts con
+// archived_at == null y keep_open == false. Firestore solo encuentra así a
+// los documentos que tienen los campos: esta migración los agrega a los
+// posts creados antes.
+func init() {
+	Register(Migration{
+		Version:    9,
+		Name:       "posts_moderation",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			var updates []firestore.Update
+			updates = append(updates, setMissing(data, "pinned_at", nil)...)
+			updates = append(updates, setMissing(data, "locked_at", nil)...)
+			updates = append(updates, setMissing(data, "archived_at", nil)...)
+			updates = append(updates, setMissing(data, "keep_open", false)...)
+			return updates
+		},
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// Los posts guardan sus tags normalizados (ver models.NormalizeTag) y los
+// tags se buscan por su nombre exacto. Esta migración normaliza los tags de
+// los posts anteriores; el registro de tags se arma después con
+// POST /api/admin/tags/recount.
+func init() {
+	Register(Migration{
+		Version:    10,
+		Name:       "posts_tags",
+		Collection: "posts",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			raw, ok := data["tags"].([]interface{})
+			if !ok {
+				return nil
+			}
+			tags := make([]string, 0, len(raw))
+			for _, t := range raw {
+				if s, ok := t.(string); ok {
+					tags = append(tags, s)
+				}
+			}
+			normalized := models.NormalizeTags(tags)
+			if len(tags) == len(raw) && slices.Equal(tags, normalized) {
+				return nil
+			}
+			return []firestore.Update{{Path: "tags", Value: normalized}}
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "cloud.google.com/go/firestore"
+
+// Igual que 0001 para los comentarios, que se guardaban con los campos en
+// camelCase (postId, createdAt...) a diferencia de los posts y los votos. La
+// API los sigue devolviendo en camelCase: solo cambia cómo se guardan.
+func init() {
+	Register(Migration{
+		Version:    11,
+		Name:       "comments_snake_case",
+		Collection: "comments",
+		Apply: func(data map[string]interface{}) []firestore.Update {
+			return rename(data, map[string]string{
+				"commentID": "comment_id",
+				"postId":    "post_id",
+				"authorId":  "author_id",
+				"parentId":  "parent_id",
+				"createdAt": "created_at",
+				"updatedAt": "updated_at",
+				"deletedAt": "deleted_at",
+				"deletedBy": "deleted_by",
+			})
+		},
+	})
+}
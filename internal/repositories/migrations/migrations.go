@@ -0,0 +1,96 @@
+// Package migrations versiona el esquema de los documentos de Firestore.
+//
+// Cada migración es código Go que se registra con Register y recorre una
+// colección documento por documento. El progreso se guarda en la colección
+// schema_migrations (un documento por versión, con el ID del último documento
+// procesado), así que una migración interrumpida continúa donde quedó. Las
+// escrituras usan como precondición la hora de actualización del documento
+// leído, para no pisar cambios hechos por el servidor mientras corre.
+package migrations
+
+import (
+	"fmt"
+	"sort"
+
+	"cloud.google.com/go/firestore"
+)
+
+// Migration cambia los documentos de una colección.
+//
+// Apply recibe los datos de un documento y devuelve los cambios a aplicar, o
+// nil si el documento ya está migrado. Tiene que ser idempotente: al reanudar
+// una migración se pueden volver a procesar documentos del último lote.
+//
+// Create, si no es nil, devuelve documentos de otras colecciones que se crean
+// antes de aplicar los cambios de Apply al documento. Los que ya existen no
+// se tocan, así que reanudar no pisa datos escritos después.
+type Migration struct {
+	Version    int
+	Name       string
+	Collection string
+	Apply      func(data map[string]interface{}) []firestore.Update
+	Create     func(id string, data map[string]interface{}) []Doc
+}
+
+// Doc es un documento que crea una migración.
+type Doc struct {
+	Collection string
+	ID         string
+	Data       interface{}
+}
+
+var registry = map[int]Migration{}
+
+// Register agrega una migración. Se llama desde el init de cada archivo de
+// migración; una versión repetida es un error de programación.
+func Register(m Migration) {
+	if other, dup := registry[m.Version]; dup {
+		panic(fmt.Sprintf("versión de migración %d duplicada: %s y %s", m.Version, other.Name, m.Name))
+	}
+	if m.Collection == "" || m.Apply == nil {
+		panic(fmt.Sprintf("migración %d (%s) sin colección o sin Apply", m.Version, m.Name))
+	}
+	registry[m.Version] = m
+}
+
+// All devuelve las migraciones registradas ordenadas por versión.
+func All() []Migration {
+	all := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Version < all[j].Version
+	})
+	return all
+}
+
+// rename devuelve los cambios para renombrar los campos de from a to (viejo
+// -> nuevo). Si el documento ya tiene el campo nuevo se conserva su valor y
+// solo se borra el viejo.
+func rename(data map[string]interface{}, fields map[string]string) []firestore.Update {
+	var updates []firestore.Update
+	for from, to := range fields {
+		value, ok := data[from]
+		if !ok {
+			continue
+		}
+		if _, exists := data[to]; !exists {
+			updates = append(updates, firestore.Update{Path: to, Value: value})
+		}
+		updates = append(updates, firestore.Update{Path: from, Value: firestore.Delete})
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Path < updates[j].Path
+	})
+	return updates
+}
+
+// setMissing devuelve el cambio para agregar el campo con value si el
+// documento no lo tiene.
+func setMissing(data map[string]interface{}, field string, value interface{}) []firestore.Update {
+	if _, ok := data[field]; ok {
+		return nil
+	}
+	return []firestore.Update{{Path: field, Value: value}}
+}
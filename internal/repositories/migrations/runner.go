@@ -0,0 +1,420 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stateCollection guarda el progreso de cada migración.
+const stateCollection = "schema_migrations"
+
+// DefaultBatchSize es la cantidad de documentos por lote si no se indica otra.
+const DefaultBatchSize = 300
+
+// leaseDuration es cuánto tiempo una ejecución se reserva una migración sin
+// guardar progreso. Pasado ese tiempo otra ejecución la puede retomar.
+const leaseDuration = 5 * time.Minute
+
+const (
+	stateRunning = "running"
+	stateDone    = "done"
+)
+
+// state es el documento de schema_migrations de una migración.
+type state struct {
+	Name       string     `firestore:"name"`
+	Status     string     `firestore:"status"`
+	Cursor     string     `firestore:"cursor"`
+	Processed  int        `firestore:"processed"`
+	Updated    int        `firestore:"updated"`
+	Owner      string     `firestore:"owner"`
+	LeaseUntil time.Time  `firestore:"lease_until"`
+	StartedAt  time.Time  `firestore:"started_at"`
+	UpdatedAt  time.Time  `firestore:"updated_at"`
+	FinishedAt *time.Time `firestore:"finished_at"`
+}
+
+// Status es el estado de una migración registrada.
+type Status struct {
+	Migration
+	// Running indica que la migración empezó pero no terminó; Cursor es el
+	// último documento procesado.
+	Running    bool
+	Cursor     string
+	Processed  int
+	Updated    int
+	FinishedAt *time.Time
+}
+
+// Options configura una ejecución de Run.
+type Options struct {
+	// DryRun recorre los documentos y cuenta los que cambiarían sin escribir
+	// nada, ni siquiera el progreso.
+	DryRun    bool
+	BatchSize int
+	// Progress, si no es nil, se llama después de cada lote.
+	Progress func(Result)
+}
+
+// Result resume lo que hizo Run con una migración.
+type Result struct {
+	Migration
+	// Resumed indica que se continuó desde el progreso de una ejecución anterior.
+	Resumed bool
+	// Processed y Updated cuentan los documentos revisados y los cambiados (o
+	// que se cambiarían, con DryRun) en esta ejecución.
+	Processed int
+	Updated   int
+	Done      bool
+}
+
+func stateRef(db *firestore.Client, m Migration) *firestore.DocumentRef {
+	return db.Collection(stateCollection).Doc(fmt.Sprintf("%04d", m.Version))
+}
+
+// loadState devuelve el progreso guardado de la migración, o nil si nunca corrió.
+func loadState(get func(*firestore.DocumentRef) (*firestore.DocumentSnapshot, error), ref *firestore.DocumentRef) (*state, error) {
+	snap, err := get(ref)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s/%s: %w", stateCollection, ref.ID, err)
+	}
+	var st state
+	if err := snap.DataTo(&st); err != nil {
+		return nil, fmt.Errorf("error decodificando %s/%s: %w", stateCollection, ref.ID, err)
+	}
+	return &st, nil
+}
+
+// getter lee documentos fuera de una transacción.
+func getter(ctx context.Context) func(*firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+	return func(ref *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+		return ref.Get(ctx)
+	}
+}
+
+// Statuses devuelve todas las migraciones registradas y su progreso.
+func Statuses(ctx context.Context, db *firestore.Client) ([]Status, error) {
+	all := All()
+	statuses := make([]Status, 0, len(all))
+	for _, m := range all {
+		ref := stateRef(db, m)
+		st, err := loadState(getter(ctx), ref)
+		if err != nil {
+			return nil, err
+		}
+		s := Status{Migration: m}
+		if st != nil {
+			s.Running = st.Status == stateRunning
+			s.Cursor = st.Cursor
+			s.Processed = st.Processed
+			s.Updated = st.Updated
+			s.FinishedAt = st.FinishedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Run aplica en orden las migraciones que no terminaron y devuelve lo hecho
+// con cada una. Con DryRun las migraciones se evalúan sobre los datos
+// actuales, sin las que les preceden aplicadas.
+func Run(ctx context.Context, db *firestore.Client, opts Options) ([]Result, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	var results []Result
+	for _, m := range All() {
+		st, err := loadState(getter(ctx), stateRef(db, m))
+		if err != nil {
+			return results, err
+		}
+		if st != nil && st.Status == stateDone {
+			continue
+		}
+
+		r := &runner{db: db, m: m, opts: opts, ref: stateRef(db, m), owner: repositories.NewDocumentID()}
+		res, err := r.run(ctx, st)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("migración %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return results, nil
+}
+
+// runner aplica una migración. owner identifica esta ejecución en la reserva
+// guardada en schema_migrations.
+type runner struct {
+	db    *firestore.Client
+	m     Migration
+	opts  Options
+	ref   *firestore.DocumentRef
+	owner string
+}
+
+func (r *runner) run(ctx context.Context, st *state) (Result, error) {
+	res := Result{Migration: r.m}
+
+	cursor := ""
+	if st != nil {
+		cursor = st.Cursor
+	}
+	if !r.opts.DryRun {
+		claimed, err := r.claim(ctx)
+		if err != nil {
+			return res, err
+		}
+		cursor = claimed.Cursor
+	}
+	res.Resumed = cursor != ""
+
+	for {
+		q := r.db.Collection(r.m.Collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(r.opts.BatchSize)
+		if cursor != "" {
+			q = q.StartAfter(cursor)
+		}
+		docs, err := q.Documents(ctx).GetAll()
+		if err != nil {
+			return res, fmt.Errorf("error leyendo %s: %w", r.m.Collection, err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		updated, err := r.applyBatch(ctx, docs)
+		if err != nil {
+			return res, err
+		}
+		cursor = docs[len(docs)-1].Ref.ID
+		res.Processed += len(docs)
+		res.Updated += updated
+
+		if !r.opts.DryRun {
+			if err := r.save(ctx, func(st *state) {
+				st.Cursor = cursor
+				st.Processed += len(docs)
+				st.Updated += updated
+			}); err != nil {
+				return res, err
+			}
+		}
+		if r.opts.Progress != nil {
+			r.opts.Progress(res)
+		}
+		if len(docs) < r.opts.BatchSize {
+			break
+		}
+	}
+
+	if r.opts.DryRun {
+		return res, nil
+	}
+	err := r.save(ctx, func(st *state) {
+		now := time.Now()
+		st.Status = stateDone
+		st.Owner = ""
+		st.FinishedAt = &now
+	})
+	res.Done = err == nil
+	return res, err
+}
+
+// claim reserva la migración para esta ejecución y devuelve el progreso
+// guardado. Falla si otra ejecución la tiene reservada.
+func (r *runner) claim(ctx context.Context) (*state, error) {
+	var claimed *state
+	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		st, err := loadState(tx.Get, r.ref)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if st == nil {
+			st = &state{Name: r.m.Name, StartedAt: now}
+		}
+		if st.Owner != "" && st.Owner != r.owner && st.LeaseUntil.After(now) {
+			return fmt.Errorf("otra ejecución la está aplicando (reservada hasta %s)", st.LeaseUntil.Format(time.RFC3339))
+		}
+		st.Status = stateRunning
+		st.Owner = r.owner
+		st.LeaseUntil = now.Add(leaseDuration)
+		st.UpdatedAt = now
+		claimed = st
+		return tx.Set(r.ref, st)
+	})
+	return claimed, err
+}
+
+// errLeaseLost indica que otra ejecución tomó la migración mientras esta corría.
+var errLeaseLost = errors.New("otra ejecución tomó la migración")
+
+// save guarda el progreso y renueva la reserva, si sigue siendo de esta ejecución.
+func (r *runner) save(ctx context.Context, update func(*state)) error {
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		st, err := loadState(tx.Get, r.ref)
+		if err != nil {
+			return err
+		}
+		if st == nil || st.Owner != r.owner {
+			return errLeaseLost
+		}
+		now := time.Now()
+		update(st)
+		st.UpdatedAt = now
+		if st.Status == stateRunning {
+			st.LeaseUntil = now.Add(leaseDuration)
+		}
+		return tx.Set(r.ref, st)
+	})
+}
+
+// applyBatch aplica la migración a los documentos y devuelve cuántos cambió.
+// Cada escritura exige que el documento no haya cambiado desde que se leyó;
+// si cambió, se vuelve a leer y aplicar.
+func (r *runner) applyBatch(ctx context.Context, docs []*firestore.DocumentSnapshot) (int, error) {
+	type write struct {
+		doc     *firestore.DocumentSnapshot
+		updates []firestore.Update
+		job     *firestore.BulkWriterJob
+	}
+
+	var writes []*write
+	for _, doc := range docs {
+		updates := r.m.Apply(doc.Data())
+		if len(updates) == 0 {
+			continue
+		}
+		writes = append(writes, &write{doc: doc, updates: updates})
+	}
+	if r.opts.DryRun || len(writes) == 0 {
+		return len(writes), nil
+	}
+
+	if r.m.Create != nil {
+		var created []Doc
+		for _, w := range writes {
+			created = append(created, r.m.Create(w.doc.Ref.ID, w.doc.Data())...)
+		}
+		if err := r.create(ctx, created); err != nil {
+			return 0, err
+		}
+	}
+
+	bw := r.db.BulkWriter(ctx)
+	for _, w := range writes {
+		job, err := bw.Update(w.doc.Ref, w.updates, firestore.LastUpdateTime(w.doc.UpdateTime))
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("error actualizando %s: %w", w.doc.Ref.Path, err)
+		}
+		w.job = job
+	}
+	bw.End()
+
+	for _, w := range writes {
+		_, err := w.job.Results()
+		if status.Code(err) == codes.FailedPrecondition {
+			err = r.retry(ctx, w.doc.Ref)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error actualizando %s: %w", w.doc.Ref.Path, err)
+		}
+	}
+	return len(writes), nil
+}
+
+// create crea los documentos que no existen.
+func (r *runner) create(ctx context.Context, docs []Doc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	type job struct {
+		ref *firestore.DocumentRef
+		job *firestore.BulkWriterJob
+	}
+
+	var jobs []job
+	bw := r.db.BulkWriter(ctx)
+	for _, d := range docs {
+		ref := r.db.Collection(d.Collection).Doc(d.ID)
+		j, err := bw.Create(ref, d.Data)
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("error creando %s: %w", ref.Path, err)
+		}
+		jobs = append(jobs, job{ref: ref, job: j})
+	}
+	bw.End()
+
+	for _, j := range jobs {
+		if _, err := j.job.Results(); err != nil && status.Code(err) != codes.AlreadyExists {
+			return fmt.Errorf("error creando %s: %w", j.ref.Path, err)
+		}
+	}
+	return nil
+}
+
+// maxRetries es cuántas veces se vuelve a intentar un documento que sigue
+// cambiando mientras se migra.
+const maxRetries = 5
+
+// retry aplica la migración a un documento que cambió durante el lote.
+func (r *runner) retry(ctx context.Context, ref *firestore.DocumentRef) error {
+	if r.m.Create != nil {
+		return r.retryWithCreate(ctx, ref)
+	}
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		updates := r.m.Apply(doc.Data())
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Update(ref, updates)
+	})
+}
+
+// retryWithCreate es retry para las migraciones con Create. Los documentos a
+// crear pueden ser muchos más de los que entran en una transacción, así que
+// se crean primero y después se actualiza el documento con la misma
+// precondición que en el lote.
+func (r *runner) retryWithCreate(ctx context.Context, ref *firestore.DocumentRef) error {
+	for i := 0; i < maxRetries; i++ {
+		doc, err := ref.Get(ctx)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		updates := r.m.Apply(doc.Data())
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := r.create(ctx, r.m.Create(ref.ID, doc.Data())); err != nil {
+			return err
+		}
+		_, err = ref.Update(ctx, updates, firestore.LastUpdateTime(doc.UpdateTime))
+		if status.Code(err) != codes.FailedPrecondition {
+			return err
+		}
+	}
+	return fmt.Errorf("el documento siguió cambiando después de %d intentos", maxRetries)
+}
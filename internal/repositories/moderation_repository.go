@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// ModerationRepository guarda el registro de las acciones de moderación de
+// los subforos.
+type ModerationRepository interface {
+	// Record agrega la acción al registro.
+	Record(ctx context.Context, entry *models.ModerationLogEntry) error
+	// GetLog pagina el registro del subforo, las acciones más recientes
+	// primero.
+	GetLog(ctx context.Context, forumID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error)
+}
+
+type moderationRepository struct {
+	db *firestore.Client
+}
+
+// NewModerationRepository crea un ModerationRepository sobre Firestore.
+func NewModerationRepository(db *firestore.Client) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+func (r *moderationRepository) Record(ctx context.Context, entry *models.ModerationLogEntry) error {
+	entry.ID = NewDocumentID()
+	if _, err := r.db.Collection("moderationLog").Doc(entry.ID).Create(ctx, entry); err != nil {
+		return fmt.Errorf("error al registrar la acción de moderación: %w", err)
+	}
+	return nil
+}
+
+func (r *moderationRepository) GetLog(ctx context.Context, forumID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.ModerationLogEntry]{}, err
+	}
+	size := page.Size()
+
+	q := r.db.Collection("moderationLog").
+		Where("forum_id", "==", forumID).
+		OrderBy("created_at", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	iter := q.Limit(size + 1).Documents(ctx)
+	defer iter.Stop()
+
+	entries := make([]*models.ModerationLogEntry, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return models.Page[*models.ModerationLogEntry]{}, fmt.Errorf("error al obtener el registro de moderación: %w", err)
+		}
+		var e models.ModerationLogEntry
+		if err := doc.DataTo(&e); err != nil {
+			return models.Page[*models.ModerationLogEntry]{}, fmt.Errorf("error al decodificar acción de moderación: %w", err)
+		}
+		e.ID = doc.Ref.ID
+		entries = append(entries, &e)
+	}
+	return NewPage(entries, size, ModerationCursor), nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrAlreadyVoted indica que el usuario ya votó en la encuesta.
+var ErrAlreadyVoted = errors.New("ya votaste en esta encuesta")
+
+// PollRepository guarda los votos de las encuestas de los posts.
+type PollRepository interface {
+	// CastBallot guarda el voto. Devuelve ErrAlreadyVoted si el usuario ya
+	// había votado en la encuesta.
+	CastBallot(ctx context.Context, ballot *models.PollBallot) error
+	// GetBallot devuelve el voto del usuario, o nil si no votó.
+	GetBallot(ctx context.Context, postID, userID string) (*models.PollBallot, error)
+	// Tally cuenta los votos de la encuesta del post, que tiene options opciones.
+	Tally(ctx context.Context, postID string, options int) (*models.PollTally, error)
+}
+
+// BallotID es el ID del voto de un usuario en la encuesta de un post; que
+// sea fijo impide votar dos veces.
+func BallotID(postID, userID string) string {
+	return postID + "_" + userID
+}
+
+type pollRepository struct {
+	db *firestore.Client
+}
+
+// NewPollRepository crea un PollRepository sobre Firestore.
+func NewPollRepository(db *firestore.Client) PollRepository {
+	return &pollRepository{db: db}
+}
+
+func (r *pollRepository) CastBallot(ctx context.Context, ballot *models.PollBallot) error {
+	ballot.ID = BallotID(ballot.PostID, ballot.UserID)
+	_, err := r.db.Collection("pollBallots").Doc(ballot.ID).Create(ctx, ballot)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrAlreadyVoted
+	}
+	if err != nil {
+		return fmt.Errorf("error al guardar el voto de la encuesta: %w", err)
+	}
+	return nil
+}
+
+func (r *pollRepository) GetBallot(ctx context.Context, postID, userID string) (*models.PollBallot, error) {
+	doc, err := r.db.Collection("pollBallots").Doc(BallotID(postID, userID)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el voto de la encuesta: %w", err)
+	}
+	var ballot models.PollBallot
+	if err := doc.DataTo(&ballot); err != nil {
+		return nil, err
+	}
+	ballot.ID = doc.Ref.ID
+	return &ballot, nil
+}
+
+// Tally usa consultas de conteo (una por opción) en lugar de leer todos los
+// votos.
+func (r *pollRepository) Tally(ctx context.Context, postID string, options int) (*models.PollTally, error) {
+	ballots := r.db.Collection("pollBallots").Where("post_id", "==", postID)
+
+	voters, err := count(ctx, ballots)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los votos de la encuesta: %w", err)
+	}
+	tally := &models.PollTally{Voters: voters, Counts: make([]int, options)}
+	if voters == 0 {
+		return tally, nil
+	}
+	for i := range tally.Counts {
+		if tally.Counts[i], err = count(ctx, ballots.Where("options", "array-contains", i)); err != nil {
+			return nil, fmt.Errorf("error al contar los votos de la encuesta: %w", err)
+		}
+	}
+	return tally, nil
+}
+
+// count cuenta los documentos de q sin leerlos.
+func count(ctx context.Context, q firestore.Query) (int, error) {
+	result, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("resultado de conteo inesperado: %v", result["count"])
+	}
+	return int(value.GetIntegerValue()), nil
+}
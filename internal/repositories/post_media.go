@@ -0,0 +1,27 @@
+package repositories
+
+import "github.com/JuanPidarraga/talkus-backend/internal/models"
+
+// LegacyMedia arma la galería de un post que solo tiene ImageURL e ImageID,
+// como los anteriores a las galerías. No cambia los posts que ya tienen
+// galería.
+func LegacyMedia(p *models.Post) {
+	if p.Media != nil {
+		return
+	}
+	p.Media = []models.Media{}
+	if p.ImageURL != "" {
+		p.Media = append(p.Media, models.Media{ID: NewDocumentID(), URL: p.ImageURL, PublicID: p.ImageID})
+	}
+}
+
+// KeepMedia devuelve edit con la galería de post si edit no trae una, para que
+// una edición del texto no pise los cambios hechos con UpdateMedia.
+func KeepMedia(post, edit *models.Post) *models.Post {
+	if edit.Media != nil {
+		return edit
+	}
+	kept := *edit
+	kept.Media, kept.ImageURL, kept.ImageID = post.Media, post.ImageURL, post.ImageID
+	return &kept
+}
@@ -2,24 +2,119 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-type PostRepository struct {
-	db *firestore.Client
+// ErrPublished indica que el post ya está publicado y no se puede tratar
+// como borrador.
+var ErrPublished = errors.New("el post ya está publicado")
+
+// ErrPinLimit indica que el subforo ya tiene models.MaxPinnedPosts posts
+// fijados.
+var ErrPinLimit = fmt.Errorf("el subforo ya tiene %d posts fijados", models.MaxPinnedPosts)
+
+type PostRepository interface {
+	GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error)
+	// GetAll pagina los posts publicados con el orden order.
+	GetAll(ctx context.Context, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+	Create(ctx context.Context, p *models.Post) error
+	// Edit cambia el título, el contenido, los tags y la imagen del post y
+	// guarda la versión nueva como revisión hecha por editorID. Si no cambia
+	// nada, no guarda ninguna revisión. Si p no trae galería (Media nil), se
+	// conserva la guardada.
+	Edit(ctx context.Context, id, editorID string, p *models.Post) error
+	// GetRevisions devuelve las revisiones del post de la más antigua a la
+	// más nueva; vacío si nunca se editó.
+	GetRevisions(ctx context.Context, postID string) ([]*models.PostRevision, error)
+	// GetPostsByAuthorID pagina los posts publicados del autor; con drafts
+	// incluye también sus borradores y posts programados.
+	GetPostsByAuthorID(ctx context.Context, authorID string, drafts bool, page models.PageRequest) (models.Page[*models.Post], error)
+	// GetDrafts pagina los borradores y posts programados del autor.
+	GetDrafts(ctx context.Context, authorID string, page models.PageRequest) (models.Page[*models.Post], error)
+	// UpdateDraft cambia el título, el contenido, los tags, el subforo, el
+	// estado y la fecha de publicación de un post sin publicar. Devuelve
+	// ErrPublished si ya se publicó.
+	UpdateDraft(ctx context.Context, id string, p *models.Post) error
+	// DuePosts devuelve hasta limit posts programados para antes de before,
+	// los más atrasados primero.
+	DuePosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error)
+	// Publish publica el post con el veredicto dado; at pasa a ser su fecha de
+	// creación. Devuelve ErrPublished si ya estaba publicado.
+	Publish(ctx context.Context, id, verdict string, at time.Time) error
+	GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error)
+	IncrementReaction(ctx context.Context, postID string, reactionType string, delta int) error
+	SavePostForUser(ctx context.Context, userID, postID string) error
+	RemoveSavedPost(ctx context.Context, userID, postID string) error
+	GetSavedPostsByUser(ctx context.Context, userID string, page models.PageRequest) (models.Page[*models.Post], error)
+	IsPostSavedByUser(ctx context.Context, userID, postID string) (bool, error)
+	// GetPostsByForumID pagina los posts publicados y no reportados del
+	// subforo con el orden order.
+	GetPostsByForumID(ctx context.Context, forumID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+	// GetPostsByTag pagina los posts publicados y no reportados con el tag
+	// con el orden order.
+	GetPostsByTag(ctx context.Context, tag string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+	// GetFeed pagina con el orden order los posts publicados y no reportados
+	// de los subforos forumIDs o con alguno de los tags, sin los de los
+	// autores blockedIDs.
+	GetFeed(ctx context.Context, forumIDs, tags, blockedIDs []string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+	// ReplaceTag reemplaza el tag from por to en todos los posts, también en
+	// los borradores y los de la papelera, y devuelve los IDs de los que
+	// cambió.
+	ReplaceTag(ctx context.Context, from, to string) ([]string, error)
+	// GetPinnedPosts devuelve los posts publicados y fijados del subforo, el
+	// fijado más recientemente primero. GetPostsByForumID no los incluye.
+	GetPinnedPosts(ctx context.Context, forumID string) ([]*models.Post, error)
+	// Moderate aplica entry.Action al post en entry.CreatedAt (ver
+	// models.Post.Moderate) y devuelve el post y si cambió. Si cambió, guarda
+	// entry en el registro de moderación con el subforo del post. Al fijar
+	// un post devuelve ErrPinLimit si el subforo ya tiene
+	// models.MaxPinnedPosts fijados. La lectura, el conteo de los fijados y
+	// las escrituras van en una transacción.
+	Moderate(ctx context.Context, id string, entry *models.ModerationLogEntry) (*models.Post, bool, error)
+	// ArchiveBefore archiva en at los posts publicados del subforo creados
+	// antes de before, salvo los que un moderador desarchivó, y devuelve sus
+	// IDs.
+	ArchiveBefore(ctx context.Context, forumID string, before, at time.Time) ([]string, error)
+	// RerankRising recalcula el valor de rising, que depende de la
+	// antigüedad, de los posts publicados creados desde since, con now como
+	// momento del cálculo, y devuelve cuántos actualizó.
+	RerankRising(ctx context.Context, since, now time.Time) (int, error)
+	// GetCrossposts devuelve los crossposts publicados del post, los más
+	// recientes primero.
+	GetCrossposts(ctx context.Context, postID string) ([]*models.Post, error)
+	// MarkCrossposts guarda en todos los crossposts del post la fecha en que
+	// se eliminó el original; nil indica que se restauró.
+	MarkCrossposts(ctx context.Context, postID string, deletedAt *time.Time) error
+	GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string, page models.PageRequest) (models.Page[*models.Post], error)
+	ReportPost(ctx context.Context, postID string) error
+	// UpdateMedia reemplaza la galería del post por la que devuelve update a
+	// partir del post actual. La lectura y la escritura van en una
+	// transacción, para que dos cambios simultáneos no se pisen. Si cambia la
+	// portada, guarda la versión nueva como revisión hecha por editorID, igual
+	// que Edit.
+	UpdateMedia(ctx context.Context, id, editorID string, update func(*models.Post) ([]models.Media, error)) error
 }
 
-func NewPostRepository(db *firestore.Client) *PostRepository {
-	return &PostRepository{db: db}
+type postRepository struct {
+	db    *firestore.Client
+	users UserLoader
 }
 
-func (r *PostRepository) GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error) {
+func NewPostRepository(db *firestore.Client, users UserLoader) PostRepository {
+	return &postRepository{db: db, users: users}
+}
+
+func (r *postRepository) GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error) {
 	doc, err := r.db.Collection("posts").Doc(id).Get(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener el post por ID %s: %w", id, err)
@@ -30,78 +125,127 @@ func (r *PostRepository) GetPostByID(ctx context.Context, id string) (*models.Po
 		return nil, fmt.Errorf("error al decodificar el post: %w", err)
 	}
 	p.ID = doc.Ref.ID
+	if p.DeletedAt != nil {
+		return nil, fmt.Errorf("error al obtener el post por ID %s: está en la papelera", id)
+	}
 
 	post := &models.PostWithAuthor{
 		Post: p,
 	}
 
 	if p.AuthorID != "" {
-		userDoc, err := r.db.Collection("users").Doc(p.AuthorID).Get(ctx)
+		author, err := LoadUser(ctx, r.users, p.AuthorID)
 		if err != nil {
 			return nil, fmt.Errorf("error al obtener el autor con ID %s: %w", p.AuthorID, err)
 		}
-
-		var u models.User
-		if err := userDoc.DataTo(&u); err != nil {
-			return nil, fmt.Errorf("error al decodificar datos del usuario %s: %w", p.AuthorID, err)
+		if author == nil {
+			return nil, fmt.Errorf("error al obtener el autor con ID %s: no existe", p.AuthorID)
 		}
-		u.UID = userDoc.Ref.ID
 
-		post.Author = &u
+		post.Author = author
 	}
 
 	return post, nil
 }
 
-func (r *PostRepository) GetAll(ctx context.Context) ([]*models.Post, error) {
-	iter := r.db.
-		Collection("posts").
-		OrderBy("created_at", firestore.Desc).
-		Documents(ctx)
+// published es la consulta de los posts visibles para todos.
+func (r *postRepository) published() firestore.Query {
+	return r.db.Collection("posts").Where("status", "==", models.PostPublished)
+}
+
+// queryPostsPage ordena q por fecha de creación (más recientes primero, con el
+// ID del documento para desempatar), lo pagina desde el cursor y carga los
+// autores de la página. Los posts en la papelera no se incluyen.
+func (r *postRepository) queryPostsPage(ctx context.Context, q firestore.Query, page models.PageRequest) (models.Page[*models.Post], error) {
+	return r.querySortedPage(ctx, q, models.PostSort{Mode: models.SortNew}, page)
+}
+
+// rankFields son los campos precalculados de cada orden (ver models.PostRanks).
+var rankFields = map[models.PostSortMode]string{
+	models.SortHot:           "ranks.hot",
+	models.SortTop:           "ranks.score",
+	models.SortControversial: "ranks.controversy",
+	models.SortRising:        "ranks.rising",
+}
+
+// querySortedPage es queryPostsPage con el orden order: primero por su campo
+// precalculado y después por fecha e ID. Si el orden tiene ventana de
+// tiempo, solo incluye los posts creados dentro de ella.
+func (r *postRepository) querySortedPage(ctx context.Context, q firestore.Query, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	size := page.Size()
+
+	posts, err := readPosts(sortedQuery(q, order, cursor).Limit(size+1).Documents(ctx), size+1, nil)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	result := NewPage(posts, size, SortedPostCursor(order))
+	r.attachAuthors(ctx, result.Items)
+	return result, nil
+}
+
+// sortedQuery agrega a q los filtros, el orden y el cursor de
+// querySortedPage.
+func sortedQuery(q firestore.Query, order models.PostSort, cursor *Cursor) firestore.Query {
+	q = q.Where("deleted_at", "==", nil)
+	if since := order.Since(time.Now()); !since.IsZero() {
+		q = q.Where("created_at", ">=", since)
+	}
+	field, ranked := rankFields[order.Mode]
+	if ranked {
+		q = q.OrderBy(field, firestore.Desc)
+	}
+	q = q.OrderBy("created_at", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if cursor != nil && ranked {
+		q = q.StartAfter(cursor.Rank, cursor.Time, cursor.ID)
+	} else if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	return q
+}
+
+// readPosts lee posts de iter hasta juntar n o llegar al final. Si keep no es
+// nil, descarta los posts para los que devuelve false.
+func readPosts(iter *firestore.DocumentIterator, n int, keep func(p *models.Post) bool) ([]*models.Post, error) {
 	defer iter.Stop()
 
 	posts := make([]*models.Post, 0)
-	authorIDs := make(map[string]bool)
-
-	for {
+	for len(posts) < n {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error al iterar posts: %w", err)
+			return nil, err
 		}
 
 		var p models.Post
-
 		if err := doc.DataTo(&p); err != nil {
 			return nil, fmt.Errorf("error al decodificar post: %w", err)
 		}
 		p.ID = doc.Ref.ID
-
-		// Registramos los IDs de autores para buscarlos después
-		if p.AuthorID != "" {
-			authorIDs[p.AuthorID] = true
+		if keep == nil || keep(&p) {
+			posts = append(posts, &p)
 		}
-
-		posts = append(posts, &p)
 	}
+	return posts, nil
+}
 
-	authorInfo := make(map[string]*models.User)
-	for authorID := range authorIDs {
-		userDoc, err := r.db.Collection("users").Doc(authorID).Get(ctx)
-		if err != nil {
+// attachAuthors carga los autores de posts en un solo lote y los asigna.
+func (r *postRepository) attachAuthors(ctx context.Context, posts []*models.Post) {
+	authorIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.AuthorID)
+	}
 
-			fmt.Printf("Error al buscar el autor %s: %v\n", authorID, err)
-			continue
-		}
-		var user models.User
-		if err := userDoc.DataTo(&user); err != nil {
-			fmt.Printf("Error al decodificar usuario %s: %v\n", authorID, err)
-			continue
-		}
-		user.UID = userDoc.Ref.ID
-		authorInfo[authorID] = &user
+	authorInfo, err := r.users.LoadUsers(ctx, authorIDs)
+	if err != nil {
+		fmt.Printf("Error al buscar autores: %v\n", err)
+		return
 	}
 
 	for _, post := range posts {
@@ -109,12 +253,20 @@ func (r *PostRepository) GetAll(ctx context.Context) ([]*models.Post, error) {
 			post.Author = author
 		}
 	}
+}
 
+func (r *postRepository) GetAll(ctx context.Context, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	posts, err := r.querySortedPage(ctx, r.published(), order, page)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar posts: %w", err)
+	}
 	return posts, nil
 }
 
-func (r *PostRepository) Create(ctx context.Context, p *models.Post) error {
+func (r *postRepository) Create(ctx context.Context, p *models.Post) error {
 	p.CreatedAt = time.Now()
+	LegacyMedia(p)
+	p.Rank(p.CreatedAt)
 	doc, _, err := r.db.Collection("posts").Add(ctx, map[string]interface{}{
 		"title":      p.Title,
 		"content":    p.Content,
@@ -128,6 +280,19 @@ func (r *PostRepository) Create(ctx context.Context, p *models.Post) error {
 		"image_id":   p.ImageID,
 		"verdict":    p.Verdict,
 		"created_at": p.CreatedAt,
+		"deleted_at": nil,
+		"status":     p.Status,
+		"publish_at": p.PublishAt,
+		"poll":       p.Poll,
+		"media":      p.Media,
+		"link":       p.Link,
+		"ranks":      p.Ranks,
+		// Sin moderar; los campos tienen que existir para las consultas
+		"pinned_at":    nil,
+		"locked_at":    nil,
+		"archived_at":  nil,
+		"keep_open":    false,
+		"crosspost_of": p.CrosspostOf,
 	})
 	if err != nil {
 		return err
@@ -136,78 +301,94 @@ func (r *PostRepository) Create(ctx context.Context, p *models.Post) error {
 	return nil
 }
 
-func (r *PostRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.Collection("posts").Doc(id).Delete(ctx)
-	if err != nil {
-		return fmt.Errorf("error al eliminar el post: %w", err)
-	}
-	return nil
-}
+// Edit guarda los cambios y sus revisiones en una transacción, para que dos
+// ediciones simultáneas no usen el mismo número de revisión.
+func (r *postRepository) Edit(ctx context.Context, id, editorID string, p *models.Post) error {
+	ref := r.db.Collection("posts").Doc(id)
+	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var post models.Post
+		if err := doc.DataTo(&post); err != nil {
+			return err
+		}
+		if post.DeletedAt != nil {
+			return fmt.Errorf("el post %s está en la papelera", id)
+		}
+		post.ID = id
+		p := KeepMedia(&post, p)
 
-func (r *PostRepository) Edit(ctx context.Context, id string, p *models.Post) error {
-	_, err := r.db.Collection("posts").Doc(id).Set(ctx, map[string]interface{}{
-		"title":   p.Title,
-		"content": p.Content,
-		//"author_id":  p.AuthorID,
-		"tags": p.Tags,
-		//"forum_id":  p.ForumID,
-		"image_id":  p.ImageID,
-		"image_url": p.ImageURL,
-	}, firestore.MergeAll)
+		now := time.Now()
+		revisions := NewRevisions(&post, p, editorID, now)
+		if revisions == nil {
+			return nil
+		}
+		for _, rev := range revisions {
+			if err := tx.Create(r.db.Collection("postRevisions").Doc(rev.ID), rev); err != nil {
+				return err
+			}
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "title", Value: p.Title},
+			{Path: "content", Value: p.Content},
+			{Path: "tags", Value: p.Tags},
+			{Path: "image_id", Value: p.ImageID},
+			{Path: "image_url", Value: p.ImageURL},
+			{Path: "media", Value: p.Media},
+			{Path: "updated_at", Value: now},
+			{Path: "edited_at", Value: now},
+			{Path: "revisions", Value: revisions[len(revisions)-1].Number},
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("error al editar el post: %w", err)
 	}
 	return nil
 }
 
-func (r *PostRepository) GetPostsByAuthorID(ctx context.Context, authorID string) ([]*models.Post, error) {
-	iter := r.db.
-		Collection("posts").
-		Where("author_id", "==", authorID).
+func (r *postRepository) GetRevisions(ctx context.Context, postID string) ([]*models.PostRevision, error) {
+	iter := r.db.Collection("postRevisions").
+		Where("post_id", "==", postID).
+		OrderBy("number", firestore.Asc).
 		Documents(ctx)
 	defer iter.Stop()
 
-	posts := make([]*models.Post, 0)
-
+	revisions := make([]*models.PostRevision, 0)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error al iterar posts del autor: %w", err)
-		}
-
-		var p models.Post
-		if err := doc.DataTo(&p); err != nil {
-			return nil, fmt.Errorf("error al decodificar post: %w", err)
+			return nil, fmt.Errorf("error al iterar las revisiones del post: %w", err)
 		}
-		p.ID = doc.Ref.ID
-
-		// Obtener información del autor
-		if p.AuthorID != "" {
-			userDoc, err := r.db.Collection("users").Doc(p.AuthorID).Get(ctx)
-			if err == nil {
-				var user models.User
-				if err := userDoc.DataTo(&user); err == nil {
-					user.UID = userDoc.Ref.ID
-					p.Author = &user
-				}
-			}
+		var rev models.PostRevision
+		if err := doc.DataTo(&rev); err != nil {
+			return nil, err
 		}
-
-		posts = append(posts, &p)
+		rev.ID = doc.Ref.ID
+		revisions = append(revisions, &rev)
 	}
+	return revisions, nil
+}
 
-	// Ordenar por fecha de creación (más recientes primero)
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].CreatedAt.After(posts[j].CreatedAt)
-	})
+func (r *postRepository) GetPostsByAuthorID(ctx context.Context, authorID string, drafts bool, page models.PageRequest) (models.Page[*models.Post], error) {
+	q := r.db.Collection("posts").Query
+	if !drafts {
+		q = r.published()
+	}
+	q = q.Where("author_id", "==", authorID)
 
+	posts, err := r.queryPostsPage(ctx, q, page)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar posts del autor: %w", err)
+	}
 	return posts, nil
 }
 
-func (r *PostRepository) GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error) {
+func (r *postRepository) GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error) {
 	// Primero obtener todos los votos del usuario
 	votesIter := r.db.
 		Collection("votes").
@@ -226,17 +407,14 @@ func (r *PostRepository) GetPostsILiked(ctx context.Context, userID string) ([]*
 			return nil, fmt.Errorf("error al iterar votos del usuario: %w", err)
 		}
 
-		var vote struct {
-			PostID string `firestore:"post_id"`
-			Type   string `firestore:"type"`
-		}
+		var vote models.Vote
 		if err := doc.DataTo(&vote); err != nil {
 			return nil, fmt.Errorf("error al decodificar voto: %w", err)
 		}
 
 		// Solo incluir posts que tengan like y no sean comentarios
-		if vote.PostID != "" && vote.Type == "like" {
-			postIDs[vote.PostID] = true
+		if target, id := vote.Target(); target == models.TargetPost && vote.Type == models.Like {
+			postIDs[id] = true
 		}
 	}
 
@@ -261,22 +439,16 @@ func (r *PostRepository) GetPostsILiked(ctx context.Context, userID string) ([]*
 			continue
 		}
 		p.ID = postDoc.Ref.ID
-
-		// Obtener información del autor
-		if p.AuthorID != "" {
-			userDoc, err := r.db.Collection("users").Doc(p.AuthorID).Get(ctx)
-			if err == nil {
-				var user models.User
-				if err := userDoc.DataTo(&user); err == nil {
-					user.UID = userDoc.Ref.ID
-					p.Author = &user
-				}
-			}
+		if p.DeletedAt != nil {
+			continue
 		}
 
 		posts = append(posts, &p)
 	}
 
+	// Obtener información de los autores
+	r.attachAuthors(ctx, posts)
+
 	// Ordenar por fecha de creación (más recientes primero)
 	sort.Slice(posts, func(i, j int) bool {
 		return posts[i].CreatedAt.After(posts[j].CreatedAt)
@@ -285,19 +457,45 @@ func (r *PostRepository) GetPostsILiked(ctx context.Context, userID string) ([]*
 	return posts, nil
 }
 
-func (r *PostRepository) IncrementReaction(ctx context.Context, postID string, reactionType string, delta int) error {
-	field := "likes"
-	if reactionType == "dislike" {
-		field = "dislikes"
-	}
-	_, err := r.db.Collection("posts").Doc(postID).Update(ctx, []firestore.Update{
-		{Path: field, Value: firestore.Increment(delta)},
+// IncrementReaction suma delta al contador y recalcula los valores de orden
+// en una transacción.
+func (r *postRepository) IncrementReaction(ctx context.Context, postID string, reactionType string, delta int) error {
+	ref := r.db.Collection("posts").Doc(postID)
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		field, likes, dislikes := "likes", delta, 0
+		if reactionType == "dislike" {
+			field, likes, dislikes = "dislikes", 0, delta
+		}
+		ranks, err := rerank(doc, likes, dislikes)
+		if err != nil {
+			return err
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: field, Value: firestore.Increment(delta)},
+			{Path: "ranks", Value: ranks},
+		})
 	})
-	return err
+}
+
+// rerank devuelve los valores de orden del post de doc después de sumarle
+// likes y dislikes a sus contadores.
+func rerank(doc *firestore.DocumentSnapshot, likes, dislikes int) (models.PostRanks, error) {
+	var post models.Post
+	if err := doc.DataTo(&post); err != nil {
+		return models.PostRanks{}, fmt.Errorf("error al decodificar el post: %w", err)
+	}
+	post.Likes += likes
+	post.Dislikes += dislikes
+	post.Rank(time.Now())
+	return post.Ranks, nil
 }
 
 // Guarda un post para un usuario en la colección userSavedPosts
-func (r *PostRepository) SavePostForUser(ctx context.Context, userID, postID string) error {
+func (r *postRepository) SavePostForUser(ctx context.Context, userID, postID string) error {
 	_, _, err := r.db.
 		Collection("userSavedPosts").
 		Add(ctx, map[string]interface{}{
@@ -309,7 +507,7 @@ func (r *PostRepository) SavePostForUser(ctx context.Context, userID, postID str
 }
 
 // Elimina el bookmark de un post para un usuario
-func (r *PostRepository) RemoveSavedPost(ctx context.Context, userID, postID string) error {
+func (r *postRepository) RemoveSavedPost(ctx context.Context, userID, postID string) error {
 	q := r.db.
 		Collection("userSavedPosts").
 		Where("user_id", "==", userID).
@@ -326,88 +524,86 @@ func (r *PostRepository) RemoveSavedPost(ctx context.Context, userID, postID str
 	return err
 }
 
-// Lista todos los posts guardados por un usuario
-func (r *PostRepository) GetSavedPostsByUser(ctx context.Context, userID string) ([]*models.Post, error) {
+// savedRef es un documento de userSavedPosts.
+type savedRef struct {
+	ID      string
+	PostID  string
+	SavedAt time.Time
+}
+
+// savedCursor es el cursor de los guardados, ordenados por fecha de guardado.
+func savedCursor(s savedRef) Cursor {
+	return Cursor{Time: s.SavedAt, ID: s.ID}
+}
+
+// Lista los posts guardados por un usuario, del más reciente al más antiguo
+func (r *postRepository) GetSavedPostsByUser(ctx context.Context, userID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	size := page.Size()
+
 	// Obtener los IDs de posts guardados
-	saveIter := r.db.
+	q := r.db.
 		Collection("userSavedPosts").
 		Where("user_id", "==", userID).
 		OrderBy("saved_at", firestore.Desc).
-		Documents(ctx)
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	saveIter := q.Limit(size + 1).Documents(ctx)
 	defer saveIter.Stop()
 
-	var postIDs []string
+	var refs []savedRef
 	for {
 		doc, err := saveIter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error iterando guardados: %w", err)
+			return models.Page[*models.Post]{}, fmt.Errorf("error iterando guardados: %w", err)
 		}
 
 		data := doc.Data()
 		pid, ok := data["post_id"].(string)
 		if ok {
-			postIDs = append(postIDs, pid)
+			savedAt, _ := data["saved_at"].(time.Time)
+			refs = append(refs, savedRef{ID: doc.Ref.ID, PostID: pid, SavedAt: savedAt})
 		}
 	}
+	saves := NewPage(refs, size, savedCursor)
 
 	//  Obtener los posts
-	posts := make([]*models.Post, 0)
-	authorIDs := make(map[string]bool)
-
-	for _, postID := range postIDs {
-		postDoc, err := r.db.Collection("posts").Doc(postID).Get(ctx)
+	posts := make([]*models.Post, 0, len(saves.Items))
+	for _, s := range saves.Items {
+		postDoc, err := r.db.Collection("posts").Doc(s.PostID).Get(ctx)
 		if err != nil {
-			fmt.Printf("Post no encontrado: %s\n", postID)
+			fmt.Printf("Post no encontrado: %s\n", s.PostID)
 			continue
 		}
 
 		var post models.Post
 		if err := postDoc.DataTo(&post); err != nil {
-			fmt.Printf("Error decodificando post %s: %v\n", postID, err)
+			fmt.Printf("Error decodificando post %s: %v\n", s.PostID, err)
 			continue
 		}
 
 		post.ID = postDoc.Ref.ID
-		posts = append(posts, &post)
-
-		if post.AuthorID != "" {
-			authorIDs[post.AuthorID] = true
-		}
-	}
-
-	//  Obtener los autores
-	authorInfo := make(map[string]*models.User)
-	for aid := range authorIDs {
-		userDoc, err := r.db.Collection("users").Doc(aid).Get(ctx)
-		if err != nil {
-			fmt.Printf("No se encontró autor: %s\n", aid)
+		if post.DeletedAt != nil {
 			continue
 		}
-
-		var user models.User
-		if err := userDoc.DataTo(&user); err != nil {
-			fmt.Printf("Error decodificando autor %s: %v\n", aid, err)
-			continue
-		}
-
-		user.UID = userDoc.Ref.ID
-		authorInfo[aid] = &user
+		posts = append(posts, &post)
 	}
 
-	// Asociar autor a cada post
-	for _, post := range posts {
-		if author, ok := authorInfo[post.AuthorID]; ok {
-			post.Author = author
-		}
-	}
+	//  Obtener los autores
+	r.attachAuthors(ctx, posts)
 
-	return posts, nil
+	return models.Page[*models.Post]{Items: posts, NextCursor: saves.NextCursor}, nil
 }
 
-func (r *PostRepository) IsPostSavedByUser(ctx context.Context, userID, postID string) (bool, error) {
+func (r *postRepository) IsPostSavedByUser(ctx context.Context, userID, postID string) (bool, error) {
 	q := r.db.
 		Collection("userSavedPosts").
 		Where("user_id", "==", userID).
@@ -420,56 +616,266 @@ func (r *PostRepository) IsPostSavedByUser(ctx context.Context, userID, postID s
 	return len(docs) > 0, nil
 }
 
-func (r *PostRepository) GetPostsByForumID(ctx context.Context, forumID string) ([]*models.Post, error) {
-	iter := r.db.
-		Collection("posts").
+func (r *postRepository) GetPostsByForumID(ctx context.Context, forumID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	q := r.published().
 		Where("forum_id", "==", forumID).
 		Where("is_flagged", "==", false).
+		Where("pinned_at", "==", nil)
+	return r.querySortedPage(ctx, q, order, page)
+}
+
+func (r *postRepository) GetPinnedPosts(ctx context.Context, forumID string) ([]*models.Post, error) {
+	iter := r.published().
+		Where("forum_id", "==", forumID).
+		Where("deleted_at", "==", nil).
+		Where("pinned_at", ">", time.Time{}).
+		OrderBy("pinned_at", firestore.Desc).
 		Documents(ctx)
-	defer iter.Stop()
-	posts := make([]*models.Post, 0)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
+	posts, err := readPosts(iter, models.MaxPinnedPosts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los posts fijados: %w", err)
+	}
+	r.attachAuthors(ctx, posts)
+	return posts, nil
+}
+
+func (r *postRepository) GetCrossposts(ctx context.Context, postID string) ([]*models.Post, error) {
+	iter := r.published().
+		Where("crosspost_of.post_id", "==", postID).
+		Where("deleted_at", "==", nil).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	posts, err := readPosts(iter, math.MaxInt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los crossposts: %w", err)
+	}
+	r.attachAuthors(ctx, posts)
+	return posts, nil
+}
+
+func (r *postRepository) MarkCrossposts(ctx context.Context, postID string, deletedAt *time.Time) error {
+	docs, err := r.db.Collection("posts").
+		Where("crosspost_of.post_id", "==", postID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return fmt.Errorf("error al buscar los crossposts: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	bw := r.db.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		job, err := bw.Update(doc.Ref, []firestore.Update{{Path: "crosspost_of.deleted_at", Value: deletedAt}})
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("error al actualizar el crosspost %s: %w", doc.Ref.ID, err)
 		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for i, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("error al actualizar el crosspost %s: %w", docs[i].Ref.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	q := r.published().
+		Where("tags", "array-contains", tag).
+		Where("is_flagged", "==", false)
+	return r.querySortedPage(ctx, q, order, page)
+}
+
+// feedChunk es la cantidad máxima de valores de un filtro "in" o
+// "array-contains-any" de Firestore.
+const feedChunk = 30
+
+// GetFeed consulta los subforos y los tags de a feedChunk, con el mismo orden
+// y desde el mismo cursor, y mezcla los resultados. Los posts de los autores
+// bloqueados y los que ya trajo otra consulta se descartan al leer, así que
+// cada consulta sigue leyendo hasta juntar una página completa.
+func (r *postRepository) GetFeed(ctx context.Context, forumIDs, tags, blockedIDs []string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	size := page.Size()
+	blocked := make(map[string]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = true
+	}
+
+	filters := make([]firestore.PropertyFilter, 0)
+	for start := 0; start < len(forumIDs); start += feedChunk {
+		filters = append(filters, firestore.PropertyFilter{
+			Path: "forum_id", Operator: "in", Value: forumIDs[start:min(start+feedChunk, len(forumIDs))],
+		})
+	}
+	for start := 0; start < len(tags); start += feedChunk {
+		filters = append(filters, firestore.PropertyFilter{
+			Path: "tags", Operator: "array-contains-any", Value: tags[start:min(start+feedChunk, len(tags))],
+		})
+	}
+
+	posts := make([]*models.Post, 0)
+	seen := make(map[string]bool)
+	for _, filter := range filters {
+		q := r.published().
+			WhereEntity(filter).
+			Where("is_flagged", "==", false)
+		found, err := readPosts(sortedQuery(q, order, cursor).Documents(ctx), size+1, func(p *models.Post) bool {
+			return !blocked[p.AuthorID] && !seen[p.ID]
+		})
 		if err != nil {
-			return nil, err
+			return models.Page[*models.Post]{}, fmt.Errorf("error al obtener el feed: %w", err)
 		}
+		for _, p := range found {
+			seen[p.ID] = true
+		}
+		posts = append(posts, found...)
+	}
+
+	// Mismo orden que las consultas: valor de orden, fecha e ID descendentes
+	cursorOf := SortedPostCursor(order)
+	sort.Slice(posts, func(i, j int) bool {
+		a, b := cursorOf(posts[i]), cursorOf(posts[j])
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		return a.ID > b.ID
+	})
+	result := NewPage(posts, size, cursorOf)
+	r.attachAuthors(ctx, result.Items)
+	return result, nil
+}
+
+// ReplaceTag lee los posts con from y los actualiza con un BulkWriter.
+func (r *postRepository) ReplaceTag(ctx context.Context, from, to string) ([]string, error) {
+	docs, err := r.db.Collection("posts").
+		Where("tags", "array-contains", from).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar los posts del tag %s: %w", from, err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	bw := r.db.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
 		var p models.Post
 		if err := doc.DataTo(&p); err != nil {
-			continue
+			bw.End()
+			return nil, fmt.Errorf("error al decodificar post: %w", err)
 		}
-		p.ID = doc.Ref.ID
+		job, err := bw.Update(doc.Ref, []firestore.Update{{Path: "tags", Value: models.ReplaceTag(p.Tags, from, to)}})
+		if err != nil {
+			bw.End()
+			return nil, fmt.Errorf("error al actualizar el post %s: %w", doc.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, doc.Ref.ID)
+	}
+	bw.End()
 
-		// Obtener información del autor
-		if p.AuthorID != "" {
-			userDoc, err := r.db.Collection("users").Doc(p.AuthorID).Get(ctx)
-			if err == nil {
-				var user models.User
-				if err := userDoc.DataTo(&user); err == nil {
-					user.UID = userDoc.Ref.ID
-					p.Author = &user
-				}
-			}
+	for i, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return nil, fmt.Errorf("error al actualizar el post %s: %w", ids[i], err)
 		}
-		posts = append(posts, &p)
 	}
+	return ids, nil
+}
 
-	// Ordenar por fecha de creación (más recientes primero)
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+func (r *postRepository) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string, page models.PageRequest) (models.Page[*models.Post], error) {
+	q := r.published().
+		Where("forum_id", "==", forumID).
+		Where("verdict", "==", verdict).
+		Where("is_flagged", "==", false)
+	return r.queryPostsPage(ctx, q, page)
+}
+
+// Reporta un post marcando is_flagged como true
+func (r *postRepository) ReportPost(ctx context.Context, postID string) error {
+	_, err := r.db.Collection("posts").Doc(postID).Update(ctx, []firestore.Update{
+		{Path: "is_flagged", Value: true},
 	})
+	if err != nil {
+		return fmt.Errorf("error al reportar el post: %w", err)
+	}
+	return nil
+}
+
+func (r *postRepository) GetDrafts(ctx context.Context, authorID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	q := r.db.
+		Collection("posts").
+		Where("author_id", "==", authorID).
+		Where("status", "in", []models.PostStatus{models.PostDraft, models.PostScheduled})
 
+	posts, err := r.queryPostsPage(ctx, q, page)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar borradores: %w", err)
+	}
 	return posts, nil
 }
 
-func (r *PostRepository) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string) ([]*models.Post, error) {
-	iter := r.db.
-		Collection("posts").
-		Where("forum_id", "==", forumID).
-		Where("verdict", "==", verdict).
-		Where("is_flagged", "==", false).
+// unpublished actualiza en una transacción el post id si existe, no está en
+// la papelera y todavía no se publicó. updates recibe el post actual.
+func (r *postRepository) unpublished(ctx context.Context, id string, updates func(post *models.Post) []firestore.Update) error {
+	ref := r.db.Collection("posts").Doc(id)
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var post models.Post
+		if err := doc.DataTo(&post); err != nil {
+			return err
+		}
+		if post.DeletedAt != nil {
+			return fmt.Errorf("el post %s está en la papelera", id)
+		}
+		if post.IsPublished() {
+			return ErrPublished
+		}
+		return tx.Update(ref, updates(&post))
+	})
+}
+
+func (r *postRepository) UpdateDraft(ctx context.Context, id string, p *models.Post) error {
+	err := r.unpublished(ctx, id, func(*models.Post) []firestore.Update {
+		return []firestore.Update{
+			{Path: "title", Value: p.Title},
+			{Path: "content", Value: p.Content},
+			{Path: "tags", Value: p.Tags},
+			{Path: "forum_id", Value: p.ForumID},
+			{Path: "status", Value: p.Status},
+			{Path: "publish_at", Value: p.PublishAt},
+			{Path: "updated_at", Value: time.Now()},
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error al editar el borrador: %w", err)
+	}
+	return nil
+}
+
+func (r *postRepository) DuePosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	iter := r.db.Collection("posts").
+		Where("status", "==", models.PostScheduled).
+		Where("deleted_at", "==", nil).
+		Where("publish_at", "<=", before).
+		OrderBy("publish_at", firestore.Asc).
+		Limit(limit).
 		Documents(ctx)
 	defer iter.Stop()
 
@@ -480,43 +886,229 @@ func (r *PostRepository) GetPostsByForumIDWithVerdict(ctx context.Context, forum
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error al buscar posts programados: %w", err)
 		}
 		var p models.Post
 		if err := doc.DataTo(&p); err != nil {
-			continue
+			return nil, fmt.Errorf("error al decodificar post: %w", err)
 		}
 		p.ID = doc.Ref.ID
+		posts = append(posts, &p)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) Publish(ctx context.Context, id, verdict string, at time.Time) error {
+	err := r.unpublished(ctx, id, func(post *models.Post) []firestore.Update {
+		// La fecha de publicación pasa a ser la de creación, que cuenta en hot
+		post.CreatedAt = at
+		post.Rank(time.Now())
+		return []firestore.Update{
+			{Path: "status", Value: models.PostPublished},
+			{Path: "publish_at", Value: nil},
+			{Path: "verdict", Value: verdict},
+			{Path: "created_at", Value: at},
+			{Path: "ranks", Value: post.Ranks},
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error al publicar el post: %w", err)
+	}
+	return nil
+}
 
-		// Obtener información del autor
-		if p.AuthorID != "" {
-			userDoc, err := r.db.Collection("users").Doc(p.AuthorID).Get(ctx)
-			if err == nil {
-				var user models.User
-				if err := userDoc.DataTo(&user); err == nil {
-					user.UID = userDoc.Ref.ID
-					p.Author = &user
+func (r *postRepository) UpdateMedia(ctx context.Context, id, editorID string, update func(*models.Post) ([]models.Media, error)) error {
+	ref := r.db.Collection("posts").Doc(id)
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return fmt.Errorf("error al obtener el post %s: %w", id, err)
+		}
+		var post models.Post
+		if err := doc.DataTo(&post); err != nil {
+			return fmt.Errorf("error al decodificar el post: %w", err)
+		}
+		if post.DeletedAt != nil {
+			return fmt.Errorf("el post %s está en la papelera", id)
+		}
+		post.ID = id
+		LegacyMedia(&post)
+
+		media, err := update(&post)
+		if err != nil {
+			return err
+		}
+		edited := post
+		edited.SetMedia(media)
+
+		now := time.Now()
+		updates := []firestore.Update{
+			{Path: "media", Value: edited.Media},
+			{Path: "image_url", Value: edited.ImageURL},
+			{Path: "image_id", Value: edited.ImageID},
+			{Path: "updated_at", Value: now},
+		}
+		if revisions := NewRevisions(&post, &edited, editorID, now); revisions != nil {
+			for _, rev := range revisions {
+				if err := tx.Create(r.db.Collection("postRevisions").Doc(rev.ID), rev); err != nil {
+					return err
 				}
 			}
+			updates = append(updates,
+				firestore.Update{Path: "edited_at", Value: now},
+				firestore.Update{Path: "revisions", Value: revisions[len(revisions)-1].Number},
+			)
 		}
-		posts = append(posts, &p)
+		return tx.Update(ref, updates)
+	})
+}
+
+// moderationUpdates son los cambios que guardan el estado de moderación del post.
+func moderationUpdates(post *models.Post) []firestore.Update {
+	return []firestore.Update{
+		{Path: "pinned_at", Value: post.PinnedAt},
+		{Path: "locked_at", Value: post.LockedAt},
+		{Path: "archived_at", Value: post.ArchivedAt},
+		{Path: "keep_open", Value: post.KeepOpen},
 	}
+}
 
-	// Ordenar por fecha de creación (más recientes primero)
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+// Moderate cuenta los fijados con una consulta dentro de la transacción, así
+// dos moderadores que fijan a la vez no superan el límite.
+func (r *postRepository) Moderate(ctx context.Context, id string, entry *models.ModerationLogEntry) (*models.Post, bool, error) {
+	ref := r.db.Collection("posts").Doc(id)
+	var post models.Post
+	var changed bool
+	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return fmt.Errorf("error al obtener el post %s: %w", id, err)
+		}
+		post = models.Post{}
+		if err := doc.DataTo(&post); err != nil {
+			return fmt.Errorf("error al decodificar el post: %w", err)
+		}
+		if post.DeletedAt != nil {
+			return fmt.Errorf("el post %s está en la papelera", id)
+		}
+		post.ID = id
+
+		if entry.Action == models.ActionPin && post.PinnedAt == nil {
+			pinned, err := tx.Documents(r.published().
+				Where("forum_id", "==", post.ForumID).
+				Where("deleted_at", "==", nil).
+				Where("pinned_at", ">", time.Time{}).
+				Limit(models.MaxPinnedPosts)).GetAll()
+			if err != nil {
+				return fmt.Errorf("error al obtener los posts fijados: %w", err)
+			}
+			if len(pinned) >= models.MaxPinnedPosts {
+				return ErrPinLimit
+			}
+		}
+		if changed = post.Moderate(entry.Action, entry.CreatedAt); !changed {
+			return nil
+		}
+		if err := tx.Update(ref, moderationUpdates(&post)); err != nil {
+			return err
+		}
+		entry.ID = NewDocumentID()
+		entry.ForumID, entry.PostID = post.ForumID, id
+		return tx.Create(r.db.Collection("moderationLog").Doc(entry.ID), entry)
 	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &post, changed, nil
+}
 
-	return posts, nil
+// RerankRising escribe cada post con la condición de que no haya cambiado
+// desde que se leyó: si un voto lo cambió mientras tanto, ya recalculó sus
+// valores y se omite.
+func (r *postRepository) RerankRising(ctx context.Context, since, now time.Time) (int, error) {
+	docs, err := r.published().
+		Where("deleted_at", "==", nil).
+		Where("created_at", ">=", since).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("error al buscar posts para recalcular rising: %w", err)
+	}
+
+	bw := r.db.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		var p models.Post
+		if err := doc.DataTo(&p); err != nil {
+			bw.End()
+			return 0, fmt.Errorf("error al decodificar el post %s: %w", doc.Ref.ID, err)
+		}
+		p.Rank(now)
+		job, err := bw.Update(doc.Ref,
+			[]firestore.Update{{Path: "ranks.rising", Value: p.Ranks.Rising}},
+			firestore.LastUpdateTime(doc.UpdateTime))
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("error al recalcular rising del post %s: %w", doc.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	updated := 0
+	var firstErr error
+	for i, job := range jobs {
+		_, err := job.Results()
+		if status.Code(err) == codes.FailedPrecondition {
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error al recalcular rising del post %s: %w", docs[i].Ref.ID, err)
+			}
+			continue
+		}
+		updated++
+	}
+	return updated, firstErr
 }
 
-// Reporta un post marcando is_flagged como true
-func (r *PostRepository) ReportPost(ctx context.Context, postID string) error {
-	_, err := r.db.Collection("posts").Doc(postID).Update(ctx, []firestore.Update{
-		{Path: "is_flagged", Value: true},
-	})
+func (r *postRepository) ArchiveBefore(ctx context.Context, forumID string, before, at time.Time) ([]string, error) {
+	docs, err := r.published().
+		Where("forum_id", "==", forumID).
+		Where("deleted_at", "==", nil).
+		Where("archived_at", "==", nil).
+		Where("keep_open", "==", false).
+		Where("created_at", "<", before).
+		Documents(ctx).GetAll()
 	if err != nil {
-		return fmt.Errorf("error al reportar el post: %w", err)
+		return nil, fmt.Errorf("error al buscar posts para archivar: %w", err)
 	}
-	return nil
+
+	ids := make([]string, 0, len(docs))
+	bw := r.db.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		job, err := bw.Update(doc.Ref, []firestore.Update{{Path: "archived_at", Value: at}})
+		if err != nil {
+			bw.End()
+			return nil, fmt.Errorf("error al archivar el post %s: %w", doc.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, doc.Ref.ID)
+	}
+	bw.End()
+
+	// Cada escritura es independiente: se devuelven las que sí se hicieron
+	archived := make([]string, 0, len(ids))
+	var firstErr error
+	for i, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error al archivar el post %s: %w", ids[i], err)
+			}
+			continue
+		}
+		archived = append(archived, ids[i])
+	}
+	return archived, firstErr
 }
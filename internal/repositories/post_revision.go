@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// RevisionID es el ID de la revisión number del post.
+func RevisionID(postID string, number int) string {
+	return fmt.Sprintf("%s_%d", postID, number)
+}
+
+func revisionOf(p *models.Post, number int, editorID string, at time.Time) *models.PostRevision {
+	return &models.PostRevision{
+		ID:       RevisionID(p.ID, number),
+		PostID:   p.ID,
+		Number:   number,
+		EditorID: editorID,
+		EditedAt: at,
+		Title:    p.Title,
+		Content:  p.Content,
+		Tags:     slices.Clone(p.Tags),
+		ImageURL: p.ImageURL,
+		ImageID:  p.ImageID,
+	}
+}
+
+// NewRevisions devuelve las revisiones que agrega editar post con los valores
+// de edit: la revisión 1 con el post original si todavía no tenía ninguna, y
+// la de la edición. Si la edición no cambia nada devuelve nil.
+func NewRevisions(post, edit *models.Post, editorID string, at time.Time) []*models.PostRevision {
+	if post.Title == edit.Title && post.Content == edit.Content &&
+		slices.Equal(post.Tags, edit.Tags) && post.ImageID == edit.ImageID && post.ImageURL == edit.ImageURL {
+		return nil
+	}
+
+	var revisions []*models.PostRevision
+	number := post.Revisions
+	if number == 0 {
+		number = 1
+		revisions = append(revisions, revisionOf(post, number, post.AuthorID, post.CreatedAt))
+	}
+	edited := *edit
+	edited.ID = post.ID
+	return append(revisions, revisionOf(&edited, number+1, editorID, at))
+}
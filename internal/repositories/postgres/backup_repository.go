@@ -0,0 +1,439 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type backupRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBackupRepository crea un BackupRepository sobre PostgreSQL.
+func NewBackupRepository(db *pgxpool.Pool) repositories.BackupRepository {
+	return &backupRepository{db: db}
+}
+
+// each ejecuta la consulta y llama a fn con cada fila decodificada por scan.
+func each[T any](ctx context.Context, db *pgxpool.Pool, sql string, scan func(rowScanner) (T, error), fn func(T) error) error {
+	rows, err := db.Query(ctx, sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *backupRepository) ExportUsers(ctx context.Context, fn func(*models.UserRecord) error) error {
+	return each(ctx, r.db, `
+		SELECT uid, username, email, profile_photo, banner_image, extra, created_at, updated_at
+		FROM users ORDER BY uid`,
+		func(row rowScanner) (*models.UserRecord, error) {
+			var u models.UserRecord
+			err := row.Scan(&u.UID, &u.Username, &u.Email, &u.ProfilePhoto, &u.BannerImage, &u.Extra, &u.CreatedAt, &u.UpdatedAt)
+			if len(u.Extra) == 0 {
+				u.Extra = nil
+			}
+			return &u, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportSubforos(ctx context.Context, fn func(*models.Subforo) error) error {
+	return each(ctx, r.db, `SELECT `+subforoColumns+` FROM subforos ORDER BY id`, scanSubforo, fn)
+}
+
+func (r *backupRepository) ExportPosts(ctx context.Context, fn func(*models.Post) error) error {
+	return each(ctx, r.db, `SELECT `+postColumns+postFrom+` ORDER BY p.id`,
+		func(row rowScanner) (*models.Post, error) {
+			p, err := scanPost(row)
+			if err != nil {
+				return nil, err
+			}
+			p.Author = nil
+			return p, nil
+		}, fn)
+}
+
+func (r *backupRepository) ExportComments(ctx context.Context, fn func(*models.Comment) error) error {
+	return each(ctx, r.db, `SELECT `+commentColumns+` FROM comments c ORDER BY c.id`,
+		func(row rowScanner) (*models.Comment, error) { return scanComment(row) }, fn)
+}
+
+func (r *backupRepository) ExportVotes(ctx context.Context, fn func(*models.Vote) error) error {
+	return each(ctx, r.db, `SELECT `+voteColumns+` FROM votes ORDER BY id`, scanVote, fn)
+}
+
+func (r *backupRepository) ExportSavedPosts(ctx context.Context, fn func(*models.SavedPost) error) error {
+	return each(ctx, r.db, `SELECT id, user_id, post_id, saved_at FROM saved_posts ORDER BY id`,
+		func(row rowScanner) (*models.SavedPost, error) {
+			var s models.SavedPost
+			err := row.Scan(&s.ID, &s.UserID, &s.PostID, &s.SavedAt)
+			return &s, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportPostRevisions(ctx context.Context, fn func(*models.PostRevision) error) error {
+	return each(ctx, r.db, `
+		SELECT id, post_id, number, editor_id, edited_at, title, content, tags, image_url, image_id
+		FROM post_revisions ORDER BY id`,
+		func(row rowScanner) (*models.PostRevision, error) {
+			var rev models.PostRevision
+			err := row.Scan(&rev.ID, &rev.PostID, &rev.Number, &rev.EditorID, &rev.EditedAt,
+				&rev.Title, &rev.Content, &rev.Tags, &rev.ImageURL, &rev.ImageID)
+			return &rev, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportPollBallots(ctx context.Context, fn func(*models.PollBallot) error) error {
+	return each(ctx, r.db, `SELECT id, post_id, user_id, options, created_at FROM poll_ballots ORDER BY id`,
+		func(row rowScanner) (*models.PollBallot, error) {
+			var b models.PollBallot
+			err := row.Scan(&b.ID, &b.PostID, &b.UserID, &b.Options, &b.CreatedAt)
+			return &b, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportBlocks(ctx context.Context, fn func(*models.Block) error) error {
+	return each(ctx, r.db, `SELECT id, user_id, blocked_id, created_at FROM user_blocks ORDER BY id`,
+		func(row rowScanner) (*models.Block, error) {
+			var b models.Block
+			err := row.Scan(&b.ID, &b.UserID, &b.BlockedID, &b.CreatedAt)
+			return &b, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportModerationLog(ctx context.Context, fn func(*models.ModerationLogEntry) error) error {
+	return each(ctx, r.db, `SELECT id, forum_id, post_id, action, moderator_id, created_at FROM moderation_log ORDER BY id`,
+		func(row rowScanner) (*models.ModerationLogEntry, error) {
+			var e models.ModerationLogEntry
+			err := row.Scan(&e.ID, &e.ForumID, &e.PostID, &e.Action, &e.ModeratorID, &e.CreatedAt)
+			return &e, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportEmbeddings(ctx context.Context, fn func(*models.PostEmbedding) error) error {
+	return each(ctx, r.db, `SELECT post_id, model, vector, text_hash, updated_at FROM post_embeddings ORDER BY post_id`,
+		func(row rowScanner) (*models.PostEmbedding, error) {
+			var e models.PostEmbedding
+			err := row.Scan(&e.PostID, &e.Model, &e.Vector, &e.TextHash, &e.UpdatedAt)
+			return &e, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportTags(ctx context.Context, fn func(*models.Tag) error) error {
+	return each(ctx, r.db, `SELECT `+tagColumns+` FROM tags ORDER BY name`, scanTag, fn)
+}
+
+func (r *backupRepository) ExportTagFollows(ctx context.Context, fn func(*models.TagFollow) error) error {
+	return each(ctx, r.db, `SELECT id, user_id, tag, created_at FROM tag_follows ORDER BY id`,
+		func(row rowScanner) (*models.TagFollow, error) {
+			var f models.TagFollow
+			err := row.Scan(&f.ID, &f.UserID, &f.Tag, &f.CreatedAt)
+			return &f, err
+		}, fn)
+}
+
+func (r *backupRepository) ExportSubforoJoins(ctx context.Context, fn func(*models.SubforoJoin) error) error {
+	return each(ctx, r.db, `SELECT id, forum_id, user_id, joined_at FROM subforo_joins ORDER BY id`,
+		func(row rowScanner) (*models.SubforoJoin, error) {
+			var j models.SubforoJoin
+			err := row.Scan(&j.ID, &j.ForumID, &j.UserID, &j.JoinedAt)
+			return &j, err
+		}, fn)
+}
+
+// importAll ejecuta sql una vez por elemento, con los argumentos de args, en
+// una sola transacción.
+func importAll[T any](ctx context.Context, db *pgxpool.Pool, sql string, items []T, args func(T) []any) error {
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		batch.Queue(sql, args(item)...)
+	}
+	return pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+// nullTime guarda las fechas vacías como NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (r *backupRepository) ImportUsers(ctx context.Context, users []*models.UserRecord) error {
+	err := importAll(ctx, r.db, upsertUser, users, func(u *models.UserRecord) []any {
+		return userArgs(u.UID, u.Data())
+	})
+	if err != nil {
+		return fmt.Errorf("error al importar usuarios: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportSubforos(ctx context.Context, subforos []*models.Subforo) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO subforos (id, title, description, created_by, created_at, updated_at, categories,
+			moderators, is_active, banner_url, icon_url, members, archive_after_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE SET
+			title = EXCLUDED.title, description = EXCLUDED.description, created_by = EXCLUDED.created_by,
+			created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+			categories = EXCLUDED.categories, moderators = EXCLUDED.moderators,
+			is_active = EXCLUDED.is_active, banner_url = EXCLUDED.banner_url,
+			icon_url = EXCLUDED.icon_url, members = EXCLUDED.members,
+			archive_after_days = EXCLUDED.archive_after_days`,
+		subforos, func(s *models.Subforo) []any {
+			return []any{
+				s.ForumID, s.Title, s.Description, s.CreatedBy, s.CreatedAt, s.UpdatedAt, nonNil(s.Categories),
+				nonNil(s.Moderators), s.IsActive, s.BannerURL, s.IconURL, nonNil(s.Members), s.ArchiveAfterDays,
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar subforos: %w", err)
+	}
+	return nil
+}
+
+// ImportPosts arma la galería de los posts de respaldos anteriores a las
+// galerías.
+func (r *backupRepository) ImportPosts(ctx context.Context, posts []*models.Post) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO posts (id, title, content, author_id, tags, is_flagged, forum_id,
+			likes, dislikes, image_url, image_id, verdict, created_at, updated_at, deleted_at, deleted_by,
+			edited_at, revisions, status, publish_at, poll, media, link,
+			pinned_at, locked_at, archived_at, keep_open, crosspost_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+			$24, $25, $26, $27, $28)
+		ON CONFLICT (id) DO UPDATE SET
+			title = EXCLUDED.title, content = EXCLUDED.content, author_id = EXCLUDED.author_id,
+			tags = EXCLUDED.tags, is_flagged = EXCLUDED.is_flagged, forum_id = EXCLUDED.forum_id,
+			likes = EXCLUDED.likes, dislikes = EXCLUDED.dislikes, image_url = EXCLUDED.image_url,
+			image_id = EXCLUDED.image_id, verdict = EXCLUDED.verdict,
+			created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+			deleted_at = EXCLUDED.deleted_at, deleted_by = EXCLUDED.deleted_by,
+			edited_at = EXCLUDED.edited_at, revisions = EXCLUDED.revisions,
+			status = EXCLUDED.status, publish_at = EXCLUDED.publish_at, poll = EXCLUDED.poll,
+			media = EXCLUDED.media, link = EXCLUDED.link,
+			pinned_at = EXCLUDED.pinned_at, locked_at = EXCLUDED.locked_at,
+			archived_at = EXCLUDED.archived_at, keep_open = EXCLUDED.keep_open,
+			crosspost_of = EXCLUDED.crosspost_of`,
+		posts, func(p *models.Post) []any {
+			repositories.LegacyMedia(p)
+			repositories.LegacyTags(p)
+			return []any{
+				p.ID, p.Title, p.Content, p.AuthorID, nonNil(p.Tags), p.IsFlagged, p.ForumID,
+				p.Likes, p.Dislikes, p.ImageURL, p.ImageID, p.Verdict, p.CreatedAt, nullTime(p.UpdatedAt),
+				p.DeletedAt, p.DeletedBy, p.EditedAt, p.Revisions, postStatus(p), p.PublishAt, p.Poll,
+				p.Media, p.Link, p.PinnedAt, p.LockedAt, p.ArchivedAt, p.KeepOpen, p.CrosspostOf,
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar posts: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportComments(ctx context.Context, comments []*models.Comment) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO comments (id, post_id, parent_id, author_id, content, likes, dislikes,
+			created_at, updated_at, deleted_at, deleted_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			post_id = EXCLUDED.post_id, parent_id = EXCLUDED.parent_id, author_id = EXCLUDED.author_id,
+			content = EXCLUDED.content, likes = EXCLUDED.likes, dislikes = EXCLUDED.dislikes,
+			created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+			deleted_at = EXCLUDED.deleted_at, deleted_by = EXCLUDED.deleted_by`,
+		comments, func(c *models.Comment) []any {
+			return []any{
+				c.CommentID, c.PostID, c.ParentID, c.AuthorID, c.Content, c.Likes, c.Dislikes,
+				c.CreatedAt, c.UpdatedAt, c.DeletedAt, c.DeletedBy,
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar comentarios: %w", err)
+	}
+	return nil
+}
+
+// ImportVotes completa el destino de los votos exportados antes de que los
+// votos lo guardaran. Si el usuario ya tiene un voto al mismo destino, se
+// reemplaza por el importado.
+func (r *backupRepository) ImportVotes(ctx context.Context, votes []*models.Vote) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO votes (id, user_id, target_type, target_id, post_id, comment_id, type, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (target_type, target_id, user_id) DO UPDATE SET
+			id = EXCLUDED.id, post_id = EXCLUDED.post_id, comment_id = EXCLUDED.comment_id,
+			type = EXCLUDED.type, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at`,
+		votes, func(v *models.Vote) []any {
+			target, targetID := v.Target()
+			return []any{
+				v.VoteID, v.UserID, target, targetID, v.PostID, v.CommentID, v.Type,
+				v.CreatedAt, v.UpdatedAt,
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar votos: %w", err)
+	}
+	return nil
+}
+
+// ImportSavedPosts ignora los guardados repetidos, ya sea por ID o por el par
+// usuario/post.
+func (r *backupRepository) ImportSavedPosts(ctx context.Context, saved []*models.SavedPost) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO saved_posts (id, user_id, post_id, saved_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		saved, func(s *models.SavedPost) []any {
+			return []any{s.ID, s.UserID, s.PostID, s.SavedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar guardados: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportPostRevisions(ctx context.Context, revisions []*models.PostRevision) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO post_revisions (id, post_id, number, editor_id, edited_at,
+			title, content, tags, image_url, image_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			post_id = EXCLUDED.post_id, number = EXCLUDED.number, editor_id = EXCLUDED.editor_id,
+			edited_at = EXCLUDED.edited_at, title = EXCLUDED.title, content = EXCLUDED.content,
+			tags = EXCLUDED.tags, image_url = EXCLUDED.image_url, image_id = EXCLUDED.image_id`,
+		revisions, func(rev *models.PostRevision) []any {
+			return []any{
+				rev.ID, rev.PostID, rev.Number, rev.EditorID, rev.EditedAt,
+				rev.Title, rev.Content, nonNil(rev.Tags), rev.ImageURL, rev.ImageID,
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar revisiones: %w", err)
+	}
+	return nil
+}
+
+// ImportPollBallots ignora los votos repetidos, ya sea por ID o por el par
+// post/usuario.
+func (r *backupRepository) ImportPollBallots(ctx context.Context, ballots []*models.PollBallot) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO poll_ballots (id, post_id, user_id, options, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`,
+		ballots, func(b *models.PollBallot) []any {
+			return []any{b.ID, b.PostID, b.UserID, b.Options, b.CreatedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar votos de encuestas: %w", err)
+	}
+	return nil
+}
+
+// ImportBlocks ignora los bloqueos repetidos, ya sea por ID o por el par
+// usuario/bloqueado.
+func (r *backupRepository) ImportBlocks(ctx context.Context, blocks []*models.Block) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO user_blocks (id, user_id, blocked_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		blocks, func(b *models.Block) []any {
+			return []any{b.ID, b.UserID, b.BlockedID, b.CreatedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar bloqueos: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportModerationLog(ctx context.Context, entries []*models.ModerationLogEntry) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO moderation_log (id, forum_id, post_id, action, moderator_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING`,
+		entries, func(e *models.ModerationLogEntry) []any {
+			return []any{e.ID, e.ForumID, e.PostID, e.Action, e.ModeratorID, e.CreatedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar el registro de moderación: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportEmbeddings(ctx context.Context, embeddings []*models.PostEmbedding) error {
+	err := importAll(ctx, r.db, upsertEmbedding, embeddings, func(e *models.PostEmbedding) []any {
+		return []any{e.PostID, e.Model, e.Vector, e.TextHash, e.UpdatedAt}
+	})
+	if err != nil {
+		return fmt.Errorf("error al importar los vectores de los posts: %w", err)
+	}
+	return nil
+}
+
+func (r *backupRepository) ImportTags(ctx context.Context, tags []*models.Tag) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO tags (name, synonym_of, post_count, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			synonym_of = EXCLUDED.synonym_of,
+			post_count = EXCLUDED.post_count,
+			created_at = EXCLUDED.created_at`,
+		tags, func(t *models.Tag) []any {
+			return []any{t.Name, t.SynonymOf, t.PostCount, t.CreatedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar los tags: %w", err)
+	}
+	return nil
+}
+
+// ImportTagFollows ignora los seguimientos repetidos, ya sea por ID o por el
+// par usuario/tag.
+func (r *backupRepository) ImportTagFollows(ctx context.Context, follows []*models.TagFollow) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO tag_follows (id, user_id, tag, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		follows, func(f *models.TagFollow) []any {
+			return []any{f.ID, f.UserID, f.Tag, f.CreatedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar los tags seguidos: %w", err)
+	}
+	return nil
+}
+
+// ImportSubforoJoins ignora los ingresos repetidos, ya sea por ID o por el par
+// subforo/usuario.
+func (r *backupRepository) ImportSubforoJoins(ctx context.Context, joins []*models.SubforoJoin) error {
+	err := importAll(ctx, r.db, `
+		INSERT INTO subforo_joins (id, forum_id, user_id, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		joins, func(j *models.SubforoJoin) []any {
+			return []any{j.ID, j.ForumID, j.UserID, j.JoinedAt}
+		})
+	if err != nil {
+		return fmt.Errorf("error al importar los ingresos a subforos: %w", err)
+	}
+	return nil
+}
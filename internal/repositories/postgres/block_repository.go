@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type blockRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBlockRepository crea un BlockRepository sobre PostgreSQL.
+func NewBlockRepository(db *pgxpool.Pool) repositories.BlockRepository {
+	return &blockRepository{db: db}
+}
+
+func (r *blockRepository) Block(ctx context.Context, block *models.Block) error {
+	block.ID = repositories.BlockID(block.UserID, block.BlockedID)
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_blocks (id, user_id, blocked_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET created_at = EXCLUDED.created_at`,
+		block.ID, block.UserID, block.BlockedID, block.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al bloquear usuario: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, userID, blockedID string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_blocks WHERE user_id = $1 AND blocked_id = $2`, userID, blockedID)
+	if err != nil {
+		return fmt.Errorf("error al desbloquear usuario: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) GetBlocked(ctx context.Context, userID string) ([]*models.Block, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, blocked_id, created_at
+		FROM user_blocks WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener usuarios bloqueados: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := make([]*models.Block, 0)
+	for rows.Next() {
+		var b models.Block
+		if err := rows.Scan(&b.ID, &b.UserID, &b.BlockedID, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &b)
+	}
+	return blocks, rows.Err()
+}
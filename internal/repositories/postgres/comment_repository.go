@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type commentRepository struct {
+	db    *pgxpool.Pool
+	users repositories.UserLoader
+}
+
+// NewCommentRepository crea un CommentRepository sobre PostgreSQL.
+func NewCommentRepository(db *pgxpool.Pool, users repositories.UserLoader) repositories.CommentRepository {
+	return &commentRepository{db: db, users: users}
+}
+
+const commentColumns = `
+	c.id, c.post_id, c.parent_id, c.author_id, c.content, c.likes, c.dislikes,
+	c.created_at, c.updated_at, c.deleted_at, c.deleted_by`
+
+func scanComment(row rowScanner, extra ...any) (*models.Comment, error) {
+	var c models.Comment
+	dest := []any{
+		&c.CommentID, &c.PostID, &c.ParentID, &c.AuthorID, &c.Content, &c.Likes, &c.Dislikes,
+		&c.CreatedAt, &c.UpdatedAt, &c.DeletedAt, &c.DeletedBy,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// scanCommentWithAuthor lee commentColumns seguidas de las columnas del autor.
+func scanCommentWithAuthor(row rowScanner) (*models.Comment, error) {
+	var author nullableUser
+	c, err := scanComment(row, &author.UID, &author.Username, &author.ProfilePhoto, &author.BannerImage, &author.Email)
+	if err != nil {
+		return nil, err
+	}
+	c.Author = author.user()
+	return c, nil
+}
+
+const commentAuthorColumns = `, u.uid, u.username, u.profile_photo, u.banner_image, u.email`
+
+func (r *commentRepository) insert(ctx context.Context, comment *models.Comment) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO comments (id, post_id, parent_id, author_id, content, likes, dislikes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		comment.CommentID, comment.PostID, comment.ParentID, comment.AuthorID, comment.Content,
+		comment.Likes, comment.Dislikes, comment.CreatedAt, comment.UpdatedAt,
+	)
+	return err
+}
+
+func (r *commentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	comment.CommentID = repositories.NewDocumentID()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	if err := r.insert(ctx, comment); err != nil {
+		return err
+	}
+
+	if author, err := repositories.LoadUser(ctx, r.users, comment.AuthorID); err == nil {
+		comment.Author = author
+	}
+	return nil
+}
+
+func (r *commentRepository) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
+	return scanComment(r.db.QueryRow(ctx,
+		`SELECT `+commentColumns+` FROM comments c WHERE c.id = $1 AND c.deleted_at IS NULL`, commentID))
+}
+
+func (r *commentRepository) GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+
+	// Mismo orden que GetAllCommentsByPostID: likes descendente y, a igualdad,
+	// del más antiguo al más reciente.
+	args := []any{postID}
+	after := "true"
+	if cursor != nil {
+		args = append(args, cursor.Score, cursor.Time, cursor.ID)
+		after = "(c.likes < $2 OR (c.likes = $2 AND (c.created_at, c.id) > ($3, $4)))"
+	}
+	args = append(args, page.Size()+1)
+
+	comments, err := r.queryCommentsWithAuthor(ctx, `
+		SELECT `+commentColumns+commentAuthorColumns+`
+		FROM comments c
+		LEFT JOIN users u ON u.uid = c.author_id
+		WHERE c.post_id = $1 AND c.deleted_at IS NULL AND `+after+`
+		ORDER BY c.likes DESC, c.created_at, c.id
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	return repositories.NewPage(comments, page.Size(), repositories.CommentCursor), nil
+}
+
+func (r *commentRepository) GetAllCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
+	return r.queryCommentsWithAuthor(ctx, `
+		SELECT `+commentColumns+commentAuthorColumns+`
+		FROM comments c
+		LEFT JOIN users u ON u.uid = c.author_id
+		WHERE c.post_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.likes DESC, c.created_at, c.id`, postID)
+}
+
+func (r *commentRepository) GetRootComments(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	args, after, limit, err := pageArgs([]any{postID}, page, "c.created_at", "c.id", false)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	comments, err := r.queryCommentsWithAuthor(ctx, `
+		SELECT `+commentColumns+commentAuthorColumns+`
+		FROM comments c
+		LEFT JOIN users u ON u.uid = c.author_id
+		WHERE c.post_id = $1 AND c.parent_id = '' AND c.deleted_at IS NULL AND `+after+`
+		ORDER BY c.created_at, c.id
+		`+limit, args...)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	return repositories.NewPage(comments, page.Size(), repositories.RootCommentCursor), nil
+}
+
+func (r *commentRepository) GetRepliesTo(ctx context.Context, parentIDs []string) ([]models.Comment, error) {
+	return r.queryCommentsWithAuthor(ctx, `
+		SELECT `+commentColumns+commentAuthorColumns+`
+		FROM comments c
+		LEFT JOIN users u ON u.uid = c.author_id
+		WHERE c.parent_id = ANY($1) AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id`, parentIDs)
+}
+
+// queryCommentsWithAuthor ejecuta una consulta que selecciona commentColumns y
+// commentAuthorColumns.
+func (r *commentRepository) queryCommentsWithAuthor(ctx context.Context, sql string, args ...any) ([]models.Comment, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanCommentWithAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+	return comments, rows.Err()
+}
+
+func (r *commentRepository) UpdateComment(ctx context.Context, commentID string, updatedContent string) (*models.Comment, error) {
+	c, err := scanCommentWithAuthor(r.db.QueryRow(ctx, `
+		WITH c AS (
+			UPDATE comments SET content = $2, updated_at = now()
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+commentColumns+commentAuthorColumns+`
+		FROM c
+		LEFT JOIN users u ON u.uid = c.author_id`, commentID, updatedContent))
+	if isNoRows(err) {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment")
+	}
+	return c, nil
+}
+
+func (r *commentRepository) CreateReply(ctx context.Context, parentID string, comment *models.Comment) error {
+	parent, err := r.GetCommentByID(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("parent comment not found: %w", err)
+	}
+
+	comment.PostID = parent.PostID
+	comment.ParentID = parentID
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	if err := comment.Validate(); err != nil {
+		return fmt.Errorf("comment validation failed: %w", err)
+	}
+
+	comment.CommentID = repositories.NewDocumentID()
+	if err := r.insert(ctx, comment); err != nil {
+		return fmt.Errorf("failed to save comment: %w", err)
+	}
+	return nil
+}
+
+func (r *commentRepository) GetReplies(ctx context.Context, parentID string) ([]models.Comment, error) {
+	if _, err := r.GetCommentByID(ctx, parentID); err != nil {
+		return nil, fmt.Errorf("parent comment does not exist: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		WHERE c.parent_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id`, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+	defer rows.Close()
+
+	var replies []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error iterating comments: %w", err)
+		}
+		replies = append(replies, *c)
+	}
+	return replies, rows.Err()
+}
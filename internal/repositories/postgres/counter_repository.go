@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type counterRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCounterRepository crea un CounterRepository sobre PostgreSQL.
+func NewCounterRepository(db *pgxpool.Pool) repositories.CounterRepository {
+	return &counterRepository{db: db}
+}
+
+// voteCounts cuenta los votos de cada destino del tipo indicado.
+const voteCounts = `
+	SELECT target_id,
+		count(*) FILTER (WHERE type = 'like') AS likes,
+		count(*) FILTER (WHERE type = 'dislike') AS dislikes
+	FROM votes
+	WHERE target_type = $1
+	GROUP BY target_id`
+
+// targetVoteCounts cuenta los votos al destino con ID igual a la columna idColumn.
+func targetVoteCounts(target models.VoteTarget, idColumn string) string {
+	count := func(t models.VoteType) string {
+		return `(SELECT count(*) FROM votes v WHERE v.target_type = '` + string(target) +
+			`' AND v.target_id = ` + idColumn + ` AND v.type = '` + string(t) + `')`
+	}
+	return count(models.Like) + `, ` + count(models.Dislike)
+}
+
+// queryDrift ejecuta una consulta que devuelve, por documento, el ID, los
+// contadores guardados y los reales, y se queda con los que no coinciden.
+func (r *counterRepository) queryDrift(ctx context.Context, sql string, args ...any) (int, []models.CounterDrift, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	checked := 0
+	var drift []models.CounterDrift
+	for rows.Next() {
+		var d models.CounterDrift
+		if err := rows.Scan(&d.ID, &d.StoredLikes, &d.StoredDislikes, &d.Likes, &d.Dislikes); err != nil {
+			return checked, nil, err
+		}
+		checked++
+		if d.Drifted() {
+			drift = append(drift, d)
+		}
+	}
+	return checked, drift, rows.Err()
+}
+
+func (r *counterRepository) PostCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	checked, drift, err := r.queryDrift(ctx, `
+		SELECT p.id, p.likes, p.dislikes, COALESCE(v.likes, 0), COALESCE(v.dislikes, 0)
+		FROM posts p
+		LEFT JOIN (`+voteCounts+`) v ON v.target_id = p.id
+		ORDER BY p.id`, models.TargetPost)
+	if err != nil {
+		return checked, nil, fmt.Errorf("error al revisar contadores de posts: %w", err)
+	}
+	return checked, drift, nil
+}
+
+func (r *counterRepository) CommentCounterDrift(ctx context.Context) (int, []models.CounterDrift, error) {
+	checked, drift, err := r.queryDrift(ctx, `
+		SELECT c.id, c.likes, c.dislikes, COALESCE(v.likes, 0), COALESCE(v.dislikes, 0)
+		FROM comments c
+		LEFT JOIN (`+voteCounts+`) v ON v.target_id = c.id
+		ORDER BY c.id`, models.TargetComment)
+	if err != nil {
+		return checked, nil, fmt.Errorf("error al revisar contadores de comentarios: %w", err)
+	}
+	return checked, drift, nil
+}
+
+// repair bloquea la fila con lock (que devuelve los contadores guardados y los
+// reales) y, si no coinciden, guarda los reales con update.
+func (r *counterRepository) repair(ctx context.Context, id, lock, update string) (models.CounterDrift, error) {
+	d := models.CounterDrift{ID: id}
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, lock, id).Scan(&d.StoredLikes, &d.StoredDislikes, &d.Likes, &d.Dislikes)
+		if err != nil {
+			return err
+		}
+		if !d.Drifted() {
+			return nil
+		}
+		d.Repaired = true
+		_, err = tx.Exec(ctx, update, id, d.Likes, d.Dislikes)
+		return err
+	})
+	return d, err
+}
+
+func (r *counterRepository) RepairPostCounters(ctx context.Context, postID string) (models.CounterDrift, error) {
+	d, err := r.repair(ctx, postID, `
+		SELECT p.likes, p.dislikes, `+targetVoteCounts(models.TargetPost, "p.id")+`
+		FROM posts p WHERE p.id = $1
+		FOR UPDATE`,
+		`UPDATE posts SET likes = $2, dislikes = $3 WHERE id = $1`)
+	if err != nil {
+		return d, fmt.Errorf("error al reparar contadores del post %s: %w", postID, err)
+	}
+	return d, nil
+}
+
+func (r *counterRepository) RepairCommentCounters(ctx context.Context, commentID string) (models.CounterDrift, error) {
+	d, err := r.repair(ctx, commentID, `
+		SELECT c.likes, c.dislikes, `+targetVoteCounts(models.TargetComment, "c.id")+`
+		FROM comments c WHERE c.id = $1
+		FOR UPDATE`,
+		`UPDATE comments SET likes = $2, dislikes = $3 WHERE id = $1`)
+	if err != nil {
+		return d, fmt.Errorf("error al reparar contadores del comentario %s: %w", commentID, err)
+	}
+	return d, nil
+}
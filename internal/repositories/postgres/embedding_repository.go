@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type embeddingRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewEmbeddingRepository crea un EmbeddingRepository sobre PostgreSQL.
+func NewEmbeddingRepository(db *pgxpool.Pool) repositories.EmbeddingRepository {
+	return &embeddingRepository{db: db}
+}
+
+func (r *embeddingRepository) GetEmbedding(ctx context.Context, postID string) (*models.PostEmbedding, error) {
+	e := models.PostEmbedding{PostID: postID}
+	err := r.db.QueryRow(ctx, `
+		SELECT model, vector, text_hash, updated_at FROM post_embeddings WHERE post_id = $1`,
+		postID,
+	).Scan(&e.Model, &e.Vector, &e.TextHash, &e.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el vector del post %s: %w", postID, err)
+	}
+	return &e, nil
+}
+
+// upsertEmbedding guarda un vector reemplazando el anterior del post.
+const upsertEmbedding = `
+	INSERT INTO post_embeddings (post_id, model, vector, text_hash, updated_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (post_id) DO UPDATE SET
+		model = EXCLUDED.model,
+		vector = EXCLUDED.vector,
+		text_hash = EXCLUDED.text_hash,
+		updated_at = EXCLUDED.updated_at`
+
+func (r *embeddingRepository) SaveEmbedding(ctx context.Context, e *models.PostEmbedding) error {
+	if _, err := r.db.Exec(ctx, upsertEmbedding, e.PostID, e.Model, e.Vector, e.TextHash, e.UpdatedAt); err != nil {
+		return fmt.Errorf("error al guardar el vector del post %s: %w", e.PostID, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID identifica el advisory lock que evita que dos procesos
+// apliquen migraciones a la vez.
+const migrationLockID = 7_240_501
+
+// Migration es un archivo SQL versionado de migrations/, con nombre
+// NNNN_descripcion.sql.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus indica si una migración ya fue aplicada y cuándo.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations devuelve las migraciones embebidas ordenadas por versión.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo migraciones: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("nombre de migración inválido %q: se espera NNNN_descripcion.sql", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("nombre de migración inválido %q: %w", name, err)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("versión de migración %d duplicada: %s y %s", version, other, name)
+		}
+		seen[version] = name
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo migración %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(name, ".sql"),
+			SQL:     string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, db *pgxpool.Pool) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, db *pgxpool.Pool) (map[int]time.Time, error) {
+	rows, err := db.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatuses devuelve todas las migraciones conocidas y si ya se aplicaron.
+func MigrationStatuses(ctx context.Context, db *pgxpool.Pool) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations devuelve las migraciones que todavía no se aplicaron.
+func PendingMigrations(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+	statuses, err := MigrationStatuses(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate aplica en orden las migraciones pendientes, cada una en su propia
+// transacción, y devuelve las que se aplicaron.
+func Migrate(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo conexión: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return nil, fmt.Errorf("error tomando lock de migraciones: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0, len(pending))
+	for _, m := range pending {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return applied, fmt.Errorf("error iniciando transacción para %s: %w", m.Name, err)
+		}
+		if _, err := tx.Exec(ctx, m.SQL); err != nil {
+			tx.Rollback(ctx)
+			return applied, fmt.Errorf("error aplicando migración %s: %w", m.Name, err)
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			m.Version, m.Name,
+		); err != nil {
+			tx.Rollback(ctx)
+			return applied, fmt.Errorf("error registrando migración %s: %w", m.Name, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return applied, fmt.Errorf("error confirmando migración %s: %w", m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
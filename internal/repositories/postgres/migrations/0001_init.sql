@@ -0,0 +1,94 @@
+-- Esquema inicial: mismas colecciones que en Firestore.
+-- Los IDs son TEXT para poder conservar los IDs de los documentos de Firestore.
+
+CREATE TABLE users (
+    uid           TEXT PRIMARY KEY,
+    username      TEXT NOT NULL DEFAULT '',
+    email         TEXT NOT NULL DEFAULT '',
+    profile_photo TEXT NOT NULL DEFAULT '',
+    banner_image  TEXT NOT NULL DEFAULT '',
+    extra         JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE TABLE subforos (
+    id          TEXT PRIMARY KEY,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by  TEXT NOT NULL DEFAULT '',
+    categories  TEXT[] NOT NULL DEFAULT '{}',
+    moderators  TEXT[] NOT NULL DEFAULT '{}',
+    members     TEXT[] NOT NULL DEFAULT '{}',
+    is_active   BOOLEAN NOT NULL DEFAULT true,
+    banner_url  TEXT NOT NULL DEFAULT '',
+    icon_url    TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX subforos_active_created_idx ON subforos (created_at DESC, id DESC) WHERE is_active;
+CREATE INDEX subforos_members_idx ON subforos USING GIN (members);
+CREATE INDEX subforos_moderators_idx ON subforos USING GIN (moderators);
+
+CREATE TABLE posts (
+    id         TEXT PRIMARY KEY,
+    author_id  TEXT NOT NULL DEFAULT '',
+    forum_id   TEXT NOT NULL DEFAULT '',
+    title      TEXT NOT NULL DEFAULT '',
+    content    TEXT NOT NULL DEFAULT '',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    is_flagged BOOLEAN NOT NULL DEFAULT false,
+    image_url  TEXT NOT NULL DEFAULT '',
+    image_id   TEXT NOT NULL DEFAULT '',
+    likes      INTEGER NOT NULL DEFAULT 0,
+    dislikes   INTEGER NOT NULL DEFAULT 0,
+    verdict    TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX posts_created_idx ON posts (created_at DESC, id DESC);
+CREATE INDEX posts_author_created_idx ON posts (author_id, created_at DESC, id DESC);
+CREATE INDEX posts_forum_created_idx ON posts (forum_id, created_at DESC, id DESC) WHERE NOT is_flagged;
+CREATE INDEX posts_forum_verdict_idx ON posts (forum_id, verdict, created_at DESC) WHERE NOT is_flagged;
+
+CREATE TABLE comments (
+    id         TEXT PRIMARY KEY,
+    post_id    TEXT NOT NULL,
+    parent_id  TEXT NOT NULL DEFAULT '',
+    author_id  TEXT NOT NULL DEFAULT '',
+    content    TEXT NOT NULL,
+    likes      INTEGER NOT NULL DEFAULT 0,
+    dislikes   INTEGER NOT NULL DEFAULT 0,
+    reactions  JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comments_post_likes_idx ON comments (post_id, likes DESC);
+CREATE INDEX comments_parent_created_idx ON comments (parent_id, created_at);
+
+CREATE TABLE votes (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    post_id    TEXT NOT NULL DEFAULT '',
+    comment_id TEXT NOT NULL DEFAULT '',
+    type       TEXT NOT NULL CHECK (type IN ('like', 'dislike')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX votes_post_idx ON votes (post_id);
+CREATE INDEX votes_comment_idx ON votes (comment_id);
+CREATE INDEX votes_user_post_idx ON votes (user_id, post_id);
+
+CREATE TABLE saved_posts (
+    id       TEXT PRIMARY KEY,
+    user_id  TEXT NOT NULL,
+    post_id  TEXT NOT NULL,
+    saved_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, post_id)
+);
+
+CREATE INDEX saved_posts_user_saved_idx ON saved_posts (user_id, saved_at DESC);
@@ -0,0 +1,44 @@
+-- Votos unificados: cada voto apunta a un destino (post o comentario) y un
+-- usuario tiene a lo sumo un voto por destino. Las reacciones que se guardaban
+-- dentro de los comentarios pasan a ser votos.
+
+ALTER TABLE votes
+    ADD COLUMN target_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN target_id   TEXT NOT NULL DEFAULT '';
+
+UPDATE votes SET
+    target_type = CASE WHEN comment_id <> '' THEN 'comment' ELSE 'post' END,
+    target_id   = CASE WHEN comment_id <> '' THEN comment_id ELSE post_id END;
+
+INSERT INTO votes (id, user_id, target_type, target_id, post_id, comment_id, type, created_at, updated_at)
+SELECT 'comment:' || c.id || ':' || r.key, r.key, 'comment', c.id, c.post_id, c.id, r.value,
+       c.updated_at, c.updated_at
+FROM comments c, jsonb_each_text(c.reactions) r
+WHERE r.value IN ('like', 'dislike')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE comments DROP COLUMN reactions;
+
+-- Si un usuario votó varias veces al mismo destino queda el último voto.
+DELETE FROM votes v
+USING (
+    SELECT id, row_number() OVER (
+        PARTITION BY target_type, target_id, user_id
+        ORDER BY updated_at DESC, created_at DESC, id
+    ) AS n
+    FROM votes
+) d
+WHERE v.id = d.id AND d.n > 1;
+
+UPDATE votes SET id = target_type || ':' || target_id || ':' || user_id
+WHERE id <> target_type || ':' || target_id || ':' || user_id;
+
+ALTER TABLE votes
+    ALTER COLUMN target_type DROP DEFAULT,
+    ALTER COLUMN target_id DROP DEFAULT,
+    ADD CONSTRAINT votes_target_type_check CHECK (target_type IN ('post', 'comment'));
+
+DROP INDEX votes_comment_idx;
+DROP INDEX votes_user_post_idx;
+CREATE UNIQUE INDEX votes_target_user_idx ON votes (target_type, target_id, user_id);
+CREATE INDEX votes_user_target_idx ON votes (user_id, target_type, target_id);
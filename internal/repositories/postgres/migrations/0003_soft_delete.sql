@@ -0,0 +1,26 @@
+-- Papelera: los posts y comentarios eliminados conservan sus datos con
+-- deleted_at y deleted_by hasta que se purgan.
+
+ALTER TABLE posts
+    ADD COLUMN deleted_at TIMESTAMPTZ,
+    ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comments
+    ADD COLUMN deleted_at TIMESTAMPTZ,
+    ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+-- Los listados solo ven lo que no está en la papelera.
+DROP INDEX posts_created_idx;
+DROP INDEX posts_author_created_idx;
+DROP INDEX posts_forum_created_idx;
+DROP INDEX posts_forum_verdict_idx;
+CREATE INDEX posts_created_idx ON posts (created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX posts_author_created_idx ON posts (author_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX posts_forum_created_idx ON posts (forum_id, created_at DESC, id DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL;
+CREATE INDEX posts_forum_verdict_idx ON posts (forum_id, verdict, created_at DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL;
+
+CREATE INDEX posts_trash_idx ON posts (deleted_by, deleted_at DESC, id DESC) WHERE deleted_at IS NOT NULL;
+CREATE INDEX comments_trash_idx ON comments (deleted_by, deleted_at DESC, id DESC) WHERE deleted_at IS NOT NULL;
+CREATE INDEX comments_post_idx ON comments (post_id);
@@ -0,0 +1,21 @@
+-- Historial de ediciones: cada edición de un post guarda una revisión con el
+-- contenido que quedó. La revisión 1 es el post como se publicó.
+
+ALTER TABLE posts
+    ADD COLUMN edited_at TIMESTAMPTZ,
+    ADD COLUMN revisions INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE post_revisions (
+    id        TEXT PRIMARY KEY,
+    post_id   TEXT NOT NULL,
+    number    INTEGER NOT NULL,
+    editor_id TEXT NOT NULL DEFAULT '',
+    edited_at TIMESTAMPTZ NOT NULL,
+    title     TEXT NOT NULL DEFAULT '',
+    content   TEXT NOT NULL DEFAULT '',
+    tags      TEXT[] NOT NULL DEFAULT '{}',
+    image_url TEXT NOT NULL DEFAULT '',
+    image_id  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX post_revisions_post_number_idx ON post_revisions (post_id, number);
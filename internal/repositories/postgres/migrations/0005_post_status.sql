@@ -0,0 +1,19 @@
+-- Borradores y publicación programada. Los posts existentes están publicados.
+
+ALTER TABLE posts
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published',
+    ADD COLUMN publish_at TIMESTAMPTZ;
+
+-- Los listados públicos solo ven los posts publicados.
+DROP INDEX posts_created_idx;
+DROP INDEX posts_forum_created_idx;
+DROP INDEX posts_forum_verdict_idx;
+CREATE INDEX posts_created_idx ON posts (created_at DESC, id DESC)
+    WHERE deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_forum_created_idx ON posts (forum_id, created_at DESC, id DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_forum_verdict_idx ON posts (forum_id, verdict, created_at DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL AND status = 'published';
+
+CREATE INDEX posts_scheduled_idx ON posts (publish_at)
+    WHERE status = 'scheduled' AND deleted_at IS NULL;
@@ -0,0 +1,12 @@
+-- Encuestas: la encuesta viaja con el post y cada usuario vota una sola vez.
+
+ALTER TABLE posts ADD COLUMN poll JSONB;
+
+CREATE TABLE poll_ballots (
+    id         TEXT PRIMARY KEY,
+    post_id    TEXT NOT NULL,
+    user_id    TEXT NOT NULL,
+    options    INTEGER[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (post_id, user_id)
+);
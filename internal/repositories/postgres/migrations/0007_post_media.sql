@@ -0,0 +1,13 @@
+-- Galerías: cada post tiene una lista ordenada de imágenes. image_url e
+-- image_id quedan con la primera. Los posts con imagen la pasan a la galería.
+
+ALTER TABLE posts ADD COLUMN media JSONB NOT NULL DEFAULT '[]';
+
+UPDATE posts
+SET media = jsonb_build_array(jsonb_build_object(
+    'id', substr(md5(id || image_id), 1, 20),
+    'url', image_url,
+    'public_id', image_id,
+    'caption', '',
+    'alt', ''))
+WHERE image_url <> '';
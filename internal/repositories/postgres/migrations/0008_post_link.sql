@@ -0,0 +1,3 @@
+-- Posts de enlace: la URL y su vista previa viajan con el post.
+
+ALTER TABLE posts ADD COLUMN link JSONB;
@@ -0,0 +1,46 @@
+-- Órdenes hot, top, controversial y rising de los listados. Los valores se
+-- guardan en cada post y los recalcula un trigger cada vez que cambian los
+-- votos o la fecha de creación, con las mismas fórmulas que models.RankPost.
+
+ALTER TABLE posts
+    ADD COLUMN score INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN hot DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN controversy DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN rising DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE FUNCTION posts_rank() RETURNS trigger AS $$
+DECLARE
+    net DOUBLE PRECISION := NEW.likes - NEW.dislikes;
+BEGIN
+    NEW.score := NEW.likes - NEW.dislikes;
+    NEW.hot := sign(net) * log(greatest(abs(net), 1))
+        + extract(epoch FROM NEW.created_at - TIMESTAMPTZ '2024-01-01 00:00:00+00')::DOUBLE PRECISION / 45000;
+    NEW.controversy := 0;
+    IF NEW.likes > 0 AND NEW.dislikes > 0 THEN
+        NEW.controversy := power((NEW.likes + NEW.dislikes)::DOUBLE PRECISION,
+            least(NEW.likes, NEW.dislikes)::DOUBLE PRECISION / greatest(NEW.likes, NEW.dislikes));
+    END IF;
+    NEW.rising := net / greatest(extract(epoch FROM now() - NEW.created_at)::DOUBLE PRECISION / 3600, 1);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_rank BEFORE INSERT OR UPDATE OF likes, dislikes, created_at ON posts
+    FOR EACH ROW EXECUTE FUNCTION posts_rank();
+
+-- Calcula los valores de los posts existentes
+UPDATE posts SET likes = likes;
+
+-- rising solo mira las últimas 24 horas: le alcanza con posts_created_idx.
+CREATE INDEX posts_hot_idx ON posts (hot DESC, created_at DESC, id DESC)
+    WHERE deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_top_idx ON posts (score DESC, created_at DESC, id DESC)
+    WHERE deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_controversy_idx ON posts (controversy DESC, created_at DESC, id DESC)
+    WHERE deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_forum_hot_idx ON posts (forum_id, hot DESC, created_at DESC, id DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_forum_top_idx ON posts (forum_id, score DESC, created_at DESC, id DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL AND status = 'published';
+CREATE INDEX posts_forum_controversy_idx ON posts (forum_id, controversy DESC, created_at DESC, id DESC)
+    WHERE NOT is_flagged AND deleted_at IS NULL AND status = 'published';
@@ -0,0 +1,11 @@
+-- Usuarios bloqueados: sus posts no aparecen en el feed de quien los bloqueó.
+
+CREATE TABLE user_blocks (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    blocked_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, blocked_id)
+);
+
+CREATE INDEX user_blocks_user_idx ON user_blocks (user_id, created_at DESC);
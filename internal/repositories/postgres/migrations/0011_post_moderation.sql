@@ -0,0 +1,27 @@
+-- Moderación de posts: fijados, cerrados y archivados, y el registro de las
+-- acciones de los moderadores.
+
+ALTER TABLE posts
+    ADD COLUMN pinned_at TIMESTAMPTZ,
+    ADD COLUMN locked_at TIMESTAMPTZ,
+    ADD COLUMN archived_at TIMESTAMPTZ,
+    ADD COLUMN keep_open BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE subforos ADD COLUMN archive_after_days INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX posts_forum_pinned_idx ON posts (forum_id, pinned_at DESC)
+    WHERE pinned_at IS NOT NULL AND deleted_at IS NULL AND status = 'published';
+-- Posts que el archivado automático todavía puede archivar
+CREATE INDEX posts_forum_archivable_idx ON posts (forum_id, created_at)
+    WHERE archived_at IS NULL AND NOT keep_open AND deleted_at IS NULL AND status = 'published';
+
+CREATE TABLE moderation_log (
+    id           TEXT PRIMARY KEY,
+    forum_id     TEXT NOT NULL,
+    post_id      TEXT NOT NULL,
+    action       TEXT NOT NULL,
+    moderator_id TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX moderation_log_forum_idx ON moderation_log (forum_id, created_at DESC, id DESC);
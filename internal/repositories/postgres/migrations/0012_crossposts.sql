@@ -0,0 +1,6 @@
+-- Crossposts: la referencia al post original viaja con el crosspost.
+
+ALTER TABLE posts ADD COLUMN crosspost_of JSONB;
+
+CREATE INDEX posts_crosspost_of_idx ON posts ((crosspost_of->>'post_id'))
+    WHERE crosspost_of IS NOT NULL;
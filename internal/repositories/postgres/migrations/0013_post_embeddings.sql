@@ -0,0 +1,9 @@
+-- Vectores de los posts para la búsqueda semántica, uno por post.
+
+CREATE TABLE post_embeddings (
+    post_id    TEXT PRIMARY KEY,
+    model      TEXT NOT NULL,
+    vector     DOUBLE PRECISION[] NOT NULL,
+    text_hash  TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
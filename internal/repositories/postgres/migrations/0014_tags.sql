@@ -0,0 +1,54 @@
+-- Registro de tags: los posts guardan sus tags normalizados (ver
+-- models.NormalizeTag) y cada tag lleva la cantidad de posts publicados que lo
+-- tienen. Un sinónimo apunta al tag que se guarda en su lugar.
+
+-- Normaliza los tags de los posts existentes: minúsculas, sin tildes ni # al
+-- principio, con las palabras unidas por guiones y sin repetidos. translate
+-- cubre las letras con tilde del español y el portugués.
+UPDATE posts p SET tags = ARRAY(
+    SELECT n.tag FROM (
+        SELECT regexp_replace(
+                   regexp_replace(
+                       lower(translate(ltrim(btrim(u.raw), '#'),
+                           'ÁÀÂÃÄÉÈÊËÍÌÎÏÓÒÔÕÖÚÙÛÜÑÇáàâãäéèêëíìîïóòôõöúùûüñç',
+                           'AAAAAEEEEIIIIOOOOOUUUUNCaaaaaeeeeiiiiooooouuuunc')),
+                       '^\s+|\s+$', '', 'g'),
+                   '\s+', '-', 'g') AS tag,
+               min(u.i) AS i
+        FROM unnest(p.tags) WITH ORDINALITY AS u(raw, i)
+        GROUP BY 1
+    ) n
+    WHERE n.tag <> ''
+    ORDER BY n.i
+)
+WHERE p.tags <> '{}';
+
+CREATE INDEX posts_tags_idx ON posts USING GIN (tags);
+
+CREATE TABLE tags (
+    name       TEXT PRIMARY KEY,
+    synonym_of TEXT NOT NULL DEFAULT '',
+    post_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Autocompletado por prefijo (LIKE 'abc%')
+CREATE INDEX tags_name_prefix_idx ON tags (name text_pattern_ops);
+CREATE INDEX tags_synonym_of_idx ON tags (synonym_of) WHERE synonym_of <> '';
+
+INSERT INTO tags (name, post_count, created_at)
+SELECT tag, count(*), min(created_at)
+FROM posts, unnest(tags) AS tag
+WHERE status = 'published' AND deleted_at IS NULL
+GROUP BY tag;
+
+-- Tags seguidos: sus posts aparecen en el feed de quien los sigue.
+CREATE TABLE tag_follows (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    tag        TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, tag)
+);
+
+CREATE INDEX tag_follows_user_idx ON tag_follows (user_id, created_at DESC);
@@ -0,0 +1,12 @@
+-- Cuándo se unió cada miembro actual a su subforo, para medir cuánto crece.
+-- Los miembros anteriores no tienen fecha: no cuentan como ingresos nuevos.
+
+CREATE TABLE subforo_joins (
+    id        TEXT PRIMARY KEY,
+    forum_id  TEXT NOT NULL,
+    user_id   TEXT NOT NULL,
+    joined_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (forum_id, user_id)
+);
+
+CREATE INDEX subforo_joins_joined_at_idx ON subforo_joins (joined_at);
@@ -0,0 +1,4 @@
+-- Los comentarios raíz de cada post, en el orden del árbol de comentarios.
+
+CREATE INDEX comments_post_roots_idx ON comments (post_id, created_at, id)
+    WHERE parent_id = '' AND deleted_at IS NULL;
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type moderationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewModerationRepository crea un ModerationRepository sobre PostgreSQL.
+func NewModerationRepository(db *pgxpool.Pool) repositories.ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+func (r *moderationRepository) Record(ctx context.Context, entry *models.ModerationLogEntry) error {
+	entry.ID = repositories.NewDocumentID()
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO moderation_log (id, forum_id, post_id, action, moderator_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		entry.ID, entry.ForumID, entry.PostID, entry.Action, entry.ModeratorID, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al registrar la acción de moderación: %w", err)
+	}
+	return nil
+}
+
+func (r *moderationRepository) GetLog(ctx context.Context, forumID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error) {
+	args, after, limit, err := pageArgs([]any{forumID}, page, "created_at", "id", true)
+	if err != nil {
+		return models.Page[*models.ModerationLogEntry]{}, err
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT id, forum_id, post_id, action, moderator_id, created_at
+		FROM moderation_log
+		WHERE forum_id = $1 AND `+after+`
+		ORDER BY created_at DESC, id DESC `+limit, args...)
+	if err != nil {
+		return models.Page[*models.ModerationLogEntry]{}, fmt.Errorf("error al obtener el registro de moderación: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*models.ModerationLogEntry, 0)
+	for rows.Next() {
+		var e models.ModerationLogEntry
+		if err := rows.Scan(&e.ID, &e.ForumID, &e.PostID, &e.Action, &e.ModeratorID, &e.CreatedAt); err != nil {
+			return models.Page[*models.ModerationLogEntry]{}, err
+		}
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.ModerationLogEntry]{}, err
+	}
+	return repositories.NewPage(entries, page.Size(), repositories.ModerationCursor), nil
+}
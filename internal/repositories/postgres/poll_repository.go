@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type pollRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPollRepository crea un PollRepository sobre PostgreSQL.
+func NewPollRepository(db *pgxpool.Pool) repositories.PollRepository {
+	return &pollRepository{db: db}
+}
+
+// CastBallot se apoya en la restricción única (post_id, user_id): si el
+// insert no agrega filas, el usuario ya había votado.
+func (r *pollRepository) CastBallot(ctx context.Context, ballot *models.PollBallot) error {
+	ballot.ID = repositories.BallotID(ballot.PostID, ballot.UserID)
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO poll_ballots (id, post_id, user_id, options, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`,
+		ballot.ID, ballot.PostID, ballot.UserID, ballot.Options, ballot.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al guardar el voto de la encuesta: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrAlreadyVoted
+	}
+	return nil
+}
+
+func (r *pollRepository) GetBallot(ctx context.Context, postID, userID string) (*models.PollBallot, error) {
+	var b models.PollBallot
+	err := r.db.QueryRow(ctx, `
+		SELECT id, post_id, user_id, options, created_at
+		FROM poll_ballots WHERE post_id = $1 AND user_id = $2`,
+		postID, userID,
+	).Scan(&b.ID, &b.PostID, &b.UserID, &b.Options, &b.CreatedAt)
+	if isNoRows(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el voto de la encuesta: %w", err)
+	}
+	return &b, nil
+}
+
+func (r *pollRepository) Tally(ctx context.Context, postID string, options int) (*models.PollTally, error) {
+	tally := &models.PollTally{Counts: make([]int, options)}
+	err := r.db.QueryRow(ctx, `SELECT count(*) FROM poll_ballots WHERE post_id = $1`, postID).
+		Scan(&tally.Voters)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los votos de la encuesta: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT option, count(*)
+		FROM poll_ballots, unnest(options) AS option
+		WHERE post_id = $1
+		GROUP BY option`,
+		postID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los votos de la encuesta: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var option, n int
+		if err := rows.Scan(&option, &n); err != nil {
+			return nil, err
+		}
+		if option >= 0 && option < options {
+			tally.Counts[option] = n
+		}
+	}
+	return tally, rows.Err()
+}
@@ -0,0 +1,642 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type postRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostRepository crea un PostRepository sobre PostgreSQL.
+func NewPostRepository(db *pgxpool.Pool) repositories.PostRepository {
+	return &postRepository{db: db}
+}
+
+// queryPosts ejecuta una consulta que selecciona postColumns y devuelve los posts.
+func (r *postRepository) queryPosts(ctx context.Context, sql string, args ...any) ([]*models.Post, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]*models.Post, 0)
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar post: %w", err)
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+func (r *postRepository) GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error) {
+	p, err := scanPost(r.db.QueryRow(ctx, `SELECT `+postColumns+postFrom+` WHERE p.id = $1 AND p.deleted_at IS NULL`, id))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el post por ID %s: %w", id, err)
+	}
+	if p.AuthorID != "" && p.Author == nil {
+		return nil, fmt.Errorf("error al obtener el autor con ID %s: no existe", p.AuthorID)
+	}
+
+	author := p.Author
+	p.Author = nil
+	return &models.PostWithAuthor{Post: *p, Author: author}, nil
+}
+
+// published es la condición de los posts visibles para todos.
+const published = "p.status = 'published'"
+
+// postStatus es el estado a guardar: los posts sin estado están publicados.
+func postStatus(p *models.Post) models.PostStatus {
+	if p.Status == "" {
+		return models.PostPublished
+	}
+	return p.Status
+}
+
+// queryPostsPage pagina los posts que cumplen where (con sus argumentos) y no
+// están en la papelera, ordenados por fecha de creación, más recientes primero.
+func (r *postRepository) queryPostsPage(ctx context.Context, page models.PageRequest, where string, args ...any) (models.Page[*models.Post], error) {
+	args, after, limit, err := pageArgs(args, page, "p.created_at", "p.id", true)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.deleted_at IS NULL AND `+where+` AND `+after+`
+		ORDER BY p.created_at DESC, p.id DESC `+limit, args...)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	return repositories.NewPage(posts, page.Size(), repositories.PostCursor), nil
+}
+
+// rankColumns son las columnas precalculadas de cada orden (ver la migración
+// 0009_post_ranks.sql).
+var rankColumns = map[models.PostSortMode]string{
+	models.SortHot:           "p.hot",
+	models.SortTop:           "p.score",
+	models.SortControversial: "p.controversy",
+	models.SortRising:        "p.rising",
+}
+
+// querySortedPage es queryPostsPage con el orden order: primero por su
+// columna precalculada y después por fecha e ID. Si el orden tiene ventana
+// de tiempo, solo incluye los posts creados dentro de ella.
+func (r *postRepository) querySortedPage(ctx context.Context, order models.PostSort, page models.PageRequest, where string, args ...any) (models.Page[*models.Post], error) {
+	column, ranked := rankColumns[order.Mode]
+	if !ranked {
+		return r.queryPostsPage(ctx, page, where, args...)
+	}
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+
+	if since := order.Since(time.Now()); !since.IsZero() {
+		args = append(args, since)
+		where += fmt.Sprintf(" AND p.created_at >= $%d", len(args))
+	}
+	after := "true"
+	if cursor != nil {
+		args = append(args, cursor.Rank, cursor.Time, cursor.ID)
+		after = fmt.Sprintf("(%s, p.created_at, p.id) < ($%d::DOUBLE PRECISION, $%d, $%d)",
+			column, len(args)-2, len(args)-1, len(args))
+	}
+	args = append(args, page.Size()+1)
+
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.deleted_at IS NULL AND `+where+` AND `+after+`
+		ORDER BY `+column+` DESC, p.created_at DESC, p.id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	return repositories.NewPage(posts, page.Size(), repositories.SortedPostCursor(order)), nil
+}
+
+func (r *postRepository) GetAll(ctx context.Context, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	posts, err := r.querySortedPage(ctx, order, page, published)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) Create(ctx context.Context, p *models.Post) error {
+	p.CreatedAt = time.Now()
+	id := repositories.NewDocumentID()
+	repositories.LegacyMedia(p)
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO posts (id, title, content, author_id, tags, is_flagged, forum_id,
+			likes, dislikes, image_url, image_id, verdict, created_at, status, publish_at, poll, media, link,
+			crosspost_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`,
+		id, p.Title, p.Content, p.AuthorID, nonNil(p.Tags), p.IsFlagged, p.ForumID,
+		p.Likes, p.Dislikes, p.ImageURL, p.ImageID, p.Verdict, p.CreatedAt, postStatus(p), p.PublishAt, p.Poll,
+		p.Media, p.Link, p.CrosspostOf,
+	)
+	if err != nil {
+		return err
+	}
+	p.ID = id
+	return nil
+}
+
+// Edit bloquea la fila del post mientras guarda los cambios y sus
+// revisiones, para que dos ediciones simultáneas no usen el mismo número.
+func (r *postRepository) Edit(ctx context.Context, id, editorID string, p *models.Post) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		post, err := scanPost(tx.QueryRow(ctx, `SELECT `+postColumns+postFrom+`
+			WHERE p.id = $1 AND p.deleted_at IS NULL
+			FOR UPDATE OF p`, id))
+		if err != nil {
+			return err
+		}
+
+		p := repositories.KeepMedia(post, p)
+		now := time.Now()
+		revisions := repositories.NewRevisions(post, p, editorID, now)
+		if revisions == nil {
+			return nil
+		}
+		if err := insertRevisions(ctx, tx, revisions); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE posts
+			SET title = $2, content = $3, tags = $4, image_id = $5, image_url = $6,
+				updated_at = $7, edited_at = $7, revisions = $8, media = $9
+			WHERE id = $1`,
+			id, p.Title, p.Content, nonNil(p.Tags), p.ImageID, p.ImageURL,
+			now, revisions[len(revisions)-1].Number, nonNilMedia(p.Media),
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error al editar el post: %w", err)
+	}
+	return nil
+}
+
+// insertRevisions guarda las revisiones nuevas del post dentro de tx.
+func insertRevisions(ctx context.Context, tx pgx.Tx, revisions []*models.PostRevision) error {
+	for _, rev := range revisions {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO post_revisions (id, post_id, number, editor_id, edited_at,
+				title, content, tags, image_url, image_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			rev.ID, rev.PostID, rev.Number, rev.EditorID, rev.EditedAt,
+			rev.Title, rev.Content, nonNil(rev.Tags), rev.ImageURL, rev.ImageID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postRepository) GetRevisions(ctx context.Context, postID string) ([]*models.PostRevision, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, post_id, number, editor_id, edited_at, title, content, tags, image_url, image_id
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY number`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las revisiones del post: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*models.PostRevision, 0)
+	for rows.Next() {
+		var rev models.PostRevision
+		if err := rows.Scan(&rev.ID, &rev.PostID, &rev.Number, &rev.EditorID, &rev.EditedAt,
+			&rev.Title, &rev.Content, &rev.Tags, &rev.ImageURL, &rev.ImageID); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *postRepository) GetPostsByAuthorID(ctx context.Context, authorID string, drafts bool, page models.PageRequest) (models.Page[*models.Post], error) {
+	where := "p.author_id = $1"
+	if !drafts {
+		where += " AND " + published
+	}
+	posts, err := r.queryPostsPage(ctx, page, where, authorID)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar posts del autor: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error) {
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.id IN (
+			SELECT v.target_id FROM votes v
+			WHERE v.user_id = $1 AND v.type = 'like' AND v.target_type = 'post'
+		) AND p.deleted_at IS NULL
+		ORDER BY p.created_at DESC, p.id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error al iterar votos del usuario: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) IncrementReaction(ctx context.Context, postID string, reactionType string, delta int) error {
+	column := "likes"
+	if reactionType == "dislike" {
+		column = "dislikes"
+	}
+	tag, err := r.db.Exec(ctx,
+		`UPDATE posts SET `+column+` = `+column+` + $2 WHERE id = $1`, postID, delta)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("post %s no existe", postID)
+	}
+	return nil
+}
+
+func (r *postRepository) SavePostForUser(ctx context.Context, userID, postID string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO saved_posts (id, user_id, post_id, saved_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (user_id, post_id) DO NOTHING`,
+		repositories.NewDocumentID(), userID, postID,
+	)
+	return err
+}
+
+func (r *postRepository) RemoveSavedPost(ctx context.Context, userID, postID string) error {
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM saved_posts WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	return err
+}
+
+// savedPost es un post guardado junto con la posición del guardado en el listado.
+type savedPost struct {
+	post  *models.Post
+	saved repositories.Cursor
+}
+
+func (r *postRepository) GetSavedPostsByUser(ctx context.Context, userID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	args, after, limit, err := pageArgs([]any{userID}, page, "s.saved_at", "s.id", true)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+postColumns+`, s.saved_at, s.id`+postFrom+`
+		JOIN saved_posts s ON s.post_id = p.id
+		WHERE s.user_id = $1 AND p.deleted_at IS NULL AND `+after+`
+		ORDER BY s.saved_at DESC, s.id DESC `+limit, args...)
+	if err != nil {
+		return models.Page[*models.Post]{}, fmt.Errorf("error iterando guardados: %w", err)
+	}
+	defer rows.Close()
+
+	var saves []savedPost
+	for rows.Next() {
+		var s savedPost
+		if s.post, err = scanPost(rows, &s.saved.Time, &s.saved.ID); err != nil {
+			return models.Page[*models.Post]{}, fmt.Errorf("error iterando guardados: %w", err)
+		}
+		saves = append(saves, s)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.Post]{}, fmt.Errorf("error iterando guardados: %w", err)
+	}
+
+	savesPage := repositories.NewPage(saves, page.Size(), func(s savedPost) repositories.Cursor { return s.saved })
+	posts := make([]*models.Post, 0, len(savesPage.Items))
+	for _, s := range savesPage.Items {
+		posts = append(posts, s.post)
+	}
+	return models.Page[*models.Post]{Items: posts, NextCursor: savesPage.NextCursor}, nil
+}
+
+func (r *postRepository) IsPostSavedByUser(ctx context.Context, userID, postID string) (bool, error) {
+	var saved bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM saved_posts WHERE user_id = $1 AND post_id = $2)`,
+		userID, postID,
+	).Scan(&saved)
+	if err != nil {
+		return false, fmt.Errorf("error checking saved post: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *postRepository) GetPostsByForumID(ctx context.Context, forumID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	return r.querySortedPage(ctx, order, page,
+		"p.forum_id = $1 AND NOT p.is_flagged AND p.pinned_at IS NULL AND "+published, forumID)
+}
+
+func (r *postRepository) GetPinnedPosts(ctx context.Context, forumID string) ([]*models.Post, error) {
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.forum_id = $1 AND p.pinned_at IS NOT NULL AND p.deleted_at IS NULL AND `+published+`
+		ORDER BY p.pinned_at DESC`, forumID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los posts fijados: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	return r.querySortedPage(ctx, order, page,
+		"p.tags @> ARRAY[$1::text] AND NOT p.is_flagged AND "+published, tag)
+}
+
+func (r *postRepository) GetFeed(ctx context.Context, forumIDs, tags, blockedIDs []string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	posts, err := r.querySortedPage(ctx, order, page,
+		"(p.forum_id = ANY($1) OR p.tags && $2) AND p.author_id <> ALL($3) AND NOT p.is_flagged AND "+published,
+		nonNil(forumIDs), nonNil(tags), nonNil(blockedIDs))
+	if err != nil {
+		return posts, fmt.Errorf("error al obtener el feed: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) GetCrossposts(ctx context.Context, postID string) ([]*models.Post, error) {
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.crosspost_of->>'post_id' = $1 AND p.deleted_at IS NULL AND `+published+`
+		ORDER BY p.created_at DESC, p.id DESC`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los crossposts: %w", err)
+	}
+	return posts, nil
+}
+
+// MarkCrossposts escribe deleted_at dentro de crosspost_of; con deletedAt
+// nil queda como null en el JSON.
+func (r *postRepository) MarkCrossposts(ctx context.Context, postID string, deletedAt *time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE posts
+		SET crosspost_of = jsonb_set(crosspost_of, '{deleted_at}', COALESCE(to_jsonb($2::timestamptz), 'null'::jsonb))
+		WHERE crosspost_of->>'post_id' = $1`, postID, deletedAt)
+	if err != nil {
+		return fmt.Errorf("error al actualizar los crossposts: %w", err)
+	}
+	return nil
+}
+
+// ReplaceTag conserva el orden de los tags: to queda donde estaba el primero
+// de from y to.
+func (r *postRepository) ReplaceTag(ctx context.Context, from, to string) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE posts SET tags = ARRAY(
+			SELECT t FROM unnest(array_replace(tags, $1, $2)) WITH ORDINALITY AS u(t, i)
+			GROUP BY t ORDER BY min(i)
+		)
+		WHERE tags @> ARRAY[$1::text]
+		RETURNING id`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error al reemplazar el tag %s: %w", from, err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postRepository) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string, page models.PageRequest) (models.Page[*models.Post], error) {
+	return r.queryPostsPage(ctx, page, "p.forum_id = $1 AND p.verdict = $2 AND NOT p.is_flagged AND "+published, forumID, verdict)
+}
+
+func (r *postRepository) ReportPost(ctx context.Context, postID string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE posts SET is_flagged = true WHERE id = $1`, postID)
+	if err != nil {
+		return fmt.Errorf("error al reportar el post: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("error al reportar el post: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// isNoRows indica si err viene de una consulta sin resultados.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+func (r *postRepository) GetDrafts(ctx context.Context, authorID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	posts, err := r.queryPostsPage(ctx, page, "p.author_id = $1 AND NOT "+published, authorID)
+	if err != nil {
+		return posts, fmt.Errorf("error al iterar borradores: %w", err)
+	}
+	return posts, nil
+}
+
+// unpublished ejecuta sql, que actualiza el post $1, solo si el post existe,
+// no está en la papelera y todavía no se publicó.
+func (r *postRepository) unpublished(ctx context.Context, sql string, args ...any) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var status models.PostStatus
+		err := tx.QueryRow(ctx, `
+			SELECT status FROM posts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, args[0]).Scan(&status)
+		if isNoRows(err) {
+			return fmt.Errorf("%s no existe", args[0])
+		}
+		if err != nil {
+			return err
+		}
+		if status == models.PostPublished {
+			return repositories.ErrPublished
+		}
+		_, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+}
+
+func (r *postRepository) UpdateDraft(ctx context.Context, id string, p *models.Post) error {
+	err := r.unpublished(ctx, `
+		UPDATE posts
+		SET title = $2, content = $3, tags = $4, forum_id = $5, status = $6, publish_at = $7, updated_at = now()
+		WHERE id = $1`,
+		id, p.Title, p.Content, nonNil(p.Tags), p.ForumID, p.Status, p.PublishAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al editar el borrador: %w", err)
+	}
+	return nil
+}
+
+func (r *postRepository) DuePosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+postFrom+`
+		WHERE p.status = 'scheduled' AND p.deleted_at IS NULL AND p.publish_at <= $1
+		ORDER BY p.publish_at
+		LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar posts programados: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) Publish(ctx context.Context, id, verdict string, at time.Time) error {
+	err := r.unpublished(ctx, `
+		UPDATE posts
+		SET status = 'published', publish_at = NULL, verdict = $2, created_at = $3
+		WHERE id = $1`,
+		id, verdict, at,
+	)
+	if err != nil {
+		return fmt.Errorf("error al publicar el post: %w", err)
+	}
+	return nil
+}
+
+// UpdateMedia bloquea la fila del post mientras cambia la galería.
+func (r *postRepository) UpdateMedia(ctx context.Context, id, editorID string, update func(*models.Post) ([]models.Media, error)) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		post, err := scanPost(tx.QueryRow(ctx, `SELECT `+postColumns+postFrom+`
+			WHERE p.id = $1 AND p.deleted_at IS NULL
+			FOR UPDATE OF p`, id))
+		if isNoRows(err) {
+			return fmt.Errorf("error al obtener el post por ID %s: no existe", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		media, err := update(post)
+		if err != nil {
+			return err
+		}
+		edited := *post
+		edited.SetMedia(media)
+
+		now := time.Now()
+		revisions := repositories.NewRevisions(post, &edited, editorID, now)
+		if err := insertRevisions(ctx, tx, revisions); err != nil {
+			return err
+		}
+		edits := post.Revisions
+		editedAt := post.EditedAt
+		if revisions != nil {
+			edits, editedAt = revisions[len(revisions)-1].Number, &now
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE posts SET media = $2, image_url = $3, image_id = $4, updated_at = $5,
+				edited_at = $6, revisions = $7
+			WHERE id = $1`,
+			id, nonNilMedia(edited.Media), edited.ImageURL, edited.ImageID, now, editedAt, edits,
+		)
+		return err
+	})
+}
+
+// Moderate bloquea la fila del subforo antes de contar los fijados, así dos
+// moderadores que fijan a la vez no superan el límite.
+func (r *postRepository) Moderate(ctx context.Context, id string, entry *models.ModerationLogEntry) (*models.Post, bool, error) {
+	var post *models.Post
+	var changed bool
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		post, err = scanPost(tx.QueryRow(ctx, `SELECT `+postColumns+postFrom+`
+			WHERE p.id = $1 AND p.deleted_at IS NULL
+			FOR UPDATE OF p`, id))
+		if isNoRows(err) {
+			return fmt.Errorf("error al obtener el post por ID %s: no existe", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		if entry.Action == models.ActionPin && post.PinnedAt == nil {
+			if _, err := tx.Exec(ctx, `SELECT 1 FROM subforos WHERE id = $1 FOR UPDATE`, post.ForumID); err != nil {
+				return err
+			}
+			var pinned int
+			err := tx.QueryRow(ctx, `
+				SELECT count(*) FROM posts p
+				WHERE p.forum_id = $1 AND p.pinned_at IS NOT NULL AND p.deleted_at IS NULL AND `+published,
+				post.ForumID).Scan(&pinned)
+			if err != nil {
+				return err
+			}
+			if pinned >= models.MaxPinnedPosts {
+				return repositories.ErrPinLimit
+			}
+		}
+		if changed = post.Moderate(entry.Action, entry.CreatedAt); !changed {
+			return nil
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE posts SET pinned_at = $2, locked_at = $3, archived_at = $4, keep_open = $5
+			WHERE id = $1`,
+			id, post.PinnedAt, post.LockedAt, post.ArchivedAt, post.KeepOpen,
+		)
+		if err != nil {
+			return err
+		}
+		entry.ID = repositories.NewDocumentID()
+		entry.ForumID, entry.PostID = post.ForumID, id
+		_, err = tx.Exec(ctx, `
+			INSERT INTO moderation_log (id, forum_id, post_id, action, moderator_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			entry.ID, entry.ForumID, entry.PostID, entry.Action, entry.ModeratorID, entry.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return post, changed, nil
+}
+
+// RerankRising usa la misma fórmula que el trigger posts_rank de
+// 0009_post_ranks.sql, que solo se dispara al cambiar los votos.
+func (r *postRepository) RerankRising(ctx context.Context, since, now time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE posts p
+		SET rising = (p.likes - p.dislikes)::DOUBLE PRECISION
+			/ greatest(extract(epoch FROM $2::timestamptz - p.created_at)::DOUBLE PRECISION / 3600, 1)
+		WHERE p.created_at >= $1 AND p.deleted_at IS NULL AND `+published,
+		since, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error al recalcular rising: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *postRepository) ArchiveBefore(ctx context.Context, forumID string, before, at time.Time) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE posts p SET archived_at = $3
+		WHERE p.forum_id = $1 AND p.created_at < $2 AND p.archived_at IS NULL AND NOT p.keep_open
+			AND p.deleted_at IS NULL AND `+published+`
+		RETURNING p.id`,
+		forumID, before, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al archivar posts: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
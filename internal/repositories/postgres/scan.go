@@ -0,0 +1,117 @@
+// Package postgres implementa los repositorios sobre PostgreSQL. El esquema
+// se versiona con los archivos SQL de migrations/ y se aplica con Migrate.
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// rowScanner es la parte común de pgx.Row y pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// postColumns son las columnas que lee scanPost: el post y su autor (LEFT JOIN
+// con users, por eso las columnas del autor pueden ser NULL).
+const postColumns = `
+	p.id, p.author_id, p.forum_id, p.title, p.content, p.tags, p.is_flagged,
+	p.image_url, p.image_id, p.likes, p.dislikes, p.verdict, p.created_at, p.updated_at,
+	p.deleted_at, p.deleted_by, p.edited_at, p.revisions, p.status, p.publish_at, p.poll, p.media, p.link,
+	p.score, p.hot, p.controversy, p.rising, p.pinned_at, p.locked_at, p.archived_at, p.keep_open,
+	p.crosspost_of,
+	u.uid, u.username, u.profile_photo, u.banner_image, u.email`
+
+const postFrom = `
+	FROM posts p
+	LEFT JOIN users u ON u.uid = p.author_id`
+
+// scanPost lee postColumns y, a continuación, las columnas extra que se pidan.
+func scanPost(row rowScanner, extra ...any) (*models.Post, error) {
+	var p models.Post
+	var updatedAt *time.Time
+	var author nullableUser
+	dest := []any{
+		&p.ID, &p.AuthorID, &p.ForumID, &p.Title, &p.Content, &p.Tags, &p.IsFlagged,
+		&p.ImageURL, &p.ImageID, &p.Likes, &p.Dislikes, &p.Verdict, &p.CreatedAt, &updatedAt,
+		&p.DeletedAt, &p.DeletedBy, &p.EditedAt, &p.Revisions, &p.Status, &p.PublishAt, &p.Poll, &p.Media, &p.Link,
+		&p.Ranks.Score, &p.Ranks.Hot, &p.Ranks.Controversy, &p.Ranks.Rising,
+		&p.PinnedAt, &p.LockedAt, &p.ArchivedAt, &p.KeepOpen,
+		&p.CrosspostOf,
+		&author.UID, &author.Username, &author.ProfilePhoto, &author.BannerImage, &author.Email,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if updatedAt != nil {
+		p.UpdatedAt = *updatedAt
+	}
+	p.Author = author.user()
+	return &p, nil
+}
+
+// nullableUser recibe las columnas de un LEFT JOIN con users.
+type nullableUser struct {
+	UID, Username, ProfilePhoto, BannerImage, Email *string
+}
+
+func (n nullableUser) user() *models.User {
+	if n.UID == nil {
+		return nil
+	}
+	return &models.User{
+		UID:          *n.UID,
+		Username:     deref(n.Username),
+		ProfilePhoto: deref(n.ProfilePhoto),
+		BannerImage:  deref(n.BannerImage),
+		Email:        deref(n.Email),
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// nonNil evita escribir NULL en columnas TEXT[] NOT NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nonNilMedia evita escribir null en la columna media.
+func nonNilMedia(m []models.Media) []models.Media {
+	if m == nil {
+		return []models.Media{}
+	}
+	return m
+}
+
+// pageArgs añade a args el cursor (si lo hay) y el límite de la página, y
+// devuelve la condición sobre las columnas de orden (timeCol, idCol) para
+// seguir después del cursor. desc indica si el listado es descendente.
+func pageArgs(args []any, page models.PageRequest, timeCol, idCol string, desc bool) ([]any, string, string, error) {
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	cond := "true"
+	if cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		args = append(args, cursor.Time, cursor.ID)
+		cond = fmt.Sprintf("(%s, %s) %s ($%d, $%d)", timeCol, idCol, op, len(args)-1, len(args))
+	}
+	args = append(args, page.Size()+1)
+	return args, cond, fmt.Sprintf("LIMIT $%d", len(args)), nil
+}
@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type subforoRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSubforoRepository crea un SubforoRepository sobre PostgreSQL.
+func NewSubforoRepository(db *pgxpool.Pool) repositories.SubforoRepository {
+	return &subforoRepository{db: db}
+}
+
+const subforoColumns = `
+	id, title, description, created_by, created_at, updated_at, categories,
+	moderators, is_active, banner_url, icon_url, members, archive_after_days`
+
+func scanSubforo(row rowScanner) (*models.Subforo, error) {
+	var s models.Subforo
+	err := row.Scan(
+		&s.ForumID, &s.Title, &s.Description, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt, &s.Categories,
+		&s.Moderators, &s.IsActive, &s.BannerURL, &s.IconURL, &s.Members, &s.ArchiveAfterDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *subforoRepository) querySubforos(ctx context.Context, sql string, args ...any) ([]*models.Subforo, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subforos := make([]*models.Subforo, 0)
+	for rows.Next() {
+		s, err := scanSubforo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar subforo: %w", err)
+		}
+		subforos = append(subforos, s)
+	}
+	return subforos, rows.Err()
+}
+
+func (r *subforoRepository) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
+	s, err := scanSubforo(r.db.QueryRow(ctx, `SELECT `+subforoColumns+` FROM subforos WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el subforo por ID %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func (r *subforoRepository) GetSubforosByIDs(ctx context.Context, ids []string) (map[string]*models.Subforo, error) {
+	list, err := r.querySubforos(ctx, `SELECT `+subforoColumns+` FROM subforos WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo subforos: %w", err)
+	}
+	subforos := make(map[string]*models.Subforo, len(list))
+	for _, s := range list {
+		subforos[s.ForumID] = s
+	}
+	return subforos, nil
+}
+
+func (r *subforoRepository) GetAll(ctx context.Context, page models.PageRequest) (models.Page[*models.Subforo], error) {
+	args, after, limit, err := pageArgs(nil, page, "created_at", "id", true)
+	if err != nil {
+		return models.Page[*models.Subforo]{}, err
+	}
+	subforos, err := r.querySubforos(ctx, `SELECT `+subforoColumns+` FROM subforos
+		WHERE is_active AND `+after+`
+		ORDER BY created_at DESC, id DESC `+limit, args...)
+	if err != nil {
+		return models.Page[*models.Subforo]{}, fmt.Errorf("error al iterar subforos: %w", err)
+	}
+	return repositories.NewPage(subforos, page.Size(), repositories.SubforoCursor), nil
+}
+
+func (r *subforoRepository) Create(ctx context.Context, subforo *models.Subforo) error {
+	subforo.CreatedAt = time.Now()
+	id := repositories.NewDocumentID()
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO subforos (id, title, description, created_by, categories, updated_at,
+			moderators, is_active, created_at, banner_url, icon_url, members)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		id, subforo.Title, subforo.Description, subforo.CreatedBy, nonNil(subforo.Categories), subforo.CreatedAt,
+		nonNil(subforo.Moderators), subforo.IsActive, subforo.CreatedAt, subforo.BannerURL, subforo.IconURL,
+		nonNil(subforo.Members),
+	)
+	if err != nil {
+		return err
+	}
+	subforo.ForumID = id
+	return nil
+}
+
+func (r *subforoRepository) Deactivate(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `UPDATE subforos SET is_active = false, updated_at = now() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al desactivar el subforo: %w", err)
+	}
+	return nil
+}
+
+func (r *subforoRepository) EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error) {
+	_, err := r.db.Exec(ctx, `
+		UPDATE subforos
+		SET title = $2, description = $3, categories = $4, banner_url = $5, icon_url = $6,
+			moderators = $7, is_active = $8, updated_at = now()
+		WHERE id = $1`,
+		id, subforo.Title, subforo.Description, nonNil(subforo.Categories), subforo.BannerURL, subforo.IconURL,
+		nonNil(subforo.Moderators), subforo.IsActive,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al editar el subforo: %w", err)
+	}
+
+	updatedSubforo, err := r.GetSubforoByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el subforo actualizado: %w", err)
+	}
+	return updatedSubforo, nil
+}
+
+// JoinSubforo bloquea la fila del subforo para no guardar una fecha nueva si
+// el usuario ya era miembro.
+func (r *subforoRepository) JoinSubforo(ctx context.Context, subforoID, userID string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var member bool
+		err := tx.QueryRow(ctx, `
+			SELECT $2 = ANY(members) FROM subforos WHERE id = $1 FOR UPDATE`, subforoID, userID).Scan(&member)
+		if isNoRows(err) {
+			return fmt.Errorf("subforo %s no existe", subforoID)
+		}
+		if err != nil || member {
+			return err
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(ctx, `
+			UPDATE subforos SET members = array_append(members, $2), updated_at = $3
+			WHERE id = $1`, subforoID, userID, now)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO subforo_joins (id, forum_id, user_id, joined_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`,
+			repositories.SubforoJoinID(subforoID, userID), subforoID, userID, now)
+		return err
+	})
+}
+
+func (r *subforoRepository) LeaveSubforo(ctx context.Context, subforoID, userID string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE subforos SET members = array_remove(members, $2), updated_at = now()
+			WHERE id = $1`, subforoID, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("subforo %s no existe", subforoID)
+		}
+		_, err = tx.Exec(ctx, `
+			DELETE FROM subforo_joins WHERE forum_id = $1 AND user_id = $2`, subforoID, userID)
+		return err
+	})
+}
+
+func (r *subforoRepository) GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error) {
+	return r.querySubforos(ctx, `SELECT `+subforoColumns+` FROM subforos
+		WHERE members @> ARRAY[$1]::text[] OR moderators @> ARRAY[$1]::text[]`, userID)
+}
+
+func (r *subforoRepository) SetArchiveAfter(ctx context.Context, id string, days int) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subforos SET archive_after_days = $2, updated_at = now() WHERE id = $1`, id, days)
+	if err != nil {
+		return fmt.Errorf("error al cambiar el archivado del subforo: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("subforo %s no existe", id)
+	}
+	return nil
+}
+
+func (r *subforoRepository) GetArchiving(ctx context.Context) ([]*models.Subforo, error) {
+	subforos, err := r.querySubforos(ctx, `SELECT `+subforoColumns+` FROM subforos
+		WHERE is_active AND archive_after_days > 0`)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar subforos con archivado: %w", err)
+	}
+	return subforos, nil
+}
+
+func (r *subforoRepository) CountJoins(ctx context.Context, since time.Time) (map[string]int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT forum_id, count(*)::int FROM subforo_joins
+		WHERE joined_at >= $1
+		GROUP BY forum_id`, since)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los ingresos a subforos: %w", err)
+	}
+	defer rows.Close()
+
+	joins := make(map[string]int)
+	for rows.Next() {
+		var forumID string
+		var n int
+		if err := rows.Scan(&forumID, &n); err != nil {
+			return nil, err
+		}
+		joins[forumID] = n
+	}
+	return joins, rows.Err()
+}
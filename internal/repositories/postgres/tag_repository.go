@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type tagRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewTagRepository crea un TagRepository sobre PostgreSQL.
+func NewTagRepository(db *pgxpool.Pool) repositories.TagRepository {
+	return &tagRepository{db: db}
+}
+
+const tagColumns = `name, synonym_of, post_count, created_at`
+
+func scanTag(row rowScanner) (*models.Tag, error) {
+	var t models.Tag
+	err := row.Scan(&t.Name, &t.SynonymOf, &t.PostCount, &t.CreatedAt)
+	return &t, err
+}
+
+// queryTags devuelve los tags de sql, que selecciona tagColumns.
+func (r *tagRepository) queryTags(ctx context.Context, sql string, args ...any) ([]*models.Tag, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]*models.Tag, 0)
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (r *tagRepository) GetTag(ctx context.Context, name string) (*models.Tag, error) {
+	t, err := scanTag(r.db.QueryRow(ctx, `SELECT `+tagColumns+` FROM tags WHERE name = $1`, name))
+	if isNoRows(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el tag %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func (r *tagRepository) GetTags(ctx context.Context, names []string) (map[string]*models.Tag, error) {
+	found, err := r.queryTags(ctx, `SELECT `+tagColumns+` FROM tags WHERE name = ANY($1)`, nonNil(names))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tags: %w", err)
+	}
+	tags := make(map[string]*models.Tag, len(found))
+	for _, t := range found {
+		tags[t.Name] = t
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) SaveTag(ctx context.Context, tag *models.Tag) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO tags (name, synonym_of, post_count, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			synonym_of = EXCLUDED.synonym_of,
+			post_count = EXCLUDED.post_count,
+			created_at = EXCLUDED.created_at`,
+		tag.Name, tag.SynonymOf, tag.PostCount, tag.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al guardar el tag %s: %w", tag.Name, err)
+	}
+	return nil
+}
+
+// likeEscaper escapa los comodines de LIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *tagRepository) SearchTags(ctx context.Context, prefix string, limit int) ([]*models.Tag, error) {
+	tags, err := r.queryTags(ctx, `
+		SELECT `+tagColumns+` FROM tags
+		WHERE name LIKE $1
+		ORDER BY name COLLATE "C"
+		LIMIT $2`,
+		likeEscaper.Replace(prefix)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetSynonyms(ctx context.Context, name string) ([]*models.Tag, error) {
+	tags, err := r.queryTags(ctx, `
+		SELECT `+tagColumns+` FROM tags WHERE synonym_of = $1 ORDER BY name COLLATE "C"`, name)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los sinónimos de %s: %w", name, err)
+	}
+	return tags, nil
+}
+
+// Recount actualiza los tags que existen y crea los que tienen posts en una
+// sola transacción.
+func (r *tagRepository) Recount(ctx context.Context, names []string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		for _, name := range names {
+			_, err := tx.Exec(ctx, `
+				WITH n AS (
+					SELECT count(*)::int AS posts FROM posts p
+					WHERE p.tags @> ARRAY[$1::text] AND p.deleted_at IS NULL AND `+published+`
+				)
+				INSERT INTO tags (name, post_count, created_at)
+				SELECT $1, n.posts, $2 FROM n
+				WHERE n.posts > 0 OR EXISTS (SELECT 1 FROM tags WHERE name = $1)
+				ON CONFLICT (name) DO UPDATE SET post_count = EXCLUDED.post_count`,
+				name, time.Now())
+			if err != nil {
+				return fmt.Errorf("error al actualizar el tag %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *tagRepository) Trending(ctx context.Context, since time.Time, limit int) ([]*models.Tag, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT n.tag, COALESCE(t.synonym_of, ''), COALESCE(t.post_count, 0), COALESCE(t.created_at, 'epoch'), n.posts
+		FROM (
+			SELECT tag, count(*)::int AS posts
+			FROM posts p, unnest(p.tags) AS tag
+			WHERE p.created_at >= $1 AND p.deleted_at IS NULL AND `+published+`
+			GROUP BY tag
+		) n
+		LEFT JOIN tags t ON t.name = n.tag
+		ORDER BY n.posts DESC, n.tag COLLATE "C"
+		LIMIT $2`,
+		since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tags en tendencia: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]*models.Tag, 0)
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.Name, &t.SynonymOf, &t.PostCount, &t.CreatedAt, &t.RecentPosts); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	return tags, rows.Err()
+}
+
+func (r *tagRepository) Follow(ctx context.Context, follow *models.TagFollow) error {
+	follow.ID = repositories.TagFollowID(follow.UserID, follow.Tag)
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO tag_follows (id, user_id, tag, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET created_at = EXCLUDED.created_at`,
+		follow.ID, follow.UserID, follow.Tag, follow.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error al seguir el tag: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) Unfollow(ctx context.Context, userID, tag string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM tag_follows WHERE user_id = $1 AND tag = $2`, userID, tag)
+	if err != nil {
+		return fmt.Errorf("error al dejar de seguir el tag: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) GetFollowed(ctx context.Context, userID string) ([]*models.TagFollow, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, tag, created_at
+		FROM tag_follows WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tags seguidos: %w", err)
+	}
+	defer rows.Close()
+
+	follows := make([]*models.TagFollow, 0)
+	for rows.Next() {
+		var f models.TagFollow
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Tag, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, &f)
+	}
+	return follows, rows.Err()
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type trashRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewTrashRepository crea un TrashRepository sobre PostgreSQL.
+func NewTrashRepository(db *pgxpool.Pool) repositories.TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// trash manda a la papelera la fila de table. Si ya estaba, no la cambia.
+func (r *trashRepository) trash(ctx context.Context, table, id, deletedBy string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE `+table+` SET deleted_at = COALESCE(deleted_at, now()),
+			deleted_by = CASE WHEN deleted_at IS NULL THEN $2 ELSE deleted_by END
+		WHERE id = $1`, id, deletedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s no existe", id)
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashPost(ctx context.Context, postID, deletedBy string) error {
+	if err := r.trash(ctx, "posts", postID, deletedBy); err != nil {
+		return fmt.Errorf("error al eliminar el post: %w", err)
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashComment(ctx context.Context, commentID, deletedBy string) error {
+	if err := r.trash(ctx, "comments", commentID, deletedBy); err != nil {
+		return fmt.Errorf("error al eliminar el comentario: %w", err)
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashedPost(ctx context.Context, postID string) (*models.Post, error) {
+	p, err := scanPost(r.db.QueryRow(ctx,
+		`SELECT `+postColumns+postFrom+` WHERE p.id = $1 AND p.deleted_at IS NOT NULL`, postID))
+	if isNoRows(err) {
+		return nil, repositories.ErrNotInTrash
+	}
+	return p, err
+}
+
+func (r *trashRepository) TrashedComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	c, err := scanComment(r.db.QueryRow(ctx,
+		`SELECT `+commentColumns+` FROM comments c WHERE c.id = $1 AND c.deleted_at IS NOT NULL`, commentID))
+	if isNoRows(err) {
+		return nil, repositories.ErrNotInTrash
+	}
+	return c, err
+}
+
+// restore saca de la papelera la fila de table.
+func (r *trashRepository) restore(ctx context.Context, table, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE `+table+` SET deleted_at = NULL, deleted_by = ''
+		WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotInTrash
+	}
+	return nil
+}
+
+func (r *trashRepository) RestorePost(ctx context.Context, postID string) error {
+	return r.restore(ctx, "posts", postID)
+}
+
+func (r *trashRepository) RestoreComment(ctx context.Context, commentID string) error {
+	return r.restore(ctx, "comments", commentID)
+}
+
+// trashWhere es la condición de la papelera para el filtro, con los
+// argumentos a partir de $1. forumColumn es la expresión con el subforo del
+// elemento.
+func trashWhere(alias, forumColumn string, filter models.TrashFilter) (string, []any) {
+	where := alias + ".deleted_at IS NOT NULL"
+	var args []any
+	add := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			where += fmt.Sprintf(" AND %s = $%d", column, len(args))
+		}
+	}
+	add(alias+".deleted_by", filter.DeletedBy)
+	add(alias+".author_id", filter.AuthorID)
+	add(forumColumn, filter.ForumID)
+	return where, args
+}
+
+func (r *trashRepository) GetTrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error) {
+	where, args := trashWhere("p", "p.forum_id", filter)
+	args, after, limit, err := pageArgs(args, page, "p.deleted_at", "p.id", true)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	posts, err := queryAll(ctx, r.db, `SELECT `+postColumns+postFrom+`
+		WHERE `+where+` AND `+after+`
+		ORDER BY p.deleted_at DESC, p.id DESC `+limit, scanRowPost, args...)
+	if err != nil {
+		return models.Page[*models.Post]{}, fmt.Errorf("error al iterar la papelera de posts: %w", err)
+	}
+	return repositories.NewPage(posts, page.Size(), repositories.TrashedPostCursor), nil
+}
+
+func (r *trashRepository) GetTrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error) {
+	where, args := trashWhere("c", "(SELECT forum_id FROM posts WHERE id = c.post_id)", filter)
+	args, after, limit, err := pageArgs(args, page, "c.deleted_at", "c.id", true)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	comments, err := queryAll(ctx, r.db, `SELECT `+commentColumns+` FROM comments c
+		WHERE `+where+` AND `+after+`
+		ORDER BY c.deleted_at DESC, c.id DESC `+limit, scanRowComment, args...)
+	if err != nil {
+		return models.Page[models.Comment]{}, fmt.Errorf("error al iterar la papelera de comentarios: %w", err)
+	}
+	return repositories.NewPage(comments, page.Size(), repositories.TrashedCommentCursor), nil
+}
+
+func (r *trashRepository) ExpiredPosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	posts, err := queryAll(ctx, r.db, `SELECT `+postColumns+postFrom+`
+		WHERE p.deleted_at < $1
+		ORDER BY p.deleted_at, p.id
+		LIMIT $2`, scanRowPost, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar posts vencidos en la papelera: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *trashRepository) ExpiredComments(ctx context.Context, before time.Time, limit int) ([]models.Comment, error) {
+	comments, err := queryAll(ctx, r.db, `SELECT `+commentColumns+` FROM comments c
+		WHERE c.deleted_at < $1
+		ORDER BY c.deleted_at, c.id
+		LIMIT $2`, scanRowComment, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar comentarios vencidos en la papelera: %w", err)
+	}
+	return comments, nil
+}
+
+func scanRowPost(row rowScanner) (*models.Post, error) { return scanPost(row) }
+
+func scanRowComment(row rowScanner) (models.Comment, error) {
+	c, err := scanComment(row)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	return *c, nil
+}
+
+// queryAll ejecuta la consulta y lee cada fila con scan.
+func queryAll[T any](ctx context.Context, db *pgxpool.Pool, sql string, scan func(rowScanner) (T, error), args ...any) ([]T, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]T, 0)
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *trashRepository) PurgePost(ctx context.Context, postID string) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		for _, sql := range []string{
+			`DELETE FROM votes WHERE target_type = 'comment'
+				AND target_id IN (SELECT id FROM comments WHERE post_id = $1)`,
+			`DELETE FROM votes WHERE target_type = 'post' AND target_id = $1`,
+			`DELETE FROM comments WHERE post_id = $1`,
+			`DELETE FROM saved_posts WHERE post_id = $1`,
+			`DELETE FROM post_revisions WHERE post_id = $1`,
+			`DELETE FROM poll_ballots WHERE post_id = $1`,
+			`DELETE FROM post_embeddings WHERE post_id = $1`,
+			`DELETE FROM posts WHERE id = $1`,
+		} {
+			if _, err := tx.Exec(ctx, sql, postID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error al purgar el post %s: %w", postID, err)
+	}
+	return nil
+}
+
+// commentTree son los IDs del comentario $1 y de sus respuestas en todos los niveles.
+const commentTree = `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM comments WHERE id = $1
+		UNION
+		SELECT c.id FROM comments c JOIN tree t ON c.parent_id = t.id
+	)`
+
+func (r *trashRepository) PurgeComment(ctx context.Context, commentID string) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, commentTree+`
+			DELETE FROM votes WHERE target_type = 'comment' AND target_id IN (SELECT id FROM tree)`,
+			commentID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, commentTree+`
+			DELETE FROM comments WHERE id IN (SELECT id FROM tree)`, commentID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error al purgar el comentario %s: %w", commentID, err)
+	}
+	return nil
+}
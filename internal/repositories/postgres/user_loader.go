@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewUserLoader crea el UserLoader de PostgreSQL, que lee cada lote de
+// usuarios con una sola consulta. Los listados ya traen el autor con un JOIN;
+// el loader se usa donde no hay una consulta a la que unirlo.
+func NewUserLoader(db *pgxpool.Pool) repositories.UserLoader {
+	return repositories.NewCachedUserLoader(func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+		rows, err := db.Query(ctx,
+			`SELECT uid, username, profile_photo, banner_image, email FROM users WHERE uid = ANY($1)`, ids)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo usuarios: %w", err)
+		}
+		defer rows.Close()
+
+		users := make(map[string]*models.User, len(ids))
+		for rows.Next() {
+			var u models.User
+			if err := rows.Scan(&u.UID, &u.Username, &u.ProfilePhoto, &u.BannerImage, &u.Email); err != nil {
+				return nil, fmt.Errorf("error obteniendo usuarios: %w", err)
+			}
+			users[u.UID] = &u
+		}
+		return users, rows.Err()
+	})
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type userRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewUserRepository crea un UserRepository sobre PostgreSQL.
+func NewUserRepository(db *pgxpool.Pool) repositories.UserRepository {
+	return &userRepository{db: db}
+}
+
+// GetUserByID devuelve el perfil como mapa, con las mismas claves que el
+// documento de Firestore. Los campos sin columna propia vienen de "extra".
+func (r *userRepository) GetUserByID(ctx context.Context, userID string) (map[string]interface{}, error) {
+	var u models.User
+	var extra map[string]interface{}
+	var createdAt time.Time
+	var updatedAt *time.Time
+	err := r.db.QueryRow(ctx, `
+		SELECT uid, username, email, profile_photo, banner_image, extra, created_at, updated_at
+		FROM users WHERE uid = $1`, userID,
+	).Scan(&u.UID, &u.Username, &u.Email, &u.ProfilePhoto, &u.BannerImage, &extra, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo usuario: %w", err)
+	}
+
+	data := make(map[string]interface{}, len(extra)+7)
+	for k, v := range extra {
+		data[k] = v
+	}
+	data["uid"] = u.UID
+	data["username"] = u.Username
+	data["email"] = u.Email
+	data["profile_photo"] = u.ProfilePhoto
+	data["banner_image"] = u.BannerImage
+	data["createdAt"] = createdAt
+	if updatedAt != nil {
+		data["updatedAt"] = *updatedAt
+	}
+	return data, nil
+}
+
+// upsertUser inserta un usuario o reemplaza el perfil si ya existe.
+const upsertUser = `
+	INSERT INTO users (uid, username, email, profile_photo, banner_image, extra, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (uid) DO UPDATE SET
+		username = EXCLUDED.username, email = EXCLUDED.email,
+		profile_photo = EXCLUDED.profile_photo, banner_image = EXCLUDED.banner_image,
+		extra = EXCLUDED.extra, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at`
+
+// userArgs reparte el documento del usuario entre las columnas de upsertUser;
+// lo que no tiene columna propia va a "extra".
+func userArgs(userID string, userData map[string]interface{}) []any {
+	extra := make(map[string]interface{})
+	createdAt := time.Now()
+	var updatedAt *time.Time
+	columns := map[string]string{}
+	for k, v := range userData {
+		switch k {
+		case "uid":
+		case "username", "email", "profile_photo", "banner_image":
+			s, _ := v.(string)
+			columns[k] = s
+		case "createdAt":
+			if t, ok := v.(time.Time); ok {
+				createdAt = t
+			}
+		case "updatedAt":
+			if t, ok := v.(time.Time); ok {
+				updatedAt = &t
+			}
+		default:
+			extra[k] = v
+		}
+	}
+	return []any{
+		userID, columns["username"], columns["email"], columns["profile_photo"], columns["banner_image"],
+		extra, createdAt, updatedAt,
+	}
+}
+
+// CreateUser guarda el perfil completo, reemplazando el anterior si existía.
+func (r *userRepository) CreateUser(ctx context.Context, userID string, userData map[string]interface{}) error {
+	_, err := r.db.Exec(ctx, upsertUser, userArgs(userID, userData)...)
+	return err
+}
+
+func (r *userRepository) EditUserProfile(ctx context.Context, userID string, userData models.User) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE users SET profile_photo = $2, username = $3, banner_image = $4
+		WHERE uid = $1`,
+		userID, userData.ProfilePhoto, userData.Username, userData.BannerImage,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("usuario %s no existe", userID)
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateUserEmail(ctx context.Context, userID, email string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE users SET email = $2, updated_at = now() WHERE uid = $1`, userID, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("usuario %s no existe", userID)
+	}
+	return nil
+}
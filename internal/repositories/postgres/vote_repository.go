@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type voteRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewVoteRepository crea un VoteRepository sobre PostgreSQL.
+func NewVoteRepository(db *pgxpool.Pool) repositories.VoteRepository {
+	return &voteRepository{db: db}
+}
+
+const voteColumns = `id, user_id, target_type, target_id, post_id, comment_id, type, created_at, updated_at`
+
+func scanVote(row rowScanner) (*models.Vote, error) {
+	var v models.Vote
+	if err := row.Scan(&v.VoteID, &v.UserID, &v.TargetType, &v.TargetID, &v.PostID, &v.CommentID,
+		&v.Type, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *voteRepository) queryVotes(ctx context.Context, sql string, args ...any) ([]models.Vote, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []models.Vote
+	for rows.Next() {
+		v, err := scanVote(rows)
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, *v)
+	}
+	return votes, rows.Err()
+}
+
+func (r *voteRepository) GetVoteByID(ctx context.Context, voteID string) (*models.Vote, error) {
+	return scanVote(r.db.QueryRow(ctx, `SELECT `+voteColumns+` FROM votes WHERE id = $1`, voteID))
+}
+
+func (r *voteRepository) GetVotesByTarget(ctx context.Context, target models.VoteTarget, targetID string) ([]models.Vote, error) {
+	return r.queryVotes(ctx, `SELECT `+voteColumns+` FROM votes WHERE target_type = $1 AND target_id = $2`,
+		target, targetID)
+}
+
+func (r *voteRepository) GetUserVote(ctx context.Context, userID string, target models.VoteTarget, targetID string) (*models.Vote, error) {
+	v, err := scanVote(r.db.QueryRow(ctx,
+		`SELECT `+voteColumns+` FROM votes WHERE target_type = $1 AND target_id = $2 AND user_id = $3`,
+		target, targetID, userID))
+	if isNoRows(err) {
+		// No existe voto previo
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error buscando voto previo: %w", err)
+	}
+	return v, nil
+}
+
+func (r *voteRepository) GetUserVotes(ctx context.Context, userID string, target models.VoteTarget, targetIDs []string) (map[string]*models.Vote, error) {
+	votes, err := r.queryVotes(ctx,
+		`SELECT `+voteColumns+` FROM votes WHERE user_id = $1 AND target_type = $2 AND target_id = ANY($3)`,
+		userID, target, targetIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error buscando votos del usuario: %w", err)
+	}
+	byTarget := make(map[string]*models.Vote, len(votes))
+	for i := range votes {
+		byTarget[votes[i].TargetID] = &votes[i]
+	}
+	return byTarget, nil
+}
+
+// React bloquea la fila del destino, así que las reacciones a un mismo post o
+// comentario se aplican de a una.
+func (r *voteRepository) React(ctx context.Context, userID string, target models.VoteTarget, targetID, reaction string) (*models.Vote, error) {
+	table := repositories.TargetCollection(target)
+	id := repositories.VoteID(target, targetID, userID)
+	var result *models.Vote
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		result = nil
+		var postID string
+		err := tx.QueryRow(ctx, `SELECT `+postIDColumn(target)+` FROM `+table+` WHERE id = $1 FOR UPDATE`, targetID).Scan(&postID)
+		if isNoRows(err) {
+			return fmt.Errorf("%s %s no existe", target, targetID)
+		}
+		if err != nil {
+			return err
+		}
+
+		prev, err := scanVote(tx.QueryRow(ctx, `SELECT `+voteColumns+` FROM votes WHERE id = $1`, id))
+		if isNoRows(err) {
+			prev = nil
+		} else if err != nil {
+			return err
+		}
+
+		prevType := ""
+		if prev != nil {
+			prevType = string(prev.Type)
+		}
+		next := reaction
+		if next == "none" {
+			next = ""
+		}
+
+		if likes, dislikes := repositories.ReactionDelta(prevType, next); likes != 0 || dislikes != 0 {
+			if _, err := tx.Exec(ctx,
+				`UPDATE `+table+` SET likes = likes + $2, dislikes = dislikes + $3 WHERE id = $1`,
+				targetID, likes, dislikes); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			_, err := tx.Exec(ctx, `DELETE FROM votes WHERE id = $1`, id)
+			return err
+		}
+
+		now := time.Now()
+		v := &models.Vote{
+			VoteID:     id,
+			UserID:     userID,
+			TargetType: target,
+			TargetID:   targetID,
+			PostID:     postID,
+			Type:       models.VoteType(next),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if target == models.TargetComment {
+			v.CommentID = targetID
+		}
+		if prev != nil {
+			v.CreatedAt = prev.CreatedAt
+			if prevType == next {
+				v.UpdatedAt = prev.UpdatedAt
+			}
+		}
+		result = v
+		_, err = tx.Exec(ctx, `
+			INSERT INTO votes (`+voteColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (id) DO UPDATE SET type = EXCLUDED.type, updated_at = EXCLUDED.updated_at`,
+			v.VoteID, v.UserID, v.TargetType, v.TargetID, v.PostID, v.CommentID, v.Type, v.CreatedAt, v.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// postIDColumn es la columna con el ID del post en la tabla del destino.
+func postIDColumn(target models.VoteTarget) string {
+	if target == models.TargetComment {
+		return "post_id"
+	}
+	return "id"
+}
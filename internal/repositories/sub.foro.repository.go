@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -10,16 +11,48 @@ import (
 	"google.golang.org/api/iterator"
 )
 
-type SubforoRepository struct {
+type SubforoRepository interface {
+	GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error)
+	// GetSubforosByIDs devuelve los subforos encontrados indexados por ID,
+	// leídos por lote. Los IDs vacíos, repetidos o inexistentes no son un
+	// error: simplemente no están en el mapa.
+	GetSubforosByIDs(ctx context.Context, ids []string) (map[string]*models.Subforo, error)
+	GetAll(ctx context.Context, page models.PageRequest) (models.Page[*models.Subforo], error)
+	Create(ctx context.Context, subforo *models.Subforo) error
+	Deactivate(ctx context.Context, id string) error
+	EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error)
+	// JoinSubforo agrega a userID a los miembros y guarda cuándo se unió.
+	// Unirse dos veces no es un error y conserva la fecha de la primera.
+	JoinSubforo(ctx context.Context, subforoID, userID string) error
+	// LeaveSubforo saca a userID de los miembros y borra cuándo se unió.
+	LeaveSubforo(ctx context.Context, subforoID, userID string) error
+	GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error)
+	// SetArchiveAfter cambia la antigüedad en días a partir de la cual se
+	// archivan los posts del subforo; 0 desactiva el archivado automático.
+	SetArchiveAfter(ctx context.Context, id string, days int) error
+	// GetArchiving devuelve los subforos activos con archivado automático.
+	GetArchiving(ctx context.Context) ([]*models.Subforo, error)
+	// CountJoins devuelve, por ID de subforo, cuántos de sus miembros
+	// actuales se unieron desde since.
+	CountJoins(ctx context.Context, since time.Time) (map[string]int, error)
+}
+
+// SubforoJoinID es el ID de que userID se una a forumID; que sea fijo hace
+// que unirse sea idempotente.
+func SubforoJoinID(forumID, userID string) string {
+	return forumID + "_" + userID
+}
+
+type subforoRepository struct {
 	db *firestore.Client
 }
 
-func NewSubforoRepository(db *firestore.Client) *SubforoRepository {
-	return &SubforoRepository{db: db}
+func NewSubforoRepository(db *firestore.Client) SubforoRepository {
+	return &subforoRepository{db: db}
 }
 
 // GetSubforoByID obtiene un subforo por su ID
-func (r *SubforoRepository) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
+func (r *subforoRepository) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
 	doc, err := r.db.Collection("subforos").Doc(id).Get(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener el subforo por ID %s: %w", id, err)
@@ -34,13 +67,54 @@ func (r *SubforoRepository) GetSubforoByID(ctx context.Context, id string) (*mod
 	return &subforo, nil
 }
 
-// GetAll obtiene todos los subforos ordenados por fecha de creación
-func (r *SubforoRepository) GetAll(ctx context.Context) ([]*models.Subforo, error) {
-	iter := r.db.
+// GetSubforosByIDs lee los subforos con una llamada GetAll por lote.
+func (r *subforoRepository) GetSubforosByIDs(ctx context.Context, ids []string) (map[string]*models.Subforo, error) {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	ids = slices.DeleteFunc(ids, func(id string) bool { return id == "" })
+	subforos := make(map[string]*models.Subforo, len(ids))
+	for start := 0; start < len(ids); start += maxBatchGet {
+		end := min(start+maxBatchGet, len(ids))
+		refs := make([]*firestore.DocumentRef, 0, end-start)
+		for _, id := range ids[start:end] {
+			refs = append(refs, r.db.Collection("subforos").Doc(id))
+		}
+
+		docs, err := r.db.GetAll(ctx, refs)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo subforos: %w", err)
+		}
+		for _, doc := range docs {
+			if !doc.Exists() {
+				continue
+			}
+			var subforo models.Subforo
+			if err := doc.DataTo(&subforo); err != nil {
+				return nil, fmt.Errorf("error al decodificar el subforo %s: %w", doc.Ref.ID, err)
+			}
+			subforo.ForumID = doc.Ref.ID
+			subforos[subforo.ForumID] = &subforo
+		}
+	}
+	return subforos, nil
+}
+
+// GetAll pagina los subforos activos ordenados por fecha de creación
+func (r *subforoRepository) GetAll(ctx context.Context, page models.PageRequest) (models.Page[*models.Subforo], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Subforo]{}, err
+	}
+	size := page.Size()
+
+	q := r.db.
 		Collection("subforos").
 		Where("is_active", "==", true).
 		OrderBy("created_at", firestore.Desc).
-		Documents(ctx)
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	iter := q.Limit(size + 1).Documents(ctx)
 	defer iter.Stop()
 
 	subforos := make([]*models.Subforo, 0)
@@ -51,23 +125,23 @@ func (r *SubforoRepository) GetAll(ctx context.Context) ([]*models.Subforo, erro
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error al iterar subforos: %w", err)
+			return models.Page[*models.Subforo]{}, fmt.Errorf("error al iterar subforos: %w", err)
 		}
 
 		var subforo models.Subforo
 		if err := doc.DataTo(&subforo); err != nil {
-			return nil, fmt.Errorf("error al decodificar subforo: %w", err)
+			return models.Page[*models.Subforo]{}, fmt.Errorf("error al decodificar subforo: %w", err)
 		}
 		subforo.ForumID = doc.Ref.ID
 
 		subforos = append(subforos, &subforo)
 	}
 
-	return subforos, nil
+	return NewPage(subforos, size, SubforoCursor), nil
 }
 
 // Create crea un nuevo subforo
-func (r *SubforoRepository) Create(ctx context.Context, subforo *models.Subforo) error {
+func (r *subforoRepository) Create(ctx context.Context, subforo *models.Subforo) error {
 	subforo.CreatedAt = time.Now()
 	doc, _, err := r.db.Collection("subforos").Add(ctx, map[string]interface{}{
 		"title":       subforo.Title,
@@ -89,7 +163,7 @@ func (r *SubforoRepository) Create(ctx context.Context, subforo *models.Subforo)
 	return nil
 }
 
-func (r *SubforoRepository) Deactivate(ctx context.Context, id string) error {
+func (r *subforoRepository) Deactivate(ctx context.Context, id string) error {
 
 	_, err := r.db.Collection("subforos").Doc(id).Set(ctx, map[string]interface{}{
 		"is_active":  false,
@@ -102,7 +176,7 @@ func (r *SubforoRepository) Deactivate(ctx context.Context, id string) error {
 }
 
 // edita un subforo existente
-func (r *SubforoRepository) EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error) {
+func (r *subforoRepository) EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error) {
 	_, err := r.db.Collection("subforos").Doc(id).Set(ctx, map[string]interface{}{
 		"title":       subforo.Title,
 		"description": subforo.Description,
@@ -126,25 +200,51 @@ func (r *SubforoRepository) EditSubforo(ctx context.Context, id string, subforo
 	return updatedSubforo, nil
 }
 
-// Unir usuario a subforo
-func (r *SubforoRepository) JoinSubforo(ctx context.Context, subforoID, userID string) error {
-	_, err := r.db.Collection("subforos").Doc(subforoID).Update(ctx, []firestore.Update{
-		{Path: "members", Value: firestore.ArrayUnion(userID)},
-		{Path: "updated_at", Value: time.Now()},
+// Unir usuario a subforo. La transacción lee los miembros para no guardar
+// una fecha nueva si ya lo era.
+func (r *subforoRepository) JoinSubforo(ctx context.Context, subforoID, userID string) error {
+	ref := r.db.Collection("subforos").Doc(subforoID)
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var subforo models.Subforo
+		if err := doc.DataTo(&subforo); err != nil {
+			return fmt.Errorf("error al decodificar el subforo: %w", err)
+		}
+		if slices.Contains(subforo.Members, userID) {
+			return nil
+		}
+
+		now := time.Now()
+		err = tx.Update(ref, []firestore.Update{
+			{Path: "members", Value: firestore.ArrayUnion(userID)},
+			{Path: "updated_at", Value: now},
+		})
+		if err != nil {
+			return err
+		}
+		join := &models.SubforoJoin{ForumID: subforoID, UserID: userID, JoinedAt: now}
+		return tx.Set(r.db.Collection("subforoJoins").Doc(SubforoJoinID(subforoID, userID)), join)
 	})
-	return err
 }
 
 // Salir de subforo
-func (r *SubforoRepository) LeaveSubforo(ctx context.Context, subforoID, userID string) error {
-	_, err := r.db.Collection("subforos").Doc(subforoID).Update(ctx, []firestore.Update{
-		{Path: "members", Value: firestore.ArrayRemove(userID)},
-		{Path: "updated_at", Value: time.Now()},
+func (r *subforoRepository) LeaveSubforo(ctx context.Context, subforoID, userID string) error {
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		err := tx.Update(r.db.Collection("subforos").Doc(subforoID), []firestore.Update{
+			{Path: "members", Value: firestore.ArrayRemove(userID)},
+			{Path: "updated_at", Value: time.Now()},
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Delete(r.db.Collection("subforoJoins").Doc(SubforoJoinID(subforoID, userID)))
 	})
-	return err
 }
 
-func (r *SubforoRepository) GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error) {
+func (r *subforoRepository) GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error) {
 	// Crear un mapa para evitar duplicados
 	subforosMap := make(map[string]*models.Subforo)
 
@@ -198,3 +298,66 @@ func (r *SubforoRepository) GetSubforosByUserID(ctx context.Context, userID stri
 
 	return subforos, nil
 }
+
+func (r *subforoRepository) SetArchiveAfter(ctx context.Context, id string, days int) error {
+	_, err := r.db.Collection("subforos").Doc(id).Update(ctx, []firestore.Update{
+		{Path: "archive_after_days", Value: days},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("error al cambiar el archivado del subforo: %w", err)
+	}
+	return nil
+}
+
+func (r *subforoRepository) GetArchiving(ctx context.Context) ([]*models.Subforo, error) {
+	iter := r.db.Collection("subforos").
+		Where("is_active", "==", true).
+		Where("archive_after_days", ">", 0).
+		Documents(ctx)
+	defer iter.Stop()
+
+	subforos := make([]*models.Subforo, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar subforos con archivado: %w", err)
+		}
+		var subforo models.Subforo
+		if err := doc.DataTo(&subforo); err != nil {
+			return nil, fmt.Errorf("error al decodificar subforo: %w", err)
+		}
+		subforo.ForumID = doc.Ref.ID
+		subforos = append(subforos, &subforo)
+	}
+	return subforos, nil
+}
+
+// CountJoins lee solo el subforo de cada ingreso desde since.
+func (r *subforoRepository) CountJoins(ctx context.Context, since time.Time) (map[string]int, error) {
+	iter := r.db.Collection("subforoJoins").
+		Where("joined_at", ">=", since).
+		Select("forum_id").
+		Documents(ctx)
+	defer iter.Stop()
+
+	joins := make(map[string]int)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al contar los ingresos a subforos: %w", err)
+		}
+		var join models.SubforoJoin
+		if err := doc.DataTo(&join); err != nil {
+			return nil, fmt.Errorf("error al decodificar el ingreso %s: %w", doc.Ref.ID, err)
+		}
+		joins[join.ForumID]++
+	}
+	return joins, nil
+}
@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TagRepository guarda el registro de tags y los tags que sigue cada
+// usuario. Los nombres que recibe ya están normalizados (ver
+// models.NormalizeTag).
+type TagRepository interface {
+	// GetTag devuelve el tag, o nil si no existe.
+	GetTag(ctx context.Context, name string) (*models.Tag, error)
+	// GetTags devuelve los tags de names que existen, por nombre.
+	GetTags(ctx context.Context, names []string) (map[string]*models.Tag, error)
+	// SaveTag crea o reemplaza el tag.
+	SaveTag(ctx context.Context, tag *models.Tag) error
+	// SearchTags devuelve hasta limit tags, sinónimos incluidos, cuyo nombre
+	// empieza con prefix, en orden alfabético.
+	SearchTags(ctx context.Context, prefix string, limit int) ([]*models.Tag, error)
+	// GetSynonyms devuelve los sinónimos del tag en orden alfabético.
+	GetSynonyms(ctx context.Context, name string) ([]*models.Tag, error)
+	// Recount guarda en cada tag de names la cantidad de posts publicados,
+	// fuera de la papelera, que lo tienen. Crea los tags que no existen y
+	// tienen posts.
+	Recount(ctx context.Context, names []string) error
+	// Trending devuelve hasta limit tags de los posts publicados desde since,
+	// del que más posts tiene al que menos, con RecentPosts.
+	Trending(ctx context.Context, since time.Time, limit int) ([]*models.Tag, error)
+
+	// Follow guarda que el usuario sigue el tag. Seguir dos veces el mismo
+	// tag no es un error.
+	Follow(ctx context.Context, follow *models.TagFollow) error
+	// Unfollow deja de seguir el tag, si lo seguía.
+	Unfollow(ctx context.Context, userID, tag string) error
+	// GetFollowed devuelve los tags que sigue userID, los más recientes
+	// primero.
+	GetFollowed(ctx context.Context, userID string) ([]*models.TagFollow, error)
+}
+
+// TagFollowID es el ID de que userID siga tag; que sea fijo hace que seguir
+// sea idempotente.
+func TagFollowID(userID, tag string) string {
+	return userID + "_" + tag
+}
+
+// LegacyTags normaliza los tags de un post anterior al registro de tags, que
+// los guardaba como se escribieron.
+func LegacyTags(p *models.Post) {
+	if p.Tags != nil {
+		p.Tags = models.NormalizeTags(p.Tags)
+	}
+}
+
+// SortTrending ordena los tags por RecentPosts, de más a menos, y por nombre
+// a igual cantidad.
+func SortTrending(tags []*models.Tag) {
+	slices.SortFunc(tags, func(a, b *models.Tag) int {
+		if c := cmp.Compare(b.RecentPosts, a.RecentPosts); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+type tagRepository struct {
+	db *firestore.Client
+}
+
+// NewTagRepository crea un TagRepository sobre Firestore.
+func NewTagRepository(db *firestore.Client) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) GetTag(ctx context.Context, name string) (*models.Tag, error) {
+	doc, err := r.db.Collection("tags").Doc(name).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el tag %s: %w", name, err)
+	}
+	var t models.Tag
+	if err := doc.DataTo(&t); err != nil {
+		return nil, fmt.Errorf("error al decodificar el tag %s: %w", name, err)
+	}
+	return &t, nil
+}
+
+func (r *tagRepository) GetTags(ctx context.Context, names []string) (map[string]*models.Tag, error) {
+	tags := make(map[string]*models.Tag, len(names))
+	if len(names) == 0 {
+		return tags, nil
+	}
+	refs := make([]*firestore.DocumentRef, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, r.db.Collection("tags").Doc(name))
+	}
+	docs, err := r.db.GetAll(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tags: %w", err)
+	}
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var t models.Tag
+		if err := doc.DataTo(&t); err != nil {
+			return nil, fmt.Errorf("error al decodificar el tag %s: %w", doc.Ref.ID, err)
+		}
+		tags[doc.Ref.ID] = &t
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) SaveTag(ctx context.Context, tag *models.Tag) error {
+	if _, err := r.db.Collection("tags").Doc(tag.Name).Set(ctx, tag); err != nil {
+		return fmt.Errorf("error al guardar el tag %s: %w", tag.Name, err)
+	}
+	return nil
+}
+
+// readTags lee los tags de iter.
+func readTags(iter *firestore.DocumentIterator) ([]*models.Tag, error) {
+	defer iter.Stop()
+
+	tags := make([]*models.Tag, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var t models.Tag
+		if err := doc.DataTo(&t); err != nil {
+			return nil, fmt.Errorf("error al decodificar el tag %s: %w", doc.Ref.ID, err)
+		}
+		tags = append(tags, &t)
+	}
+	return tags, nil
+}
+
+// SearchTags busca por rango: los nombres que empiezan con prefix están entre
+// prefix y prefix seguido de \uf8ff, mayor que cualquier letra.
+func (r *tagRepository) SearchTags(ctx context.Context, prefix string, limit int) ([]*models.Tag, error) {
+	tags, err := readTags(r.db.Collection("tags").
+		Where("name", ">=", prefix).
+		Where("name", "<=", prefix+"\uf8ff").
+		OrderBy("name", firestore.Asc).
+		Limit(limit).
+		Documents(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetSynonyms(ctx context.Context, name string) ([]*models.Tag, error) {
+	tags, err := readTags(r.db.Collection("tags").Where("synonym_of", "==", name).Documents(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los sinónimos de %s: %w", name, err)
+	}
+	slices.SortFunc(tags, func(a, b *models.Tag) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return tags, nil
+}
+
+// Recount cuenta los posts de cada tag sin leerlos y guarda el resultado en
+// una transacción por tag.
+func (r *tagRepository) Recount(ctx context.Context, names []string) error {
+	for _, name := range names {
+		n, err := count(ctx, r.db.Collection("posts").
+			Where("tags", "array-contains", name).
+			Where("status", "==", models.PostPublished).
+			Where("deleted_at", "==", nil))
+		if err != nil {
+			return fmt.Errorf("error al contar los posts del tag %s: %w", name, err)
+		}
+
+		ref := r.db.Collection("tags").Doc(name)
+		err = r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			doc, err := tx.Get(ref)
+			if status.Code(err) == codes.NotFound {
+				if n == 0 {
+					return nil
+				}
+				return tx.Create(ref, &models.Tag{Name: name, PostCount: n, CreatedAt: time.Now()})
+			}
+			if err != nil {
+				return err
+			}
+			return tx.Update(doc.Ref, []firestore.Update{{Path: "post_count", Value: n}})
+		})
+		if err != nil {
+			return fmt.Errorf("error al actualizar el tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Trending lee solo los tags de los posts de la ventana y los cuenta en
+// memoria.
+func (r *tagRepository) Trending(ctx context.Context, since time.Time, limit int) ([]*models.Tag, error) {
+	q := r.db.Collection("posts").
+		Where("status", "==", models.PostPublished).
+		Where("deleted_at", "==", nil)
+	if !since.IsZero() {
+		q = q.Where("created_at", ">=", since)
+	}
+	iter := q.Select("tags").Documents(ctx)
+	defer iter.Stop()
+
+	counts := make(map[string]int)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener los tags en tendencia: %w", err)
+		}
+		var p models.Post
+		if err := doc.DataTo(&p); err != nil {
+			return nil, fmt.Errorf("error al decodificar post: %w", err)
+		}
+		for _, tag := range p.Tags {
+			counts[tag]++
+		}
+	}
+	return r.trendingTags(ctx, counts, limit)
+}
+
+// trendingTags arma los limit tags con más posts de counts, con los datos del
+// registro de los que existen.
+func (r *tagRepository) trendingTags(ctx context.Context, counts map[string]int, limit int) ([]*models.Tag, error) {
+	trending := make([]*models.Tag, 0, len(counts))
+	for name, n := range counts {
+		trending = append(trending, &models.Tag{Name: name, RecentPosts: n})
+	}
+	SortTrending(trending)
+	trending = trending[:min(limit, len(trending))]
+
+	names := make([]string, 0, len(trending))
+	for _, t := range trending {
+		names = append(names, t.Name)
+	}
+	registered, err := r.GetTags(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range trending {
+		if reg, ok := registered[t.Name]; ok {
+			reg.RecentPosts = t.RecentPosts
+			trending[i] = reg
+		}
+	}
+	return trending, nil
+}
+
+// Follow usa Set para no pisar con un error el seguimiento que ya existía;
+// la fecha pasa a ser la del último.
+func (r *tagRepository) Follow(ctx context.Context, follow *models.TagFollow) error {
+	follow.ID = TagFollowID(follow.UserID, follow.Tag)
+	if _, err := r.db.Collection("tagFollows").Doc(follow.ID).Set(ctx, follow); err != nil {
+		return fmt.Errorf("error al seguir el tag: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) Unfollow(ctx context.Context, userID, tag string) error {
+	if _, err := r.db.Collection("tagFollows").Doc(TagFollowID(userID, tag)).Delete(ctx); err != nil {
+		return fmt.Errorf("error al dejar de seguir el tag: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) GetFollowed(ctx context.Context, userID string) ([]*models.TagFollow, error) {
+	iter := r.db.Collection("tagFollows").
+		Where("user_id", "==", userID).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	follows := make([]*models.TagFollow, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener los tags seguidos: %w", err)
+		}
+		var f models.TagFollow
+		if err := doc.DataTo(&f); err != nil {
+			return nil, fmt.Errorf("error al decodificar el tag seguido: %w", err)
+		}
+		f.ID = doc.Ref.ID
+		follows = append(follows, &f)
+	}
+	return follows, nil
+}
@@ -0,0 +1,406 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotInTrash indica que el post o comentario no existe o no está en la papelera.
+var ErrNotInTrash = errors.New("no está en la papelera")
+
+// TrashRepository maneja la papelera de posts y comentarios. Un elemento
+// eliminado conserva sus datos con DeletedAt y DeletedBy, deja de aparecer en
+// los listados y se puede restaurar hasta que se purga.
+type TrashRepository interface {
+	// TrashPost manda el post a la papelera; si ya estaba, no hace nada.
+	TrashPost(ctx context.Context, postID, deletedBy string) error
+	TrashComment(ctx context.Context, commentID, deletedBy string) error
+	// TrashedPost devuelve el post si está en la papelera, o ErrNotInTrash.
+	TrashedPost(ctx context.Context, postID string) (*models.Post, error)
+	TrashedComment(ctx context.Context, commentID string) (*models.Comment, error)
+	RestorePost(ctx context.Context, postID string) error
+	RestoreComment(ctx context.Context, commentID string) error
+	// GetTrashedPosts pagina los posts de la papelera que cumplen el filtro,
+	// del eliminado más recientemente al más antiguo.
+	GetTrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error)
+	GetTrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error)
+	// ExpiredPosts devuelve hasta limit posts eliminados antes de before, los
+	// más antiguos primero.
+	ExpiredPosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error)
+	ExpiredComments(ctx context.Context, before time.Time, limit int) ([]models.Comment, error)
+	// PurgePost borra el post para siempre junto con sus comentarios, los
+	// votos al post y a esos comentarios, los guardados y las revisiones del post.
+	PurgePost(ctx context.Context, postID string) error
+	// PurgeComment borra el comentario para siempre junto con sus respuestas y
+	// los votos a todos ellos.
+	PurgeComment(ctx context.Context, commentID string) error
+}
+
+// TrashedPostCursor es el cursor de la papelera de posts, ordenada por fecha
+// de eliminación.
+func TrashedPostCursor(p *models.Post) Cursor {
+	return Cursor{Time: *p.DeletedAt, ID: p.ID}
+}
+
+// TrashedCommentCursor es el cursor de la papelera de comentarios.
+func TrashedCommentCursor(c models.Comment) Cursor {
+	return Cursor{Time: *c.DeletedAt, ID: c.CommentID}
+}
+
+type trashRepository struct {
+	db *firestore.Client
+}
+
+// NewTrashRepository crea un TrashRepository sobre Firestore.
+func NewTrashRepository(db *firestore.Client) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// setDeleted cambia los campos de eliminación del documento en una
+// transacción. trash indica si se manda a la papelera o se restaura; extra
+// agrega campos a la actualización al mandarlo a la papelera.
+func (r *trashRepository) setDeleted(ctx context.Context, ref *firestore.DocumentRef, atField, byField string, trash bool, deletedBy string, extra ...firestore.Update) error {
+	return r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			if trash {
+				return fmt.Errorf("%s no existe", ref.Path)
+			}
+			return ErrNotInTrash
+		}
+		if err != nil {
+			return err
+		}
+		deleted := doc.Data()[atField] != nil
+		if trash == deleted {
+			if trash {
+				return nil
+			}
+			return ErrNotInTrash
+		}
+		if !trash {
+			return tx.Update(ref, []firestore.Update{
+				{Path: atField, Value: nil},
+				{Path: byField, Value: firestore.Delete},
+			})
+		}
+		return tx.Update(ref, append([]firestore.Update{
+			{Path: atField, Value: time.Now()},
+			{Path: byField, Value: deletedBy},
+		}, extra...))
+	})
+}
+
+func (r *trashRepository) TrashPost(ctx context.Context, postID, deletedBy string) error {
+	err := r.setDeleted(ctx, r.db.Collection("posts").Doc(postID), "deleted_at", "deleted_by", true, deletedBy)
+	if err != nil {
+		return fmt.Errorf("error al eliminar el post: %w", err)
+	}
+	return nil
+}
+
+// TrashComment guarda también el subforo del post en deleted_forum_id, porque
+// los comentarios no lo tienen y la papelera se filtra por subforo.
+func (r *trashRepository) TrashComment(ctx context.Context, commentID, deletedBy string) error {
+	ref := r.db.Collection("comments").Doc(commentID)
+	var forumID string
+	if doc, err := ref.Get(ctx); err == nil {
+		if postID, _ := doc.Data()["post_id"].(string); postID != "" {
+			if post, err := r.db.Collection("posts").Doc(postID).Get(ctx); err == nil {
+				forumID, _ = post.Data()["forum_id"].(string)
+			}
+		}
+	}
+	err := r.setDeleted(ctx, ref, "deleted_at", "deleted_by", true, deletedBy,
+		firestore.Update{Path: "deleted_forum_id", Value: forumID})
+	if err != nil {
+		return fmt.Errorf("error al eliminar el comentario: %w", err)
+	}
+	return nil
+}
+
+func (r *trashRepository) RestorePost(ctx context.Context, postID string) error {
+	return r.setDeleted(ctx, r.db.Collection("posts").Doc(postID), "deleted_at", "deleted_by", false, "")
+}
+
+func (r *trashRepository) RestoreComment(ctx context.Context, commentID string) error {
+	return r.setDeleted(ctx, r.db.Collection("comments").Doc(commentID), "deleted_at", "deleted_by", false, "",
+		firestore.Update{Path: "deleted_forum_id", Value: firestore.Delete})
+}
+
+func postFromDoc(doc *firestore.DocumentSnapshot) (*models.Post, error) {
+	var p models.Post
+	if err := doc.DataTo(&p); err != nil {
+		return nil, fmt.Errorf("error al decodificar post: %w", err)
+	}
+	p.ID = doc.Ref.ID
+	return &p, nil
+}
+
+func commentFromDoc(doc *firestore.DocumentSnapshot) (models.Comment, error) {
+	var c models.Comment
+	if err := doc.DataTo(&c); err != nil {
+		return c, fmt.Errorf("error al decodificar comentario: %w", err)
+	}
+	c.CommentID = doc.Ref.ID
+	return c, nil
+}
+
+func (r *trashRepository) TrashedPost(ctx context.Context, postID string) (*models.Post, error) {
+	doc, err := r.db.Collection("posts").Doc(postID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotInTrash
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := postFromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if p.DeletedAt == nil {
+		return nil, ErrNotInTrash
+	}
+	return p, nil
+}
+
+func (r *trashRepository) TrashedComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	doc, err := r.db.Collection("comments").Doc(commentID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotInTrash
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := commentFromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if c.DeletedAt == nil {
+		return nil, ErrNotInTrash
+	}
+	return &c, nil
+}
+
+// trashQuery ordena la papelera de la colección, con los campos indicados,
+// por fecha de eliminación descendente y la pagina desde el cursor.
+func trashQuery(col *firestore.CollectionRef, atField, byField, authorField, forumField string, filter models.TrashFilter, cursor *Cursor, size int) firestore.Query {
+	q := col.Where(atField, ">", time.Time{})
+	for _, f := range [][2]string{{byField, filter.DeletedBy}, {authorField, filter.AuthorID}, {forumField, filter.ForumID}} {
+		if f[1] != "" {
+			q = q.Where(f[0], "==", f[1])
+		}
+	}
+	q = q.OrderBy(atField, firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc)
+	if cursor != nil {
+		q = q.StartAfter(cursor.Time, cursor.ID)
+	}
+	return q.Limit(size + 1)
+}
+
+// queryAll decodifica todos los documentos de la consulta.
+func queryAll[T any](ctx context.Context, q firestore.Query, decode func(*firestore.DocumentSnapshot) (T, error)) ([]T, error) {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	items := make([]T, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, err := decode(doc)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+func (r *trashRepository) GetTrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	q := trashQuery(r.db.Collection("posts"), "deleted_at", "deleted_by", "author_id", "forum_id", filter, cursor, page.Size())
+	posts, err := queryAll(ctx, q, postFromDoc)
+	if err != nil {
+		return models.Page[*models.Post]{}, fmt.Errorf("error al iterar la papelera de posts: %w", err)
+	}
+	return NewPage(posts, page.Size(), TrashedPostCursor), nil
+}
+
+func (r *trashRepository) GetTrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error) {
+	cursor, err := DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	q := trashQuery(r.db.Collection("comments"), "deleted_at", "deleted_by", "author_id", "deleted_forum_id", filter, cursor, page.Size())
+	comments, err := queryAll(ctx, q, commentFromDoc)
+	if err != nil {
+		return models.Page[models.Comment]{}, fmt.Errorf("error al iterar la papelera de comentarios: %w", err)
+	}
+	return NewPage(comments, page.Size(), TrashedCommentCursor), nil
+}
+
+func (r *trashRepository) ExpiredPosts(ctx context.Context, before time.Time, limit int) ([]*models.Post, error) {
+	q := r.db.Collection("posts").
+		Where("deleted_at", "<", before).
+		OrderBy("deleted_at", firestore.Asc).
+		Limit(limit)
+	posts, err := queryAll(ctx, q, postFromDoc)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar posts vencidos en la papelera: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *trashRepository) ExpiredComments(ctx context.Context, before time.Time, limit int) ([]models.Comment, error) {
+	q := r.db.Collection("comments").
+		Where("deleted_at", "<", before).
+		OrderBy("deleted_at", firestore.Asc).
+		Limit(limit)
+	comments, err := queryAll(ctx, q, commentFromDoc)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar comentarios vencidos en la papelera: %w", err)
+	}
+	return comments, nil
+}
+
+// refs devuelve las referencias de los documentos de la consulta.
+func refs(ctx context.Context, q firestore.Query) ([]*firestore.DocumentRef, error) {
+	return queryAll(ctx, q, func(doc *firestore.DocumentSnapshot) (*firestore.DocumentRef, error) {
+		return doc.Ref, nil
+	})
+}
+
+// commentVoteRefs devuelve los votos a los comentarios indicados.
+func (r *trashRepository) commentVoteRefs(ctx context.Context, commentIDs []string) ([]*firestore.DocumentRef, error) {
+	var all []*firestore.DocumentRef
+	// Los filtros "in" admiten hasta 30 valores
+	for start := 0; start < len(commentIDs); start += 30 {
+		end := min(start+30, len(commentIDs))
+		found, err := refs(ctx, r.db.Collection("votes").
+			Where("target_type", "==", string(models.TargetComment)).
+			Where("target_id", "in", commentIDs[start:end]))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, found...)
+	}
+	return all, nil
+}
+
+// deleteRefs borra los documentos con un BulkWriter. Los documentos
+// repetidos se borran una sola vez.
+func (r *trashRepository) deleteRefs(ctx context.Context, docs []*firestore.DocumentRef) error {
+	bw := r.db.BulkWriter(ctx)
+	seen := make(map[string]bool, len(docs))
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, ref := range docs {
+		if seen[ref.Path] {
+			continue
+		}
+		seen[ref.Path] = true
+		job, err := bw.Delete(ref)
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("error al borrar %s: %w", ref.Path, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgePost borra primero los votos, guardados, revisiones, votos de la
+// encuesta, comentarios y el vector, y al final el post: si se corta a la mitad, el post
+// sigue en la papelera y el próximo intento termina el trabajo.
+func (r *trashRepository) PurgePost(ctx context.Context, postID string) error {
+	comments, err := refs(ctx, r.db.Collection("comments").Where("post_id", "==", postID))
+	if err != nil {
+		return fmt.Errorf("error al buscar los comentarios del post %s: %w", postID, err)
+	}
+	commentIDs := make([]string, 0, len(comments))
+	for _, ref := range comments {
+		commentIDs = append(commentIDs, ref.ID)
+	}
+
+	var docs []*firestore.DocumentRef
+	for _, q := range []firestore.Query{
+		r.db.Collection("votes").Where("post_id", "==", postID),
+		r.db.Collection("userSavedPosts").Where("post_id", "==", postID),
+		r.db.Collection("postRevisions").Where("post_id", "==", postID),
+		r.db.Collection("pollBallots").Where("post_id", "==", postID),
+	} {
+		found, err := refs(ctx, q)
+		if err != nil {
+			return fmt.Errorf("error al purgar el post %s: %w", postID, err)
+		}
+		docs = append(docs, found...)
+	}
+	votes, err := r.commentVoteRefs(ctx, commentIDs)
+	if err != nil {
+		return fmt.Errorf("error al purgar el post %s: %w", postID, err)
+	}
+	docs = append(append(docs, votes...), comments...)
+	docs = append(docs, r.db.Collection("postEmbeddings").Doc(postID))
+
+	if err := r.deleteRefs(ctx, docs); err != nil {
+		return fmt.Errorf("error al purgar el post %s: %w", postID, err)
+	}
+	if _, err := r.db.Collection("posts").Doc(postID).Delete(ctx); err != nil {
+		return fmt.Errorf("error al purgar el post %s: %w", postID, err)
+	}
+	return nil
+}
+
+// PurgeComment borra primero las respuestas y los votos, y al final el
+// comentario, igual que PurgePost.
+func (r *trashRepository) PurgeComment(ctx context.Context, commentID string) error {
+	// Respuestas en todos los niveles
+	ids := []string{commentID}
+	var replies []*firestore.DocumentRef
+	for pending := []string{commentID}; len(pending) > 0; {
+		parent := pending[0]
+		pending = pending[1:]
+		found, err := refs(ctx, r.db.Collection("comments").Where("parent_id", "==", parent))
+		if err != nil {
+			return fmt.Errorf("error al buscar las respuestas de %s: %w", parent, err)
+		}
+		for _, ref := range found {
+			ids = append(ids, ref.ID)
+			pending = append(pending, ref.ID)
+		}
+		replies = append(replies, found...)
+	}
+
+	votes, err := r.commentVoteRefs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error al purgar el comentario %s: %w", commentID, err)
+	}
+	if err := r.deleteRefs(ctx, append(votes, replies...)); err != nil {
+		return fmt.Errorf("error al purgar el comentario %s: %w", commentID, err)
+	}
+	if _, err := r.db.Collection("comments").Doc(commentID).Delete(ctx); err != nil {
+		return fmt.Errorf("error al purgar el comentario %s: %w", commentID, err)
+	}
+	return nil
+}
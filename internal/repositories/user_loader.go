@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/firestore"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+// UserLoader carga usuarios por lote para completar los autores de posts y
+// comentarios. Si el contexto trae una caché de request (WithUserCache), cada
+// usuario se lee como mucho una vez por request.
+type UserLoader interface {
+	// LoadUsers devuelve los usuarios encontrados indexados por ID. Los IDs
+	// vacíos, repetidos o inexistentes no son un error: simplemente no están en
+	// el mapa.
+	LoadUsers(ctx context.Context, ids []string) (map[string]*models.User, error)
+}
+
+// LoadUser carga un único usuario con loader. Devuelve nil si no existe.
+func LoadUser(ctx context.Context, loader UserLoader, id string) (*models.User, error) {
+	users, err := loader.LoadUsers(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return users[id], nil
+}
+
+type userCacheKey struct{}
+
+// userCache guarda los usuarios ya leídos durante un request. Un valor nil
+// indica que el usuario no existe, para no volver a buscarlo.
+type userCache struct {
+	mu    sync.Mutex
+	users map[string]*models.User
+}
+
+// WithUserCache devuelve un contexto con una caché de usuarios vacía. Se crea
+// una por request (ver middleware.UserCache).
+func WithUserCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userCacheKey{}, &userCache{users: make(map[string]*models.User)})
+}
+
+func userCacheFrom(ctx context.Context) *userCache {
+	cache, _ := ctx.Value(userCacheKey{}).(*userCache)
+	return cache
+}
+
+// CachedUserLoader añade la caché de request a una función que lee usuarios
+// por lote. fetch recibe solo los IDs que no están en caché, sin repetidos.
+type CachedUserLoader struct {
+	fetch func(ctx context.Context, ids []string) (map[string]*models.User, error)
+}
+
+// NewCachedUserLoader crea un UserLoader a partir de la lectura por lote de un
+// backend.
+func NewCachedUserLoader(fetch func(ctx context.Context, ids []string) (map[string]*models.User, error)) *CachedUserLoader {
+	return &CachedUserLoader{fetch: fetch}
+}
+
+func (l *CachedUserLoader) LoadUsers(ctx context.Context, ids []string) (map[string]*models.User, error) {
+	users := make(map[string]*models.User, len(ids))
+	cache := userCacheFrom(ctx)
+
+	missing := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	if cache != nil {
+		cache.mu.Lock()
+	}
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if cache != nil {
+			if u, ok := cache.users[id]; ok {
+				if u != nil {
+					users[id] = u
+				}
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+	if cache != nil {
+		cache.mu.Unlock()
+	}
+	if len(missing) == 0 {
+		return users, nil
+	}
+
+	fetched, err := l.fetch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+	}
+	for _, id := range missing {
+		u := fetched[id]
+		if u != nil {
+			users[id] = u
+		}
+		if cache != nil {
+			cache.users[id] = u
+		}
+	}
+	return users, nil
+}
+
+// maxBatchGet es el máximo de documentos que se piden en cada GetAll.
+const maxBatchGet = 300
+
+// NewUserLoader crea el UserLoader de Firestore, que lee los usuarios de la
+// colección "users" con una sola llamada GetAll por lote.
+func NewUserLoader(db *firestore.Client) UserLoader {
+	return NewCachedUserLoader(func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+		users := make(map[string]*models.User, len(ids))
+		for start := 0; start < len(ids); start += maxBatchGet {
+			end := min(start+maxBatchGet, len(ids))
+			refs := make([]*firestore.DocumentRef, 0, end-start)
+			for _, id := range ids[start:end] {
+				refs = append(refs, db.Collection("users").Doc(id))
+			}
+
+			docs, err := db.GetAll(ctx, refs)
+			if err != nil {
+				return nil, fmt.Errorf("error obteniendo usuarios: %w", err)
+			}
+			for _, doc := range docs {
+				if !doc.Exists() {
+					continue
+				}
+				var user models.User
+				if err := doc.DataTo(&user); err != nil {
+					fmt.Printf("Error al decodificar usuario %s: %v\n", doc.Ref.ID, err)
+					continue
+				}
+				user.UID = doc.Ref.ID
+				users[user.UID] = &user
+			}
+		}
+		return users, nil
+	})
+}
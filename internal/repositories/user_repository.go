@@ -3,23 +3,31 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
 )
 
-// UserRepository se encarga de interactuar con la colección "users" en Firestore.
-type UserRepository struct {
+// UserRepository se encarga de interactuar con la colección "users".
+type UserRepository interface {
+	GetUserByID(ctx context.Context, userID string) (map[string]interface{}, error)
+	CreateUser(ctx context.Context, userID string, userData map[string]interface{}) error
+	EditUserProfile(ctx context.Context, userID string, userData models.User) error
+	UpdateUserEmail(ctx context.Context, userID, email string) error
+}
+
+type userRepository struct {
 	db *firestore.Client
 }
 
 // NewUserRepository crea una nueva instancia del repositorio.
-func NewUserRepository(db *firestore.Client) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *firestore.Client) UserRepository {
+	return &userRepository{db: db}
 }
 
 // GetUserByID busca y retorna el documento del usuario por ID.
-func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (map[string]interface{}, error) {
+func (r *userRepository) GetUserByID(ctx context.Context, userID string) (map[string]interface{}, error) {
 	doc, err := r.db.Collection("users").Doc(userID).Get(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo usuario: %w", err)
@@ -28,13 +36,13 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (map[st
 }
 
 // CreateUser permite crear un usuario.
-func (r *UserRepository) CreateUser(ctx context.Context, userID string, userData map[string]interface{}) error {
+func (r *userRepository) CreateUser(ctx context.Context, userID string, userData map[string]interface{}) error {
 	_, err := r.db.Collection("users").Doc(userID).Set(ctx, userData)
 	return err
 }
 
 // EditUserProfile permite editar la foto de perfil de un usuario.
-func (r *UserRepository) EditUserProfile(ctx context.Context, userID string, userData models.User) error {
+func (r *userRepository) EditUserProfile(ctx context.Context, userID string, userData models.User) error {
 	_, err := r.db.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
 		{
 			Path:  "profile_photo",
@@ -51,3 +59,18 @@ func (r *UserRepository) EditUserProfile(ctx context.Context, userID string, use
 	})
 	return err
 }
+
+// UpdateUserEmail actualiza el email guardado en el perfil del usuario.
+func (r *userRepository) UpdateUserEmail(ctx context.Context, userID, email string) error {
+	_, err := r.db.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{
+			Path:  "email",
+			Value: email,
+		},
+		{
+			Path:  "updatedAt",
+			Value: time.Now(),
+		},
+	})
+	return err
+}
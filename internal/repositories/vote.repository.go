@@ -8,44 +8,67 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// VoteRepository guarda los votos a posts y comentarios. Cada usuario tiene a
+// lo sumo un voto por destino, y los contadores de likes y dislikes del
+// destino se actualizan junto con el voto.
 type VoteRepository interface {
-	CreateVote(ctx context.Context, vote *models.Vote) error
 	GetVoteByID(ctx context.Context, voteID string) (*models.Vote, error)
-	GetVotesByPostID(ctx context.Context, postID string) ([]models.Vote, error)
-	GetVotesByCommentID(ctx context.Context, commentID string) ([]models.Vote, error)
-	DeleteVote(ctx context.Context, voteID string) error
-	AddReaction(ctx context.Context, id, reactionType string) error
-	AddVote(ctx context.Context, v *models.Vote) error
-	GetUserVote(ctx context.Context, userID, postID string) (*models.Vote, error)
+	GetVotesByTarget(ctx context.Context, target models.VoteTarget, targetID string) ([]models.Vote, error)
+	GetUserVote(ctx context.Context, userID string, target models.VoteTarget, targetID string) (*models.Vote, error)
+	// GetUserVotes devuelve los votos del usuario a los destinos indicados,
+	// por ID de destino. Los destinos sin voto no aparecen.
+	GetUserVotes(ctx context.Context, userID string, target models.VoteTarget, targetIDs []string) (map[string]*models.Vote, error)
+	// React deja el voto del usuario al destino en reaction ("like",
+	// "dislike" o "none" para quitarlo) y ajusta los contadores del destino,
+	// todo de forma atómica. Repetir la misma reacción no cambia nada.
+	// Devuelve el voto resultante, o nil si quedó sin voto.
+	React(ctx context.Context, userID string, target models.VoteTarget, targetID, reaction string) (*models.Vote, error)
 }
 
-type voteRepository struct {
-	db *firestore.Client
+// VoteID es el ID del documento del voto de un usuario a un post o
+// comentario. Al ser siempre el mismo, un usuario no puede tener dos votos al
+// mismo destino.
+func VoteID(target models.VoteTarget, targetID, userID string) string {
+	return string(target) + ":" + targetID + ":" + userID
 }
 
-func NewVoteRepository(db *firestore.Client) VoteRepository {
-	return &voteRepository{db: db}
-}
-
-func (r *voteRepository) CreateVote(ctx context.Context, vote *models.Vote) error {
-	// Validar que solo uno de postId o commentId esté presente
-	if vote.PostID == "" && vote.CommentID == "" {
-		return fmt.Errorf("un voto debe estar asociado a un post o un comentario")
+// ReactionDelta devuelve cuánto cambian los likes y dislikes del destino al
+// pasar el voto de un usuario de prev a next ("like", "dislike" o vacío si no
+// hay voto).
+func ReactionDelta(prev, next string) (likes, dislikes int) {
+	switch prev {
+	case string(models.Like):
+		likes--
+	case string(models.Dislike):
+		dislikes--
 	}
-
-	if vote.VoteID == "" {
-		docRef := r.db.Collection("votes").NewDoc() // Firestore genera un nuevo ID automáticamente
-		vote.VoteID = docRef.ID                     // Asignamos el ID generado por Firestore al voto
+	switch next {
+	case string(models.Like):
+		likes++
+	case string(models.Dislike):
+		dislikes++
 	}
+	return likes, dislikes
+}
 
-	vote.CreatedAt = time.Now()
+// TargetCollection es la colección donde están los documentos del tipo de destino.
+func TargetCollection(target models.VoteTarget) string {
+	if target == models.TargetComment {
+		return "comments"
+	}
+	return "posts"
+}
 
-	// Crear o actualizar el voto en la colección de Firestore
-	_, err := r.db.Collection("votes").Doc(vote.VoteID).Set(ctx, vote)
+type voteRepository struct {
+	db *firestore.Client
+}
 
-	return err
+func NewVoteRepository(db *firestore.Client) VoteRepository {
+	return &voteRepository{db: db}
 }
 
 func (r *voteRepository) GetVoteByID(ctx context.Context, voteID string) (*models.Vote, error) {
@@ -59,13 +82,18 @@ func (r *voteRepository) GetVoteByID(ctx context.Context, voteID string) (*model
 	if err != nil {
 		return nil, err
 	}
+	vote.VoteID = doc.Ref.ID
 
 	return &vote, nil
 }
 
-func (r *voteRepository) GetVotesByPostID(ctx context.Context, postID string) ([]models.Vote, error) {
+func (r *voteRepository) GetVotesByTarget(ctx context.Context, target models.VoteTarget, targetID string) ([]models.Vote, error) {
 	var votes []models.Vote
-	iter := r.db.Collection("votes").Where("postId", "==", postID).Documents(ctx)
+	iter := r.db.Collection("votes").
+		Where("target_type", "==", target).
+		Where("target_id", "==", targetID).
+		Documents(ctx)
+	defer iter.Stop()
 
 	for {
 		doc, err := iter.Next()
@@ -81,93 +109,203 @@ func (r *voteRepository) GetVotesByPostID(ctx context.Context, postID string) ([
 		if err != nil {
 			return nil, err
 		}
+		vote.VoteID = doc.Ref.ID
 
 		votes = append(votes, vote)
 	}
 	return votes, nil
 }
 
-func (r *voteRepository) GetVotesByCommentID(ctx context.Context, commentID string) ([]models.Vote, error) {
-	var votes []models.Vote
-	iter := r.db.Collection("votes").Where("commentId", "==", commentID).Documents(ctx)
+// legacyVotes busca votos del usuario al destino guardados con ID aleatorio,
+// antes de usar VoteID. Se buscan por post_id o comment_id, que tienen
+// también los votos anteriores a target_type.
+func (r *voteRepository) legacyVotes(userID string, target models.VoteTarget, targetID string) firestore.Query {
+	field := "post_id"
+	if target == models.TargetComment {
+		field = "comment_id"
+	}
+	return r.db.Collection("votes").
+		Where("user_id", "==", userID).
+		Where(field, "==", targetID)
+}
 
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
+// isVoteTo indica si el voto va al destino (descarta, al buscar por post_id,
+// los votos a comentarios del post).
+func isVoteTo(v *models.Vote, target models.VoteTarget, targetID string) bool {
+	t, id := v.Target()
+	return t == target && id == targetID
+}
+
+func (r *voteRepository) GetUserVote(ctx context.Context, userID string, target models.VoteTarget, targetID string) (*models.Vote, error) {
+	snap, err := r.db.Collection("votes").Doc(VoteID(target, targetID, userID)).Get(ctx)
+	if err == nil {
+		var v models.Vote
+		if err := snap.DataTo(&v); err != nil {
+			return nil, fmt.Errorf("error decodificando voto previo: %w", err)
 		}
-		if err != nil {
-			return nil, err
+		v.VoteID = snap.Ref.ID
+		return &v, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("error buscando voto previo: %w", err)
+	}
+
+	docs, err := r.legacyVotes(userID, target, targetID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error buscando voto previo: %w", err)
+	}
+	for _, doc := range docs {
+		var v models.Vote
+		if err := doc.DataTo(&v); err != nil {
+			return nil, fmt.Errorf("error decodificando voto previo: %w", err)
+		}
+		if isVoteTo(&v, target, targetID) {
+			v.VoteID = doc.Ref.ID
+			return &v, nil
+		}
+	}
+	// No existe voto previo
+	return nil, nil
+}
+
+func (r *voteRepository) GetUserVotes(ctx context.Context, userID string, target models.VoteTarget, targetIDs []string) (map[string]*models.Vote, error) {
+	votes := make(map[string]*models.Vote)
+	for start := 0; start < len(targetIDs); start += maxBatchGet {
+		end := min(start+maxBatchGet, len(targetIDs))
+		refs := make([]*firestore.DocumentRef, 0, end-start)
+		for _, id := range targetIDs[start:end] {
+			refs = append(refs, r.db.Collection("votes").Doc(VoteID(target, id, userID)))
 		}
 
-		var vote models.Vote
-		err = doc.DataTo(&vote)
+		docs, err := r.db.GetAll(ctx, refs)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error al obtener votos del usuario: %w", err)
+		}
+		for _, doc := range docs {
+			if !doc.Exists() {
+				continue
+			}
+			var v models.Vote
+			if err := doc.DataTo(&v); err != nil {
+				return nil, fmt.Errorf("error decodificando voto %s: %w", doc.Ref.ID, err)
+			}
+			v.VoteID = doc.Ref.ID
+			votes[v.TargetID] = &v
 		}
-
-		votes = append(votes, vote)
 	}
 	return votes, nil
 }
 
-func (r *voteRepository) DeleteVote(ctx context.Context, voteID string) error {
-	_, err := r.db.Collection("votes").Doc(voteID).Delete(ctx)
-	return err
-}
+func (r *voteRepository) React(ctx context.Context, userID string, target models.VoteTarget, targetID, reaction string) (*models.Vote, error) {
+	targetRef := r.db.Collection(TargetCollection(target)).Doc(targetID)
+	voteRef := r.db.Collection("votes").Doc(VoteID(target, targetID, userID))
 
-func (r *voteRepository) AddReaction(ctx context.Context, id, reactionType string) error {
-    field := "likes"
-    if reactionType == "dislike" {
-        field = "dislikes"
-    }
-    _, err := r.db.Collection("posts").Doc(id).Update(ctx, []firestore.Update{
-        {Path: field, Value: firestore.Increment(1)},
-    })
-    return err
-}
+	var result *models.Vote
+	err := r.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		result = nil
+		targetDoc, err := tx.Get(targetRef)
+		if err != nil {
+			return fmt.Errorf("%s %s no existe: %w", target, targetID, err)
+		}
 
-func (r *voteRepository) AddVote(ctx context.Context, v *models.Vote) error {
-    now := time.Now()
-    v.CreatedAt = now
-    v.UpdatedAt = now
-
-    doc, _, err := r.db.Collection("votes").Add(ctx, map[string]interface{}{
-        "user_id":    v.UserID,
-        "post_id":    v.PostID,
-        "comment_id": v.CommentID,
-        "type":       v.Type,
-        "created_at": v.CreatedAt,
-        "updated_at": v.UpdatedAt,
-    })
-    if err != nil {
-        return fmt.Errorf("error añadiendo vote: %w", err)
-    }
-    v.VoteID = doc.ID
-    return nil
-}
+		var prev *models.Vote
+		doc, err := tx.Get(voteRef)
+		switch {
+		case err == nil:
+			prev = &models.Vote{}
+			if err := doc.DataTo(prev); err != nil {
+				return err
+			}
+		case status.Code(err) != codes.NotFound:
+			return err
+		}
+
+		// Votos guardados con ID aleatorio: se reemplazan por el de ID fijo
+		legacy, err := tx.Documents(r.legacyVotes(userID, target, targetID)).GetAll()
+		if err != nil {
+			return err
+		}
+		var stale []*firestore.DocumentRef
+		for _, d := range legacy {
+			if d.Ref.ID == voteRef.ID {
+				continue
+			}
+			var v models.Vote
+			if err := d.DataTo(&v); err != nil || !isVoteTo(&v, target, targetID) {
+				continue
+			}
+			if prev == nil {
+				prev = &v
+			}
+			stale = append(stale, d.Ref)
+		}
 
-func (r *voteRepository) GetUserVote(ctx context.Context, userID, postID string) (*models.Vote, error) {
-    iter := r.db.Collection("votes").
-        Where("user_id", "==", userID).
-        Where("post_id", "==", postID).
-        Limit(1).
-        Documents(ctx)
-    defer iter.Stop()
-
-    doc, err := iter.Next()
-    if err == iterator.Done {
-        // No existe voto previo
-        return nil, nil
-    }
-    if err != nil {
-        return nil, fmt.Errorf("error buscando voto previo: %w", err)
-    }
-
-    var v models.Vote
-    if err := doc.DataTo(&v); err != nil {
-        return nil, fmt.Errorf("error decodificando voto previo: %w", err)
-    }
-    v.VoteID = doc.Ref.ID
-    return &v, nil
-}
\ No newline at end of file
+		prevType := ""
+		if prev != nil {
+			prevType = string(prev.Type)
+		}
+		next := reaction
+		if next == "none" {
+			next = ""
+		}
+
+		for _, ref := range stale {
+			if err := tx.Delete(ref); err != nil {
+				return err
+			}
+		}
+
+		if likes, dislikes := ReactionDelta(prevType, next); likes != 0 || dislikes != 0 {
+			updates := []firestore.Update{
+				{Path: "likes", Value: firestore.Increment(likes)},
+				{Path: "dislikes", Value: firestore.Increment(dislikes)},
+			}
+			if target == models.TargetPost {
+				ranks, err := rerank(targetDoc, likes, dislikes)
+				if err != nil {
+					return err
+				}
+				updates = append(updates, firestore.Update{Path: "ranks", Value: ranks})
+			}
+			if err := tx.Update(targetRef, updates); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			if prev != nil {
+				return tx.Delete(voteRef)
+			}
+			return nil
+		}
+
+		now := time.Now()
+		v := &models.Vote{
+			VoteID:     voteRef.ID,
+			UserID:     userID,
+			TargetType: target,
+			TargetID:   targetID,
+			PostID:     targetID,
+			Type:       models.VoteType(next),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if target == models.TargetComment {
+			v.CommentID = targetID
+			v.PostID, _ = targetDoc.Data()["post_id"].(string)
+		}
+		result = v
+		if prev != nil {
+			v.CreatedAt = prev.CreatedAt
+			if prevType == next && len(stale) == 0 {
+				v.UpdatedAt = prev.UpdatedAt
+				return nil
+			}
+		}
+		return tx.Set(voteRef, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
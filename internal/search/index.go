@@ -0,0 +1,308 @@
+// Package search mantiene un índice de texto completo (bleve) de los posts,
+// comentarios y subforos visibles. El índice es local: se guarda en un
+// directorio o en memoria y no depende de ningún servicio externo.
+//
+// Los textos se analizan en español: se quitan las palabras vacías, se
+// reducen las palabras a su raíz (canciones → cancion) y se ignoran las
+// tildes, así que "canción" y "cancion" encuentran lo mismo.
+//
+// Para la búsqueda semántica el paquete mantiene además los vectores de los
+// posts (Vectors), que se comparan por similitud coseno.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// Campos de los documentos del índice.
+const (
+	fieldType      = "type"
+	fieldPostID    = "post_id"
+	fieldForumID   = "forum_id"
+	fieldAuthorID  = "author_id"
+	fieldTags      = "tags"
+	fieldTitle     = "title"
+	fieldContent   = "content"
+	fieldCreatedAt = "created_at"
+)
+
+// storedFields son los campos que se devuelven con cada resultado.
+var storedFields = []string{fieldType, fieldPostID, fieldForumID, fieldAuthorID, fieldTitle, fieldCreatedAt}
+
+// Index es el índice de búsqueda. Se puede usar desde varias goroutines.
+type Index struct {
+	idx bleve.Index
+}
+
+// newMapping indexa title y content con el analizador de español y el resto
+// de los campos como valores exactos.
+func newMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = es.AnalyzerName
+	text.IncludeTermVectors = true
+
+	exact := bleve.NewKeywordFieldMapping()
+	exact.IncludeInAll = false
+
+	date := bleve.NewDateTimeFieldMapping()
+	date.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt(fieldTitle, text)
+	doc.AddFieldMappingsAt(fieldContent, text)
+	for _, name := range []string{fieldType, fieldPostID, fieldForumID, fieldAuthorID, fieldTags} {
+		doc.AddFieldMappingsAt(name, exact)
+	}
+	doc.AddFieldMappingsAt(fieldCreatedAt, date)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = keyword.Name
+	return m
+}
+
+// Open abre el índice del directorio path y lo crea si no existe; created
+// indica si se creó, y por lo tanto está vacío. Con path vacío el índice vive
+// en memoria y se pierde al terminar.
+func Open(path string) (index *Index, created bool, err error) {
+	if path == "" {
+		idx, err := bleve.NewMemOnly(newMapping())
+		if err != nil {
+			return nil, false, fmt.Errorf("error al crear el índice de búsqueda: %w", err)
+		}
+		return &Index{idx: idx}, true, nil
+	}
+
+	idx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		idx, err = bleve.New(path, newMapping())
+		created = true
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error al abrir el índice de búsqueda %s: %w", path, err)
+	}
+	return &Index{idx: idx}, created, nil
+}
+
+// Create crea un índice vacío en path, que no debe existir.
+func Create(path string) (*Index, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("el índice de búsqueda %s ya existe", path)
+	}
+	idx, err := bleve.New(path, newMapping())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el índice de búsqueda %s: %w", path, err)
+	}
+	return &Index{idx: idx}, nil
+}
+
+func (i *Index) Close() error {
+	return i.idx.Close()
+}
+
+// docID es el ID del documento en el índice: los IDs de posts, comentarios y
+// subforos pueden repetirse entre colecciones.
+func docID(t models.SearchType, id string) string {
+	return string(t) + ":" + id
+}
+
+func postDoc(p *models.Post) map[string]interface{} {
+	return map[string]interface{}{
+		fieldType:      string(models.SearchPost),
+		fieldForumID:   p.ForumID,
+		fieldAuthorID:  p.AuthorID,
+		fieldTags:      models.NormalizeTags(p.Tags),
+		fieldTitle:     p.Title,
+		fieldContent:   p.Content,
+		fieldCreatedAt: p.CreatedAt,
+	}
+}
+
+// commentDoc lleva el subforo del post para poder filtrar por subforo.
+func commentDoc(c *models.Comment, forumID string) map[string]interface{} {
+	return map[string]interface{}{
+		fieldType:      string(models.SearchComment),
+		fieldPostID:    c.PostID,
+		fieldForumID:   forumID,
+		fieldAuthorID:  c.AuthorID,
+		fieldContent:   c.Content,
+		fieldCreatedAt: c.CreatedAt,
+	}
+}
+
+// subforoDoc indexa las categorías como tags.
+func subforoDoc(s *models.Subforo) map[string]interface{} {
+	return map[string]interface{}{
+		fieldType:      string(models.SearchSubforo),
+		fieldForumID:   s.ForumID,
+		fieldAuthorID:  s.CreatedBy,
+		fieldTags:      models.NormalizeTags(s.Categories),
+		fieldTitle:     s.Title,
+		fieldContent:   s.Description,
+		fieldCreatedAt: s.CreatedAt,
+	}
+}
+
+func (i *Index) IndexPost(p *models.Post) error {
+	return i.idx.Index(docID(models.SearchPost, p.ID), postDoc(p))
+}
+
+func (i *Index) IndexComment(c *models.Comment, forumID string) error {
+	return i.idx.Index(docID(models.SearchComment, c.CommentID), commentDoc(c, forumID))
+}
+
+func (i *Index) IndexSubforo(s *models.Subforo) error {
+	return i.idx.Index(docID(models.SearchSubforo, s.ForumID), subforoDoc(s))
+}
+
+func (i *Index) Delete(t models.SearchType, id string) error {
+	return i.idx.Delete(docID(t, id))
+}
+
+// DeletePost quita del índice el post y todos sus comentarios.
+func (i *Index) DeletePost(postID string) error {
+	q := bleve.NewTermQuery(postID)
+	q.SetField(fieldPostID)
+	req := bleve.NewSearchRequestOptions(q, 1000, 0, false)
+
+	batch := i.idx.NewBatch()
+	batch.Delete(docID(models.SearchPost, postID))
+	for {
+		res, err := i.idx.Search(req)
+		if err != nil {
+			return err
+		}
+		for _, hit := range res.Hits {
+			batch.Delete(hit.ID)
+		}
+		if len(res.Hits) < req.Size {
+			break
+		}
+		req.From += req.Size
+	}
+	return i.idx.Batch(batch)
+}
+
+// Search busca con los filtros de q, de mayor a menor relevancia. El cursor
+// de la página es la cantidad de resultados ya devueltos.
+func (i *Index) Search(q models.SearchQuery, page models.PageRequest) (models.Page[*models.SearchHit], error) {
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.SearchHit]{}, err
+	}
+	from := 0
+	if cursor != nil {
+		if cursor.Score < 0 {
+			return models.Page[*models.SearchHit]{}, models.ErrInvalidCursor
+		}
+		from = cursor.Score
+	}
+	size := page.Size()
+
+	req := bleve.NewSearchRequestOptions(buildQuery(q), size+1, from, false)
+	req.Fields = storedFields
+	req.Highlight = bleve.NewHighlightWithStyle("html")
+	req.Highlight.AddField(fieldTitle)
+	req.Highlight.AddField(fieldContent)
+	req.SortBy([]string{"-_score", "_id"})
+
+	res, err := i.idx.Search(req)
+	if err != nil {
+		return models.Page[*models.SearchHit]{}, fmt.Errorf("error al buscar: %w", err)
+	}
+
+	hits := make([]*models.SearchHit, 0, len(res.Hits))
+	for _, h := range res.Hits {
+		hit := &models.SearchHit{
+			Type:       models.SearchType(stringField(h.Fields, fieldType)),
+			ID:         h.ID[strings.IndexByte(h.ID, ':')+1:],
+			PostID:     stringField(h.Fields, fieldPostID),
+			ForumID:    stringField(h.Fields, fieldForumID),
+			AuthorID:   stringField(h.Fields, fieldAuthorID),
+			Title:      stringField(h.Fields, fieldTitle),
+			Score:      h.Score,
+			Highlights: h.Fragments,
+		}
+		if hit.Highlights == nil {
+			hit.Highlights = map[string][]string{}
+		}
+		if t, err := time.Parse(time.RFC3339, stringField(h.Fields, fieldCreatedAt)); err == nil {
+			hit.CreatedAt = t
+		}
+		hits = append(hits, hit)
+	}
+
+	// Se pidió un resultado de más para saber si hay otra página
+	result := models.Page[*models.SearchHit]{Items: hits}
+	if len(hits) > size {
+		result.Items = hits[:size]
+		last := hits[size-1]
+		result.NextCursor = repositories.EncodeCursor(repositories.Cursor{Score: from + size, ID: docID(last.Type, last.ID)})
+	}
+	return result, nil
+}
+
+// buildQuery busca el texto en el título (con más peso) y en el contenido, y
+// agrega los filtros.
+func buildQuery(q models.SearchQuery) query.Query {
+	title := bleve.NewMatchQuery(q.Text)
+	title.SetField(fieldTitle)
+	title.SetBoost(2)
+	content := bleve.NewMatchQuery(q.Text)
+	content.SetField(fieldContent)
+	conj := bleve.NewConjunctionQuery(bleve.NewDisjunctionQuery(title, content))
+
+	term := func(field, value string) {
+		if value == "" {
+			return
+		}
+		t := bleve.NewTermQuery(value)
+		t.SetField(field)
+		conj.AddQuery(t)
+	}
+	term(fieldType, string(q.Type))
+	term(fieldForumID, q.ForumID)
+	term(fieldAuthorID, q.AuthorID)
+	if tag := models.NormalizeTag(q.Tag); tag != "" {
+		tags := bleve.NewDisjunctionQuery()
+		for _, t := range append([]string{tag}, q.Synonyms...) {
+			tq := bleve.NewTermQuery(t)
+			tq.SetField(fieldTags)
+			tags.AddQuery(tq)
+		}
+		conj.AddQuery(tags)
+	}
+
+	if q.From != nil || q.To != nil {
+		var start, end time.Time
+		if q.From != nil {
+			start = *q.From
+		}
+		if q.To != nil {
+			end = *q.To
+		}
+		inclusive := true
+		dates := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		dates.SetField(fieldCreatedAt)
+		conj.AddQuery(dates)
+	}
+	return conj
+}
+
+func stringField(fields map[string]interface{}, name string) string {
+	s, _ := fields[name].(string)
+	return s
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+func TestSearchTagFilter(t *testing.T) {
+	index, _, err := Open("")
+	if err != nil {
+		t.Fatalf("error creando el índice: %v", err)
+	}
+	defer index.Close()
+
+	subforos := map[string][]string{
+		"f1": {"Programación"},
+		"f2": {"golang"},
+		"f3": {"Música"},
+	}
+	for id, categories := range subforos {
+		s := &models.Subforo{ForumID: id, Title: "foro de " + id, Description: "un foro", Categories: categories}
+		if err := index.IndexSubforo(s); err != nil {
+			t.Fatalf("error indexando el subforo: %v", err)
+		}
+	}
+
+	tests := []struct {
+		tag      string
+		synonyms []string
+		want     []string
+	}{
+		{tag: "programacion", want: []string{"f1"}},
+		{tag: "#Programación ", want: []string{"f1"}},
+		{tag: "go", synonyms: []string{"golang"}, want: []string{"f2"}},
+	}
+	for _, tt := range tests {
+		q := models.SearchQuery{Text: "foro", Tag: tt.tag, Synonyms: tt.synonyms}
+		hits, err := index.Search(q, models.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("%q: error buscando: %v", tt.tag, err)
+		}
+		var got []string
+		for _, h := range hits.Items {
+			got = append(got, h.ForumID)
+		}
+		if len(got) != len(tt.want) || len(got) > 0 && got[0] != tt.want[0] {
+			t.Errorf("%q: encontró %v, se esperaba %v", tt.tag, got, tt.want)
+		}
+	}
+}
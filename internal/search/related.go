@@ -0,0 +1,270 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+)
+
+const (
+	// relatedCandidates es la cantidad de candidatos que aporta cada criterio
+	// (vector, tags y subforo) antes de puntuarlos.
+	relatedCandidates = 50
+	// MaxRelated es la cantidad de relacionados que se calculan y guardan por
+	// post.
+	MaxRelated = 40
+
+	// Pesos de cada criterio en el puntaje, que queda entre 0 y 1.
+	relatedVectorWeight = 0.6
+	relatedTagsWeight   = 0.3
+	relatedForumWeight  = 0.1
+)
+
+// Related calcula los posts relacionados con uno, mezclando la similitud de
+// sus vectores, los tags en común y el subforo, y guarda el resultado de cada
+// post en caché hasta que vence o el post cambia (Invalidate). Se puede usar
+// desde varias goroutines.
+type Related struct {
+	index   *Index
+	vectors *Vectors
+	ttl     time.Duration
+	size    int
+
+	mu    sync.Mutex
+	cache map[string]cachedRelated
+}
+
+type cachedRelated struct {
+	related []RelatedHit
+	expires time.Time
+}
+
+// RelatedHit es un post relacionado y su puntaje.
+type RelatedHit struct {
+	PostID string
+	Score  float64
+}
+
+// NewRelated crea un Related que guarda hasta size resultados (por defecto
+// 1000) durante ttl (por defecto 1h).
+func NewRelated(index *Index, vectors *Vectors, ttl time.Duration, size int) *Related {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	return &Related{
+		index:   index,
+		vectors: vectors,
+		ttl:     ttl,
+		size:    size,
+		cache:   make(map[string]cachedRelated),
+	}
+}
+
+// Find devuelve hasta MaxRelated posts relacionados con post, de mayor a
+// menor puntaje.
+func (r *Related) Find(post *models.Post) ([]RelatedHit, error) {
+	if related, ok := r.cached(post.ID); ok {
+		return related, nil
+	}
+	related, err := r.find(post)
+	if err != nil {
+		return nil, err
+	}
+	r.store(post.ID, related)
+	return related, nil
+}
+
+// Invalidate descarta el resultado guardado del post y los de los posts que
+// lo tienen entre sus relacionados, cuyo puntaje pudo cambiar. Los posts
+// que pasan a ser parecidos a él recién lo incluyen cuando vence su
+// resultado.
+func (r *Related) Invalidate(postID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, postID)
+	for key, entry := range r.cache {
+		if slices.ContainsFunc(entry.related, func(h RelatedHit) bool { return h.PostID == postID }) {
+			delete(r.cache, key)
+		}
+	}
+}
+
+func (r *Related) cached(postID string) ([]RelatedHit, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.cache[postID]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return slices.Clone(entry.related), true
+}
+
+// store guarda el resultado. Con la caché llena, primero descarta los
+// vencidos y, si no alcanza, el que vence antes.
+func (r *Related) store(postID string, related []RelatedHit) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if _, ok := r.cache[postID]; !ok && len(r.cache) >= r.size {
+		oldest := ""
+		for key, entry := range r.cache {
+			if now.After(entry.expires) {
+				delete(r.cache, key)
+			} else if oldest == "" || entry.expires.Before(r.cache[oldest].expires) {
+				oldest = key
+			}
+		}
+		if len(r.cache) >= r.size {
+			delete(r.cache, oldest)
+		}
+	}
+	r.cache[postID] = cachedRelated{related: slices.Clone(related), expires: now.Add(r.ttl)}
+}
+
+// candidate es lo que se sabe de un posible relacionado.
+type candidate struct {
+	forumID string
+	tags    []string
+}
+
+// find junta candidatos por cada criterio y los puntúa con los tres.
+func (r *Related) find(post *models.Post) ([]RelatedHit, error) {
+	// Similitud con todos los posts que tienen vector
+	similarity := make(map[string]float64)
+	ids := make(map[string]bool)
+	if vector := r.vectors.vector(post.ID); vector != nil {
+		for i, hit := range r.vectors.Search(vector, "", 0) {
+			similarity[hit.PostID] = hit.Similarity
+			// El primero es el propio post
+			if i <= relatedCandidates {
+				ids[hit.PostID] = true
+			}
+		}
+	}
+
+	tags := models.NormalizeTags(post.Tags)
+	if len(tags) > 0 {
+		byTags := make([]query.Query, 0, len(tags))
+		for _, t := range tags {
+			q := bleve.NewTermQuery(t)
+			q.SetField(fieldTags)
+			byTags = append(byTags, q)
+		}
+		if err := r.index.postIDs(bleve.NewDisjunctionQuery(byTags...), false, ids); err != nil {
+			return nil, err
+		}
+	}
+	sameForum := bleve.NewTermQuery(post.ForumID)
+	sameForum.SetField(fieldForumID)
+	if err := r.index.postIDs(sameForum, true, ids); err != nil {
+		return nil, err
+	}
+	delete(ids, post.ID)
+
+	candidates, err := r.index.candidates(ids)
+	if err != nil {
+		return nil, err
+	}
+	related := make([]RelatedHit, 0, len(candidates))
+	for id, c := range candidates {
+		score := relatedVectorWeight*max(similarity[id], 0) + relatedTagsWeight*jaccard(tags, c.tags)
+		if c.forumID == post.ForumID {
+			score += relatedForumWeight
+		}
+		related = append(related, RelatedHit{PostID: id, Score: score})
+	}
+	slices.SortFunc(related, func(a, b RelatedHit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.PostID, b.PostID)
+	})
+	if len(related) > MaxRelated {
+		related = related[:MaxRelated]
+	}
+	return related, nil
+}
+
+// jaccard es la proporción de tags en común entre a y b.
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	union := make(map[string]bool, len(a)+len(b))
+	for _, t := range a {
+		union[t] = true
+	}
+	shared := 0
+	for _, t := range b {
+		if union[t] {
+			shared++
+		} else {
+			union[t] = true
+		}
+	}
+	return float64(shared) / float64(len(union))
+}
+
+// postIDs agrega a ids los relatedCandidates posts que mejor cumplen q (uno
+// más, por si está el propio post): por relevancia o, con newest, los más
+// recientes.
+func (i *Index) postIDs(q query.Query, newest bool, ids map[string]bool) error {
+	posts := bleve.NewTermQuery(string(models.SearchPost))
+	posts.SetField(fieldType)
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(posts, q), relatedCandidates+1, 0, false)
+	if newest {
+		req.SortBy([]string{"-" + fieldCreatedAt, "_id"})
+	}
+	res, err := i.idx.Search(req)
+	if err != nil {
+		return err
+	}
+	for _, h := range res.Hits {
+		ids[h.ID[len(models.SearchPost)+1:]] = true
+	}
+	return nil
+}
+
+// candidates lee del índice el subforo y los tags de los posts ids. Los que
+// ya no están en el índice se omiten.
+func (i *Index) candidates(ids map[string]bool) (map[string]candidate, error) {
+	docIDs := make([]string, 0, len(ids))
+	for id := range ids {
+		docIDs = append(docIDs, docID(models.SearchPost, id))
+	}
+	out := make(map[string]candidate, len(ids))
+	if len(docIDs) == 0 {
+		return out, nil
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewDocIDQuery(docIDs), len(docIDs), 0, false)
+	req.Fields = []string{fieldForumID, fieldTags}
+	res, err := i.idx.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range res.Hits {
+		c := candidate{forumID: stringField(h.Fields, fieldForumID)}
+		// Un solo tag vuelve como string y varios como lista
+		switch tags := h.Fields[fieldTags].(type) {
+		case string:
+			c.tags = []string{tags}
+		case []interface{}:
+			for _, t := range tags {
+				if s, ok := t.(string); ok {
+					c.tags = append(c.tags, s)
+				}
+			}
+		}
+		out[h.ID[len(models.SearchPost)+1:]] = c
+	}
+	return out, nil
+}
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelatedInvalidate(t *testing.T) {
+	r := NewRelated(nil, nil, time.Hour, 0)
+	r.store("a", []RelatedHit{{PostID: "b", Score: 0.9}, {PostID: "c", Score: 0.5}})
+	r.store("b", []RelatedHit{{PostID: "a", Score: 0.9}})
+	r.store("d", []RelatedHit{{PostID: "e", Score: 0.4}})
+
+	// Editar c descarta su resultado y el de a, que lo incluye
+	r.Invalidate("c")
+	if _, ok := r.cached("a"); ok {
+		t.Error("el resultado de a, que incluye a c, sigue guardado")
+	}
+	for _, id := range []string{"b", "d"} {
+		if _, ok := r.cached(id); !ok {
+			t.Errorf("se descartó el resultado de %s, que no incluye a c", id)
+		}
+	}
+
+	r.Invalidate("b")
+	if _, ok := r.cached("b"); ok {
+		t.Error("el resultado de b sigue guardado")
+	}
+	if _, ok := r.cached("d"); !ok {
+		t.Error("se descartó el resultado de d")
+	}
+}
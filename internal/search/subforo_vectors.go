@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+// SubforoVectors compara los posts de un usuario con los subforos. Los
+// vectores del título y la descripción de los subforos se calculan la primera
+// vez que se piden y se guardan en memoria hasta que el subforo cambia. Se
+// puede usar desde varias goroutines.
+type SubforoVectors struct {
+	vectors  *Vectors
+	embedder service.Embedder
+
+	mu    sync.Mutex
+	cache map[string]subforoVector
+}
+
+type subforoVector struct {
+	// hash es models.EmbeddingHash del texto del que se calculó el vector.
+	hash   string
+	vector []float64
+}
+
+// NewSubforoVectors crea un SubforoVectors que compara con los vectores de
+// los posts de vectors.
+func NewSubforoVectors(vectors *Vectors, embedder service.Embedder) *SubforoVectors {
+	return &SubforoVectors{
+		vectors:  vectors,
+		embedder: embedder,
+		cache:    make(map[string]subforoVector),
+	}
+}
+
+// subforoText es el texto del subforo del que se calcula su vector.
+func subforoText(s *models.Subforo) string {
+	return s.Title + " " + s.Description
+}
+
+// Similarity devuelve, por ID de subforo, la similitud coseno entre el
+// promedio de los vectores de los posts postIDs y cada subforo. Si ninguno de
+// los posts tiene vector devuelve un mapa vacío, sin calcular los de los
+// subforos.
+func (s *SubforoVectors) Similarity(ctx context.Context, postIDs []string, subforos []*models.Subforo) (map[string]float64, error) {
+	similarity := make(map[string]float64, len(subforos))
+	mean := s.vectors.Mean(postIDs)
+	if mean == nil {
+		return similarity, nil
+	}
+	vectors, err := s.load(ctx, subforos)
+	if err != nil {
+		return nil, err
+	}
+	for id, vector := range vectors {
+		similarity[id] = dot(mean, vector)
+	}
+	return similarity, nil
+}
+
+// load devuelve los vectores normalizados de los subforos, y calcula de a
+// embedBatch los que no están guardados o cuyo texto cambió.
+func (s *SubforoVectors) load(ctx context.Context, subforos []*models.Subforo) (map[string][]float64, error) {
+	vectors := make(map[string][]float64, len(subforos))
+	var pending []*models.Subforo
+	s.mu.Lock()
+	for _, sf := range subforos {
+		cached, ok := s.cache[sf.ForumID]
+		if ok && cached.hash == models.EmbeddingHash(subforoText(sf)) {
+			vectors[sf.ForumID] = cached.vector
+		} else {
+			pending = append(pending, sf)
+		}
+	}
+	s.mu.Unlock()
+
+	for batch := range slices.Chunk(pending, embedBatch) {
+		texts := make([]string, len(batch))
+		for i, sf := range batch {
+			texts[i] = subforoText(sf)
+		}
+		embedded, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for i, sf := range batch {
+			vector := normalize(embedded[i])
+			s.cache[sf.ForumID] = subforoVector{hash: models.EmbeddingHash(texts[i]), vector: vector}
+			vectors[sf.ForumID] = vector
+		}
+		s.mu.Unlock()
+	}
+	return vectors, nil
+}
@@ -0,0 +1,227 @@
+package search
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+const (
+	// rebuildBatch es la cantidad de documentos por lote al reconstruir el índice.
+	rebuildBatch = 500
+	// embedTimeout es el tiempo máximo para calcular el vector de un post.
+	embedTimeout = 10 * time.Second
+)
+
+// Sync mantiene el índice al día con los repositorios. Cada método vuelve a
+// leer el documento y lo indexa si es visible o lo quita del índice si no
+// (no existe, está en la papelera, es un borrador o el subforo está
+// inactivo). Los errores solo se registran en el log: una falla del índice no
+// hace fallar la operación que lo actualizaba, y se corrige reconstruyendo el
+// índice.
+//
+// Sync también mantiene los vectores de los posts: los calcula con embedder
+// cuando cambia el texto, los guarda en embeddings y los agrega a vectors. Y
+// descarta los relacionados guardados del post que cambió.
+type Sync struct {
+	index      *Index
+	vectors    *Vectors
+	related    *Related
+	posts      repositories.PostRepository
+	comments   repositories.CommentRepository
+	subforos   repositories.SubforoRepository
+	embeddings repositories.EmbeddingRepository
+	embedder   service.Embedder
+}
+
+func NewSync(index *Index, vectors *Vectors, related *Related, posts repositories.PostRepository, comments repositories.CommentRepository, subforos repositories.SubforoRepository, embeddings repositories.EmbeddingRepository, embedder service.Embedder) *Sync {
+	return &Sync{
+		index:      index,
+		vectors:    vectors,
+		related:    related,
+		posts:      posts,
+		comments:   comments,
+		subforos:   subforos,
+		embeddings: embeddings,
+		embedder:   embedder,
+	}
+}
+
+// visiblePost devuelve el post si es visible, o nil.
+func (s *Sync) visiblePost(ctx context.Context, id string) *models.Post {
+	post, err := s.posts.GetPostByID(ctx, id)
+	if err != nil || !post.Post.IsPublished() {
+		return nil
+	}
+	return &post.Post
+}
+
+// Post sincroniza el post y sus comentarios, que solo son visibles mientras
+// lo sea el post. Los relacionados guardados que lo incluyen se descartan
+// después de actualizar el índice y el vector, para que no se vuelvan a
+// calcular con los anteriores.
+func (s *Sync) Post(ctx context.Context, id string) {
+	defer s.related.Invalidate(id)
+	post := s.visiblePost(ctx, id)
+	if post == nil {
+		s.vectors.Delete(id)
+		if err := s.index.DeletePost(id); err != nil {
+			log.Printf("⚠️ Índice de búsqueda: no se pudo quitar el post %s: %v", id, err)
+		}
+		return
+	}
+	s.embed(ctx, post)
+	if err := s.index.IndexPost(post); err != nil {
+		log.Printf("⚠️ Índice de búsqueda: no se pudo indexar el post %s: %v", id, err)
+		return
+	}
+	comments, err := s.comments.GetAllCommentsByPostID(ctx, id)
+	if err != nil {
+		log.Printf("⚠️ Índice de búsqueda: no se pudieron leer los comentarios del post %s: %v", id, err)
+		return
+	}
+	for i := range comments {
+		if err := s.index.IndexComment(&comments[i], post.ForumID); err != nil {
+			log.Printf("⚠️ Índice de búsqueda: no se pudo indexar el comentario %s: %v", comments[i].CommentID, err)
+		}
+	}
+}
+
+// embed agrega el vector del post a vectors, calculándolo antes si el texto
+// cambió desde que se guardó. Si no se puede calcular, el post conserva el
+// vector anterior, si lo tenía.
+func (s *Sync) embed(ctx context.Context, post *models.Post) {
+	stored, err := s.embeddings.GetEmbedding(ctx, post.ID)
+	if err != nil {
+		log.Printf("⚠️ Búsqueda semántica: %v", err)
+		return
+	}
+	if stored == nil || !stored.Current(post, s.embedder.Model()) {
+		ctx, cancel := context.WithTimeout(ctx, embedTimeout)
+		defer cancel()
+		text := post.EmbeddingText()
+		vectors, err := s.embedder.Embed(ctx, []string{text})
+		if err != nil {
+			log.Printf("⚠️ Búsqueda semántica: no se pudo calcular el vector del post %s: %v", post.ID, err)
+			return
+		}
+		stored = &models.PostEmbedding{
+			PostID:    post.ID,
+			Model:     s.embedder.Model(),
+			Vector:    vectors[0],
+			TextHash:  models.EmbeddingHash(text),
+			UpdatedAt: time.Now(),
+		}
+		if err := s.embeddings.SaveEmbedding(ctx, stored); err != nil {
+			log.Printf("⚠️ Búsqueda semántica: %v", err)
+			return
+		}
+	}
+	s.vectors.Put(post.ID, post.ForumID, stored.Vector)
+}
+
+func (s *Sync) Comment(ctx context.Context, id string) {
+	var err error
+	comment, getErr := s.comments.GetCommentByID(ctx, id)
+	var post *models.Post
+	if getErr == nil && comment.DeletedAt == nil {
+		post = s.visiblePost(ctx, comment.PostID)
+	}
+	if post == nil {
+		err = s.index.Delete(models.SearchComment, id)
+	} else {
+		err = s.index.IndexComment(comment, post.ForumID)
+	}
+	if err != nil {
+		log.Printf("⚠️ Índice de búsqueda: no se pudo sincronizar el comentario %s: %v", id, err)
+	}
+}
+
+func (s *Sync) Subforo(ctx context.Context, id string) {
+	var err error
+	subforo, getErr := s.subforos.GetSubforoByID(ctx, id)
+	if getErr != nil || subforo == nil || !subforo.IsActive {
+		err = s.index.Delete(models.SearchSubforo, id)
+	} else {
+		err = s.index.IndexSubforo(subforo)
+	}
+	if err != nil {
+		log.Printf("⚠️ Índice de búsqueda: no se pudo sincronizar el subforo %s: %v", id, err)
+	}
+}
+
+// RebuildResult cuenta los documentos indexados al reconstruir el índice.
+type RebuildResult struct {
+	Subforos int
+	Posts    int
+	Comments int
+}
+
+// Rebuild indexa todos los posts, comentarios y subforos visibles recorriendo
+// las colecciones completas con backup. El índice debería estar vacío: lo que
+// ya no existe en los repositorios no se quita.
+func (i *Index) Rebuild(ctx context.Context, backup repositories.BackupRepository) (*RebuildResult, error) {
+	result := &RebuildResult{}
+	batch := i.idx.NewBatch()
+	flush := func() error {
+		if batch.Size() < rebuildBatch {
+			return nil
+		}
+		if err := i.idx.Batch(batch); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+
+	err := backup.ExportSubforos(ctx, func(s *models.Subforo) error {
+		if !s.IsActive {
+			return nil
+		}
+		result.Subforos++
+		if err := batch.Index(docID(models.SearchSubforo, s.ForumID), subforoDoc(s)); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Los comentarios necesitan el subforo de su post visible
+	forums := make(map[string]string)
+	err = backup.ExportPosts(ctx, func(p *models.Post) error {
+		if p.DeletedAt != nil || !p.IsPublished() {
+			return nil
+		}
+		forums[p.ID] = p.ForumID
+		result.Posts++
+		if err := batch.Index(docID(models.SearchPost, p.ID), postDoc(p)); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = backup.ExportComments(ctx, func(c *models.Comment) error {
+		forumID, ok := forums[c.PostID]
+		if c.DeletedAt != nil || !ok {
+			return nil
+		}
+		result.Comments++
+		if err := batch.Index(docID(models.SearchComment, c.CommentID), commentDoc(c, forumID)); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, i.idx.Batch(batch)
+}
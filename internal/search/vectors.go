@@ -0,0 +1,241 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+// embedBatch es la cantidad de posts por llamada al Embedder al completar los
+// vectores que faltan.
+const embedBatch = 32
+
+// Vectors es el índice de los vectores de los posts visibles para la
+// búsqueda semántica. Vive en memoria: se llena al arrancar con los vectores
+// guardados (Load) y compara cada búsqueda con todos los posts, lo que basta
+// para decenas de miles de posts. Se puede usar desde varias goroutines.
+type Vectors struct {
+	model string
+
+	mu    sync.RWMutex
+	posts map[string]vectorEntry
+}
+
+type vectorEntry struct {
+	forumID string
+	// vector está normalizado, así que la similitud coseno es el producto
+	// escalar.
+	vector []float64
+}
+
+// VectorHit es un post encontrado y su similitud con la búsqueda.
+type VectorHit struct {
+	PostID     string
+	Similarity float64
+}
+
+// NewVectors crea un índice vacío para los vectores del modelo model.
+func NewVectors(model string) *Vectors {
+	return &Vectors{model: model, posts: make(map[string]vectorEntry)}
+}
+
+// normalize devuelve una copia de v con norma 1, o nil si v es cero.
+func normalize(v []float64) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// Put agrega el vector del post o reemplaza el anterior.
+func (v *Vectors) Put(postID, forumID string, vector []float64) {
+	n := normalize(vector)
+	if n == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.posts[postID] = vectorEntry{forumID: forumID, vector: n}
+}
+
+// vector devuelve el vector normalizado del post, o nil si no tiene.
+func (v *Vectors) vector(postID string) []float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.posts[postID].vector
+}
+
+// Mean devuelve el promedio normalizado de los vectores de los posts, o nil
+// si ninguno tiene vector.
+func (v *Vectors) Mean(postIDs []string) []float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var sum []float64
+	for _, id := range postIDs {
+		vector := v.posts[id].vector
+		if vector == nil || (sum != nil && len(vector) != len(sum)) {
+			continue
+		}
+		if sum == nil {
+			sum = make([]float64, len(vector))
+		}
+		for i, x := range vector {
+			sum[i] += x
+		}
+	}
+	return normalize(sum)
+}
+
+// dot es el producto escalar de a y b, que con vectores normalizados es su
+// similitud coseno. Vectores de distinto largo dan 0.
+func dot(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var d float64
+	for i := range a {
+		d += a[i] * b[i]
+	}
+	return d
+}
+
+func (v *Vectors) Delete(postID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.posts, postID)
+}
+
+// Search devuelve los posts cuya similitud con vector es al menos
+// minSimilarity, de mayor a menor similitud. Con forumID solo busca en ese
+// subforo.
+func (v *Vectors) Search(vector []float64, forumID string, minSimilarity float64) []VectorHit {
+	q := normalize(vector)
+	if q == nil {
+		return nil
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	hits := make([]VectorHit, 0)
+	for id, e := range v.posts {
+		if (forumID != "" && e.forumID != forumID) || len(e.vector) != len(q) {
+			continue
+		}
+		var dot float64
+		for i := range q {
+			dot += q[i] * e.vector[i]
+		}
+		if dot >= minSimilarity {
+			hits = append(hits, VectorHit{PostID: id, Similarity: dot})
+		}
+	}
+	slices.SortFunc(hits, func(a, b VectorHit) int {
+		if c := cmp.Compare(b.Similarity, a.Similarity); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.PostID, b.PostID)
+	})
+	return hits
+}
+
+// visiblePosts recorre los posts publicados que no están en la papelera.
+func visiblePosts(ctx context.Context, backup repositories.BackupRepository, fn func(*models.Post)) error {
+	return backup.ExportPosts(ctx, func(p *models.Post) error {
+		if p.DeletedAt == nil && p.IsPublished() {
+			fn(p)
+		}
+		return nil
+	})
+}
+
+// Load agrega al índice los vectores guardados de los posts visibles. Los
+// vectores de otro modelo o de un texto que ya cambió se ignoran hasta que se
+// vuelvan a calcular.
+func (v *Vectors) Load(ctx context.Context, backup repositories.BackupRepository) (int, error) {
+	posts := make(map[string]*models.Post)
+	err := visiblePosts(ctx, backup, func(p *models.Post) {
+		posts[p.ID] = p
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	err = backup.ExportEmbeddings(ctx, func(e *models.PostEmbedding) error {
+		p, ok := posts[e.PostID]
+		if ok && e.Current(p, v.model) {
+			v.Put(p.ID, p.ForumID, e.Vector)
+			loaded++
+		}
+		return nil
+	})
+	return loaded, err
+}
+
+// BackfillEmbeddings calcula y guarda los vectores de los posts visibles que
+// no lo tienen o cuyo texto cambió, y devuelve cuántos guardó.
+func BackfillEmbeddings(ctx context.Context, backup repositories.BackupRepository, embeddings repositories.EmbeddingRepository, embedder service.Embedder) (int, error) {
+	current := make(map[string]string)
+	err := backup.ExportEmbeddings(ctx, func(e *models.PostEmbedding) error {
+		if e.Model == embedder.Model() {
+			current[e.PostID] = e.TextHash
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []*models.Post
+	err = visiblePosts(ctx, backup, func(p *models.Post) {
+		if current[p.ID] != models.EmbeddingHash(p.EmbeddingText()) {
+			pending = append(pending, p)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	for batch := range slices.Chunk(pending, embedBatch) {
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.EmbeddingText()
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return saved, err
+		}
+		for i, p := range batch {
+			e := &models.PostEmbedding{
+				PostID:    p.ID,
+				Model:     embedder.Model(),
+				Vector:    vectors[i],
+				TextHash:  models.EmbeddingHash(texts[i]),
+				UpdatedAt: time.Now(),
+			}
+			if err := embeddings.SaveEmbedding(ctx, e); err != nil {
+				return saved, err
+			}
+			saved++
+		}
+	}
+	return saved, nil
+}
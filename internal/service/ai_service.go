@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,7 +33,7 @@ func CalculateTextSimilarity(postText, groupText string) (float64, string, error
 	}
 
 	// Obtener embeddings usando Hugging Face
-	embeddings, err := getEmbeddings([]string{groupText, postText})
+	embeddings, err := getEmbeddings(context.Background(), []string{groupText, postText})
 	if err != nil {
 		return 0.0, "", fmt.Errorf("error getting embeddings: %v", err)
 	}
@@ -49,7 +50,7 @@ func CalculateTextSimilarity(postText, groupText string) (float64, string, error
 }
 
 // getEmbeddings gets embeddings of texts using the Hugging Face API
-func getEmbeddings(texts []string) ([][]float64, error) {
+func getEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
 	// Preparar el request
 	bodyData := map[string][]string{
 		"inputs": texts,
@@ -60,7 +61,7 @@ func getEmbeddings(texts []string) ([][]float64, error) {
 	}
 
 	// Crear request HTTP
-	req, err := http.NewRequest("POST",
+	req, err := http.NewRequestWithContext(ctx, "POST",
 		"https://router.huggingface.co/hf-inference/models/sentence-transformers/all-MiniLM-L6-v2/pipeline/feature-extraction",
 		bytes.NewBuffer(bodyJSON))
 	if err != nil {
@@ -12,25 +12,40 @@ import (
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/v4/auth"
 	"github.com/JuanPidarraga/talkus-backend/config"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/o1egl/govatar"
 )
 
+// ErrAuthNotConfigured se devuelve cuando el servidor corre sin Firebase Auth.
+var ErrAuthNotConfigured = errors.New("firebase auth no está configurado")
+
 type AuthService struct {
 	firebase *config.FirebaseApp
 	cld      *cloudinary.Cloudinary
+	userRepo repositories.UserRepository
 }
 
-func NewAuthService(firebase *config.FirebaseApp, cld *cloudinary.Cloudinary) *AuthService {
+// NewAuthService crea el servicio de autenticación. firebase puede ser nil
+// cuando el servidor corre sin proyecto de Firebase; en ese caso las
+// operaciones de Firebase Auth devuelven ErrAuthNotConfigured.
+func NewAuthService(firebase *config.FirebaseApp, cld *cloudinary.Cloudinary, userRepo repositories.UserRepository) *AuthService {
 	return &AuthService{
 		firebase: firebase,
 		cld:      cld,
+		userRepo: userRepo,
+	}
+}
+
+func (s *AuthService) authClient() (*auth.Client, error) {
+	if s.firebase == nil || s.firebase.Auth == nil {
+		return nil, ErrAuthNotConfigured
 	}
+	return s.firebase.Auth, nil
 }
 
 func (s *AuthService) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error) {
@@ -38,7 +53,12 @@ func (s *AuthService) VerifyIDToken(ctx context.Context, idToken string) (*auth.
 		return nil, errors.New("idToken is empty")
 	}
 
-	decodedToken, err := s.firebase.Auth.VerifyIDToken(ctx, idToken)
+	authClient, err := s.authClient()
+	if err != nil {
+		return nil, err
+	}
+
+	decodedToken, err := authClient.VerifyIDToken(ctx, idToken)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +72,12 @@ func (s *AuthService) VerifyIDToken(ctx context.Context, idToken string) (*auth.
 }
 
 func (s *AuthService) GetUserProfile(ctx context.Context, uid string) (*models.User, error) {
-	user, err := s.firebase.Auth.GetUser(ctx, uid)
+	authClient, err := s.authClient()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := authClient.GetUser(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +96,13 @@ func (s *AuthService) RegisterUser(ctx context.Context, username, email, passwor
 		Email(email).
 		Password(password)
 
+	authClient, err := s.authClient()
+	if err != nil {
+		return nil, err
+	}
+
 	// Crear el usuario en Firebase Authentication
-	userRecord, err := s.firebase.Auth.CreateUser(ctx, params)
+	userRecord, err := authClient.CreateUser(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -137,7 +167,8 @@ func (s *AuthService) extractInitials(name string) string {
 	return initials.String()
 }
 
-func (s *AuthService) SaveUserInFirestore(ctx context.Context, user *auth.UserRecord) error {
+// SaveUserProfile guarda el perfil del usuario recién registrado en el repositorio de usuarios.
+func (s *AuthService) SaveUserProfile(ctx context.Context, user *auth.UserRecord) error {
 	// Generar y subir avatar automáticamente
 	avatarURL, err := s.GenerateAndUploadAvatar(ctx, user.DisplayName, user.UID)
 	if err != nil {
@@ -157,8 +188,7 @@ func (s *AuthService) SaveUserInFirestore(ctx context.Context, user *auth.UserRe
 	}
 
 	// Guarda el documento en la colección "users", usando el UID como documento ID
-	_, err = s.firebase.Firestore.Collection("users").Doc(user.UID).Set(ctx, doc)
-	return err
+	return s.userRepo.CreateUser(ctx, user.UID, doc)
 }
 
 func (s *AuthService) RegisterAndSaveUser(ctx context.Context, username string, email, password string) (*auth.UserRecord, error) {
@@ -168,8 +198,8 @@ func (s *AuthService) RegisterAndSaveUser(ctx context.Context, username string,
 		return nil, err
 	}
 
-	// 2. Guardar información adicional en Firestore
-	err = s.SaveUserInFirestore(ctx, userRecord)
+	// 2. Guardar información adicional del perfil
+	err = s.SaveUserProfile(ctx, userRecord)
 	if err != nil {
 		return nil, err
 	}
@@ -221,27 +251,22 @@ func (s *AuthService) ChangeUserEmail(ctx context.Context, uid string, newEmail
 		return errors.New("nuevo email es requerido")
 	}
 
+	authClient, err := s.authClient()
+	if err != nil {
+		return err
+	}
+
 	// 1. Cambiar el email en Firebase Authentication
 	params := (&auth.UserToUpdate{}).Email(newEmail)
 
-	_, err := s.firebase.Auth.UpdateUser(ctx, uid, params)
+	_, err = authClient.UpdateUser(ctx, uid, params)
 	if err != nil {
 		return fmt.Errorf("error actualizando email en Firebase Auth: %v", err)
 	}
 
-	// 2. Actualizar el email en Firestore
-	_, err = s.firebase.Firestore.Collection("users").Doc(uid).Update(ctx, []firestore.Update{
-		{
-			Path:  "email",
-			Value: newEmail,
-		},
-		{
-			Path:  "updatedAt",
-			Value: time.Now(),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("error actualizando email en Firestore: %v", err)
+	// 2. Actualizar el email en el perfil del usuario
+	if err := s.userRepo.UpdateUserEmail(ctx, uid, newEmail); err != nil {
+		return fmt.Errorf("error actualizando email del perfil: %v", err)
 	}
 
 	return nil
@@ -262,10 +287,15 @@ func (s *AuthService) ChangeUserPassword(ctx context.Context, uid string, newPas
 		return errors.New("la contraseña debe tener al menos 6 caracteres")
 	}
 
+	authClient, err := s.authClient()
+	if err != nil {
+		return err
+	}
+
 	// Cambiar la contraseña en Firebase Authentication
 	params := (&auth.UserToUpdate{}).Password(newPassword)
 
-	_, err := s.firebase.Auth.UpdateUser(ctx, uid, params)
+	_, err = authClient.UpdateUser(ctx, uid, params)
 	if err != nil {
 		return fmt.Errorf("error actualizando contraseña en Firebase Auth: %v", err)
 	}
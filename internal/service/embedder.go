@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// MiniLMModel es el modelo de Hugging Face con el que getEmbeddings calcula
+// los vectores (384 dimensiones).
+const MiniLMModel = "sentence-transformers/all-MiniLM-L6-v2"
+
+// Embedder convierte textos en vectores: textos de significado parecido dan
+// vectores con similitud coseno alta.
+type Embedder interface {
+	// Model identifica el modelo; los vectores de modelos distintos no se
+	// pueden comparar.
+	Model() string
+	// Embed devuelve un vector por texto, en el mismo orden.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+type miniLMEmbedder struct{}
+
+// NewMiniLMEmbedder crea un Embedder que usa la API de Hugging Face con
+// HUGGINGFACE_API_KEY, igual que CalculateTextSimilarity.
+func NewMiniLMEmbedder() Embedder {
+	return miniLMEmbedder{}
+}
+
+func (miniLMEmbedder) Model() string {
+	return MiniLMModel
+}
+
+func (miniLMEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, err := getEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("se esperaban %d vectores y llegaron %d", len(texts), len(embeddings))
+	}
+	return embeddings, nil
+}
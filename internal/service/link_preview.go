@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrInvalidLink indica que la URL no es http o https absoluta.
+	ErrInvalidLink = errors.New("la URL debe ser http o https")
+	// ErrBlockedLink indica que la URL apunta a una dirección de red privada.
+	ErrBlockedLink = errors.New("la URL apunta a una dirección no permitida")
+)
+
+// LinkPreviewer obtiene la vista previa de los enlaces.
+type LinkPreviewer interface {
+	// Preview descarga la página y devuelve sus metadatos OpenGraph o Twitter
+	// card. Devuelve ErrInvalidLink o ErrBlockedLink si la URL no se puede
+	// pedir.
+	Preview(ctx context.Context, rawURL string) (*models.LinkPreview, error)
+}
+
+// LinkPreviewOptions configura NewLinkPreviewer. Los campos en cero toman el
+// valor por defecto.
+type LinkPreviewOptions struct {
+	// Timeout es el tiempo máximo de cada vista previa, redirecciones
+	// incluidas (por defecto 5s).
+	Timeout time.Duration
+	// MaxBytes es lo máximo que se lee de cada página (por defecto 1 MiB).
+	MaxBytes int64
+	// MaxRedirects es la cantidad máxima de redirecciones (por defecto 3).
+	MaxRedirects int
+	// CacheTTL es cuánto se guarda cada vista previa (por defecto 1h).
+	CacheTTL time.Duration
+	// CacheSize es la cantidad máxima de URLs en caché (por defecto 1000).
+	CacheSize int
+	// AllowPrivate permite pedir direcciones privadas y de loopback. Solo
+	// para tests contra un servidor local.
+	AllowPrivate bool
+}
+
+type linkPreviewer struct {
+	client   *http.Client
+	maxBytes int64
+	ttl      time.Duration
+	size     int
+
+	mu    sync.Mutex
+	cache map[string]cachedPreview
+}
+
+type cachedPreview struct {
+	preview *models.LinkPreview
+	expires time.Time
+}
+
+// NewLinkPreviewer crea un LinkPreviewer que pide las páginas por HTTP. Las
+// conexiones se validan después de resolver el nombre, así que un dominio
+// que resuelve a una dirección privada también se bloquea.
+func NewLinkPreviewer(opts LinkPreviewOptions) LinkPreviewer {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 1 << 20
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = 3
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = time.Hour
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 1000
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = checkDialAddr
+	}
+	transport := &http.Transport{
+		// Sin proxy: la validación de direcciones tiene que ver el destino real
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return fmt.Errorf("más de %d redirecciones", opts.MaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrInvalidLink
+			}
+			return nil
+		},
+	}
+	return &linkPreviewer{
+		client:   client,
+		maxBytes: opts.MaxBytes,
+		ttl:      opts.CacheTTL,
+		size:     opts.CacheSize,
+		cache:    make(map[string]cachedPreview),
+	}
+}
+
+// blockedPrefixes son rangos reservados que netip no clasifica como privados.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// checkDialAddr es el Control del dialer: rechaza con ErrBlockedLink las
+// conexiones a direcciones que no son públicas.
+func checkDialAddr(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil || !publicAddr(addr.Addr()) {
+		return ErrBlockedLink
+	}
+	return nil
+}
+
+// publicAddr indica si addr es una dirección pública de Internet.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeLink valida la URL y la deja en la forma que se usa como clave de
+// la caché: esquema y host en minúsculas y sin fragmento.
+func NormalizeLink(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return "", ErrInvalidLink
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+func (p *linkPreviewer) Preview(ctx context.Context, rawURL string) (*models.LinkPreview, error) {
+	link, err := NormalizeLink(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if preview := p.cached(link); preview != nil {
+		return preview, nil
+	}
+
+	preview, err := p.fetch(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	p.store(link, preview)
+	c := *preview
+	return &c, nil
+}
+
+func (p *linkPreviewer) cached(link string) *models.LinkPreview {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.cache[link]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	c := *entry.preview
+	return &c
+}
+
+// store guarda la vista previa. Con la caché llena, primero descarta las
+// vencidas y, si no alcanza, la que vence antes.
+func (p *linkPreviewer) store(link string, preview *models.LinkPreview) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if _, ok := p.cache[link]; !ok && len(p.cache) >= p.size {
+		oldest := ""
+		for key, entry := range p.cache {
+			if now.After(entry.expires) {
+				delete(p.cache, key)
+			} else if oldest == "" || entry.expires.Before(p.cache[oldest].expires) {
+				oldest = key
+			}
+		}
+		if len(p.cache) >= p.size {
+			delete(p.cache, oldest)
+		}
+	}
+	p.cache[link] = cachedPreview{preview: preview, expires: now.Add(p.ttl)}
+}
+
+func (p *linkPreviewer) fetch(ctx context.Context, link string) (*models.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, ErrInvalidLink
+	}
+	req.Header.Set("User-Agent", "TalkusBot/1.0 (+vista previa de enlaces)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := p.client.Do(req)
+	if errors.Is(err, ErrBlockedLink) || errors.Is(err, ErrInvalidLink) {
+		return nil, ErrBlockedLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al pedir %s: %w", link, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("error al pedir %s: respuesta %d", link, res.StatusCode)
+	}
+	preview := &models.LinkPreview{URL: link, FetchedAt: time.Now()}
+	contentType := res.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		// Un PDF o una imagen no tiene metadatos: queda solo la URL
+		return preview, nil
+	}
+	parseMeta(io.LimitReader(res.Body, p.maxBytes), res.Request.URL, preview)
+	return preview, nil
+}
+
+// parseMeta completa preview con las etiquetas meta del head. Prefiere
+// OpenGraph, después Twitter card y por último <title> y description. Las
+// imágenes relativas se resuelven contra base, la URL final tras las
+// redirecciones.
+func parseMeta(r io.Reader, base *url.URL, preview *models.LinkPreview) {
+	meta := make(map[string]string)
+	var title string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			fillPreview(preview, meta, title, base)
+			return
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(z.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				fillPreview(preview, meta, title, base)
+				return
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "body":
+				fillPreview(preview, meta, title, base)
+				return
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = strings.TrimSpace(string(v))
+					}
+				}
+				if _, seen := meta[key]; key != "" && content != "" && !seen {
+					meta[key] = content
+				}
+			}
+		}
+	}
+}
+
+func fillPreview(preview *models.LinkPreview, meta map[string]string, title string, base *url.URL) {
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := meta[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	preview.Title = truncate(first("og:title", "twitter:title"), 300)
+	if preview.Title == "" {
+		preview.Title = truncate(title, 300)
+	}
+	preview.Description = truncate(first("og:description", "twitter:description", "description"), 1000)
+	preview.SiteName = truncate(first("og:site_name"), 200)
+	if image := first("og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if u, err := base.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			preview.Image = u.String()
+		}
+	}
+}
+
+// truncate corta s a lo sumo en n runas.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
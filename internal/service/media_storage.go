@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// MediaStorage guarda las imágenes subidas por los usuarios.
+type MediaStorage interface {
+	// Upload sube la imagen y devuelve su URL pública y su ID.
+	Upload(ctx context.Context, file io.Reader) (url, publicID string, err error)
+	// Delete borra la imagen publicID. Una imagen que ya no existe no es un error.
+	Delete(ctx context.Context, publicID string) error
+}
+
+type cloudinaryStorage struct {
+	cld *cloudinary.Cloudinary
+}
+
+// NewCloudinaryStorage crea un MediaStorage sobre Cloudinary.
+func NewCloudinaryStorage(cld *cloudinary.Cloudinary) MediaStorage {
+	return &cloudinaryStorage{cld: cld}
+}
+
+func (s *cloudinaryStorage) Upload(ctx context.Context, file io.Reader) (string, string, error) {
+	overwrite := false
+	res, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		Folder: "posts_images",
+		// Con la fecha en nanosegundos, las imágenes de un mismo post no se pisan
+		PublicID:  fmt.Sprintf("post_%d", time.Now().UnixNano()),
+		Overwrite: &overwrite,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("error al subir la imagen: %w", err)
+	}
+	if res.Error.Message != "" {
+		return "", "", fmt.Errorf("error al subir la imagen: %s", res.Error.Message)
+	}
+	return res.SecureURL, res.PublicID, nil
+}
+
+func (s *cloudinaryStorage) Delete(ctx context.Context, publicID string) error {
+	invalidate := true
+	res, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:   publicID,
+		Invalidate: &invalidate,
+	})
+	if err != nil {
+		return fmt.Errorf("error al borrar la imagen %s: %w", publicID, err)
+	}
+	if res.Error.Message != "" {
+		return fmt.Errorf("error al borrar la imagen %s: %s", publicID, res.Error.Message)
+	}
+	// Cloudinary responde "not found" si la imagen ya no existe
+	if res.Result != "ok" && res.Result != "not found" {
+		return fmt.Errorf("error al borrar la imagen %s: %s", publicID, res.Result)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package usecases
 import (
 	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
@@ -12,37 +11,66 @@ import (
 
 type CommentUsecase interface {
 	CreateComment(ctx context.Context, comment *models.Comment) error
-	GetCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error)
-	DeleteComment(ctx context.Context, commentID string) error
+	GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error)
 	GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error)
 	UpdateComment(ctx context.Context, commentID string, userID string, updatedContent string) (*models.Comment, error)
 	CreateReply(ctx context.Context, parentID string, comment *models.Comment) error
-	GetCommentTree(ctx context.Context, postID string) ([]*models.CommentWithReplies, error)
-	AddReaction(ctx context.Context, commentID, userID, reaction string) (*models.Comment, error)
+	GetCommentTree(ctx context.Context, postID string, page models.PageRequest) (models.Page[*models.CommentWithReplies], error)
+	AddUserReactions(ctx context.Context, userID string, comments []*models.CommentWithReplies) error
 }
 
 type commentUsecase struct {
-	repo repositories.CommentRepository
+	repo    repositories.CommentRepository
+	votes   repositories.VoteRepository
+	guard   threadGuard
+	content *ContentRenderer
+	search  SearchIndexer
 }
 
-func NewCommentUsecase(repo repositories.CommentRepository) CommentUsecase {
-	return &commentUsecase{repo: repo}
+func NewCommentUsecase(repo repositories.CommentRepository, votes repositories.VoteRepository, posts repositories.PostRepository, content *ContentRenderer, search SearchIndexer) CommentUsecase {
+	return &commentUsecase{
+		repo:    repo,
+		votes:   votes,
+		guard:   threadGuard{posts: posts, comments: repo},
+		content: content,
+		search:  search,
+	}
 }
 
+// CreateComment guarda el comentario si el post admite comentarios (ver
+// models.Post.Writable).
 func (uc *commentUsecase) CreateComment(ctx context.Context, comment *models.Comment) error {
 	if err := comment.Validate(); err != nil {
 		return err
 	}
-	return uc.repo.CreateComment(ctx, comment)
+	if err := uc.guard.writable(ctx, comment.PostID); err != nil {
+		return err
+	}
+	if err := uc.repo.CreateComment(ctx, comment); err != nil {
+		return err
+	}
+	uc.search.Comment(ctx, comment.CommentID)
+	uc.content.Comments(ctx, comment)
+	return nil
 }
 
-func (uc *commentUsecase) GetCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
-	return uc.repo.GetCommentsByPostID(ctx, postID)
+func (uc *commentUsecase) GetCommentsByPostID(ctx context.Context, postID string, page models.PageRequest) (models.Page[models.Comment], error) {
+	result, err := uc.repo.GetCommentsByPostID(ctx, postID, page)
+	if err != nil {
+		return result, err
+	}
+	uc.content.CommentList(ctx, result.Items)
+	return result, nil
 }
 
 // GetCommentByID obtiene un comentario por su ID.
 func (u *commentUsecase) GetCommentByID(ctx context.Context, commentID string) (*models.Comment, error) {
-	return u.repo.GetCommentByID(ctx, commentID)
+	comment, err := u.repo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	u.content.Comments(ctx, comment)
+	return comment, nil
 }
 
 func (uc *commentUsecase) UpdateComment(ctx context.Context, commentID string, userID string, updatedContent string) (*models.Comment, error) {
@@ -61,12 +89,23 @@ func (uc *commentUsecase) UpdateComment(ctx context.Context, commentID string, u
 	if comment.AuthorID != userID {
 		return nil, fmt.Errorf("unauthorized: you can only edit your own comments")
 	}
+	// Los posts archivados son de solo lectura; los cerrados sí dejan
+	// corregir lo que ya se comentó
+	post, err := uc.guard.post(ctx, comment.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if post.ArchivedAt != nil {
+		return nil, models.ErrPostArchived
+	}
 
-	return uc.repo.UpdateComment(ctx, commentID, updatedContent)
-}
-
-func (uc *commentUsecase) DeleteComment(ctx context.Context, commentID string) error {
-	return uc.repo.DeleteComment(ctx, commentID)
+	updated, err := uc.repo.UpdateComment(ctx, commentID, updatedContent)
+	if err != nil {
+		return nil, err
+	}
+	uc.search.Comment(ctx, commentID)
+	uc.content.Comments(ctx, updated)
+	return updated, nil
 }
 
 func (uc *commentUsecase) CreateReply(ctx context.Context, parentID string, comment *models.Comment) error {
@@ -85,8 +124,18 @@ func (uc *commentUsecase) CreateReply(ctx context.Context, parentID string, comm
 		return err
 	}
 
-	// 4. Crear el comentario
-	return uc.repo.CreateComment(ctx, comment)
+	// 4. Comprobar que el post admita respuestas
+	if err := uc.guard.writable(ctx, comment.PostID); err != nil {
+		return err
+	}
+
+	// 5. Crear el comentario
+	if err := uc.repo.CreateComment(ctx, comment); err != nil {
+		return err
+	}
+	uc.search.Comment(ctx, comment.CommentID)
+	uc.content.Comments(ctx, comment)
+	return nil
 }
 
 func (uc *commentUsecase) GetReplies(ctx context.Context, parentID string) ([]models.Comment, error) {
@@ -100,62 +149,90 @@ func (uc *commentUsecase) GetReplies(ctx context.Context, parentID string) ([]mo
 		return nil, fmt.Errorf("failed to get parent comment: %w", err)
 	}
 
-	return uc.repo.GetReplies(ctx, parentID)
-}
-
-func (uc *commentUsecase) GetCommentTree(ctx context.Context, postID string) ([]*models.CommentWithReplies, error) {
-	comments, err := uc.repo.GetCommentsByPostID(ctx, postID)
+	replies, err := uc.repo.GetReplies(ctx, parentID)
 	if err != nil {
 		return nil, err
 	}
+	uc.content.CommentList(ctx, replies)
+	return replies, nil
+}
 
-	commentMap := make(map[string]*models.CommentWithReplies)
-	var roots []*models.CommentWithReplies
+// GetCommentTree pagina los comentarios raíz del post, del más antiguo al más
+// reciente, y arma el árbol con todas sus respuestas. Las respuestas se leen
+// por nivel, solo las de los comentarios de la página.
+func (uc *commentUsecase) GetCommentTree(ctx context.Context, postID string, page models.PageRequest) (models.Page[*models.CommentWithReplies], error) {
+	roots, err := uc.repo.GetRootComments(ctx, postID, page)
+	if err != nil {
+		return models.Page[*models.CommentWithReplies]{}, err
+	}
 
-	sort.Slice(comments, func(i, j int) bool {
-		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
-	})
+	result := models.Page[*models.CommentWithReplies]{
+		Items:      make([]*models.CommentWithReplies, 0, len(roots.Items)),
+		NextCursor: roots.NextCursor,
+	}
+	nodes := make(map[string]*models.CommentWithReplies)
+	level := make([]string, 0, len(roots.Items))
+	for i := range roots.Items {
+		node := &models.CommentWithReplies{Comment: &roots.Items[i], Replies: make([]*models.CommentWithReplies, 0)}
+		nodes[node.Comment.CommentID] = node
+		level = append(level, node.Comment.CommentID)
+		result.Items = append(result.Items, node)
+	}
 
-	for _, c := range comments {
-		node := &models.CommentWithReplies{
-			Comment: &c,
-			Replies: make([]*models.CommentWithReplies, 0),
+	for len(level) > 0 {
+		replies, err := uc.repo.GetRepliesTo(ctx, level)
+		if err != nil {
+			return models.Page[*models.CommentWithReplies]{}, err
 		}
-		commentMap[c.CommentID] = node
-
-		if c.ParentID == "" {
-			roots = append(roots, node)
-		} else {
-			if parent, exists := commentMap[c.ParentID]; exists {
-				parent.Replies = append(parent.Replies, node)
-			} else {
-				// Si el padre no está cargado aún, crear un nodo temporal
-				tempParent := &models.CommentWithReplies{
-					Comment: &models.Comment{CommentID: c.ParentID},
-				}
-				commentMap[c.ParentID] = tempParent
-				tempParent.Replies = append(tempParent.Replies, node)
+		var next []string
+		for i := range replies {
+			parent, ok := nodes[replies[i].ParentID]
+			if !ok || nodes[replies[i].CommentID] != nil {
+				continue
 			}
+			node := &models.CommentWithReplies{Comment: &replies[i], Replies: make([]*models.CommentWithReplies, 0)}
+			nodes[node.Comment.CommentID] = node
+			parent.Replies = append(parent.Replies, node)
+			next = append(next, node.Comment.CommentID)
 		}
+		level = next
 	}
 
-	return roots, nil
+	uc.content.CommentTree(ctx, result.Items)
+	return result, nil
 }
 
-func (uc *commentUsecase) AddReaction(ctx context.Context, commentID, userID, reaction string) (*models.Comment, error) {
-
-	if !(reaction == "like" || reaction == "dislike") {
-		return nil, fmt.Errorf("reaction must be either 'like' or 'dislike'")
+// AddUserReactions completa UserReaction en los comentarios y sus respuestas
+// con el voto del usuario, buscando todos los votos de una vez.
+func (uc *commentUsecase) AddUserReactions(ctx context.Context, userID string, comments []*models.CommentWithReplies) error {
+	var ids []string
+	var collect func(cs []*models.CommentWithReplies)
+	collect = func(cs []*models.CommentWithReplies) {
+		for _, c := range cs {
+			ids = append(ids, c.Comment.CommentID)
+			collect(c.Replies)
+		}
 	}
-
-	if reaction != "like" && reaction != "dislike" {
-		return nil, fmt.Errorf("invalid reaction type")
+	collect(comments)
+	if len(ids) == 0 {
+		return nil
 	}
 
-	_, err := uc.repo.GetCommentByID(ctx, commentID)
+	votes, err := uc.votes.GetUserVotes(ctx, userID, models.TargetComment, ids)
 	if err != nil {
-		return nil, fmt.Errorf("comment not found")
+		return err
 	}
 
-	return uc.repo.AddReaction(ctx, commentID, userID, reaction)
+	var fill func(cs []*models.CommentWithReplies)
+	fill = func(cs []*models.CommentWithReplies) {
+		for _, c := range cs {
+			if v, ok := votes[c.Comment.CommentID]; ok {
+				reaction := string(v.Type)
+				c.UserReaction = &reaction
+			}
+			fill(c.Replies)
+		}
+	}
+	fill(comments)
+	return nil
 }
@@ -0,0 +1,82 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// treeComments falla el test si se leen todos los comentarios del post.
+type treeComments struct {
+	repositories.CommentRepository
+	t *testing.T
+}
+
+func (r *treeComments) GetAllCommentsByPostID(ctx context.Context, postID string) ([]models.Comment, error) {
+	r.t.Error("GetCommentTree leyó todos los comentarios del post")
+	return r.CommentRepository.GetAllCommentsByPostID(ctx, postID)
+}
+
+func TestGetCommentTreePaginatesRoots(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	comments := &treeComments{CommentRepository: memory.NewCommentRepository(store), t: t}
+	subforos := memory.NewSubforoRepository(store)
+	content := usecases.NewContentRenderer(memory.NewUserLoader(store), subforos)
+	u := usecases.NewCommentUsecase(comments, memory.NewVoteRepository(store), memory.NewPostRepository(store), content, nopIndexer{})
+
+	// Cinco comentarios raíz, cada uno con una respuesta que a su vez tiene otra
+	const total = 5
+	for range total {
+		root := &models.Comment{PostID: "p1", AuthorID: "autor", Content: "raíz"}
+		if err := comments.CreateComment(ctx, root); err != nil {
+			t.Fatalf("error creando el comentario: %v", err)
+		}
+		parent := root.CommentID
+		for range 2 {
+			reply := &models.Comment{AuthorID: "autor", Content: "respuesta"}
+			if err := comments.CreateReply(ctx, parent, reply); err != nil {
+				t.Fatalf("error creando la respuesta: %v", err)
+			}
+			parent = reply.CommentID
+		}
+	}
+
+	seen := make(map[string]bool)
+	page := models.PageRequest{Limit: 2}
+	var last *models.Comment
+	for {
+		result, err := u.GetCommentTree(ctx, "p1", page)
+		if err != nil {
+			t.Fatalf("GetCommentTree: %v", err)
+		}
+		if len(result.Items) > 2 {
+			t.Fatalf("la página trae %d comentarios raíz, se esperaban 2", len(result.Items))
+		}
+		for _, node := range result.Items {
+			c := node.Comment
+			if c.ParentID != "" || seen[c.CommentID] {
+				t.Errorf("comentario %s repetido o que no es raíz", c.CommentID)
+			}
+			seen[c.CommentID] = true
+			if last != nil && (c.CreatedAt.Before(last.CreatedAt) || c.CreatedAt.Equal(last.CreatedAt) && c.CommentID < last.CommentID) {
+				t.Errorf("el comentario %s viene antes que %s", c.CommentID, last.CommentID)
+			}
+			last = c
+			if len(node.Replies) != 1 || len(node.Replies[0].Replies) != 1 {
+				t.Errorf("comentario %s: faltan respuestas: %+v", c.CommentID, node.Replies)
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	if len(seen) != total {
+		t.Errorf("se recorrieron %d comentarios raíz, se esperaban %d", len(seen), total)
+	}
+}
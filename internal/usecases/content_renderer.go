@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"log"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/markdown"
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// ContentRenderer completa ContentHTML en los posts y comentarios que
+// devuelven los casos de uso, resolviendo las menciones de usuarios y
+// subforos.
+type ContentRenderer struct {
+	users    repositories.UserLoader
+	subforos repositories.SubforoRepository
+}
+
+func NewContentRenderer(users repositories.UserLoader, subforos repositories.SubforoRepository) *ContentRenderer {
+	return &ContentRenderer{users: users, subforos: subforos}
+}
+
+// Posts completa ContentHTML en los posts. Los nil se ignoran.
+func (r *ContentRenderer) Posts(ctx context.Context, posts ...*models.Post) {
+	sources := make([]string, 0, len(posts))
+	for _, p := range posts {
+		if p != nil {
+			sources = append(sources, p.Content)
+		}
+	}
+	names := r.names(ctx, sources)
+	for _, p := range posts {
+		if p != nil {
+			p.ContentHTML = markdown.Render(p.Content, names)
+		}
+	}
+}
+
+// Comments completa ContentHTML en los comentarios. Los nil se ignoran.
+func (r *ContentRenderer) Comments(ctx context.Context, comments ...*models.Comment) {
+	sources := make([]string, 0, len(comments))
+	for _, c := range comments {
+		if c != nil {
+			sources = append(sources, c.Content)
+		}
+	}
+	names := r.names(ctx, sources)
+	for _, c := range comments {
+		if c != nil {
+			c.ContentHTML = markdown.Render(c.Content, names)
+		}
+	}
+}
+
+// CommentList es Comments para los listados que devuelven los comentarios
+// por valor.
+func (r *ContentRenderer) CommentList(ctx context.Context, comments []models.Comment) {
+	ptrs := make([]*models.Comment, len(comments))
+	for i := range comments {
+		ptrs[i] = &comments[i]
+	}
+	r.Comments(ctx, ptrs...)
+}
+
+// CommentTree es Comments para un árbol de comentarios con sus respuestas.
+func (r *ContentRenderer) CommentTree(ctx context.Context, tree []*models.CommentWithReplies) {
+	var comments []*models.Comment
+	var collect func(nodes []*models.CommentWithReplies)
+	collect = func(nodes []*models.CommentWithReplies) {
+		for _, n := range nodes {
+			comments = append(comments, n.Comment)
+			collect(n.Replies)
+		}
+	}
+	collect(tree)
+	r.Comments(ctx, comments...)
+}
+
+// names busca los nombres de todos los usuarios y subforos mencionados en
+// sources. Si la búsqueda falla, las menciones quedan como texto.
+func (r *ContentRenderer) names(ctx context.Context, sources []string) markdown.Names {
+	names := markdown.Names{Users: map[string]string{}, Subforos: map[string]string{}}
+	var userIDs, subforoIDs []string
+	for _, src := range sources {
+		refs := markdown.FindRefs(src)
+		userIDs = append(userIDs, refs.Users...)
+		subforoIDs = append(subforoIDs, refs.Subforos...)
+	}
+
+	if len(userIDs) > 0 {
+		users, err := r.users.LoadUsers(ctx, userIDs)
+		if err != nil {
+			log.Printf("⚠️ No se pudieron cargar los usuarios mencionados: %v", err)
+		}
+		for id, u := range users {
+			name := u.Username
+			if name == "" {
+				name = id
+			}
+			names.Users[id] = name
+		}
+	}
+	if len(subforoIDs) > 0 {
+		// Un subforo que no existe no es un error: la mención queda como texto
+		subforos, err := r.subforos.GetSubforosByIDs(ctx, subforoIDs)
+		if err != nil {
+			log.Printf("⚠️ No se pudieron cargar los subforos mencionados: %v", err)
+		}
+		for id, s := range subforos {
+			names.Subforos[id] = s.Title
+		}
+	}
+	return names
+}
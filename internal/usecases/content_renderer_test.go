@@ -0,0 +1,63 @@
+package usecases_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// countingSubforos cuenta las lecturas de subforos.
+type countingSubforos struct {
+	repositories.SubforoRepository
+	single, batch int
+}
+
+func (r *countingSubforos) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
+	r.single++
+	return r.SubforoRepository.GetSubforoByID(ctx, id)
+}
+
+func (r *countingSubforos) GetSubforosByIDs(ctx context.Context, ids []string) (map[string]*models.Subforo, error) {
+	r.batch++
+	return r.SubforoRepository.GetSubforosByIDs(ctx, ids)
+}
+
+func TestContentRendererBatchesSubforos(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	subforos := &countingSubforos{SubforoRepository: memory.NewSubforoRepository(store)}
+
+	ids := make([]string, 5)
+	for i := range ids {
+		s := &models.Subforo{Title: "Foro" + string(rune('A'+i)), CreatedBy: "u"}
+		if err := subforos.Create(ctx, s); err != nil {
+			t.Fatalf("error creando el subforo: %v", err)
+		}
+		ids[i] = s.ForumID
+	}
+
+	// Cada post menciona varios subforos, alguno repetido y uno que no existe
+	posts := make([]*models.Post, len(ids))
+	for i := range posts {
+		posts[i] = &models.Post{Content: "ver s/" + ids[i] + " y s/" + ids[0] + " y s/noexiste"}
+	}
+	r := usecases.NewContentRenderer(memory.NewUserLoader(store), subforos)
+	r.Posts(ctx, posts...)
+
+	if subforos.single != 0 || subforos.batch != 1 {
+		t.Errorf("%d lecturas sueltas y %d por lote, se esperaba 1 por lote", subforos.single, subforos.batch)
+	}
+	for i, p := range posts {
+		if !strings.Contains(p.ContentHTML, "s/Foro"+string(rune('A'+i))) || !strings.Contains(p.ContentHTML, "s/ForoA") {
+			t.Errorf("post %d: menciones sin resolver: %s", i, p.ContentHTML)
+		}
+		if strings.Contains(p.ContentHTML, `href="/s/noexiste"`) {
+			t.Errorf("post %d: el subforo inexistente quedó como enlace: %s", i, p.ContentHTML)
+		}
+	}
+}
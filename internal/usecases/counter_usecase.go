@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+type CounterUsecase interface {
+	// ReconcileCounters compara los contadores de likes y dislikes de posts y
+	// comentarios con sus votos y, si repair es true, corrige los que difieren.
+	ReconcileCounters(ctx context.Context, repair bool) (*models.CounterReport, error)
+}
+
+type counterUsecase struct {
+	repo repositories.CounterRepository
+}
+
+func NewCounterUsecase(repo repositories.CounterRepository) CounterUsecase {
+	return &counterUsecase{repo: repo}
+}
+
+func (u *counterUsecase) ReconcileCounters(ctx context.Context, repair bool) (*models.CounterReport, error) {
+	report := &models.CounterReport{StartedAt: time.Now(), Repair: repair}
+
+	var err error
+	report.PostsChecked, report.Posts, err = u.repo.PostCounterDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.CommentsChecked, report.Comments, err = u.repo.CommentCounterDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if repair {
+		// Se recalcula cada documento al repararlo: puede haber cambiado
+		// desde la revisión, y se guarda el resultado de la reparación.
+		for i, d := range report.Posts {
+			if report.Posts[i], err = u.repo.RepairPostCounters(ctx, d.ID); err != nil {
+				return nil, err
+			}
+		}
+		for i, d := range report.Comments {
+			if report.Comments[i], err = u.repo.RepairCommentCounters(ctx, d.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if report.Posts == nil {
+		report.Posts = []models.CounterDrift{}
+	}
+	if report.Comments == nil {
+		report.Comments = []models.CounterDrift{}
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// ErrSelfBlock indica que el usuario intentó bloquearse a sí mismo.
+var ErrSelfBlock = errors.New("no puedes bloquearte a ti mismo")
+
+type FeedUsecase interface {
+	// Feed pagina con el orden order los posts de los subforos activos de los
+	// que userID es miembro o moderador y los de los tags que sigue, sin los
+	// posts reportados ni los de los usuarios que bloqueó.
+	Feed(ctx context.Context, userID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+	// Block bloquea a blockedID para userID. Devuelve ErrNotFound si el
+	// usuario no existe.
+	Block(ctx context.Context, userID, blockedID string) (*models.Block, error)
+	Unblock(ctx context.Context, userID, blockedID string) error
+	// Blocked devuelve los usuarios que bloqueó userID, los más recientes
+	// primero.
+	Blocked(ctx context.Context, userID string) ([]*models.Block, error)
+}
+
+type feedUsecase struct {
+	posts    repositories.PostRepository
+	subforos repositories.SubforoRepository
+	blocks   repositories.BlockRepository
+	tags     repositories.TagRepository
+	users    repositories.UserLoader
+	content  *ContentRenderer
+}
+
+func NewFeedUsecase(posts repositories.PostRepository, subforos repositories.SubforoRepository, blocks repositories.BlockRepository, tags repositories.TagRepository, users repositories.UserLoader, content *ContentRenderer) FeedUsecase {
+	return &feedUsecase{posts: posts, subforos: subforos, blocks: blocks, tags: tags, users: users, content: content}
+}
+
+func (u *feedUsecase) Feed(ctx context.Context, userID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	subforos, err := u.subforos.GetSubforosByUserID(ctx, userID)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	forumIDs := make([]string, 0, len(subforos))
+	for _, s := range subforos {
+		if s.IsActive {
+			forumIDs = append(forumIDs, s.ForumID)
+		}
+	}
+
+	tags, err := u.followedTags(ctx, userID)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+
+	blocks, err := u.blocks.GetBlocked(ctx, userID)
+	if err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	blockedIDs := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		blockedIDs = append(blockedIDs, b.BlockedID)
+	}
+
+	result, err := u.posts.GetFeed(ctx, forumIDs, tags, blockedIDs, order, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
+}
+
+// followedTags devuelve los tags que sigue userID. Los que se unieron a otro
+// después de seguirlos cuentan como el otro.
+func (u *feedUsecase) followedTags(ctx context.Context, userID string) ([]string, error) {
+	follows, err := u.tags.GetFollowed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(follows))
+	for _, f := range follows {
+		names = append(names, f.Tag)
+	}
+	registered, err := u.tags.GetTags(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		if t, ok := registered[name]; ok {
+			name = t.Canonical()
+		}
+		if !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
+
+func (u *feedUsecase) Block(ctx context.Context, userID, blockedID string) (*models.Block, error) {
+	if userID == blockedID {
+		return nil, ErrSelfBlock
+	}
+	blocked, err := repositories.LoadUser(ctx, u.users, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked == nil {
+		return nil, ErrNotFound
+	}
+
+	block := &models.Block{UserID: userID, BlockedID: blockedID, CreatedAt: time.Now()}
+	if err := u.blocks.Block(ctx, block); err != nil {
+		return nil, err
+	}
+	block.Blocked = blocked
+	return block, nil
+}
+
+func (u *feedUsecase) Unblock(ctx context.Context, userID, blockedID string) error {
+	return u.blocks.Unblock(ctx, userID, blockedID)
+}
+
+func (u *feedUsecase) Blocked(ctx context.Context, userID string) ([]*models.Block, error) {
+	blocks, err := u.blocks.GetBlocked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		ids = append(ids, b.BlockedID)
+	}
+	users, err := u.users.LoadUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range blocks {
+		b.Blocked = users[b.BlockedID]
+	}
+	return blocks, nil
+}
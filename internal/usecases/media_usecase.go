@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+var (
+	// ErrMediaLimit indica que la galería del post ya está llena.
+	ErrMediaLimit = fmt.Errorf("un post tiene a lo sumo %d imágenes", models.MaxPostMedia)
+	// ErrInvalidMedia indica que el texto de la imagen o el orden pedido no son válidos.
+	ErrInvalidMedia = errors.New("galería inválida")
+)
+
+// maxMediaText es el largo máximo de la leyenda y el texto alternativo.
+const maxMediaText = 500
+
+type MediaUsecase interface {
+	// AddMedia sube la imagen y la agrega a la galería del post en position;
+	// si position es negativa o pasa el final, la agrega al final.
+	AddMedia(ctx context.Context, postID, userID string, file io.Reader, caption, alt string, position int) (*models.Media, error)
+	// UpdateMedia cambia la leyenda y el texto alternativo de una imagen; los
+	// nil no cambian.
+	UpdateMedia(ctx context.Context, postID, userID, mediaID string, caption, alt *string) (*models.Media, error)
+	// RemoveMedia saca la imagen de la galería y la borra del almacenamiento.
+	RemoveMedia(ctx context.Context, postID, userID, mediaID string) error
+	// ReorderMedia ordena la galería según order, que debe tener los IDs de
+	// todas sus imágenes.
+	ReorderMedia(ctx context.Context, postID, userID string, order []string) ([]models.Media, error)
+}
+
+type mediaUsecase struct {
+	posts   repositories.PostRepository
+	storage service.MediaStorage
+}
+
+func NewMediaUsecase(posts repositories.PostRepository, storage service.MediaStorage) MediaUsecase {
+	return &mediaUsecase{posts: posts, storage: storage}
+}
+
+// ValidateMediaText controla el largo de la leyenda y el texto alternativo.
+func ValidateMediaText(caption, alt string) error {
+	if len(caption) > maxMediaText || len(alt) > maxMediaText {
+		return fmt.Errorf("%w: la leyenda y el texto alternativo tienen a lo sumo %d caracteres", ErrInvalidMedia, maxMediaText)
+	}
+	return nil
+}
+
+// update cambia la galería de un post de userID con fn. Los posts archivados
+// o cerrados no admiten cambios en la galería; se controla dentro de la
+// transacción para no competir con un moderador que los archiva o cierra.
+func (u *mediaUsecase) update(ctx context.Context, postID, userID string, fn func(media []models.Media) ([]models.Media, error)) error {
+	if _, err := u.posts.GetPostByID(ctx, postID); err != nil {
+		return ErrNotFound
+	}
+	return u.posts.UpdateMedia(ctx, postID, userID, func(p *models.Post) ([]models.Media, error) {
+		if p.AuthorID != userID {
+			return nil, ErrForbidden
+		}
+		if err := p.Writable(); err != nil {
+			return nil, err
+		}
+		return fn(p.Media)
+	})
+}
+
+func (u *mediaUsecase) AddMedia(ctx context.Context, postID, userID string, file io.Reader, caption, alt string, position int) (*models.Media, error) {
+	if err := ValidateMediaText(caption, alt); err != nil {
+		return nil, err
+	}
+	url, publicID, err := u.storage.Upload(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	item := models.Media{
+		ID:       repositories.NewDocumentID(),
+		URL:      url,
+		PublicID: publicID,
+		Caption:  caption,
+		Alt:      alt,
+	}
+	err = u.update(ctx, postID, userID, func(media []models.Media) ([]models.Media, error) {
+		if len(media) >= models.MaxPostMedia {
+			return nil, ErrMediaLimit
+		}
+		if position < 0 || position > len(media) {
+			position = len(media)
+		}
+		return slices.Insert(slices.Clone(media), position, item), nil
+	})
+	if err != nil {
+		// La imagen no quedó en ningún post
+		if errDel := u.storage.Delete(ctx, publicID); errDel != nil {
+			log.Printf("⚠️ No se pudo borrar la imagen %s: %v", publicID, errDel)
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (u *mediaUsecase) UpdateMedia(ctx context.Context, postID, userID, mediaID string, caption, alt *string) (*models.Media, error) {
+	var updated models.Media
+	err := u.update(ctx, postID, userID, func(media []models.Media) ([]models.Media, error) {
+		i := slices.IndexFunc(media, func(m models.Media) bool { return m.ID == mediaID })
+		if i < 0 {
+			return nil, ErrNotFound
+		}
+		media = slices.Clone(media)
+		if caption != nil {
+			media[i].Caption = *caption
+		}
+		if alt != nil {
+			media[i].Alt = *alt
+		}
+		if err := ValidateMediaText(media[i].Caption, media[i].Alt); err != nil {
+			return nil, err
+		}
+		updated = media[i]
+		return media, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RemoveMedia no borra la imagen si la usa alguna revisión del post: se borra
+// cuando se purga el post.
+func (u *mediaUsecase) RemoveMedia(ctx context.Context, postID, userID, mediaID string) error {
+	var removed models.Media
+	err := u.update(ctx, postID, userID, func(media []models.Media) ([]models.Media, error) {
+		i := slices.IndexFunc(media, func(m models.Media) bool { return m.ID == mediaID })
+		if i < 0 {
+			return nil, ErrNotFound
+		}
+		removed = media[i]
+		return slices.Delete(slices.Clone(media), i, i+1), nil
+	})
+	if err != nil || removed.PublicID == "" {
+		return err
+	}
+
+	revisions, err := u.posts.GetRevisions(ctx, postID)
+	if err != nil {
+		return err
+	}
+	for _, rev := range revisions {
+		if rev.ImageID == removed.PublicID {
+			return nil
+		}
+	}
+	return u.storage.Delete(ctx, removed.PublicID)
+}
+
+func (u *mediaUsecase) ReorderMedia(ctx context.Context, postID, userID string, order []string) ([]models.Media, error) {
+	var reordered []models.Media
+	err := u.update(ctx, postID, userID, func(media []models.Media) ([]models.Media, error) {
+		if len(order) != len(media) {
+			return nil, fmt.Errorf("%w: el orden debe tener las %d imágenes del post", ErrInvalidMedia, len(media))
+		}
+		reordered = make([]models.Media, 0, len(media))
+		for _, id := range order {
+			i := slices.IndexFunc(media, func(m models.Media) bool { return m.ID == id })
+			if i < 0 || slices.ContainsFunc(reordered, func(m models.Media) bool { return m.ID == id }) {
+				return nil, fmt.Errorf("%w: el orden debe tener las %d imágenes del post", ErrInvalidMedia, len(media))
+			}
+			reordered = append(reordered, media[i])
+		}
+		return reordered, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reordered, nil
+}
@@ -0,0 +1,129 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// deletedStorage guarda las imágenes que se borran.
+type deletedStorage struct {
+	deleted []string
+}
+
+func (s *deletedStorage) Upload(context.Context, io.Reader) (string, string, error) {
+	return "", "", errors.New("no se sube nada")
+}
+
+func (s *deletedStorage) Delete(_ context.Context, publicID string) error {
+	s.deleted = append(s.deleted, publicID)
+	return nil
+}
+
+func TestMediaCoverRevisions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	storage := &deletedStorage{}
+	u := usecases.NewMediaUsecase(posts, storage)
+	p := usecases.NewPostUsecase(posts, memory.NewSubforoRepository(store), nil, nil, nopIndexer{}, nopTags{})
+	if err := memory.NewUserRepository(store).CreateUser(ctx, "autor", map[string]interface{}{"username": "autor"}); err != nil {
+		t.Fatalf("error creando el usuario: %v", err)
+	}
+	post := &models.Post{
+		AuthorID: "autor",
+		Title:    "galería",
+		Content:  "galería",
+		Status:   models.PostPublished,
+		Media: []models.Media{
+			{ID: "a", URL: "https://img/a", PublicID: "a"},
+			{ID: "b", URL: "https://img/b", PublicID: "b"},
+			{ID: "c", URL: "https://img/c", PublicID: "c"},
+		},
+	}
+	post.SetMedia(post.Media)
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("error creando el post: %v", err)
+	}
+
+	// Sacar una imagen que no es la portada no crea revisiones y la borra
+	if err := u.RemoveMedia(ctx, post.ID, "autor", "c"); err != nil {
+		t.Fatalf("RemoveMedia: %v", err)
+	}
+	if revisions, _ := posts.GetRevisions(ctx, post.ID); len(revisions) != 0 {
+		t.Errorf("se guardaron %d revisiones sin cambiar la portada", len(revisions))
+	}
+	// Sacar la portada la cambia: queda en la revisión 1 y no se borra
+	if err := u.RemoveMedia(ctx, post.ID, "autor", "a"); err != nil {
+		t.Fatalf("RemoveMedia: %v", err)
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != "c" {
+		t.Errorf("se borraron %v, se esperaba solo c", storage.deleted)
+	}
+
+	revisions, err := p.GetRevisions(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("error obteniendo las revisiones: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].ImageID != "a" || revisions[1].ImageID != "b" {
+		t.Fatalf("revisiones = %+v, se esperaban la 1 con a y la 2 con b", revisions)
+	}
+	diff, err := p.DiffRevisions(ctx, post.ID, 1, 2)
+	if err != nil || !diff.ImageChanged {
+		t.Errorf("DiffRevisions = %+v, %v, se esperaba ImageChanged", diff, err)
+	}
+}
+
+func TestMediaReadOnlyPosts(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	u := usecases.NewMediaUsecase(posts, nil)
+	if err := memory.NewUserRepository(store).CreateUser(ctx, "autor", map[string]interface{}{"username": "autor"}); err != nil {
+		t.Fatalf("error creando el usuario: %v", err)
+	}
+
+	tests := map[models.ModerationAction]error{
+		models.ActionArchive: models.ErrPostArchived,
+		models.ActionLock:    models.ErrPostLocked,
+	}
+	for action, want := range tests {
+		post := &models.Post{
+			AuthorID: "autor",
+			Title:    "galería",
+			Content:  "galería",
+			Status:   models.PostPublished,
+			Media:    []models.Media{{ID: "a", URL: "https://img/a"}, {ID: "b", URL: "https://img/b"}},
+		}
+		if err := posts.Create(ctx, post); err != nil {
+			t.Fatalf("error creando el post: %v", err)
+		}
+		if _, _, err := posts.Moderate(ctx, post.ID, &models.ModerationLogEntry{Action: action}); err != nil {
+			t.Fatalf("error moderando el post: %v", err)
+		}
+
+		caption := "nueva"
+		if _, err := u.UpdateMedia(ctx, post.ID, "autor", "a", &caption, nil); !errors.Is(err, want) {
+			t.Errorf("%s: UpdateMedia = %v, se esperaba %v", action, err, want)
+		}
+		if _, err := u.ReorderMedia(ctx, post.ID, "autor", []string{"b", "a"}); !errors.Is(err, want) {
+			t.Errorf("%s: ReorderMedia = %v, se esperaba %v", action, err, want)
+		}
+		if err := u.RemoveMedia(ctx, post.ID, "autor", "a"); !errors.Is(err, want) {
+			t.Errorf("%s: RemoveMedia = %v, se esperaba %v", action, err, want)
+		}
+
+		stored, err := posts.GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("error obteniendo el post: %v", err)
+		}
+		if len(stored.Post.Media) != 2 || stored.Post.Media[0].ID != "a" || stored.Post.Media[0].Caption != "" {
+			t.Errorf("%s: la galería cambió: %+v", action, stored.Post.Media)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+var (
+	// ErrPinLimit indica que el subforo ya tiene models.MaxPinnedPosts posts
+	// fijados.
+	ErrPinLimit = repositories.ErrPinLimit
+	// ErrInvalidArchiveAfter indica un plazo de archivado negativo.
+	ErrInvalidArchiveAfter = errors.New("archive_after_days no puede ser negativo")
+)
+
+type ModerationUsecase interface {
+	// Moderate aplica la acción al post y la guarda en el registro del
+	// subforo. Solo lo puede hacer un moderador del subforo del post. Aplicar
+	// una acción que no cambia nada no es un error y no se registra.
+	Moderate(ctx context.Context, postID, moderatorID string, action models.ModerationAction) (*models.Post, error)
+	// SetArchiveAfter hace que los posts del subforo se archiven days días
+	// después de publicarse; 0 desactiva el archivado automático.
+	SetArchiveAfter(ctx context.Context, forumID, moderatorID string, days int) (*models.Subforo, error)
+	// Log pagina el registro de moderación del subforo, las acciones más
+	// recientes primero. Solo lo ven los moderadores.
+	Log(ctx context.Context, forumID, moderatorID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error)
+	// ArchiveOld archiva los posts que superaron el plazo de su subforo y
+	// devuelve cuántos archivó.
+	ArchiveOld(ctx context.Context) (int, error)
+}
+
+type moderationUsecase struct {
+	posts    repositories.PostRepository
+	subforos repositories.SubforoRepository
+	log      repositories.ModerationRepository
+}
+
+func NewModerationUsecase(posts repositories.PostRepository, subforos repositories.SubforoRepository, log repositories.ModerationRepository) ModerationUsecase {
+	return &moderationUsecase{posts: posts, subforos: subforos, log: log}
+}
+
+// moderated devuelve el subforo si moderatorID lo puede moderar.
+func (u *moderationUsecase) moderated(ctx context.Context, forumID, moderatorID string) (*models.Subforo, error) {
+	subforo, err := u.subforos.GetSubforoByID(ctx, forumID)
+	if err != nil || subforo == nil {
+		return nil, ErrNotFound
+	}
+	if !subforo.CanModerate(moderatorID) {
+		return nil, ErrForbidden
+	}
+	return subforo, nil
+}
+
+func (u *moderationUsecase) Moderate(ctx context.Context, postID, moderatorID string, action models.ModerationAction) (*models.Post, error) {
+	if !action.Valid() {
+		return nil, models.ErrInvalidAction
+	}
+	post, err := u.posts.GetPostByID(ctx, postID)
+	if err != nil || !post.Post.IsPublished() {
+		return nil, ErrNotFound
+	}
+	if _, err := u.moderated(ctx, post.Post.ForumID, moderatorID); err != nil {
+		return nil, err
+	}
+
+	// El repositorio controla el límite de fijados y registra la acción en la
+	// misma transacción que la aplica
+	entry := &models.ModerationLogEntry{
+		Action:      action,
+		ModeratorID: moderatorID,
+		CreatedAt:   time.Now(),
+	}
+	moderated, _, err := u.posts.Moderate(ctx, postID, entry)
+	if err != nil {
+		return nil, err
+	}
+	return moderated, nil
+}
+
+func (u *moderationUsecase) SetArchiveAfter(ctx context.Context, forumID, moderatorID string, days int) (*models.Subforo, error) {
+	if days < 0 {
+		return nil, ErrInvalidArchiveAfter
+	}
+	subforo, err := u.moderated(ctx, forumID, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.subforos.SetArchiveAfter(ctx, forumID, days); err != nil {
+		return nil, err
+	}
+	subforo.ArchiveAfterDays = days
+	return subforo, nil
+}
+
+func (u *moderationUsecase) Log(ctx context.Context, forumID, moderatorID string, page models.PageRequest) (models.Page[*models.ModerationLogEntry], error) {
+	if _, err := u.moderated(ctx, forumID, moderatorID); err != nil {
+		return models.Page[*models.ModerationLogEntry]{}, err
+	}
+	return u.log.GetLog(ctx, forumID, page)
+}
+
+// ArchiveOld registra cada post archivado como una acción sin moderador. Si
+// falla un subforo sigue con los demás y devuelve el primer error.
+func (u *moderationUsecase) ArchiveOld(ctx context.Context) (int, error) {
+	subforos, err := u.subforos.GetArchiving(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	archived := 0
+	var firstErr error
+	for _, s := range subforos {
+		ids, err := u.posts.ArchiveBefore(ctx, s.ForumID, now.AddDate(0, 0, -s.ArchiveAfterDays), now)
+		for _, id := range ids {
+			entry := &models.ModerationLogEntry{
+				ForumID:   s.ForumID,
+				PostID:    id,
+				Action:    models.ActionArchive,
+				CreatedAt: now,
+			}
+			if err := u.log.Record(ctx, entry); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		archived += len(ids)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return archived, firstErr
+}
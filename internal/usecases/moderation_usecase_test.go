@@ -0,0 +1,81 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+func TestModerateConcurrentPins(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	subforos := memory.NewSubforoRepository(store)
+	log := memory.NewModerationRepository(store)
+	u := usecases.NewModerationUsecase(posts, subforos, log)
+
+	subforo := &models.Subforo{Title: "fijados", CreatedBy: "mod", Moderators: []string{"mod"}, IsActive: true}
+	if err := subforos.Create(ctx, subforo); err != nil {
+		t.Fatalf("error creando el subforo: %v", err)
+	}
+	ids := make([]string, 10)
+	for i := range ids {
+		post := &models.Post{ForumID: subforo.ForumID, Title: "fijar", Content: "fijar", Status: models.PostPublished}
+		if err := posts.Create(ctx, post); err != nil {
+			t.Fatalf("error creando el post: %v", err)
+		}
+		ids[i] = post.ID
+	}
+
+	// Todos se fijan a la vez: solo entran MaxPinnedPosts y el resto falla
+	// con ErrPinLimit
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	limited := 0
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := u.Moderate(ctx, id, "mod", models.ActionPin)
+			switch {
+			case errors.Is(err, usecases.ErrPinLimit):
+				mu.Lock()
+				limited++
+				mu.Unlock()
+			case err != nil:
+				t.Errorf("error al fijar: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	pinned, err := posts.GetPinnedPosts(ctx, subforo.ForumID)
+	if err != nil {
+		t.Fatalf("error obteniendo los fijados: %v", err)
+	}
+	if len(pinned) != models.MaxPinnedPosts || limited != len(ids)-models.MaxPinnedPosts {
+		t.Errorf("%d fijados y %d rechazados, se esperaban %d y %d", len(pinned), limited, models.MaxPinnedPosts, len(ids)-models.MaxPinnedPosts)
+	}
+
+	// Cada fijado quedó en el registro, y repetir la acción no lo duplica
+	if _, err := u.Moderate(ctx, pinned[0].ID, "mod", models.ActionPin); err != nil {
+		t.Fatalf("error al volver a fijar: %v", err)
+	}
+	entries, err := u.Log(ctx, subforo.ForumID, "mod", models.PageRequest{Limit: 50})
+	if err != nil {
+		t.Fatalf("error obteniendo el registro: %v", err)
+	}
+	if len(entries.Items) != models.MaxPinnedPosts {
+		t.Errorf("%d entradas en el registro, se esperaban %d", len(entries.Items), models.MaxPinnedPosts)
+	}
+	for _, e := range entries.Items {
+		if e.ForumID != subforo.ForumID || e.PostID == "" || e.Action != models.ActionPin {
+			t.Errorf("entrada incompleta: %+v", e)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+var (
+	// ErrPollClosed indica que la encuesta ya no acepta votos.
+	ErrPollClosed = errors.New("la encuesta está cerrada")
+	// ErrInvalidBallot indica que las opciones elegidas no son válidas para la encuesta.
+	ErrInvalidBallot = errors.New("opciones inválidas para la encuesta")
+)
+
+type PollUsecase interface {
+	// Vote guarda el voto de userID en la encuesta del post. Cada usuario
+	// vota una sola vez: un segundo voto devuelve repositories.ErrAlreadyVoted.
+	Vote(ctx context.Context, postID, userID string, options []int) (*models.PollResults, error)
+	// Results devuelve la encuesta vista por userID. Los resultados solo se
+	// muestran si ya votó o si la encuesta cerró.
+	Results(ctx context.Context, postID, userID string) (*models.PollResults, error)
+}
+
+type pollUsecase struct {
+	repo  repositories.PollRepository
+	posts repositories.PostRepository
+}
+
+func NewPollUsecase(repo repositories.PollRepository, posts repositories.PostRepository) PollUsecase {
+	return &pollUsecase{repo: repo, posts: posts}
+}
+
+// poll devuelve el post, que debe estar publicado y tener encuesta.
+func (u *pollUsecase) poll(ctx context.Context, postID string) (*models.Post, error) {
+	post, err := u.posts.GetPostByID(ctx, postID)
+	if err != nil || !post.Post.IsPublished() || post.Post.Poll == nil {
+		return nil, ErrNotFound
+	}
+	return &post.Post, nil
+}
+
+func (u *pollUsecase) Vote(ctx context.Context, postID, userID string, options []int) (*models.PollResults, error) {
+	post, err := u.poll(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := post.Writable(); err != nil {
+		return nil, err
+	}
+	poll := post.Poll
+	now := time.Now()
+	if poll.Closed(now) {
+		return nil, ErrPollClosed
+	}
+	if err := validateBallot(poll, options); err != nil {
+		return nil, err
+	}
+
+	ballot := &models.PollBallot{PostID: postID, UserID: userID, Options: options, CreatedAt: now}
+	if err := u.repo.CastBallot(ctx, ballot); err != nil {
+		return nil, err
+	}
+	return u.results(ctx, postID, poll, ballot, now)
+}
+
+// validateBallot exige una sola opción en las encuestas de opción única y
+// opciones distintas dentro del rango en las de opción múltiple. Deja las
+// opciones ordenadas.
+func validateBallot(poll *models.Poll, options []int) error {
+	if len(options) == 0 || (!poll.Multiple && len(options) > 1) {
+		return ErrInvalidBallot
+	}
+	slices.Sort(options)
+	for i, option := range options {
+		if option < 0 || option >= len(poll.Options) {
+			return ErrInvalidBallot
+		}
+		if i > 0 && options[i-1] == option {
+			return ErrInvalidBallot
+		}
+	}
+	return nil
+}
+
+func (u *pollUsecase) Results(ctx context.Context, postID, userID string) (*models.PollResults, error) {
+	post, err := u.poll(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	ballot, err := u.repo.GetBallot(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.results(ctx, postID, post.Poll, ballot, time.Now())
+}
+
+// results arma la vista de la encuesta para el usuario que emitió ballot
+// (nil si no votó). Solo cuenta los votos si se van a mostrar.
+func (u *pollUsecase) results(ctx context.Context, postID string, poll *models.Poll, ballot *models.PollBallot, now time.Time) (*models.PollResults, error) {
+	res := &models.PollResults{
+		PostID:    postID,
+		Poll:      *poll,
+		Closed:    poll.Closed(now),
+		MyOptions: []int{},
+	}
+	if ballot != nil {
+		res.MyOptions = ballot.Options
+	}
+	if ballot == nil && !res.Closed {
+		return res, nil
+	}
+	tally, err := u.repo.Tally(ctx, postID, len(poll.Options))
+	if err != nil {
+		return nil, err
+	}
+	res.Results = tally
+	return res, nil
+}
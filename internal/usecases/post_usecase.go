@@ -2,79 +2,426 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
 
+	"github.com/JuanPidarraga/talkus-backend/internal/diff"
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
 	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
 	"github.com/JuanPidarraga/talkus-backend/internal/service"
 )
 
 type PostUsecase struct {
-	repo        *repositories.PostRepository
-	subforoRepo *repositories.SubforoRepository
+	repo        repositories.PostRepository
+	subforoRepo repositories.SubforoRepository
+	links       service.LinkPreviewer
+	content     *ContentRenderer
+	search      SearchIndexer
+	tags        TagRegistry
 }
 
-func NewPostUsecase(repo *repositories.PostRepository, subforoRepo *repositories.SubforoRepository) *PostUsecase {
+func NewPostUsecase(repo repositories.PostRepository, subforoRepo repositories.SubforoRepository, links service.LinkPreviewer, content *ContentRenderer, search SearchIndexer, tags TagRegistry) *PostUsecase {
 	return &PostUsecase{
 		repo:        repo,
 		subforoRepo: subforoRepo,
+		links:       links,
+		content:     content,
+		search:      search,
+		tags:        tags,
 	}
 }
 
+// GetPostByID devuelve el post si está publicado. Los borradores se ven con
+// GetDrafts.
 func (u *PostUsecase) GetPostByID(ctx context.Context, id string) (*models.PostWithAuthor, error) {
 	post, err := u.repo.GetPostByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if !post.Post.IsPublished() {
+		return nil, ErrNotFound
+	}
+	u.content.Posts(ctx, &post.Post)
 	return post, nil
 }
 
-func (u *PostUsecase) GetAllPosts(ctx context.Context) ([]*models.Post, error) {
-	return u.repo.GetAll(ctx)
+// GetAllPosts pagina los posts publicados con el orden order.
+func (u *PostUsecase) GetAllPosts(ctx context.Context, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetAll(ctx, order, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
 }
 
+// CreatePost crea el post. Si es un borrador (Status draft, o scheduled con
+// PublishAt) se guarda sin publicar y el veredicto se calcula al publicarlo.
+// Si es un post de enlace (Link con la URL), guarda también su vista previa.
+// Los tags se guardan normalizados y con los sinónimos reemplazados.
 func (u *PostUsecase) CreatePost(ctx context.Context, p *models.Post) (*models.Post, error) {
+	tags, err := u.tags.Canonical(ctx, p.Tags)
+	if err != nil {
+		return nil, err
+	}
+	p.Tags = tags
+	if p.Link != nil {
+		link, err := u.PreviewLink(ctx, p.Link.URL)
+		if err != nil {
+			return nil, err
+		}
+		p.Link = link
+	}
+	if p.Status == "" {
+		p.Status = models.PostPublished
+	}
+	if p.Status == models.PostPublished {
+		p.PublishAt = nil
+		p.Verdict = u.verdict(ctx, p)
+	} else if err := validateDraft(p); err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	if p.IsPublished() {
+		u.search.Post(ctx, p.ID)
+		u.tags.Recount(ctx, p.Tags...)
+	}
+	u.content.Posts(ctx, p)
+	return p, nil
+}
+
+// PreviewLink devuelve la vista previa de la URL. Si la página no responde,
+// la vista previa tiene solo la URL: el post de enlace se puede crear igual.
+func (u *PostUsecase) PreviewLink(ctx context.Context, rawURL string) (*models.LinkPreview, error) {
+	link, err := service.NormalizeLink(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := u.links.Preview(ctx, link)
+	if errors.Is(err, service.ErrBlockedLink) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("⚠️ Sin vista previa para %s: %v", link, err)
+		return &models.LinkPreview{URL: link, FetchedAt: time.Now()}, nil
+	}
+	return preview, nil
+}
+
+// verdict analiza con IA si el post corresponde a su subforo.
+func (u *PostUsecase) verdict(ctx context.Context, p *models.Post) string {
 	// Si hay un forum_id, calcular el veredicto usando IA
 	if p.ForumID != "" {
 		// Obtener la descripción del subforo
 		subforo, err := u.subforoRepo.GetSubforoByID(ctx, p.ForumID)
 		if err != nil {
 			// Si no se puede obtener el subforo, continuar sin veredicto
-			p.Verdict = "No se pudo analizar el contenido"
-		} else {
-			// Calcular similitud y obtener veredicto
-			_, verdict, err := service.CalculateTextSimilarity(p.Title+" "+p.Content, subforo.Description)
+			return "No se pudo analizar el contenido"
+		}
+		// Calcular similitud y obtener veredicto
+		_, verdict, err := service.CalculateTextSimilarity(p.Title+" "+p.Content, subforo.Description)
+		if err != nil {
+			// Si hay error en el análisis, continuar sin veredicto
+			return "Error en el análisis de contenido"
+		}
+		return verdict
+	}
+	// Si no hay forum_id, no se puede analizar
+	return "No aplicable"
+}
+
+// GetPostsByAuthorID pagina los posts del autor. Si quien consulta (viewerID)
+// es el autor, incluye sus borradores y posts programados.
+func (u *PostUsecase) GetPostsByAuthorID(ctx context.Context, authorID, viewerID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetPostsByAuthorID(ctx, authorID, authorID == viewerID, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
+}
+
+// ErrPublishAt indica que la fecha de publicación programada no es válida.
+var ErrPublishAt = errors.New("publish_at debe ser una fecha futura")
+
+// validateDraft deja el estado del borrador según PublishAt: programado si
+// tiene fecha, que debe ser futura, o borrador si no.
+func validateDraft(p *models.Post) error {
+	if p.PublishAt == nil {
+		p.Status = models.PostDraft
+		return nil
+	}
+	if !p.PublishAt.After(time.Now()) {
+		return ErrPublishAt
+	}
+	p.Status = models.PostScheduled
+	return nil
+}
+
+// GetDrafts pagina los borradores y posts programados del autor.
+func (u *PostUsecase) GetDrafts(ctx context.Context, authorID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetDrafts(ctx, authorID, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
+}
+
+// draft devuelve el borrador id si es de userID.
+func (u *PostUsecase) draft(ctx context.Context, id, userID string) (*models.Post, error) {
+	post, err := u.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if post.Post.AuthorID != userID {
+		return nil, ErrForbidden
+	}
+	if post.Post.IsPublished() {
+		return nil, repositories.ErrPublished
+	}
+	return &post.Post, nil
+}
+
+// UpdateDraft reemplaza el título, el contenido, los tags, el subforo y la
+// fecha de publicación de un borrador de userID. Sin PublishAt el post queda
+// como borrador; con PublishAt, programado.
+func (u *PostUsecase) UpdateDraft(ctx context.Context, id, userID string, p *models.Post) error {
+	if _, err := u.draft(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := validateDraft(p); err != nil {
+		return err
+	}
+	tags, err := u.tags.Canonical(ctx, p.Tags)
+	if err != nil {
+		return err
+	}
+	p.Tags = tags
+	return u.repo.UpdateDraft(ctx, id, p)
+}
+
+// PublishDraft publica ahora un borrador de userID.
+func (u *PostUsecase) PublishDraft(ctx context.Context, id, userID string) error {
+	post, err := u.draft(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.Publish(ctx, id, u.verdict(ctx, post), time.Now()); err != nil {
+		return err
+	}
+	u.search.Post(ctx, id)
+	u.tags.Recount(ctx, post.Tags...)
+	return nil
+}
+
+// publishBatch es la cantidad de posts programados que se leen por consulta.
+const publishBatch = 100
+
+// PublishDue publica los posts programados cuya fecha ya pasó y devuelve
+// cuántos publicó. Si uno falla lo registra y sigue con los demás; quedan
+// programados para el próximo intento y el error devuelto los reúne.
+func (u *PostUsecase) PublishDue(ctx context.Context) (int, error) {
+	published := 0
+	// DuePosts devuelve primero los que fallaron, que siguen programados;
+	// failed evita reintentarlos en esta pasada y se sigue leyendo mientras
+	// un lote traiga posts nuevos.
+	failed := make(map[string]bool)
+	var errs []error
+	for {
+		limit := publishBatch + len(failed)
+		due, err := u.repo.DuePosts(ctx, time.Now(), limit)
+		if err != nil {
+			return published, errors.Join(append(errs, err)...)
+		}
+		fresh := 0
+		for _, p := range due {
+			if failed[p.ID] {
+				continue
+			}
+			fresh++
+			err := u.repo.Publish(ctx, p.ID, u.verdict(ctx, p), *p.PublishAt)
+			if errors.Is(err, repositories.ErrPublished) {
+				// Su autor lo publicó mientras tanto
+				continue
+			}
 			if err != nil {
-				// Si hay error en el análisis, continuar sin veredicto
-				p.Verdict = "Error en el análisis de contenido"
-			} else {
-				p.Verdict = verdict
+				log.Printf("⚠️ Publicación del post %s: %v", p.ID, err)
+				failed[p.ID] = true
+				errs = append(errs, fmt.Errorf("post %s: %w", p.ID, err))
+				continue
 			}
+			u.search.Post(ctx, p.ID)
+			u.tags.Recount(ctx, p.Tags...)
+			published++
+		}
+		if fresh == 0 || len(due) < limit {
+			return published, errors.Join(errs...)
 		}
-	} else {
-		// Si no hay forum_id, no se puede analizar
-		p.Verdict = "No aplicable"
 	}
+}
 
-	if err := u.repo.Create(ctx, p); err != nil {
-		return nil, err
+// RerankRising recalcula el orden rising de los posts de las últimas
+// models.RisingWindow, que baja a medida que envejecen aunque no reciban votos,
+// y devuelve cuántos actualizó.
+func (u *PostUsecase) RerankRising(ctx context.Context) (int, error) {
+	now := time.Now()
+	return u.repo.RerankRising(ctx, now.Add(-models.RisingWindow), now)
+}
+
+// CanEdit devuelve ErrForbidden si userID no es el autor del post ni
+// moderador de su subforo, y models.ErrPostArchived si el post está
+// archivado, que es de solo lectura.
+func (u *PostUsecase) CanEdit(ctx context.Context, post *models.Post, userID string) error {
+	if post.AuthorID != userID && !u.moderates(ctx, post.ForumID, userID) {
+		return ErrForbidden
 	}
-	return p, nil
+	if post.ArchivedAt != nil {
+		return models.ErrPostArchived
+	}
+	return nil
+}
+
+// moderates indica si userID modera el subforo forumID.
+func (u *PostUsecase) moderates(ctx context.Context, forumID, userID string) bool {
+	if forumID == "" {
+		return false
+	}
+	subforo, err := u.subforoRepo.GetSubforoByID(ctx, forumID)
+	return err == nil && subforo != nil && subforo.CanModerate(userID)
+}
+
+// EditPost guarda la edición del post, con los tags como en CreatePost. Solo
+// lo pueden editar su autor y los moderadores del subforo, y no si está
+// archivado (ver CanEdit). Si p no trae galería, se conserva la del post.
+func (u *PostUsecase) EditPost(ctx context.Context, id, editorID string, p *models.Post) error {
+	post, err := u.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.CanEdit(ctx, &post.Post, editorID); err != nil {
+		return err
+	}
+	tags, err := u.tags.Canonical(ctx, p.Tags)
+	if err != nil {
+		return err
+	}
+	p.Tags = tags
+	if err := u.repo.Edit(ctx, id, editorID, p); err != nil {
+		return err
+	}
+	u.search.Post(ctx, id)
+	if post.Post.IsPublished() {
+		u.tags.Recount(ctx, append(missing(post.Post.Tags, tags), missing(tags, post.Post.Tags)...)...)
+	}
+	return nil
+}
+
+// ReplaceCover reemplaza la portada del post, la primera imagen de la
+// galería, por la imagen subida url/publicID, con la leyenda y el texto
+// alternativo de la anterior. Si la galería está vacía, la agrega. Se cambia
+// en la transacción de la galería, para no pisar cambios simultáneos.
+func (u *PostUsecase) ReplaceCover(ctx context.Context, id, editorID, url, publicID string) error {
+	return u.repo.UpdateMedia(ctx, id, editorID, func(p *models.Post) ([]models.Media, error) {
+		if err := u.CanEdit(ctx, p, editorID); err != nil {
+			return nil, err
+		}
+		cover := models.Media{ID: repositories.NewDocumentID(), URL: url, PublicID: publicID}
+		if len(p.Media) == 0 {
+			return []models.Media{cover}, nil
+		}
+		media := slices.Clone(p.Media)
+		cover.Caption, cover.Alt = media[0].Caption, media[0].Alt
+		media[0] = cover
+		return media, nil
+	})
 }
 
-func (u *PostUsecase) DeletePost(ctx context.Context, id string) error {
-	return u.repo.Delete(ctx, id)
+// GetRevisions devuelve el historial del post, de la revisión más antigua a
+// la más nueva. Un post que nunca se editó tiene solo la revisión 1, que es el
+// post actual.
+func (u *PostUsecase) GetRevisions(ctx context.Context, postID string) ([]*models.PostRevision, error) {
+	post, err := u.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	revisions, err := u.repo.GetRevisions(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		p := post.Post
+		revisions = append(revisions, &models.PostRevision{
+			ID:       repositories.RevisionID(p.ID, 1),
+			PostID:   p.ID,
+			Number:   1,
+			EditorID: p.AuthorID,
+			EditedAt: p.CreatedAt,
+			Title:    p.Title,
+			Content:  p.Content,
+			Tags:     p.Tags,
+			ImageURL: p.ImageURL,
+			ImageID:  p.ImageID,
+		})
+	}
+	return revisions, nil
 }
 
-func (u *PostUsecase) GetPostsByAuthorID(ctx context.Context, authorID string) ([]*models.Post, error) {
-	return u.repo.GetPostsByAuthorID(ctx, authorID)
+// DiffRevisions compara las revisiones from y to del post.
+func (u *PostUsecase) DiffRevisions(ctx context.Context, postID string, from, to int) (*models.RevisionDiff, error) {
+	revisions, err := u.GetRevisions(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	var a, b *models.PostRevision
+	for _, rev := range revisions {
+		if rev.Number == from {
+			a = rev
+		}
+		if rev.Number == to {
+			b = rev
+		}
+	}
+	if a == nil || b == nil {
+		return nil, ErrNotFound
+	}
+
+	return &models.RevisionDiff{
+		PostID:       postID,
+		From:         from,
+		To:           to,
+		Title:        diff.Lines(a.Title, b.Title),
+		Content:      diff.Lines(a.Content, b.Content),
+		TagsAdded:    missing(b.Tags, a.Tags),
+		TagsRemoved:  missing(a.Tags, b.Tags),
+		ImageChanged: a.ImageID != b.ImageID || a.ImageURL != b.ImageURL,
+	}, nil
 }
 
-func (u *PostUsecase) EditPost(ctx context.Context, id string, p *models.Post) error {
-	return u.repo.Edit(ctx, id, p)
+// missing devuelve los tags de tags que no están en other, sin repetir.
+func missing(tags, other []string) []string {
+	result := make([]string, 0)
+	for _, tag := range tags {
+		if !slices.Contains(other, tag) && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
 }
 
 func (u *PostUsecase) GetPostsILiked(ctx context.Context, userID string) ([]*models.Post, error) {
-	return u.repo.GetPostsILiked(ctx, userID)
+	posts, err := u.repo.GetPostsILiked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u.content.Posts(ctx, posts...)
+	return posts, nil
 }
 
 func (u *PostUsecase) SavePost(ctx context.Context, userID, postID string) error {
@@ -85,22 +432,131 @@ func (u *PostUsecase) UnsavePost(ctx context.Context, userID, postID string) err
 	return u.repo.RemoveSavedPost(ctx, userID, postID)
 }
 
-func (u *PostUsecase) GetSavedPosts(ctx context.Context, userID string) ([]*models.Post, error) {
-	return u.repo.GetSavedPostsByUser(ctx, userID)
+func (u *PostUsecase) GetSavedPosts(ctx context.Context, userID string, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetSavedPostsByUser(ctx, userID, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
 }
 
 func (u *PostUsecase) IsPostSaved(ctx context.Context, userID, postID string) (bool, error) {
 	return u.repo.IsPostSavedByUser(ctx, userID, postID)
 }
 
-func (u *PostUsecase) GetPostsByForumID(ctx context.Context, forumID string) ([]*models.Post, error) {
-	return u.repo.GetPostsByForumID(ctx, forumID)
+// GetPostsByForumID pagina los posts del subforo con el orden order. La
+// primera página empieza con los posts fijados, que no cuentan para el tamaño
+// de la página.
+func (u *PostUsecase) GetPostsByForumID(ctx context.Context, forumID string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetPostsByForumID(ctx, forumID, order, page)
+	if err != nil {
+		return result, err
+	}
+	if page.Cursor == "" {
+		pinned, err := u.repo.GetPinnedPosts(ctx, forumID)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(pinned, result.Items...)
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
 }
 
-func (u *PostUsecase) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string) ([]*models.Post, error) {
-	return u.repo.GetPostsByForumIDWithVerdict(ctx, forumID, verdict)
+func (u *PostUsecase) GetPostsByForumIDWithVerdict(ctx context.Context, forumID string, verdict string, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.repo.GetPostsByForumIDWithVerdict(ctx, forumID, verdict, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
 }
 
 func (u *PostUsecase) ReportPost(ctx context.Context, postID string) error {
 	return u.repo.ReportPost(ctx, postID)
 }
+
+var (
+	// ErrCrosspostSameForum indica que se quiso compartir el post en el
+	// subforo del original.
+	ErrCrosspostSameForum = errors.New("el post ya está en ese subforo")
+	// ErrAlreadyCrossposted indica que el post ya se compartió en ese subforo.
+	ErrAlreadyCrossposted = errors.New("el post ya se compartió en ese subforo")
+)
+
+// Crosspost comparte el post postID en el subforo forumID como un post nuevo
+// de userID, con sus propios votos y comentarios. Copia el título (o usa
+// title si no está vacío), el contenido, las etiquetas y el enlace; la
+// galería y la encuesta quedan en el original. Compartir un crosspost
+// comparte su original. El veredicto se calcula con la descripción de
+// forumID.
+func (u *PostUsecase) Crosspost(ctx context.Context, postID, userID, forumID, title string) (*models.Post, error) {
+	source, err := u.repo.GetPostByID(ctx, postID)
+	if err != nil || !source.Post.IsPublished() {
+		return nil, ErrNotFound
+	}
+	of := models.CrosspostOf{
+		PostID:   source.Post.ID,
+		AuthorID: source.Post.AuthorID,
+		ForumID:  source.Post.ForumID,
+	}
+	if source.Post.CrosspostOf != nil {
+		if source.Post.CrosspostOf.DeletedAt != nil {
+			return nil, ErrNotFound
+		}
+		of = *source.Post.CrosspostOf
+	}
+
+	subforo, err := u.subforoRepo.GetSubforoByID(ctx, forumID)
+	if err != nil || subforo == nil || !subforo.IsActive {
+		return nil, ErrNotFound
+	}
+	if forumID == of.ForumID {
+		return nil, ErrCrosspostSameForum
+	}
+	crossposts, err := u.repo.GetCrossposts(ctx, of.PostID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range crossposts {
+		if c.ForumID == forumID {
+			return nil, ErrAlreadyCrossposted
+		}
+	}
+
+	if title == "" {
+		title = source.Post.Title
+	}
+	p := &models.Post{
+		AuthorID:    userID,
+		Title:       title,
+		Content:     source.Post.Content,
+		Tags:        source.Post.Tags,
+		ForumID:     forumID,
+		Link:        source.Post.Link,
+		Status:      models.PostPublished,
+		CrosspostOf: &of,
+	}
+	p.Verdict = u.verdict(ctx, p)
+	if err := u.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	u.search.Post(ctx, p.ID)
+	u.tags.Recount(ctx, p.Tags...)
+	u.content.Posts(ctx, p)
+	return p, nil
+}
+
+// GetCrossposts devuelve los crossposts del post, los más recientes primero.
+func (u *PostUsecase) GetCrossposts(ctx context.Context, postID string) ([]*models.Post, error) {
+	if _, err := u.GetPostByID(ctx, postID); err != nil {
+		return nil, ErrNotFound
+	}
+	crossposts, err := u.repo.GetCrossposts(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	u.content.Posts(ctx, crossposts...)
+	return crossposts, nil
+}
@@ -0,0 +1,170 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// failingPublish falla al publicar los posts de fail.
+type failingPublish struct {
+	repositories.PostRepository
+	fail map[string]bool
+}
+
+func (r *failingPublish) Publish(ctx context.Context, id, verdict string, at time.Time) error {
+	if r.fail[id] {
+		return errors.New("falla simulada")
+	}
+	return r.PostRepository.Publish(ctx, id, verdict, at)
+}
+
+func TestPublishDueSkipsFailures(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := &failingPublish{PostRepository: memory.NewPostRepository(store), fail: make(map[string]bool)}
+	u := usecases.NewPostUsecase(posts, memory.NewSubforoRepository(store), nil, nil, nopIndexer{}, nopTags{})
+
+	// Más de un lote, con los que fallan entre los más atrasados para que
+	// DuePosts los devuelva primero en cada consulta
+	const total = 250
+	start := time.Now().Add(-time.Hour)
+	for i := range total {
+		at := start.Add(time.Duration(i) * time.Second)
+		post := &models.Post{Title: fmt.Sprintf("programado %d", i), Content: "programado", Status: models.PostScheduled, PublishAt: &at}
+		if err := posts.Create(ctx, post); err != nil {
+			t.Fatalf("error creando el post: %v", err)
+		}
+		if i%2 == 0 {
+			posts.fail[post.ID] = true
+		}
+	}
+
+	published, err := u.PublishDue(ctx)
+	if published != total-len(posts.fail) {
+		t.Errorf("publicó %d, se esperaban %d", published, total-len(posts.fail))
+	}
+	if err == nil || !strings.Contains(err.Error(), "falla simulada") {
+		t.Errorf("error = %v, se esperaba el de los posts que fallaron", err)
+	}
+
+	// Los que fallaron siguen programados y se reintentan en la próxima pasada
+	due, err := posts.DuePosts(ctx, time.Now(), total)
+	if err != nil {
+		t.Fatalf("error obteniendo los programados: %v", err)
+	}
+	if len(due) != len(posts.fail) {
+		t.Errorf("%d siguen programados, se esperaban %d", len(due), len(posts.fail))
+	}
+	clear(posts.fail)
+	if published, err := u.PublishDue(ctx); err != nil || published != len(due) {
+		t.Errorf("reintento: publicó %d (%v), se esperaban %d", published, err, len(due))
+	}
+}
+
+func TestEditPostPermissions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	subforos := memory.NewSubforoRepository(store)
+	u := usecases.NewPostUsecase(posts, subforos, nil, nil, nopIndexer{}, nopTags{})
+
+	users := memory.NewUserRepository(store)
+	for _, uid := range []string{"autor", "mod", "otro"} {
+		if err := users.CreateUser(ctx, uid, map[string]interface{}{"username": uid}); err != nil {
+			t.Fatalf("error creando el usuario: %v", err)
+		}
+	}
+	subforo := &models.Subforo{Title: "subforo", CreatedBy: "dueño", Moderators: []string{"mod"}, IsActive: true}
+	if err := subforos.Create(ctx, subforo); err != nil {
+		t.Fatalf("error creando el subforo: %v", err)
+	}
+	post := &models.Post{ForumID: subforo.ForumID, AuthorID: "autor", Title: "original", Content: "original", Status: models.PostPublished}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("error creando el post: %v", err)
+	}
+
+	edit := func(editorID, title string) error {
+		return u.EditPost(ctx, post.ID, editorID, &models.Post{Title: title, Content: "original"})
+	}
+	if err := edit("otro", "ajeno"); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("EditPost de otro usuario = %v, se esperaba ErrForbidden", err)
+	}
+	if err := edit("autor", "del autor"); err != nil {
+		t.Errorf("EditPost del autor: %v", err)
+	}
+	if err := edit("mod", "del moderador"); err != nil {
+		t.Errorf("EditPost del moderador: %v", err)
+	}
+
+	revisions, err := posts.GetRevisions(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("error obteniendo las revisiones: %v", err)
+	}
+	for _, rev := range revisions {
+		if rev.EditorID == "otro" || rev.Title == "ajeno" {
+			t.Errorf("quedó una revisión del usuario sin permiso: %+v", rev)
+		}
+	}
+	stored, err := posts.GetPostByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("error obteniendo el post: %v", err)
+	}
+	if stored.Post.Title != "del moderador" {
+		t.Errorf("Title = %q, se esperaba %q", stored.Post.Title, "del moderador")
+	}
+}
+
+func TestEditPostKeepsGallery(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	u := usecases.NewPostUsecase(posts, memory.NewSubforoRepository(store), nil, nil, nopIndexer{}, nopTags{})
+	if err := memory.NewUserRepository(store).CreateUser(ctx, "autor", map[string]interface{}{"username": "autor"}); err != nil {
+		t.Fatalf("error creando el usuario: %v", err)
+	}
+	post := &models.Post{
+		AuthorID: "autor",
+		Title:    "galería",
+		Content:  "galería",
+		Status:   models.PostPublished,
+		Media: []models.Media{
+			{ID: "a", URL: "https://img/a", PublicID: "a", Caption: "portada", Alt: "texto"},
+			{ID: "b", URL: "https://img/b", PublicID: "b"},
+		},
+	}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("error creando el post: %v", err)
+	}
+
+	// Editar el texto no toca la galería
+	if err := u.EditPost(ctx, post.ID, "autor", &models.Post{Title: "nuevo", Content: "galería"}); err != nil {
+		t.Fatalf("EditPost: %v", err)
+	}
+	if err := u.ReplaceCover(ctx, post.ID, "otro", "https://img/c", "c"); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("ReplaceCover de otro usuario = %v, se esperaba ErrForbidden", err)
+	}
+	if err := u.ReplaceCover(ctx, post.ID, "autor", "https://img/c", "c"); err != nil {
+		t.Fatalf("ReplaceCover: %v", err)
+	}
+
+	stored, err := posts.GetPostByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("error obteniendo el post: %v", err)
+	}
+	media := stored.Post.Media
+	if len(media) != 2 || media[0].PublicID != "c" || media[0].Caption != "portada" || media[0].Alt != "texto" || media[1].ID != "b" {
+		t.Errorf("galería = %+v, se esperaba la portada c con la leyenda anterior y b", media)
+	}
+	if stored.Post.Title != "nuevo" || stored.Post.ImageID != "c" {
+		t.Errorf("Title = %q, ImageID = %q", stored.Post.Title, stored.Post.ImageID)
+	}
+}
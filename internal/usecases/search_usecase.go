@@ -0,0 +1,190 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/search"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+// ErrSemanticUnavailable indica que no se pudo calcular el vector de la
+// búsqueda, por ejemplo porque la API de embeddings no responde.
+var ErrSemanticUnavailable = errors.New("la búsqueda semántica no está disponible")
+
+const (
+	// minSimilarity es la similitud coseno mínima de un resultado de la
+	// búsqueda semántica: por debajo los posts ya no tienen relación con la
+	// búsqueda.
+	minSimilarity = 0.25
+	// DefaultRelated es la cantidad de posts relacionados que se devuelven si
+	// no se pide otra.
+	DefaultRelated = 5
+	// MaxRelated es la cantidad máxima de posts relacionados por pedido.
+	MaxRelated = 20
+)
+
+// SearchIndexer mantiene el índice de búsqueda al día. Los usecases lo llaman
+// después de cada cambio que afecta lo que se encuentra; cada método vuelve a
+// leer el documento, así que no importa qué cambió.
+type SearchIndexer interface {
+	// Post sincroniza el post y sus comentarios.
+	Post(ctx context.Context, id string)
+	Comment(ctx context.Context, id string)
+	Subforo(ctx context.Context, id string)
+}
+
+type SearchUsecase interface {
+	// Search busca en los posts, comentarios y subforos visibles, de mayor a
+	// menor relevancia.
+	Search(ctx context.Context, q models.SearchQuery, page models.PageRequest) (models.Page[*models.SearchHit], error)
+	// Semantic busca los posts visibles de significado parecido al texto, de
+	// mayor a menor similitud, aunque no compartan palabras. Con forumID solo
+	// busca en ese subforo.
+	Semantic(ctx context.Context, text, forumID string, page models.PageRequest) (models.Page[*models.SemanticHit], error)
+	// Related devuelve hasta limit posts relacionados con el post, de mayor a
+	// menor puntaje, sin los reportados. limit fuera de rango toma
+	// DefaultRelated o MaxRelated.
+	Related(ctx context.Context, postID string, limit int) ([]*models.RelatedPost, error)
+}
+
+type searchUsecase struct {
+	index    *search.Index
+	vectors  *search.Vectors
+	related  *search.Related
+	embedder service.Embedder
+	posts    repositories.PostRepository
+	content  *ContentRenderer
+	tags     TagUsecase
+}
+
+func NewSearchUsecase(index *search.Index, vectors *search.Vectors, related *search.Related, embedder service.Embedder, posts repositories.PostRepository, content *ContentRenderer, tags TagUsecase) SearchUsecase {
+	return &searchUsecase{
+		index:    index,
+		vectors:  vectors,
+		related:  related,
+		embedder: embedder,
+		posts:    posts,
+		content:  content,
+		tags:     tags,
+	}
+}
+
+func (u *searchUsecase) Search(ctx context.Context, q models.SearchQuery, page models.PageRequest) (models.Page[*models.SearchHit], error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return models.Page[*models.SearchHit]{}, models.ErrEmptySearch
+	}
+	if q.Type != "" && !q.Type.Valid() {
+		return models.Page[*models.SearchHit]{}, models.ErrInvalidSearchType
+	}
+	if q.Tag != "" {
+		if err := u.resolveTag(ctx, &q); err != nil {
+			return models.Page[*models.SearchHit]{}, err
+		}
+	}
+	return u.index.Search(q, page)
+}
+
+// resolveTag normaliza el tag del filtro como los de los posts y lo reemplaza
+// por su tag canónico, con sus sinónimos. Un tag que no está en el registro
+// se busca tal cual.
+func (u *searchUsecase) resolveTag(ctx context.Context, q *models.SearchQuery) error {
+	tag := models.NormalizeTag(q.Tag)
+	if err := models.ValidTag(tag); err != nil {
+		return err
+	}
+	t, err := u.tags.GetTag(ctx, tag)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		q.Tag = tag
+	case err != nil:
+		return err
+	default:
+		q.Tag, q.Synonyms = t.Name, t.Synonyms
+	}
+	return nil
+}
+
+// Semantic pagina con la cantidad de resultados ya devueltos como cursor, igual
+// que Search.
+func (u *searchUsecase) Semantic(ctx context.Context, text, forumID string, page models.PageRequest) (models.Page[*models.SemanticHit], error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return models.Page[*models.SemanticHit]{}, models.ErrEmptySearch
+	}
+	cursor, err := repositories.DecodeCursor(page.Cursor)
+	if err != nil {
+		return models.Page[*models.SemanticHit]{}, err
+	}
+	from := 0
+	if cursor != nil {
+		if cursor.Score < 0 {
+			return models.Page[*models.SemanticHit]{}, models.ErrInvalidCursor
+		}
+		from = cursor.Score
+	}
+	size := page.Size()
+
+	vectors, err := u.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return models.Page[*models.SemanticHit]{}, fmt.Errorf("%w: %v", ErrSemanticUnavailable, err)
+	}
+	found := u.vectors.Search(vectors[0], forumID, minSimilarity)
+
+	result := models.Page[*models.SemanticHit]{Items: make([]*models.SemanticHit, 0, size)}
+	posts := make([]*models.Post, 0, size)
+	i := from
+	for ; i < len(found) && len(result.Items) < size; i++ {
+		// El índice puede ir un poco por detrás de los posts
+		post, err := u.posts.GetPostByID(ctx, found[i].PostID)
+		if err != nil || !post.Post.IsPublished() {
+			continue
+		}
+		post.Post.Author = post.Author
+		result.Items = append(result.Items, &models.SemanticHit{Post: &post.Post, Similarity: found[i].Similarity})
+		posts = append(posts, &post.Post)
+	}
+	if i < len(found) {
+		result.NextCursor = repositories.EncodeCursor(repositories.Cursor{Score: i, ID: found[i-1].PostID})
+	}
+	u.content.Posts(ctx, posts...)
+	return result, nil
+}
+
+func (u *searchUsecase) Related(ctx context.Context, postID string, limit int) ([]*models.RelatedPost, error) {
+	if limit <= 0 {
+		limit = DefaultRelated
+	}
+	limit = min(limit, MaxRelated)
+	post, err := u.posts.GetPostByID(ctx, postID)
+	if err != nil || !post.Post.IsPublished() {
+		return nil, ErrNotFound
+	}
+	found, err := u.related.Find(&post.Post)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]*models.RelatedPost, 0, limit)
+	posts := make([]*models.Post, 0, limit)
+	for _, hit := range found {
+		if len(related) == limit {
+			break
+		}
+		// Los relacionados guardados pueden haberse reportado o eliminado después
+		p, err := u.posts.GetPostByID(ctx, hit.PostID)
+		if err != nil || !p.Post.IsPublished() || p.Post.IsFlagged {
+			continue
+		}
+		p.Post.Author = p.Author
+		related = append(related, &models.RelatedPost{Post: &p.Post, Score: hit.Score})
+		posts = append(posts, &p.Post)
+	}
+	u.content.Posts(ctx, posts...)
+	return related, nil
+}
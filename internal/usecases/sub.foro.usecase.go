@@ -1,26 +1,34 @@
 package usecases
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log"
+	"slices"
+	"time"
 
 	"github.com/JuanPidarraga/talkus-backend/internal/models"
 	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/search"
 )
 
 type SubforoUsecase struct {
-	repo *repositories.SubforoRepository
+	repo    repositories.SubforoRepository
+	posts   repositories.PostRepository
+	vectors *search.SubforoVectors
+	search  SearchIndexer
 }
 
-func NewSubforoUsecase(repo *repositories.SubforoRepository) *SubforoUsecase {
-	return &SubforoUsecase{repo: repo}
+func NewSubforoUsecase(repo repositories.SubforoRepository, posts repositories.PostRepository, vectors *search.SubforoVectors, search SearchIndexer) *SubforoUsecase {
+	return &SubforoUsecase{repo: repo, posts: posts, vectors: vectors, search: search}
 }
 func (u *SubforoUsecase) GetSubforoByID(ctx context.Context, id string) (*models.Subforo, error) {
 	return u.repo.GetSubforoByID(ctx, id)
 }
 
-func (u *SubforoUsecase) GetAllSubforos(ctx context.Context) ([]*models.Subforo, error) {
-	return u.repo.GetAll(ctx)
+func (u *SubforoUsecase) GetAllSubforos(ctx context.Context, page models.PageRequest) (models.Page[*models.Subforo], error) {
+	return u.repo.GetAll(ctx, page)
 }
 
 func (u *SubforoUsecase) CreateSubforo(ctx context.Context, subforo *models.Subforo) (*models.Subforo, error) {
@@ -31,6 +39,7 @@ func (u *SubforoUsecase) CreateSubforo(ctx context.Context, subforo *models.Subf
 	if err := u.repo.Create(ctx, subforo); err != nil {
 		return nil, err
 	}
+	u.search.Subforo(ctx, subforo.ForumID)
 
 	return subforo, nil
 }
@@ -40,11 +49,17 @@ func (u *SubforoUsecase) DeactivateSubforo(ctx context.Context, id string) (*mod
 	if err != nil {
 		return nil, err
 	}
+	u.search.Subforo(ctx, id)
 	return u.repo.GetSubforoByID(ctx, id)
 }
 
 func (u *SubforoUsecase) EditSubforo(ctx context.Context, id string, subforo *models.Subforo) (*models.Subforo, error) {
-	return u.repo.EditSubforo(ctx, id, subforo)
+	edited, err := u.repo.EditSubforo(ctx, id, subforo)
+	if err != nil {
+		return nil, err
+	}
+	u.search.Subforo(ctx, id)
+	return edited, nil
 }
 
 func (u *SubforoUsecase) JoinSubforo(ctx context.Context, subforoID, userID string) error {
@@ -58,3 +73,186 @@ func (u *SubforoUsecase) LeaveSubforo(ctx context.Context, subforoID, userID str
 func (u *SubforoUsecase) GetSubforosByUserID(ctx context.Context, userID string) ([]*models.Subforo, error) {
 	return u.repo.GetSubforosByUserID(ctx, userID)
 }
+
+const (
+	// DefaultRecommended es la cantidad de subforos recomendados que se
+	// devuelven si no se pide otra.
+	DefaultRecommended = 10
+	// MaxRecommended es la cantidad máxima de subforos recomendados por
+	// pedido.
+	MaxRecommended = 50
+
+	// recommendPosts es la cantidad de posts recientes del usuario que se
+	// comparan con los subforos.
+	recommendPosts = 50
+	// recommendGrowthWindow es el período en el que se cuentan los miembros
+	// nuevos de cada subforo.
+	recommendGrowthWindow = 30 * 24 * time.Hour
+
+	// Pesos de cada criterio en el puntaje, que queda entre 0 y 1.
+	recommendCategoryWeight   = 0.5
+	recommendSimilarityWeight = 0.3
+	recommendGrowthWeight     = 0.2
+)
+
+// Recommended devuelve hasta limit subforos activos de los que userID no es
+// miembro ni moderador, del de mayor puntaje al de menor. El puntaje mezcla
+// las categorías en común con sus subforos, la similitud de sus posts con la
+// descripción del subforo y los miembros nuevos del último mes; a igual
+// puntaje van primero los subforos con más miembros. limit fuera de rango
+// toma DefaultRecommended o MaxRecommended.
+func (u *SubforoUsecase) Recommended(ctx context.Context, userID string, limit int) ([]*models.SubforoRecommendation, error) {
+	if limit <= 0 {
+		limit = DefaultRecommended
+	}
+	limit = min(limit, MaxRecommended)
+
+	joined, err := u.repo.GetSubforosByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := u.candidates(ctx, userID, joined)
+	if err != nil {
+		return nil, err
+	}
+	growth, err := u.repo.CountJoins(ctx, time.Now().Add(-recommendGrowthWindow))
+	if err != nil {
+		return nil, err
+	}
+	maxGrowth := 0
+	for _, s := range candidates {
+		maxGrowth = max(maxGrowth, growth[s.ForumID])
+	}
+	similarity := u.similarity(ctx, userID, candidates)
+
+	recommended := make([]*models.SubforoRecommendation, 0, len(candidates))
+	for _, s := range candidates {
+		category, because := categoryOverlap(s, joined)
+		similar := max(similarity[s.ForumID], 0)
+		grown := 0.0
+		if maxGrowth > 0 {
+			grown = float64(growth[s.ForumID]) / float64(maxGrowth)
+		}
+
+		rec := &models.SubforoRecommendation{
+			Subforo: s,
+			Score:   recommendCategoryWeight*category + recommendSimilarityWeight*similar + recommendGrowthWeight*grown,
+		}
+		// El motivo es el criterio que más aportó al puntaje
+		switch best := max(recommendCategoryWeight*category, recommendSimilarityWeight*similar, recommendGrowthWeight*grown); {
+		case best == 0 && len(s.Members) > 0:
+			rec.Reason = "Subforo popular"
+		case best == 0:
+			rec.Reason = "Subforo nuevo"
+		case best == recommendCategoryWeight*category:
+			rec.Reason = "Porque te uniste a " + because.Title
+			rec.BecauseOf = because.ForumID
+		case best == recommendSimilarityWeight*similar:
+			rec.Reason = "Parecido a lo que publicas"
+		case growth[s.ForumID] == 1:
+			rec.Reason = "Creciendo: 1 miembro nuevo este mes"
+		default:
+			rec.Reason = fmt.Sprintf("Creciendo: %d miembros nuevos este mes", growth[s.ForumID])
+		}
+		recommended = append(recommended, rec)
+	}
+
+	slices.SortFunc(recommended, func(a, b *models.SubforoRecommendation) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(b.Subforo.Members), len(a.Subforo.Members)); c != 0 {
+			return c
+		}
+		return b.Subforo.CreatedAt.Compare(a.Subforo.CreatedAt)
+	})
+	return recommended[:min(limit, len(recommended))], nil
+}
+
+// candidates devuelve los subforos activos que userID no creó ni modera y de
+// los que no es miembro.
+func (u *SubforoUsecase) candidates(ctx context.Context, userID string, joined []*models.Subforo) ([]*models.Subforo, error) {
+	candidates := make([]*models.Subforo, 0)
+	page := models.PageRequest{Limit: models.MaxPageLimit}
+	for {
+		result, err := u.repo.GetAll(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range result.Items {
+			isJoined := slices.ContainsFunc(joined, func(j *models.Subforo) bool {
+				return j.ForumID == s.ForumID
+			})
+			if s.IsActive && !isJoined && !s.CanModerate(userID) {
+				candidates = append(candidates, s)
+			}
+		}
+		if result.NextCursor == "" {
+			return candidates, nil
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+// categoryOverlap devuelve la proporción de categorías de s que tiene algún
+// subforo activo de joined y el que más categorías comparte con s, o el de más
+// miembros entre los que comparten las mismas. Las categorías se comparan
+// normalizadas como los tags.
+func categoryOverlap(s *models.Subforo, joined []*models.Subforo) (float64, *models.Subforo) {
+	categories := models.NormalizeTags(s.Categories)
+	if len(categories) == 0 {
+		return 0, nil
+	}
+	shared := make(map[string]bool)
+	var because *models.Subforo
+	bestShared := 0
+	for _, j := range joined {
+		if !j.IsActive {
+			continue
+		}
+		n := 0
+		for _, c := range models.NormalizeTags(j.Categories) {
+			if slices.Contains(categories, c) {
+				shared[c] = true
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		if n > bestShared || n == bestShared && moreMembers(j, because) {
+			because, bestShared = j, n
+		}
+	}
+	return float64(len(shared)) / float64(len(categories)), because
+}
+
+// moreMembers indica si a tiene más miembros que b o, con los mismos, un ID
+// menor, para que el orden no dependa del repositorio.
+func moreMembers(a, b *models.Subforo) bool {
+	if c := cmp.Compare(len(a.Members), len(b.Members)); c != 0 {
+		return c > 0
+	}
+	return a.ForumID < b.ForumID
+}
+
+// similarity compara los posts recientes de userID con los subforos. Si no se
+// pueden calcular los vectores de los subforos la recomendación sigue sin
+// este criterio.
+func (u *SubforoUsecase) similarity(ctx context.Context, userID string, subforos []*models.Subforo) map[string]float64 {
+	posts, err := u.posts.GetPostsByAuthorID(ctx, userID, false, models.PageRequest{Limit: recommendPosts})
+	if err != nil {
+		log.Printf("⚠️ Recomendaciones sin los posts de %s: %v", userID, err)
+		return nil
+	}
+	ids := make([]string, 0, len(posts.Items))
+	for _, p := range posts.Items {
+		ids = append(ids, p.ID)
+	}
+	similarity, err := u.vectors.Similarity(ctx, ids, subforos)
+	if err != nil {
+		log.Printf("⚠️ Recomendaciones sin similitud para %s: %v", userID, err)
+		return nil
+	}
+	return similarity
+}
@@ -0,0 +1,396 @@
+package usecases
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+var (
+	// ErrSameTag indica que se quiso hacer a un tag sinónimo de sí mismo o
+	// unirlo consigo.
+	ErrSameTag = errors.New("los tags deben ser distintos")
+	// ErrTagInUse indica que el tag que se quiso agregar como sinónimo tiene
+	// posts o sinónimos propios: para eso está la unión de tags.
+	ErrTagInUse = errors.New("el tag tiene posts o sinónimos; únelo al otro en lugar de agregarlo como sinónimo")
+)
+
+const (
+	// DefaultTags es la cantidad de tags que devuelven el autocompletado y
+	// los tags en tendencia si no se pide otra.
+	DefaultTags = 10
+	// MaxTags es la cantidad máxima de tags por pedido.
+	MaxTags = 50
+	// autocompleteScan es la cantidad de tags con el prefijo que se leen para
+	// elegir los de más posts.
+	autocompleteScan = 100
+)
+
+// TagRegistry es lo que usan los demás usecases del registro de tags.
+type TagRegistry interface {
+	// Canonical normaliza los tags de un post y reemplaza los sinónimos por
+	// su tag, sin repetir. Devuelve models.ErrInvalidTag o
+	// models.ErrTagTooLong si alguno no se puede usar.
+	Canonical(ctx context.Context, raw []string) ([]string, error)
+	// Recount actualiza la cantidad de posts de los tags. Los errores solo se
+	// registran: el conteo se puede rehacer con RecountAll.
+	Recount(ctx context.Context, tags ...string)
+}
+
+type TagUsecase interface {
+	TagRegistry
+
+	// Autocomplete devuelve hasta limit tags con posts que empiezan con
+	// prefix, del que más posts tiene al que menos. Los sinónimos que
+	// coinciden devuelven su tag.
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]*models.Tag, error)
+	// Trending devuelve hasta limit tags de los posts publicados en la
+	// ventana, WindowWeek si está vacía, del que más posts tiene al que menos.
+	Trending(ctx context.Context, window models.SortWindow, limit int) ([]*models.Tag, error)
+	// GetTag devuelve el tag con sus sinónimos; si name es un sinónimo,
+	// devuelve su tag.
+	GetTag(ctx context.Context, name string) (*models.Tag, error)
+	// PostsByTag pagina con el orden order los posts publicados con el tag,
+	// sin los reportados.
+	PostsByTag(ctx context.Context, name string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error)
+
+	// Follow hace que userID siga el tag, o el tag del sinónimo. Devuelve
+	// ErrNotFound si el tag no existe.
+	Follow(ctx context.Context, userID, name string) (*models.TagFollow, error)
+	Unfollow(ctx context.Context, userID, name string) error
+	// Followed devuelve los tags que sigue userID, los más recientes primero.
+	Followed(ctx context.Context, userID string) ([]*models.TagFollow, error)
+
+	// AddSynonym hace de synonym un sinónimo de tag, que debe existir. Si
+	// synonym ya es un tag con posts o sinónimos devuelve ErrTagInUse.
+	AddSynonym(ctx context.Context, tag, synonym string) (*models.Tag, error)
+	// RemoveSynonym hace de synonym un tag independiente, sin posts.
+	RemoveSynonym(ctx context.Context, tag, synonym string) (*models.Tag, error)
+	// Merge une from a into: los posts de from pasan a tener into y from y sus
+	// sinónimos quedan como sinónimos de into. Devuelve el tag into.
+	Merge(ctx context.Context, from, into string) (*models.Tag, error)
+	// RecountAll vuelve a contar los posts de todos los tags, los de los
+	// posts y los del registro, y devuelve cuántos contó.
+	RecountAll(ctx context.Context) (int, error)
+}
+
+type tagUsecase struct {
+	tags    repositories.TagRepository
+	posts   repositories.PostRepository
+	backup  repositories.BackupRepository
+	search  SearchIndexer
+	content *ContentRenderer
+}
+
+func NewTagUsecase(tags repositories.TagRepository, posts repositories.PostRepository, backup repositories.BackupRepository, search SearchIndexer, content *ContentRenderer) TagUsecase {
+	return &tagUsecase{
+		tags:    tags,
+		posts:   posts,
+		backup:  backup,
+		search:  search,
+		content: content,
+	}
+}
+
+func (u *tagUsecase) Canonical(ctx context.Context, raw []string) ([]string, error) {
+	names := make([]string, 0, len(raw))
+	for _, r := range raw {
+		name := models.NormalizeTag(r)
+		if err := models.ValidTag(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	registered, err := u.tags.GetTags(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		if t, ok := registered[name]; ok {
+			name = t.Canonical()
+		}
+		if !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
+
+func (u *tagUsecase) Recount(ctx context.Context, tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+	if err := u.tags.Recount(ctx, tags); err != nil {
+		log.Printf("⚠️ Conteo de los tags %v: %v", tags, err)
+	}
+}
+
+// resolve devuelve el tag name, o el tag del sinónimo. Devuelve ErrNotFound
+// si no existe.
+func (u *tagUsecase) resolve(ctx context.Context, name string) (*models.Tag, error) {
+	t, err := u.tags.GetTag(ctx, models.NormalizeTag(name))
+	if err != nil {
+		return nil, err
+	}
+	if t != nil && t.SynonymOf != "" {
+		t, err = u.tags.GetTag(ctx, t.SynonymOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t == nil {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+// tagLimit lleva limit al rango de 1 a MaxTags, con DefaultTags si no se
+// pidió.
+func tagLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultTags
+	}
+	return min(limit, MaxTags)
+}
+
+func (u *tagUsecase) Autocomplete(ctx context.Context, prefix string, limit int) ([]*models.Tag, error) {
+	prefix = models.NormalizeTag(prefix)
+	if prefix == "" {
+		return nil, models.ErrInvalidTag
+	}
+	found, err := u.tags.SearchTags(ctx, prefix, autocompleteScan)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los sinónimos se cambian por su tag, que puede no empezar con prefix
+	byName := make(map[string]*models.Tag, len(found))
+	missing := make([]string, 0)
+	for _, t := range found {
+		byName[t.Name] = t
+	}
+	for _, t := range found {
+		if _, ok := byName[t.SynonymOf]; t.SynonymOf != "" && !ok {
+			missing = append(missing, t.SynonymOf)
+		}
+	}
+	canonical, err := u.tags.GetTags(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for name, t := range canonical {
+		byName[name] = t
+	}
+
+	tags := make([]*models.Tag, 0, len(found))
+	for _, f := range found {
+		t, ok := byName[f.Canonical()]
+		if ok && t.PostCount > 0 && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b *models.Tag) int {
+		return cmp.Compare(b.PostCount, a.PostCount)
+	})
+	return tags[:min(tagLimit(limit), len(tags))], nil
+}
+
+func (u *tagUsecase) Trending(ctx context.Context, window models.SortWindow, limit int) ([]*models.Tag, error) {
+	if window == "" {
+		window = models.WindowWeek
+	}
+	if !window.Valid() {
+		return nil, models.ErrInvalidWindow
+	}
+	return u.tags.Trending(ctx, window.Since(time.Now()), tagLimit(limit))
+}
+
+func (u *tagUsecase) GetTag(ctx context.Context, name string) (*models.Tag, error) {
+	t, err := u.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	synonyms, err := u.tags.GetSynonyms(ctx, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range synonyms {
+		t.Synonyms = append(t.Synonyms, s.Name)
+	}
+	return t, nil
+}
+
+// PostsByTag también lista los tags que no están en el registro, que pueden
+// tener posts hasta que se cuentan.
+func (u *tagUsecase) PostsByTag(ctx context.Context, name string, order models.PostSort, page models.PageRequest) (models.Page[*models.Post], error) {
+	tag := models.NormalizeTag(name)
+	t, err := u.resolve(ctx, tag)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return models.Page[*models.Post]{}, err
+	}
+	if t != nil {
+		tag = t.Name
+	}
+	result, err := u.posts.GetPostsByTag(ctx, tag, order, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
+}
+
+func (u *tagUsecase) Follow(ctx context.Context, userID, name string) (*models.TagFollow, error) {
+	t, err := u.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	follow := &models.TagFollow{UserID: userID, Tag: t.Name, CreatedAt: time.Now()}
+	if err := u.tags.Follow(ctx, follow); err != nil {
+		return nil, err
+	}
+	return follow, nil
+}
+
+func (u *tagUsecase) Unfollow(ctx context.Context, userID, name string) error {
+	tag := models.NormalizeTag(name)
+	if t, err := u.resolve(ctx, tag); err == nil {
+		tag = t.Name
+	}
+	return u.tags.Unfollow(ctx, userID, tag)
+}
+
+func (u *tagUsecase) Followed(ctx context.Context, userID string) ([]*models.TagFollow, error) {
+	return u.tags.GetFollowed(ctx, userID)
+}
+
+func (u *tagUsecase) AddSynonym(ctx context.Context, tag, synonym string) (*models.Tag, error) {
+	t, err := u.resolve(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	name := models.NormalizeTag(synonym)
+	if err := models.ValidTag(name); err != nil {
+		return nil, err
+	}
+	if name == t.Name {
+		return nil, ErrSameTag
+	}
+
+	s, err := u.tags.GetTag(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = &models.Tag{Name: name, CreatedAt: time.Now()}
+	} else if s.SynonymOf == "" {
+		synonyms, err := u.tags.GetSynonyms(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if s.PostCount > 0 || len(synonyms) > 0 {
+			return nil, ErrTagInUse
+		}
+	}
+	s.SynonymOf = t.Name
+	if err := u.tags.SaveTag(ctx, s); err != nil {
+		return nil, err
+	}
+	return u.GetTag(ctx, t.Name)
+}
+
+func (u *tagUsecase) RemoveSynonym(ctx context.Context, tag, synonym string) (*models.Tag, error) {
+	t, err := u.resolve(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	s, err := u.tags.GetTag(ctx, models.NormalizeTag(synonym))
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || s.SynonymOf != t.Name {
+		return nil, ErrNotFound
+	}
+	s.SynonymOf = ""
+	if err := u.tags.SaveTag(ctx, s); err != nil {
+		return nil, err
+	}
+	return u.GetTag(ctx, t.Name)
+}
+
+// Merge reemplaza el tag en los posts antes de marcarlo como sinónimo: si se
+// corta a mitad de camino, volver a unir los mismos tags termina el trabajo.
+func (u *tagUsecase) Merge(ctx context.Context, from, into string) (*models.Tag, error) {
+	source, err := u.tags.GetTag(ctx, models.NormalizeTag(from))
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrNotFound
+	}
+	target, err := u.resolve(ctx, into)
+	if err != nil {
+		return nil, err
+	}
+	if source.Name == target.Name {
+		return nil, ErrSameTag
+	}
+
+	changed, err := u.posts.ReplaceTag(ctx, source.Name, target.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range changed {
+		u.search.Post(ctx, id)
+	}
+
+	synonyms, err := u.tags.GetSynonyms(ctx, source.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range append(synonyms, source) {
+		s.SynonymOf = target.Name
+		if err := u.tags.SaveTag(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	u.Recount(ctx, source.Name, target.Name)
+	return u.GetTag(ctx, target.Name)
+}
+
+func (u *tagUsecase) RecountAll(ctx context.Context) (int, error) {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	err := u.backup.ExportPosts(ctx, func(p *models.Post) error {
+		for _, tag := range p.Tags {
+			add(tag)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = u.backup.ExportTags(ctx, func(t *models.Tag) error {
+		add(t.Name)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := u.tags.Recount(ctx, names); err != nil {
+		return 0, err
+	}
+	return len(names), nil
+}
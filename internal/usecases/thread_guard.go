@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+)
+
+// threadGuard comprueba que un post admita comentarios, respuestas y votos
+// antes de guardarlos: los posts cerrados o archivados no los admiten.
+type threadGuard struct {
+	posts    repositories.PostRepository
+	comments repositories.CommentRepository
+}
+
+// post devuelve el post, que debe estar publicado.
+func (g threadGuard) post(ctx context.Context, postID string) (*models.Post, error) {
+	post, err := g.posts.GetPostByID(ctx, postID)
+	if err != nil || !post.Post.IsPublished() {
+		return nil, ErrNotFound
+	}
+	return &post.Post, nil
+}
+
+// writable devuelve ErrNotFound si el post no existe y models.ErrPostLocked
+// o models.ErrPostArchived si no admite escrituras.
+func (g threadGuard) writable(ctx context.Context, postID string) error {
+	post, err := g.post(ctx, postID)
+	if err != nil {
+		return err
+	}
+	return post.Writable()
+}
+
+// target es writable para el post del voto, que en los votos a comentarios
+// es el post del comentario.
+func (g threadGuard) target(ctx context.Context, target models.VoteTarget, targetID string) error {
+	if target == models.TargetComment {
+		comment, err := g.comments.GetCommentByID(ctx, targetID)
+		if err != nil {
+			return ErrNotFound
+		}
+		targetID = comment.PostID
+	}
+	return g.writable(ctx, targetID)
+}
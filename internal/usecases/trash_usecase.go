@@ -0,0 +1,312 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+var (
+	// ErrForbidden indica que el usuario no puede eliminar o restaurar el elemento.
+	ErrForbidden = errors.New("no tienes permiso sobre este elemento")
+	// ErrNotFound indica que el post o comentario no existe o ya está en la papelera.
+	ErrNotFound = errors.New("no encontrado")
+)
+
+// purgeBatch es la cantidad de elementos vencidos que se leen por consulta al purgar.
+const purgeBatch = 100
+
+// TrashUsecase maneja la papelera. Los moderadores son los del subforo del
+// post (ver models.Subforo.CanModerate); admin indica que el usuario es
+// administrador, que modera todos los subforos.
+type TrashUsecase interface {
+	// DeletePost manda el post a la papelera. Solo lo puede hacer su autor o
+	// un moderador. Sus crossposts quedan marcados con el original eliminado.
+	DeletePost(ctx context.Context, postID, userID string, admin bool) error
+	// RestorePost saca el post de la papelera. Solo lo puede hacer quien lo
+	// eliminó o un moderador.
+	RestorePost(ctx context.Context, postID, userID string, admin bool) error
+	DeleteComment(ctx context.Context, commentID, userID string, admin bool) error
+	RestoreComment(ctx context.Context, commentID, userID string, admin bool) error
+	TrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error)
+	TrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error)
+	// ForumTrashedPosts pagina la papelera de posts del subforo de
+	// filter.ForumID. Solo la ven sus moderadores.
+	ForumTrashedPosts(ctx context.Context, userID string, admin bool, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error)
+	// ForumTrashedComments pagina los comentarios eliminados de posts del
+	// subforo de filter.ForumID. Solo la ven sus moderadores.
+	ForumTrashedComments(ctx context.Context, userID string, admin bool, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error)
+	// Purge borra para siempre lo que lleva en la papelera más que el
+	// tiempo de retención, con sus votos, guardados e imágenes.
+	Purge(ctx context.Context) (*models.PurgeReport, error)
+}
+
+type trashUsecase struct {
+	trash     repositories.TrashRepository
+	posts     repositories.PostRepository
+	comments  repositories.CommentRepository
+	subforos  repositories.SubforoRepository
+	media     service.MediaStorage
+	content   *ContentRenderer
+	search    SearchIndexer
+	tags      TagRegistry
+	retention time.Duration
+}
+
+func NewTrashUsecase(trash repositories.TrashRepository, posts repositories.PostRepository, comments repositories.CommentRepository, subforos repositories.SubforoRepository, media service.MediaStorage, content *ContentRenderer, search SearchIndexer, tags TagRegistry, retention time.Duration) TrashUsecase {
+	return &trashUsecase{
+		trash:     trash,
+		posts:     posts,
+		comments:  comments,
+		subforos:  subforos,
+		media:     media,
+		content:   content,
+		search:    search,
+		tags:      tags,
+		retention: retention,
+	}
+}
+
+// moderates indica si userID modera el subforo forumID.
+func (u *trashUsecase) moderates(ctx context.Context, forumID, userID string, admin bool) bool {
+	if admin {
+		return true
+	}
+	if forumID == "" {
+		return false
+	}
+	subforo, err := u.subforos.GetSubforoByID(ctx, forumID)
+	return err == nil && subforo != nil && subforo.CanModerate(userID)
+}
+
+// postForum devuelve el subforo del post, esté o no en la papelera.
+func (u *trashUsecase) postForum(ctx context.Context, postID string) string {
+	if post, err := u.posts.GetPostByID(ctx, postID); err == nil {
+		return post.Post.ForumID
+	}
+	if post, err := u.trash.TrashedPost(ctx, postID); err == nil {
+		return post.ForumID
+	}
+	return ""
+}
+
+func (u *trashUsecase) DeletePost(ctx context.Context, postID, userID string, admin bool) error {
+	post, err := u.posts.GetPostByID(ctx, postID)
+	if err != nil {
+		return ErrNotFound
+	}
+	if post.Post.AuthorID != userID && !u.moderates(ctx, post.Post.ForumID, userID, admin) {
+		return ErrForbidden
+	}
+	if err := u.trash.TrashPost(ctx, postID, userID); err != nil {
+		return err
+	}
+	u.search.Post(ctx, postID)
+	if post.Post.IsPublished() {
+		u.tags.Recount(ctx, post.Post.Tags...)
+	}
+	// Los crossposts siguen, pero indican que el original se eliminó
+	now := time.Now()
+	if err := u.posts.MarkCrossposts(ctx, postID, &now); err != nil {
+		log.Printf("⚠️ Crossposts del post %s: %v", postID, err)
+	}
+	return nil
+}
+
+func (u *trashUsecase) RestorePost(ctx context.Context, postID, userID string, admin bool) error {
+	post, err := u.trash.TrashedPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if post.DeletedBy != userID && !u.moderates(ctx, post.ForumID, userID, admin) {
+		return ErrForbidden
+	}
+	if err := u.trash.RestorePost(ctx, postID); err != nil {
+		return err
+	}
+	u.search.Post(ctx, postID)
+	if post.IsPublished() {
+		u.tags.Recount(ctx, post.Tags...)
+	}
+	if err := u.posts.MarkCrossposts(ctx, postID, nil); err != nil {
+		log.Printf("⚠️ Crossposts del post %s: %v", postID, err)
+	}
+	return nil
+}
+
+func (u *trashUsecase) DeleteComment(ctx context.Context, commentID, userID string, admin bool) error {
+	comment, err := u.comments.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return ErrNotFound
+	}
+	if comment.AuthorID != userID && !u.moderates(ctx, u.postForum(ctx, comment.PostID), userID, admin) {
+		return ErrForbidden
+	}
+	if err := u.trash.TrashComment(ctx, commentID, userID); err != nil {
+		return err
+	}
+	u.search.Comment(ctx, commentID)
+	return nil
+}
+
+func (u *trashUsecase) RestoreComment(ctx context.Context, commentID, userID string, admin bool) error {
+	comment, err := u.trash.TrashedComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.DeletedBy != userID && !u.moderates(ctx, u.postForum(ctx, comment.PostID), userID, admin) {
+		return ErrForbidden
+	}
+	if err := u.trash.RestoreComment(ctx, commentID); err != nil {
+		return err
+	}
+	u.search.Comment(ctx, commentID)
+	return nil
+}
+
+func (u *trashUsecase) TrashedPosts(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error) {
+	result, err := u.trash.GetTrashedPosts(ctx, filter, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.Posts(ctx, result.Items...)
+	return result, nil
+}
+
+func (u *trashUsecase) TrashedComments(ctx context.Context, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error) {
+	result, err := u.trash.GetTrashedComments(ctx, filter, page)
+	if err != nil {
+		return result, err
+	}
+	u.content.CommentList(ctx, result.Items)
+	return result, nil
+}
+
+// forumModerated controla que userID modere el subforo del filtro.
+func (u *trashUsecase) forumModerated(ctx context.Context, userID string, admin bool, filter models.TrashFilter) error {
+	subforo, err := u.subforos.GetSubforoByID(ctx, filter.ForumID)
+	if err != nil || subforo == nil {
+		return ErrNotFound
+	}
+	if !admin && !subforo.CanModerate(userID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (u *trashUsecase) ForumTrashedPosts(ctx context.Context, userID string, admin bool, filter models.TrashFilter, page models.PageRequest) (models.Page[*models.Post], error) {
+	if err := u.forumModerated(ctx, userID, admin, filter); err != nil {
+		return models.Page[*models.Post]{}, err
+	}
+	return u.TrashedPosts(ctx, filter, page)
+}
+
+func (u *trashUsecase) ForumTrashedComments(ctx context.Context, userID string, admin bool, filter models.TrashFilter, page models.PageRequest) (models.Page[models.Comment], error) {
+	if err := u.forumModerated(ctx, userID, admin, filter); err != nil {
+		return models.Page[models.Comment]{}, err
+	}
+	return u.TrashedComments(ctx, filter, page)
+}
+
+func (u *trashUsecase) Purge(ctx context.Context) (*models.PurgeReport, error) {
+	report := &models.PurgeReport{Before: time.Now().Add(-u.retention)}
+
+	// Los elementos que fallan quedan en la papelera; failed evita volver a
+	// leerlos en esta misma pasada. Se sigue leyendo mientras un lote borre algo.
+	failed := make(map[string]bool)
+
+	// Primero los posts, que ya se llevan sus comentarios
+	for {
+		posts, err := u.trash.ExpiredPosts(ctx, report.Before, purgeBatch+len(failed))
+		if err != nil {
+			return nil, err
+		}
+		purged := 0
+		for _, p := range posts {
+			if failed[p.ID] {
+				continue
+			}
+			// Las imágenes se borran antes que el post: si falla, el post
+			// sigue en la papelera y se reintenta en la próxima purga.
+			if err := u.deleteImages(ctx, p); err != nil {
+				log.Printf("⚠️ Purga del post %s: %v", p.ID, err)
+				failed[p.ID] = true
+				report.Failed++
+				continue
+			}
+			if err := u.trash.PurgePost(ctx, p.ID); err != nil {
+				log.Printf("⚠️ Purga del post %s: %v", p.ID, err)
+				failed[p.ID] = true
+				report.Failed++
+				continue
+			}
+			purged++
+		}
+		report.Posts += purged
+		if purged == 0 {
+			break
+		}
+	}
+
+	failed = make(map[string]bool)
+	for {
+		comments, err := u.trash.ExpiredComments(ctx, report.Before, purgeBatch+len(failed))
+		if err != nil {
+			return nil, err
+		}
+		purged := 0
+		for _, c := range comments {
+			if failed[c.CommentID] {
+				continue
+			}
+			if err := u.trash.PurgeComment(ctx, c.CommentID); err != nil {
+				log.Printf("⚠️ Purga del comentario %s: %v", c.CommentID, err)
+				failed[c.CommentID] = true
+				report.Failed++
+				continue
+			}
+			purged++
+		}
+		report.Comments += purged
+		if purged == 0 {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// deleteImages borra la galería del post y las imágenes de sus revisiones
+// anteriores.
+func (u *trashUsecase) deleteImages(ctx context.Context, p *models.Post) error {
+	revisions, err := u.posts.GetRevisions(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	images := []string{p.ImageID}
+	for _, m := range p.Media {
+		if !slices.Contains(images, m.PublicID) {
+			images = append(images, m.PublicID)
+		}
+	}
+	for _, rev := range revisions {
+		if !slices.Contains(images, rev.ImageID) {
+			images = append(images, rev.ImageID)
+		}
+	}
+	for _, id := range images {
+		if id == "" {
+			continue
+		}
+		if err := u.media.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// nopIndexer y nopTags reemplazan al índice de búsqueda y al registro de
+// tags, que la papelera solo avisa.
+type nopIndexer struct{}
+
+func (nopIndexer) Post(context.Context, string)    {}
+func (nopIndexer) Comment(context.Context, string) {}
+func (nopIndexer) Subforo(context.Context, string) {}
+
+type nopTags struct{}
+
+func (nopTags) Canonical(_ context.Context, raw []string) ([]string, error) { return raw, nil }
+func (nopTags) Recount(context.Context, ...string)                          {}
+
+func TestTrashForumModerators(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	posts := memory.NewPostRepository(store)
+	comments := memory.NewCommentRepository(store)
+	subforos := memory.NewSubforoRepository(store)
+	users := memory.NewUserRepository(store)
+	content := usecases.NewContentRenderer(memory.NewUserLoader(store), subforos)
+	u := usecases.NewTrashUsecase(memory.NewTrashRepository(store), posts, comments, subforos, nil, content, nopIndexer{}, nopTags{}, 0)
+
+	for _, uid := range []string{"autor", "mod", "otro"} {
+		if err := users.CreateUser(ctx, uid, map[string]interface{}{"username": uid}); err != nil {
+			t.Fatalf("error creando el usuario: %v", err)
+		}
+	}
+	// mod modera el primer subforo; otro, el segundo
+	forums := make([]string, 2)
+	for i, mod := range []string{"mod", "otro"} {
+		s := &models.Subforo{Title: "subforo", CreatedBy: mod, Moderators: []string{mod}, IsActive: true}
+		if err := subforos.Create(ctx, s); err != nil {
+			t.Fatalf("error creando el subforo: %v", err)
+		}
+		forums[i] = s.ForumID
+	}
+	post := &models.Post{ForumID: forums[0], AuthorID: "autor", Title: "post", Content: "post", Status: models.PostPublished}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatalf("error creando el post: %v", err)
+	}
+	comment := &models.Comment{PostID: post.ID, AuthorID: "autor", Content: "comentario"}
+	if err := comments.CreateComment(ctx, comment); err != nil {
+		t.Fatalf("error creando el comentario: %v", err)
+	}
+
+	// El moderador de otro subforo no puede eliminar; el del subforo sí
+	if err := u.DeleteComment(ctx, comment.CommentID, "otro", false); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("DeleteComment de otro subforo = %v, se esperaba ErrForbidden", err)
+	}
+	if err := u.DeleteComment(ctx, comment.CommentID, "mod", false); err != nil {
+		t.Fatalf("DeleteComment del moderador: %v", err)
+	}
+	if err := u.DeletePost(ctx, post.ID, "otro", false); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("DeletePost de otro subforo = %v, se esperaba ErrForbidden", err)
+	}
+	if err := u.DeletePost(ctx, post.ID, "mod", false); err != nil {
+		t.Fatalf("DeletePost del moderador: %v", err)
+	}
+
+	// La papelera del subforo solo la ven sus moderadores y los administradores
+	filter := models.TrashFilter{ForumID: forums[0]}
+	page := models.PageRequest{Limit: 10}
+	if _, err := u.ForumTrashedPosts(ctx, "otro", false, filter, page); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("ForumTrashedPosts de otro subforo = %v, se esperaba ErrForbidden", err)
+	}
+	trashed, err := u.ForumTrashedPosts(ctx, "otro", true, filter, page)
+	if err != nil || len(trashed.Items) != 1 || trashed.Items[0].ID != post.ID {
+		t.Errorf("ForumTrashedPosts del administrador = %+v, %v", trashed.Items, err)
+	}
+	trashedComments, err := u.ForumTrashedComments(ctx, "mod", false, filter, page)
+	if err != nil || len(trashedComments.Items) != 1 || trashedComments.Items[0].CommentID != comment.CommentID {
+		t.Errorf("ForumTrashedComments del moderador = %+v, %v", trashedComments.Items, err)
+	}
+	other, err := u.ForumTrashedPosts(ctx, "otro", false, models.TrashFilter{ForumID: forums[1]}, page)
+	if err != nil || len(other.Items) != 0 {
+		t.Errorf("ForumTrashedPosts del otro subforo = %+v, %v", other.Items, err)
+	}
+
+	// Restaurar sigue las mismas reglas
+	if err := u.RestorePost(ctx, post.ID, "otro", false); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("RestorePost de otro subforo = %v, se esperaba ErrForbidden", err)
+	}
+	if err := u.RestorePost(ctx, post.ID, "mod", false); err != nil {
+		t.Errorf("RestorePost del moderador: %v", err)
+	}
+	if err := u.RestoreComment(ctx, comment.CommentID, "otro", false); !errors.Is(err, usecases.ErrForbidden) {
+		t.Errorf("RestoreComment de otro subforo = %v, se esperaba ErrForbidden", err)
+	}
+	if err := u.RestoreComment(ctx, comment.CommentID, "mod", false); err != nil {
+		t.Errorf("RestoreComment del moderador: %v", err)
+	}
+}
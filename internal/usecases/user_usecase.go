@@ -9,10 +9,10 @@ import (
 )
 
 type UserUsecase struct {
-	repo *repositories.UserRepository
+	repo repositories.UserRepository
 }
 
-func NewUserUsecase(repo *repositories.UserRepository) *UserUsecase {
+func NewUserUsecase(repo repositories.UserRepository) *UserUsecase {
 	return &UserUsecase{repo: repo}
 }
 
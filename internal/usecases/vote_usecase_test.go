@@ -0,0 +1,212 @@
+package usecases_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/models"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/postgres"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// voteBackend son los repositorios de un backend para las pruebas de votos.
+type voteBackend struct {
+	name     string
+	posts    repositories.PostRepository
+	comments repositories.CommentRepository
+	votes    repositories.VoteRepository
+}
+
+// voteBackends devuelve el backend en memoria y, si DATABASE_URL está
+// definida, PostgreSQL con las migraciones aplicadas.
+func voteBackends(t *testing.T) []voteBackend {
+	store := memory.NewStore()
+	backends := []voteBackend{{
+		name:     "memory",
+		posts:    memory.NewPostRepository(store),
+		comments: memory.NewCommentRepository(store),
+		votes:    memory.NewVoteRepository(store),
+	}}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return backends
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("error conectando a PostgreSQL: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if _, err := postgres.Migrate(ctx, pool); err != nil {
+		t.Fatalf("error aplicando migraciones: %v", err)
+	}
+	return append(backends, voteBackend{
+		name:     "postgres",
+		posts:    postgres.NewPostRepository(pool),
+		comments: postgres.NewCommentRepository(pool, postgres.NewUserLoader(pool)),
+		votes:    postgres.NewVoteRepository(pool),
+	})
+}
+
+// voteTarget crea un post publicado, o un comentario en uno, para votarlo.
+func voteTarget(t *testing.T, b voteBackend, target models.VoteTarget) string {
+	ctx := context.Background()
+	post := &models.Post{Title: "votos", Content: "votos concurrentes", Status: models.PostPublished}
+	if err := b.posts.Create(ctx, post); err != nil {
+		t.Fatalf("error creando el post: %v", err)
+	}
+	if target == models.TargetPost {
+		return post.ID
+	}
+	comment := &models.Comment{PostID: post.ID, Content: "comentario"}
+	if err := b.comments.CreateComment(ctx, comment); err != nil {
+		t.Fatalf("error creando el comentario: %v", err)
+	}
+	return comment.CommentID
+}
+
+// assertCounters comprueba que los contadores del destino coincidan con los
+// votos guardados y con los esperados.
+func assertCounters(t *testing.T, b voteBackend, target models.VoteTarget, targetID string, wantLikes, wantDislikes int) {
+	t.Helper()
+	ctx := context.Background()
+
+	votes, err := b.votes.GetVotesByTarget(ctx, target, targetID)
+	if err != nil {
+		t.Fatalf("error obteniendo los votos: %v", err)
+	}
+	likeVotes, dislikeVotes := 0, 0
+	for _, v := range votes {
+		switch v.Type {
+		case models.Like:
+			likeVotes++
+		case models.Dislike:
+			dislikeVotes++
+		}
+	}
+
+	var likes, dislikes int
+	if target == models.TargetPost {
+		post, err := b.posts.GetPostByID(ctx, targetID)
+		if err != nil {
+			t.Fatalf("error obteniendo el post: %v", err)
+		}
+		likes, dislikes = post.Post.Likes, post.Post.Dislikes
+	} else {
+		comment, err := b.comments.GetCommentByID(ctx, targetID)
+		if err != nil {
+			t.Fatalf("error obteniendo el comentario: %v", err)
+		}
+		likes, dislikes = comment.Likes, comment.Dislikes
+	}
+
+	if likes != likeVotes || dislikes != dislikeVotes {
+		t.Errorf("contadores %d/%d, votos guardados %d/%d", likes, dislikes, likeVotes, dislikeVotes)
+	}
+	if wantLikes >= 0 && (likes != wantLikes || dislikes != wantDislikes) {
+		t.Errorf("contadores %d/%d, se esperaban %d/%d", likes, dislikes, wantLikes, wantDislikes)
+	}
+}
+
+// reactAll aplica en paralelo cada reacción de reactions, por usuario.
+func reactAll(t *testing.T, u usecases.VoteUsecase, target models.VoteTarget, targetID string, reactions map[string][]string) {
+	t.Helper()
+	var wg sync.WaitGroup
+	errs := make(chan error, 1024)
+	for userID, list := range reactions {
+		for _, reaction := range list {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := u.React(context.Background(), userID, target, targetID, reaction); err != nil {
+					errs <- err
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("error al reaccionar: %v", err)
+	}
+}
+
+func TestReactConcurrentUsers(t *testing.T) {
+	const users = 60
+	for _, b := range voteBackends(t) {
+		for _, target := range []models.VoteTarget{models.TargetPost, models.TargetComment} {
+			t.Run(b.name+"/"+string(target), func(t *testing.T) {
+				u := usecases.NewVoteUsecase(b.votes, b.posts, b.comments)
+				targetID := voteTarget(t, b, target)
+
+				// Dos tercios dan like y el resto dislike, cada uno varias veces
+				reactions := make(map[string][]string)
+				for i := range users {
+					reaction := "like"
+					if i%3 == 2 {
+						reaction = "dislike"
+					}
+					reactions[fmt.Sprintf("u%d", i)] = []string{reaction, reaction, reaction}
+				}
+				reactAll(t, u, target, targetID, reactions)
+				assertCounters(t, b, target, targetID, 40, 20)
+
+				// Después la mitad quita el voto y la otra mitad lo invierte
+				reactions = make(map[string][]string)
+				for i := range users {
+					switch {
+					case i%2 == 0:
+						reactions[fmt.Sprintf("u%d", i)] = []string{"none"}
+					case i%3 == 2:
+						reactions[fmt.Sprintf("u%d", i)] = []string{"like"}
+					default:
+						reactions[fmt.Sprintf("u%d", i)] = []string{"dislike"}
+					}
+				}
+				reactAll(t, u, target, targetID, reactions)
+				assertCounters(t, b, target, targetID, 10, 20)
+			})
+		}
+	}
+}
+
+func TestReactConcurrentSameUser(t *testing.T) {
+	for _, b := range voteBackends(t) {
+		for _, target := range []models.VoteTarget{models.TargetPost, models.TargetComment} {
+			t.Run(b.name+"/"+string(target), func(t *testing.T) {
+				u := usecases.NewVoteUsecase(b.votes, b.posts, b.comments)
+				targetID := voteTarget(t, b, target)
+
+				// El resultado depende del orden, pero el usuario queda con a
+				// lo sumo un voto y los contadores lo reflejan
+				reactions := make([]string, 0, 90)
+				for range 30 {
+					reactions = append(reactions, "like", "dislike", "none")
+				}
+				reactAll(t, u, target, targetID, map[string][]string{"u1": reactions})
+				assertCounters(t, b, target, targetID, -1, -1)
+
+				votes, err := b.votes.GetVotesByTarget(context.Background(), target, targetID)
+				if err != nil {
+					t.Fatalf("error obteniendo los votos: %v", err)
+				}
+				if len(votes) > 1 {
+					t.Errorf("el usuario tiene %d votos al mismo destino", len(votes))
+				}
+
+				// Una última reacción deja el estado fijado
+				if _, err := u.React(context.Background(), "u1", target, targetID, "dislike"); err != nil {
+					t.Fatalf("error al reaccionar: %v", err)
+				}
+				assertCounters(t, b, target, targetID, 0, 1)
+			})
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/jobs"
+	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
+)
+
+// durationEnv lee una duración (por ejemplo "1h") de la variable de entorno
+// name. Vacía devuelve 0, que deja el job desactivado.
+func durationEnv(name string) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s debe ser una duración positiva (por ejemplo 1h): %q", name, raw)
+	}
+	return d, nil
+}
+
+// defaultTrashRetention es el tiempo que pasan los elementos en la papelera
+// antes de purgarse si no se define TRASH_RETENTION.
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// trashRetention lee TRASH_RETENTION, o devuelve defaultTrashRetention si está vacía.
+func trashRetention() (time.Duration, error) {
+	retention, err := durationEnv("TRASH_RETENTION")
+	if err != nil || retention > 0 {
+		return retention, err
+	}
+	return defaultTrashRetention, nil
+}
+
+// defaultPublishInterval es cada cuánto se publican los posts programados si
+// no se define PUBLISH_INTERVAL.
+const defaultPublishInterval = time.Minute
+
+// defaultArchiveInterval es cada cuánto se archivan los posts viejos si no se
+// define ARCHIVE_INTERVAL.
+const defaultArchiveInterval = time.Hour
+
+// defaultRerankInterval es cada cuánto se recalcula el orden rising si no se
+// define RERANK_INTERVAL.
+const defaultRerankInterval = 10 * time.Minute
+
+// newScheduler registra los jobs en segundo plano que estén activados por
+// variables de entorno. La publicación de posts programados, el archivado de
+// posts viejos y el recálculo del orden rising siempre corren.
+func newScheduler(counters usecases.CounterUsecase, trash usecases.TrashUsecase, posts *usecases.PostUsecase, moderation usecases.ModerationUsecase) (*jobs.Scheduler, error) {
+	scheduler := jobs.NewScheduler()
+
+	// Revisión de contadores de likes/dislikes; con RECONCILE_REPAIR=true además los corrige
+	interval, err := durationEnv("RECONCILE_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		repair := false
+		if raw := os.Getenv("RECONCILE_REPAIR"); raw != "" {
+			if repair, err = strconv.ParseBool(raw); err != nil {
+				return nil, fmt.Errorf("RECONCILE_REPAIR debe ser true o false: %q", raw)
+			}
+		}
+		scheduler.Add(jobs.Job{
+			Name:     "reconcile-counters",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				report, err := counters.ReconcileCounters(ctx, repair)
+				if err != nil {
+					return err
+				}
+				for _, d := range report.Posts {
+					log.Printf("⚠️ Post %s: guardado %d/%d, votos %d/%d (reparado: %t)",
+						d.ID, d.StoredLikes, d.StoredDislikes, d.Likes, d.Dislikes, d.Repaired)
+				}
+				for _, d := range report.Comments {
+					log.Printf("⚠️ Comentario %s: guardado %d/%d, votos %d/%d (reparado: %t)",
+						d.ID, d.StoredLikes, d.StoredDislikes, d.Likes, d.Dislikes, d.Repaired)
+				}
+				log.Printf("Contadores revisados: %d posts (%d con diferencias), %d comentarios (%d con diferencias)",
+					report.PostsChecked, len(report.Posts), report.CommentsChecked, len(report.Comments))
+				return nil
+			},
+		})
+	}
+
+	// Purga de la papelera
+	interval, err = durationEnv("PURGE_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		scheduler.Add(jobs.Job{
+			Name:     "purge-trash",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				report, err := trash.Purge(ctx)
+				if err != nil {
+					return err
+				}
+				log.Printf("Papelera purgada: %d posts y %d comentarios eliminados antes de %s (%d fallidos)",
+					report.Posts, report.Comments, report.Before.Format(time.RFC3339), report.Failed)
+				return nil
+			},
+		})
+	}
+
+	// Publicación de los posts programados
+	interval, err = durationEnv("PUBLISH_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval == 0 {
+		interval = defaultPublishInterval
+	}
+	scheduler.Add(jobs.Job{
+		Name:     "publish-scheduled",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			published, err := posts.PublishDue(ctx)
+			if published > 0 {
+				log.Printf("Posts programados publicados: %d", published)
+			}
+			return err
+		},
+	})
+
+	// Recálculo del orden rising, que baja con la antigüedad del post
+	interval, err = durationEnv("RERANK_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval == 0 {
+		interval = defaultRerankInterval
+	}
+	scheduler.Add(jobs.Job{
+		Name:     "rerank-rising",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := posts.RerankRising(ctx)
+			return err
+		},
+	})
+
+	// Archivado de los posts de los subforos con archive_after_days
+	interval, err = durationEnv("ARCHIVE_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval == 0 {
+		interval = defaultArchiveInterval
+	}
+	scheduler.Add(jobs.Job{
+		Name:     "archive-posts",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			archived, err := moderation.ArchiveOld(ctx)
+			if archived > 0 {
+				log.Printf("Posts archivados: %d", archived)
+			}
+			return err
+		},
+	})
+
+	return scheduler, nil
+}
@@ -1,33 +1,93 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/JuanPidarraga/talkus-backend/config"
 	_ "github.com/JuanPidarraga/talkus-backend/docs"
+	"github.com/JuanPidarraga/talkus-backend/internal/backup"
 	"github.com/JuanPidarraga/talkus-backend/internal/controllers"
 	"github.com/JuanPidarraga/talkus-backend/internal/handlers"
 	"github.com/JuanPidarraga/talkus-backend/internal/middleware"
-	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/search"
 	"github.com/JuanPidarraga/talkus-backend/internal/service"
 	"github.com/JuanPidarraga/talkus-backend/internal/usecases"
 	"github.com/cloudinary/cloudinary-go/v2"
 )
 
 func main() {
+	// Cargar variables de entorno desde .env (si existe)
+	_ = godotenv.Load()
 
-	// Inicializar Firebase (con credenciales definidas en la variable de entorno FIREBASE_CREDENTIALS_PATH)
-	firebaseApp, err := config.InitFirebase()
+	// Subcomandos (por ejemplo `talkus migrate`); sin argumentos se arranca el servidor
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("❌ %s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Backend de almacenamiento: "firestore" (por defecto), "memory" o "postgres"
+	backend := os.Getenv("STORAGE_BACKEND")
+
+	// Inicializar Firebase (con credenciales definidas en la variable de entorno FIREBASE_CREDENTIALS_PATH).
+	// Firebase Auth se usa con cualquier backend; sin credenciales solo se permite el backend en memoria,
+	// con un verificador de tokens de desarrollo que hay que pedir explícitamente con AUTH_DEV=true.
+	var firebaseApp *config.FirebaseApp
+	if backend != backendMemory || os.Getenv("FIREBASE_CREDENTIALS") != "" {
+		var err error
+		firebaseApp, err = config.InitFirebase()
+		if err != nil {
+			log.Fatalf("Error inicializando Firebase: %v", err)
+		}
+		defer firebaseApp.Firestore.Close()
+	} else if os.Getenv("AUTH_DEV") != "true" {
+		log.Fatalf("Sin Firebase no hay autenticación: define FIREBASE_CREDENTIALS, o AUTH_DEV=true para aceptar cualquier token como UID (solo desarrollo)")
+	}
+
+	store, err := newStorage(context.Background(), backend, firebaseApp)
+	if err != nil {
+		log.Fatalf("Error inicializando almacenamiento: %v", err)
+	}
+	defer store.close()
+
+	// Cargar un respaldo (ver el subcomando export) al arrancar, útil con el backend en memoria
+	if dir := os.Getenv("SEED_DIR"); dir != "" {
+		results, err := backup.Import(context.Background(), store.backup, dir)
+		if err != nil {
+			log.Fatalf("Error cargando SEED_DIR: %v", err)
+		}
+		logImport(results)
+	}
+
+	// Índice de búsqueda de texto completo: SEARCH_INDEX es su directorio; sin
+	// definir vive en memoria y se llena al arrancar
+	searchIndex, err := openSearchIndex(context.Background(), store)
 	if err != nil {
-		log.Fatalf("Error inicializando Firebase: %v", err)
+		log.Fatalf("Error inicializando la búsqueda: %v", err)
 	}
-	defer firebaseApp.Firestore.Close()
+	defer searchIndex.Close()
+	// Búsqueda semántica: los vectores guardados de los posts se cargan en memoria
+	embedder := service.NewMiniLMEmbedder()
+	vectors, err := loadVectors(context.Background(), store, embedder)
+	if err != nil {
+		log.Fatalf("Error inicializando la búsqueda semántica: %v", err)
+	}
+	// Posts relacionados, guardados RELATED_CACHE_TTL (por defecto 1h) o hasta que se editan
+	relatedTTL, err := durationEnv("RELATED_CACHE_TTL")
+	if err != nil {
+		log.Fatalf("Error configurando los posts relacionados: %v", err)
+	}
+	related := search.NewRelated(searchIndex, vectors, relatedTTL, 0)
+	searchSync := search.NewSync(searchIndex, vectors, related, store.posts, store.comments, store.subforos, store.embeddings, embedder)
 
 	// Inicializar Cloudinary (con credenciales definidas en la variable de entorno CLOUDINARY_URL)
 	cld, err := cloudinary.NewFromParams(
@@ -40,40 +100,104 @@ func main() {
 		log.Fatalf("Error iniciando Cloudinary: %v", err)
 	}
 	// Servicios de autenticación
-	authService := service.NewAuthService(firebaseApp, cld)
+	authService := service.NewAuthService(firebaseApp, cld, store.users)
 	authHandler := handlers.NewAuthHandler(authService)
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	var tokenVerifier middleware.TokenVerifier = authService
+	if firebaseApp == nil {
+		log.Println("⚠️ Sin Firebase: los tokens Bearer se aceptan como UID (solo desarrollo)")
+		tokenVerifier = service.NewDevTokenVerifier()
+	}
+	authMiddleware := middleware.NewAuthMiddleware(tokenVerifier)
 
 	// Repositorios de Usuarios
-	userRepo := repositories.NewUserRepository(firebaseApp.Firestore)
+	userRepo := store.users
 	userUsecase := usecases.NewUserUsecase(userRepo)
 	userController := controllers.NewUserController(userUsecase, cld)
 
 	// Post layer
-	postRepo := repositories.NewPostRepository(firebaseApp.Firestore)
-	subforoRepo := repositories.NewSubforoRepository(firebaseApp.Firestore)
-	postUsecase := usecases.NewPostUsecase(postRepo, subforoRepo)
+	postRepo := store.posts
+	subforoRepo := store.subforos
+	// Vista previa de los posts de enlace: LINK_PREVIEW_TIMEOUT y LINK_PREVIEW_CACHE_TTL
+	linkTimeout, err := durationEnv("LINK_PREVIEW_TIMEOUT")
+	if err != nil {
+		log.Fatalf("Error configurando la vista previa de enlaces: %v", err)
+	}
+	linkCacheTTL, err := durationEnv("LINK_PREVIEW_CACHE_TTL")
+	if err != nil {
+		log.Fatalf("Error configurando la vista previa de enlaces: %v", err)
+	}
+	links := service.NewLinkPreviewer(service.LinkPreviewOptions{Timeout: linkTimeout, CacheTTL: linkCacheTTL})
+	// Markdown de posts y comentarios, convertido a HTML al leerlos
+	content := usecases.NewContentRenderer(store.loader, subforoRepo)
+	// Registro de tags normalizados, con sinónimos, páginas y tags seguidos
+	tagUsecase := usecases.NewTagUsecase(store.tags, postRepo, store.backup, searchSync, content)
+	tagController := controllers.NewTagController(tagUsecase)
+	postUsecase := usecases.NewPostUsecase(postRepo, subforoRepo, links, content, searchSync, tagUsecase)
 	postController := controllers.NewPostController(postUsecase, cld)
 
 	// Repositorios de Comentarios
-	commentRepo := repositories.NewCommentRepository(firebaseApp.Firestore)
-	commentUsecase := usecases.NewCommentUsecase(commentRepo)
+	commentRepo := store.comments
+	commentUsecase := usecases.NewCommentUsecase(commentRepo, store.votes, postRepo, content, searchSync)
 	commentController := controllers.NewCommentController(commentUsecase)
 
 	// Crear un nuevo controlador de votos
-	voteRepo := repositories.NewVoteRepository(firebaseApp.Firestore)
-	voteUsecase := usecases.NewVoteUsecase(voteRepo, postRepo)
+	voteRepo := store.votes
+	voteUsecase := usecases.NewVoteUsecase(voteRepo, postRepo, commentRepo)
 	voteController := controllers.NewVoteController(voteUsecase)
 
-	subforoUsecase := usecases.NewSubforoUsecase(subforoRepo)
+	// Galerías de imágenes de los posts
+	mediaUsecase := usecases.NewMediaUsecase(postRepo, service.NewCloudinaryStorage(cld))
+	mediaController := controllers.NewMediaController(mediaUsecase)
+
+	// Encuestas de los posts
+	pollUsecase := usecases.NewPollUsecase(store.polls, postRepo)
+	pollController := controllers.NewPollController(pollUsecase)
+
+	// Feed de los subforos y tags del usuario y usuarios bloqueados
+	feedUsecase := usecases.NewFeedUsecase(postRepo, subforoRepo, store.blocks, store.tags, store.loader, content)
+	feedController := controllers.NewFeedController(feedUsecase)
+
+	// Moderación de posts: fijar, cerrar y archivar, con su registro
+	moderationUsecase := usecases.NewModerationUsecase(postRepo, subforoRepo, store.moderation)
+	moderationController := controllers.NewModerationController(moderationUsecase)
+
+	// Búsqueda en posts, comentarios y subforos
+	searchUsecase := usecases.NewSearchUsecase(searchIndex, vectors, related, embedder, postRepo, content, tagUsecase)
+	searchController := controllers.NewSearchController(searchUsecase)
+
+	// Recomendaciones de subforos: compara los posts del usuario con las
+	// descripciones de los subforos
+	subforoVectors := search.NewSubforoVectors(vectors, embedder)
+	subforoUsecase := usecases.NewSubforoUsecase(subforoRepo, postRepo, subforoVectors, searchSync)
 	subforoController := controllers.NewSubforoController(subforoUsecase, cld)
 
+	// Revisión de contadores de likes/dislikes (endpoint de administración y job)
+	counterUsecase := usecases.NewCounterUsecase(store.counters)
+	adminController := controllers.NewAdminController(counterUsecase)
+	adminMiddleware := middleware.NewAdminMiddleware(os.Getenv("ADMIN_UIDS"))
+
+	// Papelera de posts y comentarios: se purga pasado TRASH_RETENTION (por defecto 30 días)
+	retention, err := trashRetention()
+	if err != nil {
+		log.Fatalf("Error configurando la papelera: %v", err)
+	}
+	trashUsecase := usecases.NewTrashUsecase(store.trash, postRepo, commentRepo, subforoRepo, service.NewCloudinaryStorage(cld), content, searchSync, tagUsecase, retention)
+	trashController := controllers.NewTrashController(trashUsecase, adminMiddleware)
+
+	scheduler, err := newScheduler(counterUsecase, trashUsecase, postUsecase, moderationUsecase)
+	if err != nil {
+		log.Fatalf("Error configurando jobs: %v", err)
+	}
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+
 	// Use case y controlador de IA
 	aiUsecase := usecases.NewAIUsecase()
 	aiController := controllers.NewAIController(aiUsecase)
 
 	// Usar Gorilla Mux para definir rutas
 	router := mux.NewRouter()
+	router.Use(middleware.UserCache)
 
 	publicRouter := router.PathPrefix("/public").Subrouter()
 	publicRouter.HandleFunc("/register", authHandler.Register).Methods("POST")
@@ -83,10 +207,20 @@ func main() {
 	publicRouter.HandleFunc("/posts/forum/{forum_id}", postController.GetPostsByForumID).Methods("GET")
 	publicRouter.HandleFunc("/posts", postController.Create).Methods("POST")
 	publicRouter.HandleFunc("/post/{id}", postController.GetByID).Methods("GET")
+	publicRouter.HandleFunc("/post/{id}/revisions", postController.GetRevisions).Methods("GET")
+	publicRouter.HandleFunc("/post/{id}/revisions/diff", postController.DiffRevisions).Methods("GET")
+	publicRouter.HandleFunc("/post/{id}/crossposts", postController.GetCrossposts).Methods("GET")
+	publicRouter.HandleFunc("/post/{id}/related", searchController.Related).Methods("GET")
 	publicRouter.HandleFunc("/votes/user", voteController.GetUserVote).Methods("GET")
 	publicRouter.HandleFunc("/subforos", subforoController.GetAll).Methods("GET")
 	publicRouter.HandleFunc("/subforos/{id}", subforoController.GetByID).Methods("GET")
 	publicRouter.HandleFunc("/comments/post/{postId}", commentController.GetCommentsByPostID).Methods("GET")
+	publicRouter.HandleFunc("/search", searchController.Search).Methods("GET")
+	publicRouter.HandleFunc("/search/semantic", searchController.Semantic).Methods("GET")
+	publicRouter.HandleFunc("/tags", tagController.Autocomplete).Methods("GET")
+	publicRouter.HandleFunc("/tags/trending", tagController.Trending).Methods("GET")
+	publicRouter.HandleFunc("/tags/{tag}", tagController.GetTag).Methods("GET")
+	publicRouter.HandleFunc("/tags/{tag}/posts", tagController.Posts).Methods("GET")
 	protectedRouter := router.PathPrefix("/api").Subrouter()
 	protectedRouter.Use(authMiddleware.Authenticate)
 
@@ -97,41 +231,80 @@ func main() {
 	protectedRouter.HandleFunc("/posts/liked", postController.GetPostsILiked).Methods("GET")
 	protectedRouter.HandleFunc("/change-password", authHandler.ChangePassword).Methods("PUT")
 	protectedRouter.HandleFunc("/edit-profile", userController.EditUserProfile).Methods("PUT")
-	protectedRouter.HandleFunc("/posts", postController.Delete).Methods("DELETE")
+	protectedRouter.HandleFunc("/feed", feedController.Feed).Methods("GET")
+	protectedRouter.HandleFunc("/users/blocked", feedController.Blocked).Methods("GET")
+	protectedRouter.HandleFunc("/users/{id}/block", feedController.Block).Methods("POST")
+	protectedRouter.HandleFunc("/users/{id}/block", feedController.Unblock).Methods("DELETE")
+	protectedRouter.HandleFunc("/tags/followed", tagController.Followed).Methods("GET")
+	protectedRouter.HandleFunc("/tags/{tag}/follow", tagController.Follow).Methods("POST")
+	protectedRouter.HandleFunc("/tags/{tag}/follow", tagController.Unfollow).Methods("DELETE")
+	protectedRouter.HandleFunc("/posts", trashController.DeletePost).Methods("DELETE")
 	protectedRouter.HandleFunc("/posts", postController.Edit).Methods("PUT")
-	protectedRouter.HandleFunc("/posts/{id}/react", voteController.React).Methods("POST")
 	protectedRouter.HandleFunc("/posts/{post_id}/save", postController.SavePost).Methods("POST")
 	protectedRouter.HandleFunc("/posts/{post_id}/unsave", postController.UnsavePost).Methods("DELETE")
 	protectedRouter.HandleFunc("/post/{post_id}/saved", postController.IsSaved).Methods("GET")
 	protectedRouter.HandleFunc("/posts/saved", postController.GetSavedPosts).Methods("GET")
+	protectedRouter.HandleFunc("/posts/drafts", postController.GetDrafts).Methods("GET")
+	protectedRouter.HandleFunc("/posts/drafts/{id}", postController.UpdateDraft).Methods("PUT")
+	protectedRouter.HandleFunc("/posts/drafts/{id}/publish", postController.PublishDraft).Methods("POST")
+	protectedRouter.HandleFunc("/posts/{id}/restore", trashController.RestorePost).Methods("POST")
+	protectedRouter.HandleFunc("/link-preview", postController.PreviewLink).Methods("GET")
+	protectedRouter.HandleFunc("/posts/{id}/media", mediaController.Add).Methods("POST")
+	protectedRouter.HandleFunc("/posts/{id}/media/order", mediaController.Reorder).Methods("PUT")
+	protectedRouter.HandleFunc("/posts/{id}/media/{mediaId}", mediaController.Update).Methods("PATCH")
+	protectedRouter.HandleFunc("/posts/{id}/media/{mediaId}", mediaController.Remove).Methods("DELETE")
+	protectedRouter.HandleFunc("/posts/{id}/poll/vote", pollController.Vote).Methods("POST")
+	protectedRouter.HandleFunc("/posts/{id}/poll/results", pollController.Results).Methods("GET")
+	protectedRouter.HandleFunc("/posts/{id}/moderation", moderationController.Moderate).Methods("POST")
+	protectedRouter.HandleFunc("/posts/{id}/crosspost", postController.Crosspost).Methods("POST")
 
 	// rutas para subforos
 	protectedRouter.HandleFunc("/subforos", subforoController.Create).Methods("POST")
+	protectedRouter.HandleFunc("/subforos/recommended", subforoController.Recommended).Methods("GET")
 	protectedRouter.HandleFunc("/subforos/{id}", subforoController.Delete).Methods("DELETE")
 	protectedRouter.HandleFunc("/subforos/{id}/join", subforoController.JoinSubforo).Methods("POST")
 	protectedRouter.HandleFunc("/subforos/{id}/leave", subforoController.LeaveSubforo).Methods("POST")
 	protectedRouter.HandleFunc("/subforos/{id}", subforoController.Edit).Methods("PUT")
+	protectedRouter.HandleFunc("/subforos/{id}/archive", moderationController.SetArchiveAfter).Methods("PUT")
+	protectedRouter.HandleFunc("/subforos/{id}/moderation-log", moderationController.Log).Methods("GET")
+	protectedRouter.HandleFunc("/subforos/{id}/trash/posts", trashController.ForumTrashedPosts).Methods("GET")
+	protectedRouter.HandleFunc("/subforos/{id}/trash/comments", trashController.ForumTrashedComments).Methods("GET")
 	protectedRouter.HandleFunc("/subforos/user/{user_id}", subforoController.GetSubforosByUserID).Methods("GET")
 	protectedRouter.HandleFunc("/posts/forum/{forum_id}/verdict/{verdict}", postController.GetPostsByForumIDWithVerdict).Methods("GET")
 
 	// Rutas para Comentarios
 	protectedRouter.HandleFunc("/comments", commentController.CreateComment).Methods("POST")
 	protectedRouter.HandleFunc("/comments/{commentId}", commentController.GetCommentByID).Methods("GET")
-	protectedRouter.HandleFunc("/comments/{commentId}", commentController.DeleteComment).Methods("DELETE")
+	protectedRouter.HandleFunc("/comments/{commentId}", trashController.DeleteComment).Methods("DELETE")
+	protectedRouter.HandleFunc("/comments/{commentId}/restore", trashController.RestoreComment).Methods("POST")
 	protectedRouter.HandleFunc("/comments/{commentId}", commentController.UpdateComment).Methods("PUT")
 	protectedRouter.HandleFunc("/reply", commentController.CreateReply).Methods("POST")
 	protectedRouter.HandleFunc("/post/{postId}/tree", commentController.GetCommentTree).Methods("GET")
-	protectedRouter.HandleFunc("/{commentId}/reaction", commentController.AddReaction).Methods("POST")
 
 	// Rutas para Votos
-	protectedRouter.HandleFunc("/votes", voteController.CreateVote).Methods("POST")
 	protectedRouter.HandleFunc("/votes/{voteId}", voteController.GetVoteByID).Methods("GET")
-	protectedRouter.HandleFunc("/votes/post/{postId}", voteController.GetVotesByPostID).Methods("GET")
-	protectedRouter.HandleFunc("/votes/comment/{commentId}", voteController.GetVotesByCommentID).Methods("GET")
-	protectedRouter.HandleFunc("/votes/{voteId}", voteController.DeleteVote).Methods("DELETE")
+	protectedRouter.HandleFunc("/votes/{targetType}/{targetId}", voteController.GetVotesByTarget).Methods("GET")
+	protectedRouter.HandleFunc("/votes/{targetType}/{targetId}", voteController.Vote).Methods("PUT")
+	protectedRouter.HandleFunc("/votes/{targetType}/{targetId}/me", voteController.GetMyVote).Methods("GET")
 
 	protectedRouter.HandleFunc("/posts/{id}/report", postController.ReportPost).Methods("POST")
 
+	// Rutas de la papelera
+	protectedRouter.HandleFunc("/trash/posts", trashController.MyTrashedPosts).Methods("GET")
+	protectedRouter.HandleFunc("/trash/comments", trashController.MyTrashedComments).Methods("GET")
+
+	// Rutas de administración
+	adminRouter := protectedRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(adminMiddleware.RequireAdmin)
+	adminRouter.HandleFunc("/reconcile-counters", adminController.ReconcileCounters).Methods("POST")
+	adminRouter.HandleFunc("/trash/posts", trashController.TrashedPosts).Methods("GET")
+	adminRouter.HandleFunc("/trash/comments", trashController.TrashedComments).Methods("GET")
+	adminRouter.HandleFunc("/purge-trash", trashController.PurgeTrash).Methods("POST")
+	adminRouter.HandleFunc("/tags/recount", tagController.Recount).Methods("POST")
+	adminRouter.HandleFunc("/tags/{tag}/synonyms/{synonym}", tagController.AddSynonym).Methods("PUT")
+	adminRouter.HandleFunc("/tags/{tag}/synonyms/{synonym}", tagController.RemoveSynonym).Methods("DELETE")
+	adminRouter.HandleFunc("/tags/{tag}/merge", tagController.Merge).Methods("POST")
+
 	corsOptions := cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
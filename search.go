@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JuanPidarraga/talkus-backend/internal/search"
+	"github.com/JuanPidarraga/talkus-backend/internal/service"
+)
+
+// openSearchIndex abre el índice de búsqueda del directorio SEARCH_INDEX, o
+// uno en memoria si no está definido. Un índice nuevo se llena con los datos
+// actuales del almacenamiento.
+func openSearchIndex(ctx context.Context, store *storage) (*search.Index, error) {
+	path := os.Getenv("SEARCH_INDEX")
+	index, created, err := search.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		log.Printf("🔎 Índice de búsqueda: %s", path)
+		return index, nil
+	}
+
+	result, err := index.Rebuild(ctx, store.backup)
+	if err != nil {
+		index.Close()
+		return nil, fmt.Errorf("error al llenar el índice de búsqueda: %w", err)
+	}
+	logRebuild(result)
+	return index, nil
+}
+
+// logRebuild muestra cuántos documentos se indexaron.
+func logRebuild(r *search.RebuildResult) {
+	log.Printf("🔎 Índice de búsqueda: %d subforos, %d posts y %d comentarios indexados", r.Subforos, r.Posts, r.Comments)
+}
+
+// loadVectors carga en memoria los vectores guardados de los posts visibles.
+func loadVectors(ctx context.Context, store *storage, embedder service.Embedder) (*search.Vectors, error) {
+	vectors := search.NewVectors(embedder.Model())
+	loaded, err := vectors.Load(ctx, store.backup)
+	if err != nil {
+		return nil, fmt.Errorf("error al cargar los vectores de los posts: %w", err)
+	}
+	log.Printf("🧭 Búsqueda semántica: %d posts con vector", loaded)
+	return vectors, nil
+}
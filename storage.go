@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JuanPidarraga/talkus-backend/config"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/memory"
+	"github.com/JuanPidarraga/talkus-backend/internal/repositories/postgres"
+)
+
+// Backends de almacenamiento soportados (variable de entorno STORAGE_BACKEND).
+const (
+	backendFirestore = "firestore"
+	backendMemory    = "memory"
+	backendPostgres  = "postgres"
+)
+
+// storage agrupa los repositorios del backend elegido al arrancar.
+type storage struct {
+	users      repositories.UserRepository
+	loader     repositories.UserLoader
+	posts      repositories.PostRepository
+	comments   repositories.CommentRepository
+	votes      repositories.VoteRepository
+	subforos   repositories.SubforoRepository
+	backup     repositories.BackupRepository
+	counters   repositories.CounterRepository
+	trash      repositories.TrashRepository
+	polls      repositories.PollRepository
+	blocks     repositories.BlockRepository
+	moderation repositories.ModerationRepository
+	embeddings repositories.EmbeddingRepository
+	tags       repositories.TagRepository
+
+	// close libera las conexiones del backend, si las tiene.
+	close func()
+}
+
+// newStorage construye los repositorios del backend indicado. firebaseApp solo
+// es obligatorio para el backend de Firestore.
+func newStorage(ctx context.Context, backend string, firebaseApp *config.FirebaseApp) (*storage, error) {
+	switch backend {
+	case "", backendFirestore:
+		if firebaseApp == nil {
+			return nil, fmt.Errorf("el backend %q necesita Firebase", backendFirestore)
+		}
+		db := firebaseApp.Firestore
+		loader := repositories.NewUserLoader(db)
+		return &storage{
+			users:      repositories.NewUserRepository(db),
+			loader:     loader,
+			posts:      repositories.NewPostRepository(db, loader),
+			comments:   repositories.NewCommentRepository(db, loader),
+			votes:      repositories.NewVoteRepository(db),
+			subforos:   repositories.NewSubforoRepository(db),
+			backup:     repositories.NewBackupRepository(db),
+			counters:   repositories.NewCounterRepository(db),
+			trash:      repositories.NewTrashRepository(db),
+			polls:      repositories.NewPollRepository(db),
+			blocks:     repositories.NewBlockRepository(db),
+			moderation: repositories.NewModerationRepository(db),
+			embeddings: repositories.NewEmbeddingRepository(db),
+			tags:       repositories.NewTagRepository(db),
+			close:      func() {},
+		}, nil
+	case backendMemory:
+		store := memory.NewStore()
+		return &storage{
+			users:      memory.NewUserRepository(store),
+			loader:     memory.NewUserLoader(store),
+			posts:      memory.NewPostRepository(store),
+			comments:   memory.NewCommentRepository(store),
+			votes:      memory.NewVoteRepository(store),
+			subforos:   memory.NewSubforoRepository(store),
+			backup:     memory.NewBackupRepository(store),
+			counters:   memory.NewCounterRepository(store),
+			trash:      memory.NewTrashRepository(store),
+			polls:      memory.NewPollRepository(store),
+			blocks:     memory.NewBlockRepository(store),
+			moderation: memory.NewModerationRepository(store),
+			embeddings: memory.NewEmbeddingRepository(store),
+			tags:       memory.NewTagRepository(store),
+			close:      func() {},
+		}, nil
+	case backendPostgres:
+		pool, err := config.InitPostgres(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pending, err := postgres.PendingMigrations(ctx, pool)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		if len(pending) > 0 {
+			pool.Close()
+			return nil, fmt.Errorf("hay %d migraciones pendientes, ejecuta el subcomando migrate", len(pending))
+		}
+		loader := postgres.NewUserLoader(pool)
+		return &storage{
+			users:      postgres.NewUserRepository(pool),
+			loader:     loader,
+			posts:      postgres.NewPostRepository(pool),
+			comments:   postgres.NewCommentRepository(pool, loader),
+			votes:      postgres.NewVoteRepository(pool),
+			subforos:   postgres.NewSubforoRepository(pool),
+			backup:     postgres.NewBackupRepository(pool),
+			counters:   postgres.NewCounterRepository(pool),
+			trash:      postgres.NewTrashRepository(pool),
+			polls:      postgres.NewPollRepository(pool),
+			blocks:     postgres.NewBlockRepository(pool),
+			moderation: postgres.NewModerationRepository(pool),
+			embeddings: postgres.NewEmbeddingRepository(pool),
+			tags:       postgres.NewTagRepository(pool),
+			close:      pool.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("backend de almacenamiento desconocido: %q", backend)
+	}
+}